@@ -85,6 +85,7 @@ type accountStat struct {
 	Total       int64   `json:"total"`
 	Transferred int64   `json:"transferred"`
 	Speed       float64 `json:"speed"`
+	Retries     int64   `json:"retries"`
 }
 
 func (c accountStat) JSON() string {
@@ -104,6 +105,9 @@ func (c accountStat) String() string {
 	}
 	message := fmt.Sprintf("Total: %s, Transferred: %s, Speed: %s", pb.Format(c.Total).To(pb.U_BYTES),
 		pb.Format(c.Transferred).To(pb.U_BYTES), speedBox)
+	if c.Retries > 0 {
+		message += fmt.Sprintf(", Retries: %d", c.Retries)
+	}
 	return message
 }
 
@@ -115,6 +119,7 @@ func (a *accounter) Stat() accountStat {
 		acntStat.Total = a.Total
 		acntStat.Transferred = atomic.LoadInt64(&a.current)
 		acntStat.Speed = a.write(atomic.LoadInt64(&a.current))
+		acntStat.Retries = getRetryCount()
 	})
 	return acntStat
 }
@@ -0,0 +1,72 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import "github.com/minio/cli"
+
+var adminClusterBucketDiffCmd = cli.Command{
+	Name:            "diff",
+	Usage:           "show differences between two bucket metadata exports",
+	Action:          mainClusterBucketDiff,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] SOURCE TARGET
+
+  SOURCE and TARGET are either bucket metadata zip files from 'mc admin cluster bucket export'
+  or aliases, whose current bucket metadata is exported on the fly.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Show bucket configurations changed between two exports.
+     {{.Prompt}} {{.HelpName}} /backups/cluster-metadata.zip cluster-metadata.zip
+
+  2. Compare a previous export with the live bucket metadata of 'myminio'.
+     {{.Prompt}} {{.HelpName}} /backups/cluster-metadata.zip myminio
+`,
+}
+
+func checkBucketDiffSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, "diff", 1) // last argument is exit code
+	}
+}
+
+// mainClusterBucketDiff - bucket metadata diff command
+func mainClusterBucketDiff(ctx *cli.Context) error {
+	checkBucketDiffSyntax(ctx)
+	setArchiveDiffColors()
+
+	args := ctx.Args()
+	older, err := loadClusterArchive(args.Get(0), false)
+	fatalIf(err, "Unable to read bucket metadata from `"+args.Get(0)+"`.")
+	newer, err := loadClusterArchive(args.Get(1), false)
+	fatalIf(err, "Unable to read bucket metadata from `"+args.Get(1)+"`.")
+
+	for _, d := range diffClusterArchives(older, newer) {
+		printMsg(archiveDiffMessage{Type: d.Kind, Name: d.Name, Change: d.Change})
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -35,13 +36,28 @@ import (
 	"github.com/minio/pkg/console"
 )
 
+var adminClusterBucketImportFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "only",
+		Usage: "import only these configurations: " + strings.Join(bucketArchiveKinds, ", "),
+	},
+	cli.StringSliceFlag{
+		Name:  "buckets",
+		Usage: "import only the metadata of these buckets",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show what would be imported, without importing",
+	},
+}
+
 var adminClusterBucketImportCmd = cli.Command{
 	Name:            "import",
 	Usage:           "restore bucket metadata from a zip file",
 	Action:          mainClusterBucketImport,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append(adminClusterBucketImportFlags, globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
@@ -55,6 +71,12 @@ FLAGS:
 EXAMPLES:
   1. Recover bucket metadata for all buckets from previously saved bucket metadata backup.
      {{.Prompt}} {{.HelpName}} myminio /backups/cluster-metadata.zip
+
+  2. Recover only the lifecycle and versioning configuration of buckets 'photos' and 'docs'.
+     {{.Prompt}} {{.HelpName}} --buckets photos,docs --only lifecycle,versioning myminio /backups/cluster-metadata.zip
+
+  3. Show which bucket configurations would be added or changed, without importing.
+     {{.Prompt}} {{.HelpName}} --dry-run myminio /backups/cluster-metadata.zip
 `,
 }
 
@@ -94,8 +116,22 @@ func mainClusterBucketImport(ctx *cli.Context) error {
 	_, e = zip.NewReader(r.(io.ReaderAt), sz)
 	fatalIf(probe.NewError(e).Trace(args...), fmt.Sprintf("Unable to read zip file %s", args.Get(1)))
 
-	f, e = os.Open(args.Get(1))
-	fatalIf(probe.NewError(e).Trace(args...), "Unable to get bucket metadata")
+	sel, err := newBucketSelector(ctx.StringSlice("only"), ctx.StringSlice("buckets"))
+	fatalIf(err, "Invalid bucket metadata import selection.")
+
+	dryRun := ctx.Bool("dry-run")
+	if !sel.isEmpty() || dryRun {
+		data, err := importClusterArchive(aliasedURL, args.Get(1), false, dryRun, sel)
+		fatalIf(err.Trace(args...), "Unable to prepare bucket metadata for import.")
+		if dryRun {
+			return nil
+		}
+		r = bytes.NewReader(data)
+	} else {
+		f, e = os.Open(args.Get(1))
+		fatalIf(probe.NewError(e).Trace(args...), "Unable to get bucket metadata")
+		r = f
+	}
 
 	// Create a new MinIO Admin Client
 	client, err := newAdminClient(aliasedURL)
@@ -109,7 +145,7 @@ func mainClusterBucketImport(ctx *cli.Context) error {
 	aliasedURL = filepath.Clean(aliasedURL)
 	_, bucket := url2Alias(aliasedURL)
 
-	rpt, e := client.ImportBucketMetadata(context.Background(), bucket, f)
+	rpt, e := client.ImportBucketMetadata(context.Background(), bucket, ioutil.NopCloser(r))
 	fatalIf(probe.NewError(e).Trace(aliasedURL), "Unable to import bucket metadata.")
 
 	printMsg(importMetaMsg{
@@ -22,6 +22,7 @@ import "github.com/minio/cli"
 var adminClusterBucketSubcommands = []cli.Command{
 	adminClusterBucketImportCmd,
 	adminClusterBucketExportCmd,
+	adminClusterBucketDiffCmd,
 }
 
 var adminClusterBucketCmd = cli.Command{
@@ -0,0 +1,72 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import "github.com/minio/cli"
+
+var adminClusterIAMDiffCmd = cli.Command{
+	Name:            "diff",
+	Usage:           "show differences between two IAM exports",
+	Action:          mainClusterIAMDiff,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] SOURCE TARGET
+
+  SOURCE and TARGET are either IAM info zip files from 'mc admin cluster iam export'
+  or aliases, whose current IAM info is exported on the fly.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Show users, groups, policies, service accounts and mappings changed between two exports.
+     {{.Prompt}} {{.HelpName}} /backups/myminio-iam-info.zip myminio-iam-info.zip
+
+  2. Compare a previous export with the live IAM info of 'myminio'.
+     {{.Prompt}} {{.HelpName}} /backups/myminio-iam-info.zip myminio
+`,
+}
+
+func checkIAMDiffSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 2 {
+		showCommandHelpAndExit(ctx, "diff", 1) // last argument is exit code
+	}
+}
+
+// mainClusterIAMDiff - iam info diff command
+func mainClusterIAMDiff(ctx *cli.Context) error {
+	checkIAMDiffSyntax(ctx)
+	setArchiveDiffColors()
+
+	args := ctx.Args()
+	older, err := loadClusterArchive(args.Get(0), true)
+	fatalIf(err, "Unable to read IAM info from `"+args.Get(0)+"`.")
+	newer, err := loadClusterArchive(args.Get(1), true)
+	fatalIf(err, "Unable to read IAM info from `"+args.Get(1)+"`.")
+
+	for _, d := range diffClusterArchives(older, newer) {
+		printMsg(archiveDiffMessage{Type: d.Kind, Name: d.Name, Change: d.Change})
+	}
+	return nil
+}
@@ -18,9 +18,11 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/klauspost/compress/zip"
@@ -29,19 +31,42 @@ import (
 	"github.com/minio/pkg/console"
 )
 
+var adminClusterIAMImportFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "only",
+		Usage: "import only these categories: policies, users, groups, svcaccts, mappings, user-mappings, group-mappings, sts-user-mappings, sts-group-mappings",
+	},
+	cli.StringSliceFlag{
+		Name:  "users",
+		Usage: "import only these users along with their service accounts and policy mappings",
+	},
+	cli.StringSliceFlag{
+		Name:  "groups",
+		Usage: "import only these groups along with their policy mappings",
+	},
+	cli.StringSliceFlag{
+		Name:  "policies",
+		Usage: "import only these policies",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show what would be imported, without importing",
+	},
+}
+
 var adminClusterIAMImportCmd = cli.Command{
 	Name:            "import",
 	Usage:           "imports IAM info from zipped file",
 	Action:          mainClusterIAMImport,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append(adminClusterIAMImportFlags, globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
 USAGE:
-  {{.HelpName}} [FLAGS] TARGET/BUCKET /path/to/myminio-iam-info.zip
+  {{.HelpName}} [FLAGS] TARGET /path/to/myminio-iam-info.zip
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -50,6 +75,12 @@ EXAMPLES:
   1. Set IAM info from previously exported metadata zip file.
      {{.Prompt}} {{.HelpName}} myminio /tmp/myminio-iam-info.zip
 
+  2. Import only the policies from previously exported metadata zip file.
+     {{.Prompt}} {{.HelpName}} --only policies myminio /tmp/myminio-iam-info.zip
+
+  3. Show which entries of users 'alice' and 'bob' would be added or changed, without importing.
+     {{.Prompt}} {{.HelpName}} --users alice,bob --dry-run myminio /tmp/myminio-iam-info.zip
+
 `,
 }
 
@@ -82,8 +113,22 @@ func mainClusterIAMImport(ctx *cli.Context) error {
 	_, e = zip.NewReader(r.(io.ReaderAt), sz)
 	fatalIf(probe.NewError(e).Trace(args...), fmt.Sprintf("Unable to read zip file %s", args.Get(1)))
 
-	f, e = os.Open(args.Get(1))
-	fatalIf(probe.NewError(e).Trace(args...), "Unable to get IAM info")
+	sel, err := newIAMSelector(ctx.StringSlice("only"), ctx.StringSlice("users"), ctx.StringSlice("groups"), ctx.StringSlice("policies"))
+	fatalIf(err, "Invalid IAM import selection.")
+
+	dryRun := ctx.Bool("dry-run")
+	if !sel.isEmpty() || dryRun {
+		data, err := importClusterArchive(aliasedURL, args.Get(1), true, dryRun, sel)
+		fatalIf(err.Trace(args...), "Unable to prepare IAM info for import.")
+		if dryRun {
+			return nil
+		}
+		r = bytes.NewReader(data)
+	} else {
+		f, e = os.Open(args.Get(1))
+		fatalIf(probe.NewError(e).Trace(args...), "Unable to get IAM info")
+		r = f
+	}
 
 	// Create a new MinIO Admin Client
 	client, err := newAdminClient(aliasedURL)
@@ -92,7 +137,7 @@ func mainClusterIAMImport(ctx *cli.Context) error {
 		return nil
 	}
 
-	e = client.ImportIAM(context.Background(), f)
+	e = client.ImportIAM(context.Background(), ioutil.NopCloser(r))
 	fatalIf(probe.NewError(e).Trace(aliasedURL), "Unable to import IAM info.")
 
 	if !globalJSON {
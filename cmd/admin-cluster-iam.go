@@ -22,6 +22,7 @@ import "github.com/minio/cli"
 var adminClusterIAMSubcommands = []cli.Command{
 	adminClusterIAMImportCmd,
 	adminClusterIAMExportCmd,
+	adminClusterIAMDiffCmd,
 }
 
 var adminClusterIAMCmd = cli.Command{
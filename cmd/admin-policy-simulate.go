@@ -0,0 +1,306 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/set"
+	"github.com/minio/pkg/bucket/policy"
+	"github.com/minio/pkg/console"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+var adminPolicySimulateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "user",
+		Usage: "evaluate the policies attached to a user and its groups",
+	},
+	cli.StringSliceFlag{
+		Name:  "group",
+		Usage: "evaluate the policies attached to a group",
+	},
+	cli.StringSliceFlag{
+		Name:  "policy",
+		Usage: "evaluate a policy defined in the MinIO server",
+	},
+	cli.StringSliceFlag{
+		Name:  "policy-file, f",
+		Usage: "evaluate a policy from a local JSON file",
+	},
+	cli.BoolFlag{
+		Name:  "bucket-policy",
+		Usage: "also evaluate the bucket policy of the resource's bucket",
+	},
+	cli.StringFlag{
+		Name:  "source-ip",
+		Usage: "source IP address of the simulated request",
+	},
+	cli.StringSliceFlag{
+		Name:  "condition",
+		Usage: "condition value of the simulated request, e.g. 's3:prefix=photos/'",
+	},
+}
+
+var adminPolicySimulateCmd = cli.Command{
+	Name:         "simulate",
+	Usage:        "evaluate whether policies allow an action on a resource",
+	Action:       mainAdminPolicySimulate,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(adminPolicySimulateFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] [TARGET] ACTION RESOURCE
+
+ACTION:
+  Policy action such as s3:GetObject or admin:ServerInfo.
+
+RESOURCE:
+  Bucket and object of the request, with or without the 'arn:aws:s3:::' prefix.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Evaluate policies locally and explain which statement allows or denies the request.
+  Policies are loaded from the server for the given user, groups and policy names,
+  or from local files in which case TARGET can be omitted. An explicit deny in any
+  policy overrides all allows, a request not matched by any statement is denied.
+
+EXAMPLES:
+  1. Check if user 'james' can download an object.
+     {{.Prompt}} {{.HelpName}} --user james myminio s3:GetObject mybucket/photos/2021/pic.jpg
+
+  2. Check if members of group 'auditors' can list a prefix.
+     {{.Prompt}} {{.HelpName}} --group auditors --condition s3:prefix=reports/ myminio s3:ListBucket mybucket
+
+  3. Check a local policy file for a request coming from a given IP address.
+     {{.Prompt}} {{.HelpName}} --policy-file /tmp/policy.json --source-ip 10.0.0.5 s3:PutObject mybucket/uploads/file.txt
+
+  4. Check if anonymous users can download an object through the bucket policy.
+     {{.Prompt}} {{.HelpName}} --bucket-policy myminio s3:GetObject mybucket/public/index.html
+`,
+}
+
+// policySimulateMessage container for policy simulation results.
+type policySimulateMessage struct {
+	Status   string         `json:"status"`
+	Request  policyRequest  `json:"request"`
+	Policies []string       `json:"policies"`
+	Decision policyDecision `json:"decision"`
+}
+
+func (p policySimulateMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Action:   %s\n", p.Request.Action)
+	resource := p.Request.BucketName
+	if p.Request.ObjectName != "" {
+		resource += "/" + p.Request.ObjectName
+	}
+	fmt.Fprintf(&b, "Resource: %s\n", resource)
+	if p.Request.AccountName != "" {
+		fmt.Fprintf(&b, "User:     %s\n", p.Request.AccountName)
+	}
+	if len(p.Request.Groups) > 0 {
+		fmt.Fprintf(&b, "Groups:   %s\n", strings.Join(p.Request.Groups, ", "))
+	}
+	fmt.Fprintf(&b, "Policies: %s\n", strings.Join(p.Policies, ", "))
+
+	deciding, ok := p.Decision.decidingStatement()
+	switch {
+	case p.Decision.Denied:
+		fmt.Fprintf(&b, "Result:   %s by %s\n", console.Colorize("Denied", "DENIED"), deciding)
+	case p.Decision.Allowed && ok:
+		fmt.Fprintf(&b, "Result:   %s by %s\n", console.Colorize("Allowed", "ALLOWED"), deciding)
+	default:
+		fmt.Fprintf(&b, "Result:   %s, no statement matches the request\n", console.Colorize("Denied", "DENIED"))
+	}
+
+	if len(p.Decision.Matches) > 0 {
+		fmt.Fprintf(&b, "Matching statements:\n")
+		for _, m := range p.Decision.Matches {
+			fmt.Fprintf(&b, "  %-6s %s\n", m.Effect, m)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (p policySimulateMessage) JSON() string {
+	p.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(p, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// checkAdminPolicySimulateSyntax - validate all the passed arguments
+func checkAdminPolicySimulateSyntax(ctx *cli.Context) {
+	switch len(ctx.Args()) {
+	case 3:
+	case 2:
+		if len(ctx.StringSlice("policy-file")) == 0 || ctx.String("user") != "" ||
+			len(ctx.StringSlice("group")) > 0 || len(ctx.StringSlice("policy")) > 0 || ctx.Bool("bucket-policy") {
+			fatalIf(errInvalidArgument().Trace(ctx.Args()...), "TARGET is required to load policies from the server.")
+		}
+	default:
+		showCommandHelpAndExit(ctx, "simulate", 1) // last argument is exit code
+	}
+	if len(ctx.StringSlice("policy-file")) == 0 && ctx.String("user") == "" &&
+		len(ctx.StringSlice("group")) == 0 && len(ctx.StringSlice("policy")) == 0 && !ctx.Bool("bucket-policy") {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Please specify the policies to evaluate.")
+	}
+}
+
+// loadServerPolicy fetches and parses a canned policy from the server.
+func loadServerPolicy(client *madmin.AdminClient, policyName string) (*iampolicy.Policy, *probe.Error) {
+	pinfo, e := getPolicyInfo(client, policyName)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(policyName)
+	}
+	p, e := iampolicy.ParseConfig(bytes.NewReader(pinfo.Policy))
+	if e != nil {
+		return nil, probe.NewError(e).Trace(policyName)
+	}
+	return p, nil
+}
+
+// mainAdminPolicySimulate is the handler for "mc admin policy simulate" command.
+func mainAdminPolicySimulate(ctx *cli.Context) error {
+	checkAdminPolicySimulateSyntax(ctx)
+
+	console.SetColor("Allowed", color.New(color.FgGreen, color.Bold))
+	console.SetColor("Denied", color.New(color.FgRed, color.Bold))
+
+	args := ctx.Args()
+	var aliasedURL string
+	if len(args) == 3 {
+		aliasedURL = args.Get(0)
+		args = args.Tail()
+	}
+	action := args.Get(0)
+	if !iampolicy.Action(action).IsValid() && !iampolicy.AdminAction(action).IsValid() && !iampolicy.KMSAction(action).IsValid() {
+		fatalIf(errInvalidArgument().Trace(action), "Unknown policy action `"+action+"`.")
+	}
+	bucket, object := parsePolicyResource(args.Get(1))
+
+	user := ctx.String("user")
+	groups := ctx.StringSlice("group")
+	policyNames := ctx.StringSlice("policy")
+	var sources []string
+
+	var client *madmin.AdminClient
+	if aliasedURL != "" && (user != "" || len(groups) > 0 || len(policyNames) > 0) {
+		var err *probe.Error
+		client, err = newAdminClient(aliasedURL)
+		fatalIf(err, "Unable to initialize admin connection.")
+	}
+
+	var iamPolicies []namedIAMPolicy
+	addServerPolicies := func(names, source string) {
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			p, err := loadServerPolicy(client, name)
+			fatalIf(err, "Unable to fetch policy `"+name+"`.")
+			iamPolicies = append(iamPolicies, namedIAMPolicy{Name: name, Policy: p})
+			sources = append(sources, name+" ("+source+")")
+		}
+	}
+
+	if user != "" {
+		userInfo, e := client.GetUserInfo(globalContext, user)
+		fatalIf(probe.NewError(e).Trace(user), "Unable to get user info.")
+		addServerPolicies(userInfo.PolicyName, "user "+user)
+		knownGroups := set.CreateStringSet(groups...)
+		for _, group := range userInfo.MemberOf {
+			if !knownGroups.Contains(group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+	for _, group := range groups {
+		groupDesc, e := client.GetGroupDescription(globalContext, group)
+		fatalIf(probe.NewError(e).Trace(group), "Unable to get group info.")
+		if groupDesc.Status == "disabled" {
+			continue
+		}
+		addServerPolicies(groupDesc.Policy, "group "+group)
+	}
+	for _, name := range policyNames {
+		addServerPolicies(name, "server")
+	}
+	for _, file := range ctx.StringSlice("policy-file") {
+		buf, e := os.ReadFile(file)
+		fatalIf(probe.NewError(e).Trace(file), "Unable to read policy file.")
+		p, e := iampolicy.ParseConfig(bytes.NewReader(buf))
+		fatalIf(probe.NewError(e).Trace(file), "Unable to parse policy file.")
+		iamPolicies = append(iamPolicies, namedIAMPolicy{Name: file, Policy: p})
+		sources = append(sources, file+" (file)")
+	}
+
+	conditions, err := newPolicyConditionValues(user, ctx.String("source-ip"), ctx.StringSlice("condition"))
+	fatalIf(err, "Unable to parse request conditions.")
+
+	req := policyRequest{
+		AccountName: user,
+		Groups:      groups,
+		Action:      action,
+		BucketName:  bucket,
+		ObjectName:  object,
+		Conditions:  conditions,
+	}
+
+	decision := evaluateIAMPolicies(iamPolicies, req)
+
+	if ctx.Bool("bucket-policy") {
+		if bucket == "" {
+			fatalIf(errInvalidArgument().Trace(args...), "A bucket is required to evaluate the bucket policy.")
+		}
+		bucketURL := strings.TrimSuffix(aliasedURL, "/") + "/" + bucket
+		clnt, err := newClient(bucketURL)
+		fatalIf(err.Trace(bucketURL), "Unable to initialize target `"+bucketURL+"`.")
+		_, policyJSON, err := clnt.GetAccess(globalContext)
+		fatalIf(err.Trace(bucketURL), "Unable to get bucket policy of `"+bucketURL+"`.")
+		name := "bucket policy of " + bucket
+		sources = append(sources, name)
+		if policyJSON != "" {
+			p, e := policy.ParseConfig(strings.NewReader(policyJSON), bucket)
+			fatalIf(probe.NewError(e).Trace(bucketURL), "Unable to parse bucket policy.")
+			decision = decision.merge(evaluateBucketPolicy(name, p, req))
+		}
+	}
+
+	printMsg(policySimulateMessage{
+		Request:  req,
+		Policies: sources,
+		Decision: decision,
+	})
+	return nil
+}
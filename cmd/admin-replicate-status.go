@@ -18,6 +18,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -83,7 +85,7 @@ var adminReplicateStatusCmd = cli.Command{
 	Action:       mainAdminReplicationStatus,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(globalFlags, adminReplicateStatusFlags...),
+	Flags:        append(append(globalFlags, adminReplicateStatusFlags...), replicationWatchFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -106,6 +108,10 @@ EXAMPLES:
 
     4. Drill down and view site replication status of user "foo"
        {{.Prompt}} {{.HelpName}} minio1 --user foo
+
+    5. Watch the number of buckets, policies, users and groups not yet replicated to each site
+       and post an alert to a webhook when more than 10 are pending on a site
+       {{.Prompt}} {{.HelpName}} minio1 --watch --max-pending-count 10 --webhook https://alerts.example.com/sr
 `,
 }
 
@@ -700,6 +706,23 @@ func mainAdminReplicationStatus(ctx *cli.Context) error {
 	client, err := newAdminClient(aliasedURL)
 	fatalIf(err, "Unable to initialize admin connection.")
 	opts := srStatusOpts(ctx)
+
+	if ctx.Bool("watch") {
+		alerter, err := newReplicationAlerter(ctx, aliasedURL)
+		fatalIf(err, "Unable to parse the alert flags.")
+		return watchReplicationLag(globalContext, aliasedURL, ctx.Duration("interval"), alerter, ctx.Bool("exit-on-alert"),
+			func(cctx context.Context) ([]replicationLag, *probe.Error) {
+				info, e := client.SRStatusInfo(cctx, opts)
+				if e != nil {
+					return nil, probe.NewError(e)
+				}
+				if !info.Enabled {
+					return nil, probe.NewError(errors.New("site replication is not enabled"))
+				}
+				return siteReplicationLags(info), nil
+			})
+	}
+
 	info, e := client.SRStatusInfo(globalContext, opts)
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to get cluster replication status")
 
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/minio/cli"
@@ -45,6 +46,10 @@ var adminUserSvcAcctAddFlags = []cli.Flag{
 		Name:  "policy",
 		Usage: "path to a JSON policy file",
 	},
+	cli.StringFlag{
+		Name:  "expiry",
+		Usage: "label the service account to expire after this duration in the local mc config, e.g. 90d",
+	},
 }
 
 var adminUserSvcAcctAddCmd = cli.Command{
@@ -63,12 +68,20 @@ USAGE:
 ACCOUNT:
   An account could be a regular MinIO user, STS ou LDAP user.
 
+EXPIRY:
+  The expiry label is NOT stored on the server: it is kept in svcacct-labels.json
+  in the mc configuration folder of this machine and is only reported by
+  'mc admin user svcacct inventory' run with the same configuration folder.
+
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
 EXAMPLES:
   1. Add a new service account for user 'foobar' to MinIO server.
      {{.Prompt}} {{.HelpName}} myminio foobar
+
+  2. Add a new service account for user 'foobar' to be rotated within 90 days.
+     {{.Prompt}} {{.HelpName}} --expiry 90d myminio foobar
 `,
 }
 
@@ -179,9 +192,20 @@ func mainAdminUserSvcAcctAdd(ctx *cli.Context) error {
 		TargetUser: user,
 	}
 
+	var expiry time.Duration
+	if ctx.IsSet("expiry") {
+		expiry = parseSvcAcctDuration(ctx, "expiry")
+	}
+
 	creds, e := client.AddServiceAccount(globalContext, opts)
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to add a new service account")
 
+	if expiry > 0 {
+		t := UTCNow().Add(expiry)
+		errorIf(labelSvcAcct(aliasedURL, creds.AccessKey, func(label *svcAcctLabel) { label.Expiry = &t }),
+			"Unable to label the expiry of the service account.")
+	}
+
 	printMsg(svcAcctMessage{
 		op:            "add",
 		AccessKey:     creds.AccessKey,
@@ -0,0 +1,298 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+var adminUserSvcAcctInventoryFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "user",
+		Usage: "also list the service accounts of this parent, e.g. an LDAP user",
+	},
+	cli.StringFlag{
+		Name:  "expiring-within",
+		Usage: "only list the service accounts expired or expiring within this duration, e.g. 14d",
+	},
+	cli.BoolFlag{
+		Name:  "no-expiry",
+		Usage: "only list the service accounts without an expiry label",
+	},
+}
+
+var adminUserSvcAcctInventoryCmd = cli.Command{
+	Name:         "inventory",
+	Usage:        "list the service accounts of all users with their policy, status and expiry",
+	Action:       mainAdminUserSvcAcctInventory,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(adminUserSvcAcctInventoryFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] ALIAS
+
+  The service accounts of all MinIO users and of the user of the alias are
+  listed, LDAP parents are added with --user. The expiry is the one labeled
+  by 'mc admin user svcacct add --expiry' and 'mc admin user svcacct rotate'.
+
+  The expiry and rotation labels are NOT stored on the server: they are read
+  from svcacct-labels.json in the mc configuration folder of this machine.
+  Service accounts added or rotated from another machine or another
+  configuration folder are listed without an expiry.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all service accounts.
+     {{.Prompt}} {{.HelpName}} myminio/
+
+  2. List the service accounts to rotate within the next two weeks.
+     {{.Prompt}} {{.HelpName}} --expiring-within 14d myminio/
+
+  3. List the service accounts of users and of an LDAP user which were never given an expiry.
+     {{.Prompt}} {{.HelpName}} --no-expiry --user "uid=app,ou=people,dc=example,dc=org" myminio/
+`,
+}
+
+// svcAcctInventoryMessage is a service account of the inventory.
+type svcAcctInventoryMessage struct {
+	Status        string     `json:"status"`
+	AccessKey     string     `json:"accessKey"`
+	ParentUser    string     `json:"parentUser"`
+	AccountStatus string     `json:"accountStatus"`
+	ImpliedPolicy bool       `json:"impliedPolicy"`
+	Policy        string     `json:"policy"`
+	Expiry        *time.Time `json:"expiry,omitempty"`
+	Expired       bool       `json:"expired,omitempty"`
+	RotatedFrom   string     `json:"rotatedFrom,omitempty"`
+	RotatedTo     string     `json:"rotatedTo,omitempty"`
+	DisableAfter  *time.Time `json:"disableAfter,omitempty"`
+	LabelsFile    string     `json:"labelsFile"`
+
+	now time.Time
+}
+
+var svcAcctInventoryTable = newPrettyTable(" | ",
+	Field{"AccessKey", accessFieldMaxLen},
+	Field{"Parent", 24},
+	Field{"Status", 8},
+	Field{"Policy", 40},
+	Field{"Expiry", 26},
+	Field{"Rotation", 40},
+)
+
+func svcAcctInventoryHeader() string {
+	return console.Colorize("SVCHeader", svcAcctInventoryTable.buildRow(
+		"Access Key", "Parent", "Status", "Policy", "Expiry", "Rotation"))
+}
+
+func (m svcAcctInventoryMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func (m svcAcctInventoryMessage) String() string {
+	theme := "SVCMessage"
+	expiry := "none"
+	if m.Expiry != nil {
+		days := int(m.Expiry.Sub(m.now).Hours() / 24)
+		switch {
+		case m.Expired:
+			theme = "SVCExpired"
+			expiry = fmt.Sprintf("%s EXPIRED", m.Expiry.Format("2006-01-02"))
+		default:
+			expiry = fmt.Sprintf("%s (%dd left)", m.Expiry.Format("2006-01-02"), days)
+		}
+	}
+	var rotation []string
+	if m.RotatedTo != "" {
+		rotation = append(rotation, "replaced by "+m.RotatedTo)
+	}
+	if m.DisableAfter != nil {
+		rotation = append(rotation, "disable after "+m.DisableAfter.Format("2006-01-02 15:04"))
+	}
+	if len(rotation) == 0 && m.RotatedFrom != "" {
+		rotation = append(rotation, "replaces "+m.RotatedFrom)
+	}
+	return console.Colorize(theme, svcAcctInventoryTable.buildRow(
+		m.AccessKey, m.ParentUser, m.AccountStatus, m.Policy, expiry, strings.Join(rotation, ", ")))
+}
+
+// summarizeSvcAcctPolicy describes the policy of a service account in
+// a few words, an implied policy is the one of the parent user.
+func summarizeSvcAcctPolicy(implied bool, policy, parentPolicy string) string {
+	if implied || policy == "" {
+		if parentPolicy != "" {
+			return "inherited (" + parentPolicy + ")"
+		}
+		return "inherited"
+	}
+	p, e := iampolicy.ParseConfig(bytes.NewReader([]byte(policy)))
+	if e != nil {
+		return "embedded (invalid)"
+	}
+	actions := make(map[string]struct{})
+	var denies int
+	for _, st := range p.Statements {
+		if st.Effect != "Allow" {
+			denies++
+			continue
+		}
+		for action := range st.Actions {
+			actions[string(action)] = struct{}{}
+		}
+	}
+	allowed := make([]string, 0, len(actions))
+	for action := range actions {
+		allowed = append(allowed, action)
+	}
+	sort.Strings(allowed)
+	summary := "embedded: " + strings.Join(allowed, ",")
+	if len(allowed) == 0 {
+		summary = "embedded: no allowed action"
+	}
+	if denies > 0 {
+		summary += fmt.Sprintf(" (%d deny)", denies)
+	}
+	return summary
+}
+
+// listAllServiceAccounts returns the service accounts of the given
+// parents, indexed by access key.
+func listAllServiceAccounts(client *madmin.AdminClient, parents []string) (map[string]string, *probe.Error) {
+	accounts := make(map[string]string)
+	for _, parent := range parents {
+		resp, e := client.ListServiceAccounts(globalContext, parent)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(parent)
+		}
+		for _, accessKey := range resp.Accounts {
+			accounts[accessKey] = parent
+		}
+	}
+	return accounts, nil
+}
+
+// mainAdminUserSvcAcctInventory is the handle for "mc admin user svcacct inventory" command.
+func mainAdminUserSvcAcctInventory(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, "inventory", globalErrorExitStatus)
+	}
+
+	console.SetColor("SVCHeader", color.New(color.Bold, color.FgCyan))
+	console.SetColor("SVCMessage", color.New(color.FgGreen))
+	console.SetColor("SVCExpired", color.New(color.Bold, color.FgRed))
+	console.SetColor("SVCNote", color.New(color.FgYellow))
+
+	aliasedURL := ctx.Args().Get(0)
+	_, _, aliasCfg, err := expandAlias(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to find the alias.")
+	if aliasCfg == nil {
+		fatalIf(errInvalidAliasedURL(aliasedURL), "No such alias `"+aliasedURL+"` found.")
+	}
+
+	var expiringWithin time.Duration
+	if ctx.IsSet("expiring-within") {
+		expiringWithin = parseSvcAcctDuration(ctx, "expiring-within")
+	}
+
+	client, err := newAdminClient(aliasedURL)
+	fatalIf(err, "Unable to initialize admin connection.")
+
+	users, e := client.ListUsers(globalContext)
+	fatalIf(probe.NewError(e).Trace(aliasedURL), "Unable to list users.")
+
+	// An empty parent lists the service accounts of the alias user.
+	parents := []string{""}
+	for user := range users {
+		parents = append(parents, user)
+	}
+	parents = append(parents, ctx.StringSlice("user")...)
+	accounts, err := listAllServiceAccounts(client, parents)
+	fatalIf(err, "Unable to list service accounts.")
+
+	labelsPath, err := getSvcAcctLabelsPath()
+	fatalIf(err, "Unable to find the service account labels.")
+	labels, err := loadSvcAcctLabels(labelsPath)
+	fatalIf(err, "Unable to read the service account labels.")
+
+	var msgs []svcAcctInventoryMessage
+	now := UTCNow()
+	for accessKey := range accounts {
+		info, e := client.InfoServiceAccount(globalContext, accessKey)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(accessKey), "Unable to get information of the service account.")
+			continue
+		}
+		label := labels.get(aliasCfg.URL, accessKey)
+		msg := svcAcctInventoryMessage{
+			AccessKey:     accessKey,
+			ParentUser:    info.ParentUser,
+			AccountStatus: info.AccountStatus,
+			ImpliedPolicy: info.ImpliedPolicy,
+			Policy:        summarizeSvcAcctPolicy(info.ImpliedPolicy, info.Policy, users[info.ParentUser].PolicyName),
+			Expiry:        label.Expiry,
+			Expired:       label.Expiry != nil && !now.Before(*label.Expiry),
+			RotatedFrom:   label.RotatedFrom,
+			RotatedTo:     label.RotatedTo,
+			DisableAfter:  label.DisableAfter,
+			LabelsFile:    labelsPath,
+			now:           now,
+		}
+		if ctx.Bool("no-expiry") && msg.Expiry != nil {
+			continue
+		}
+		if ctx.IsSet("expiring-within") && (msg.Expiry == nil || msg.Expiry.After(now.Add(expiringWithin))) {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if msgs[i].ParentUser != msgs[j].ParentUser {
+			return msgs[i].ParentUser < msgs[j].ParentUser
+		}
+		return msgs[i].AccessKey < msgs[j].AccessKey
+	})
+
+	if !globalJSON && len(msgs) > 0 {
+		console.Println(console.Colorize("SVCNote", "Expiry and rotation labels are local to this machine, read from "+labelsPath))
+		console.Println(svcAcctInventoryHeader())
+	}
+	for _, msg := range msgs {
+		printMsg(msg)
+	}
+	return nil
+}
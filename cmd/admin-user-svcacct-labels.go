@@ -0,0 +1,153 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// The admin API of this release has no service account description, so
+// the expiry and rotation labels are kept in the local config folder
+// only. They are not shared with other machines or config folders.
+const globalMCSvcAcctLabelsFile = "svcacct-labels.json"
+
+// svcAcctLabel is the lifecycle information of a service account.
+type svcAcctLabel struct {
+	Expiry       *time.Time `json:"expiry,omitempty"`
+	RotatedFrom  string     `json:"rotatedFrom,omitempty"`
+	RotatedTo    string     `json:"rotatedTo,omitempty"`
+	DisableAfter *time.Time `json:"disableAfter,omitempty"`
+}
+
+// svcAcctLabels are the labels of the service accounts of every server,
+// indexed by server URL then by access key.
+type svcAcctLabels map[string]map[string]svcAcctLabel
+
+// getSvcAcctLabelsPath - return the full path of the labels file.
+func getSvcAcctLabelsPath() (string, *probe.Error) {
+	p, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	return filepath.Join(p, globalMCSvcAcctLabelsFile), nil
+}
+
+// loadSvcAcctLabels reads the labels, a missing file has no labels.
+func loadSvcAcctLabels(path string) (svcAcctLabels, *probe.Error) {
+	labels := make(svcAcctLabels)
+	data, e := os.ReadFile(path)
+	if os.IsNotExist(e) {
+		return labels, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	if e = json.Unmarshal(data, &labels); e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	return labels, nil
+}
+
+// save atomically replaces the labels file.
+func (l svcAcctLabels) save(path string) *probe.Error {
+	data, e := json.MarshalIndent(l, "", "  ")
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e = os.MkdirAll(filepath.Dir(path), 0o700); e != nil {
+		return probe.NewError(e).Trace(path)
+	}
+	tmp := path + ".tmp"
+	if e = os.WriteFile(tmp, data, 0o600); e != nil {
+		return probe.NewError(e).Trace(tmp)
+	}
+	if e = os.Rename(tmp, path); e != nil {
+		os.Remove(tmp)
+		return probe.NewError(e).Trace(path)
+	}
+	return nil
+}
+
+func (l svcAcctLabels) get(serverURL, accessKey string) svcAcctLabel {
+	return l[serverURL][accessKey]
+}
+
+func (l svcAcctLabels) set(serverURL, accessKey string, label svcAcctLabel) {
+	if l[serverURL] == nil {
+		l[serverURL] = make(map[string]svcAcctLabel)
+	}
+	l[serverURL][accessKey] = label
+}
+
+// remove forgets the labels of a deleted service account.
+func (l svcAcctLabels) remove(serverURL, accessKey string) {
+	delete(l[serverURL], accessKey)
+	if len(l[serverURL]) == 0 {
+		delete(l, serverURL)
+	}
+}
+
+// dueForDisable returns the access keys of a server whose rotation
+// grace period is over at now.
+func (l svcAcctLabels) dueForDisable(serverURL string, now time.Time) []string {
+	var keys []string
+	for accessKey, label := range l[serverURL] {
+		if label.DisableAfter != nil && !now.Before(*label.DisableAfter) {
+			keys = append(keys, accessKey)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelSvcAcct updates the labels of a service account of an alias,
+// a nil update removes them.
+func labelSvcAcct(aliasedURL, accessKey string, update func(*svcAcctLabel)) *probe.Error {
+	_, _, aliasCfg, err := expandAlias(aliasedURL)
+	if err != nil {
+		return err.Trace(aliasedURL)
+	}
+	if aliasCfg == nil {
+		return errInvalidAliasedURL(aliasedURL)
+	}
+	path, err := getSvcAcctLabelsPath()
+	if err != nil {
+		return err.Trace()
+	}
+	labels, err := loadSvcAcctLabels(path)
+	if err != nil {
+		return err.Trace()
+	}
+	if update == nil {
+		if _, ok := labels[aliasCfg.URL][accessKey]; !ok {
+			return nil
+		}
+		labels.remove(aliasCfg.URL, accessKey)
+	} else {
+		label := labels.get(aliasCfg.URL, accessKey)
+		update(&label)
+		labels.set(aliasCfg.URL, accessKey, label)
+	}
+	return labels.save(path)
+}
@@ -72,6 +72,8 @@ func mainAdminUserSvcAcctRemove(ctx *cli.Context) error {
 	e := client.DeleteServiceAccount(globalContext, svcAccount)
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to remove the specified service account")
 
+	errorIf(labelSvcAcct(aliasedURL, svcAccount, nil), "Unable to remove the labels of the service account.")
+
 	printMsg(svcAcctMessage{
 		op:        "rm",
 		AccessKey: svcAccount,
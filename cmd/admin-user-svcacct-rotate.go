@@ -0,0 +1,324 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var adminUserSvcAcctRotateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "grace",
+		Usage: "keep the old service account enabled for this duration, e.g. 24h or 7d",
+		Value: "24h",
+	},
+	cli.StringFlag{
+		Name:  "expiry",
+		Usage: "label the new service account to expire after this duration, 0 for no expiry",
+		Value: "90d",
+	},
+	cli.StringFlag{
+		Name:  "secret-file",
+		Usage: "write the new credentials to this file in the 'mc alias import' format",
+	},
+	cli.StringFlag{
+		Name:  "update-alias",
+		Usage: "replace the credentials of this alias with the new credentials",
+	},
+	cli.BoolFlag{
+		Name:  "complete",
+		Usage: "disable the rotated service accounts whose grace period is over",
+	},
+}
+
+var adminUserSvcAcctRotateCmd = cli.Command{
+	Name:         "rotate",
+	Usage:        "replace a service account by a new one and disable it after a grace period",
+	Action:       mainAdminUserSvcAcctRotate,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(adminUserSvcAcctRotateFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] ALIAS SERVICE-ACCOUNT
+  {{.HelpName}} --complete ALIAS
+
+  A new service account is created for the same parent user with the same
+  policy. The old service account is disabled once the grace period is over
+  by running the command with --complete, for instance daily from cron.
+  The expiry and rotation labels are NOT stored on the server: they are kept
+  in svcacct-labels.json in the mc configuration folder of this machine and
+  reported by 'mc admin user svcacct inventory'. The old service account is
+  only disabled when --complete runs with the same configuration folder.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Rotate the service account 'J123C4ZXEQN8RK6ND35I', the new account expires in 90 days
+     and the old one can be disabled in 24 hours.
+     {{.Prompt}} {{.HelpName}} myminio/ J123C4ZXEQN8RK6ND35I
+
+  2. Rotate the service account used by the alias 'backup' and update the alias with the
+     new credentials, the old account is disabled right away.
+     {{.Prompt}} {{.HelpName}} --grace 0 --update-alias backup myminio/ J123C4ZXEQN8RK6ND35I
+
+  3. Write the new credentials to a file read by an application, keep the old account for a week.
+     {{.Prompt}} {{.HelpName}} --grace 7d --secret-file /etc/app/credentials.json myminio/ J123C4ZXEQN8RK6ND35I
+
+  4. Disable the rotated service accounts whose grace period is over.
+     {{.Prompt}} {{.HelpName}} --complete myminio/
+`,
+}
+
+// svcAcctRotateMessage is printed for a rotated service account and for
+// every old service account disabled.
+type svcAcctRotateMessage struct {
+	Status       string     `json:"status"`
+	AccessKey    string     `json:"accessKey"`
+	SecretKey    string     `json:"secretKey,omitempty"`
+	ParentUser   string     `json:"parentUser,omitempty"`
+	RotatedFrom  string     `json:"rotatedFrom,omitempty"`
+	Expiry       *time.Time `json:"expiry,omitempty"`
+	DisableAfter *time.Time `json:"disableAfter,omitempty"`
+	Disabled     []string   `json:"disabled,omitempty"`
+	SecretFile   string     `json:"secretFile,omitempty"`
+	Alias        string     `json:"alias,omitempty"`
+}
+
+func (m svcAcctRotateMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func (m svcAcctRotateMessage) String() string {
+	if m.RotatedFrom == "" {
+		return console.Colorize("SVCMessage", "Disabled rotated service account `"+m.AccessKey+"` successfully.")
+	}
+	lines := []string{fmt.Sprintf("Rotated service account `%s` to `%s`.", m.RotatedFrom, m.AccessKey)}
+	if m.SecretKey != "" {
+		lines = append(lines, "Access Key: "+m.AccessKey, "Secret Key: "+m.SecretKey)
+	}
+	if m.SecretFile != "" {
+		lines = append(lines, "Credentials written to `"+m.SecretFile+"`.")
+	}
+	if m.Alias != "" {
+		lines = append(lines, "Alias `"+m.Alias+"` updated.")
+	}
+	if m.Expiry != nil {
+		lines = append(lines, "Expires: "+m.Expiry.Format(printDate))
+	}
+	for _, key := range m.Disabled {
+		lines = append(lines, "Disabled `"+key+"`.")
+	}
+	if m.DisableAfter != nil {
+		lines = append(lines, fmt.Sprintf("`%s` stays enabled until %s, disable it then with '--complete'.",
+			m.RotatedFrom, m.DisableAfter.Format(printDate)))
+	}
+	return console.Colorize("SVCMessage", strings.Join(lines, "\n"))
+}
+
+// parseSvcAcctDuration parses a duration flag, days and weeks are accepted.
+func parseSvcAcctDuration(ctx *cli.Context, name string) time.Duration {
+	d, e := ParseDuration(ctx.String(name))
+	fatalIf(probe.NewError(e).Trace(ctx.String(name)), "Unable to parse --"+name+".")
+	if d < 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.String(name)), "--"+name+" cannot be negative.")
+	}
+	return time.Duration(d)
+}
+
+// writeSvcAcctSecretFile writes the credentials in the 'mc alias import' format.
+func writeSvcAcctSecretFile(path string, aliasCfg aliasConfigV10, creds madmin.Credentials) *probe.Error {
+	data, e := json.MarshalIndent(struct {
+		URL       string `json:"url"`
+		AccessKey string `json:"accessKey"`
+		SecretKey string `json:"secretKey"`
+		API       string `json:"api"`
+		Path      string `json:"path"`
+	}{aliasCfg.URL, creds.AccessKey, creds.SecretKey, aliasCfg.API, aliasCfg.Path}, "", "  ")
+	if e != nil {
+		return probe.NewError(e)
+	}
+	tmp := path + ".tmp"
+	if e = os.WriteFile(tmp, append(data, '\n'), 0o600); e != nil {
+		return probe.NewError(e).Trace(tmp)
+	}
+	if e = os.Rename(tmp, path); e != nil {
+		os.Remove(tmp)
+		return probe.NewError(e).Trace(path)
+	}
+	return nil
+}
+
+// updateAliasCredentials replaces the credentials of an alias, sealed
+// configs are sealed again on save.
+func updateAliasCredentials(alias string, creds madmin.Credentials) *probe.Error {
+	aliasCfg, err := getAliasConfig(alias)
+	if err != nil {
+		return err.Trace(alias)
+	}
+	mcCfg, err := loadMcConfig()
+	if err != nil {
+		return err.Trace(alias)
+	}
+	aliasCfg.AccessKey = creds.AccessKey
+	aliasCfg.SecretKey = creds.SecretKey
+	aliasCfg.SessionToken = ""
+	aliasCfg.Sealed = ""
+	mcCfg.Aliases[alias] = *aliasCfg
+	return saveMcConfig(mcCfg).Trace(alias)
+}
+
+// completeSvcAcctRotations disables the old service accounts whose
+// grace period is over.
+func completeSvcAcctRotations(client *madmin.AdminClient, serverURL string, labels svcAcctLabels, now time.Time) (disabled []string, err *probe.Error) {
+	for _, accessKey := range labels.dueForDisable(serverURL, now) {
+		e := client.UpdateServiceAccount(globalContext, accessKey, madmin.UpdateServiceAccountReq{NewStatus: "off"})
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(accessKey), "Unable to disable the rotated service account `"+accessKey+"`.")
+			err = probe.NewError(errors.New("some rotated service accounts could not be disabled"))
+			continue
+		}
+		label := labels.get(serverURL, accessKey)
+		label.DisableAfter = nil
+		labels.set(serverURL, accessKey, label)
+		disabled = append(disabled, accessKey)
+	}
+	return disabled, err
+}
+
+// mainAdminUserSvcAcctRotate is the handle for "mc admin user svcacct rotate" command.
+func mainAdminUserSvcAcctRotate(ctx *cli.Context) error {
+	args := ctx.Args()
+	complete := ctx.Bool("complete")
+	if (complete && len(args) != 1) || (!complete && len(args) != 2) {
+		showCommandHelpAndExit(ctx, "rotate", globalErrorExitStatus)
+	}
+
+	console.SetColor("SVCMessage", color.New(color.FgGreen))
+
+	aliasedURL := args.Get(0)
+	_, _, aliasCfg, err := expandAlias(aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to find the alias.")
+	if aliasCfg == nil {
+		fatalIf(errInvalidAliasedURL(aliasedURL), "No such alias `"+aliasedURL+"` found.")
+	}
+	serverURL := aliasCfg.URL
+
+	client, err := newAdminClient(aliasedURL)
+	fatalIf(err, "Unable to initialize admin connection.")
+
+	labelsPath, err := getSvcAcctLabelsPath()
+	fatalIf(err, "Unable to find the service account labels.")
+	labels, err := loadSvcAcctLabels(labelsPath)
+	fatalIf(err, "Unable to read the service account labels.")
+
+	now := UTCNow()
+	if complete {
+		disabled, disableErr := completeSvcAcctRotations(client, serverURL, labels, now)
+		fatalIf(labels.save(labelsPath), "Unable to save the service account labels.")
+		for _, accessKey := range disabled {
+			printMsg(svcAcctRotateMessage{AccessKey: accessKey})
+		}
+		if disableErr != nil {
+			return exitStatus(globalErrorExitStatus)
+		}
+		return nil
+	}
+
+	oldKey := args.Get(1)
+	grace := parseSvcAcctDuration(ctx, "grace")
+	expiry := parseSvcAcctDuration(ctx, "expiry")
+
+	info, e := client.InfoServiceAccount(globalContext, oldKey)
+	fatalIf(probe.NewError(e).Trace(args...), "Unable to get information of the service account.")
+
+	// An implied policy is inherited from the parent user, only an
+	// embedded policy is copied.
+	req := madmin.AddServiceAccountReq{TargetUser: info.ParentUser}
+	if !info.ImpliedPolicy && info.Policy != "" {
+		req.Policy = []byte(info.Policy)
+	}
+	creds, e := client.AddServiceAccount(globalContext, req)
+	fatalIf(probe.NewError(e).Trace(args...), "Unable to add the new service account.")
+
+	msg := svcAcctRotateMessage{
+		AccessKey:   creds.AccessKey,
+		ParentUser:  info.ParentUser,
+		RotatedFrom: oldKey,
+		SecretFile:  ctx.String("secret-file"),
+		Alias:       ctx.String("update-alias"),
+	}
+	if msg.SecretFile != "" {
+		fatalIf(writeSvcAcctSecretFile(msg.SecretFile, *aliasCfg, creds), "Unable to write the new credentials, the new service account is `"+creds.AccessKey+"`.")
+	}
+	if msg.Alias != "" {
+		fatalIf(updateAliasCredentials(msg.Alias, creds), "Unable to update the alias, the new service account is `"+creds.AccessKey+"`.")
+	}
+	// The secret key is only printed when it is not saved anywhere.
+	if msg.SecretFile == "" && msg.Alias == "" {
+		msg.SecretKey = creds.SecretKey
+	}
+
+	newLabel := svcAcctLabel{RotatedFrom: oldKey}
+	if expiry > 0 {
+		t := now.Add(expiry)
+		newLabel.Expiry = &t
+		msg.Expiry = &t
+	}
+	labels.set(serverURL, creds.AccessKey, newLabel)
+
+	oldLabel := labels.get(serverURL, oldKey)
+	oldLabel.RotatedTo = creds.AccessKey
+	if grace > 0 {
+		t := now.Add(grace)
+		oldLabel.DisableAfter = &t
+		msg.DisableAfter = &t
+	} else {
+		e = client.UpdateServiceAccount(globalContext, oldKey, madmin.UpdateServiceAccountReq{NewStatus: "off"})
+		if e != nil {
+			// Retried by --complete.
+			errorIf(probe.NewError(e).Trace(oldKey), "Unable to disable the old service account.")
+			oldLabel.DisableAfter = &now
+			msg.DisableAfter = &now
+		} else {
+			msg.Disabled = append(msg.Disabled, oldKey)
+		}
+	}
+	labels.set(serverURL, oldKey, oldLabel)
+	fatalIf(labels.save(labelsPath), "Unable to save the service account labels.")
+
+	printMsg(msg)
+	return nil
+}
@@ -27,6 +27,8 @@ var adminUserSvcAcctSubcommands = []cli.Command{
 	adminUserSvcAcctSetCmd,
 	adminUserSvcAcctEnableCmd,
 	adminUserSvcAcctDisableCmd,
+	adminUserSvcAcctRotateCmd,
+	adminUserSvcAcctInventoryCmd,
 }
 
 var adminUserSvcAcctCmd = cli.Command{
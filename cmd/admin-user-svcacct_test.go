@@ -0,0 +1,167 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+)
+
+func TestSvcAcctLabels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), globalMCSvcAcctLabelsFile)
+	labels, err := loadSvcAcctLabels(path)
+	if err != nil || len(labels) != 0 {
+		t.Fatalf("a missing labels file must be empty: %v %v", labels, err)
+	}
+
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	labels.set("https://a", "OLD1", svcAcctLabel{RotatedTo: "NEW1", DisableAfter: &past})
+	labels.set("https://a", "OLD2", svcAcctLabel{RotatedTo: "NEW2", DisableAfter: &future})
+	labels.set("https://a", "NEW1", svcAcctLabel{RotatedFrom: "OLD1", Expiry: &future})
+	labels.set("https://b", "OLD3", svcAcctLabel{DisableAfter: &past})
+	if err = labels.save(path); err != nil {
+		t.Fatal(err)
+	}
+	if fi, e := os.Stat(path); e != nil || fi.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected labels file %v %v", fi, e)
+	}
+
+	loaded, err := loadSvcAcctLabels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.dueForDisable("https://a", now); !reflect.DeepEqual(got, []string{"OLD1"}) {
+		t.Errorf("unexpected accounts to disable %v", got)
+	}
+	if got := loaded.get("https://a", "NEW1"); got.RotatedFrom != "OLD1" || !got.Expiry.Equal(future) {
+		t.Errorf("unexpected label %+v", got)
+	}
+	loaded.remove("https://b", "OLD3")
+	if _, ok := loaded["https://b"]; ok {
+		t.Error("servers without labels must be removed")
+	}
+}
+
+func TestSummarizeSvcAcctPolicy(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["s3:PutObject","s3:GetObject"],"Resource":["arn:aws:s3:::a/*"]},
+		{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["arn:aws:s3:::b/*"]},
+		{"Effect":"Deny","Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::a/*"]}]}`
+	testCases := []struct {
+		implied              bool
+		policy, parentPolicy string
+		want                 string
+	}{
+		{true, "", "readwrite", "inherited (readwrite)"},
+		{true, "", "", "inherited"},
+		{false, "", "", "inherited"},
+		{false, policy, "", "embedded: s3:GetObject,s3:PutObject (1 deny)"},
+		{false, "{", "", "embedded (invalid)"},
+	}
+	for i, tc := range testCases {
+		if got := summarizeSvcAcctPolicy(tc.implied, tc.policy, tc.parentPolicy); got != tc.want {
+			t.Errorf("%d: got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestWriteSvcAcctSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	aliasCfg := aliasConfigV10{URL: "https://minio.example.com", API: "s3v4", Path: "auto", AccessKey: "OLD", SecretKey: "old-secret"}
+	if err := writeSvcAcctSecretFile(path, aliasCfg, madmin.Credentials{AccessKey: "NEWKEY", SecretKey: "new-secret"}); err != nil {
+		t.Fatal(err)
+	}
+	data, e := os.ReadFile(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	var got aliasConfigV10
+	if e = json.Unmarshal(data, &got); e != nil {
+		t.Fatal(e)
+	}
+	want := aliasConfigV10{URL: "https://minio.example.com", API: "s3v4", Path: "auto", AccessKey: "NEWKEY", SecretKey: "new-secret"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCompleteSvcAcctRotations(t *testing.T) {
+	const secretKey = "minio-secret-key"
+	disabled := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/minio/admin/v3/update-service-account" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		data, e := madmin.DecryptData(secretKey, bytes.NewReader(body))
+		if e != nil {
+			t.Error(e)
+			return
+		}
+		var req madmin.UpdateServiceAccountReq
+		json.Unmarshal(data, &req)
+		accessKey := r.URL.Query().Get("accessKey")
+		if accessKey == "GONE" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		disabled[accessKey] = req.NewStatus
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	client, e := madmin.New(u.Host, "minio", secretKey, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	now := UTCNow()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	labels := make(svcAcctLabels)
+	labels.set(ts.URL, "OLD", svcAcctLabel{RotatedTo: "NEW", DisableAfter: &past})
+	labels.set(ts.URL, "LATER", svcAcctLabel{RotatedTo: "NEW2", DisableAfter: &future})
+	labels.set(ts.URL, "GONE", svcAcctLabel{DisableAfter: &past})
+
+	got, err := completeSvcAcctRotations(client, ts.URL, labels, now)
+	if err == nil {
+		t.Error("the account which could not be disabled must be reported")
+	}
+	if !reflect.DeepEqual(got, []string{"OLD"}) || !reflect.DeepEqual(disabled, map[string]string{"OLD": "off"}) {
+		t.Errorf("unexpected disabled accounts %v %v", got, disabled)
+	}
+	if label := labels.get(ts.URL, "OLD"); label.DisableAfter != nil || label.RotatedTo != "NEW" {
+		t.Errorf("unexpected label of a disabled account %+v", label)
+	}
+	if labels.get(ts.URL, "GONE").DisableAfter == nil {
+		t.Error("the account which could not be disabled must be retried")
+	}
+}
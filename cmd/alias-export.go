@@ -0,0 +1,90 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var aliasExportCmd = cli.Command{
+	Name:            "export",
+	ShortName:       "e",
+	Usage:           "export configuration info of an alias as a JSON formatted string",
+	Action:          mainAliasExport,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} ALIAS
+
+  The output is in the format accepted by 'mc alias import'. Sealed
+  credentials are unsealed, which requires the configuration to be unlocked.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Export the credentials of 'myminio' to a file.
+     {{.Prompt}} {{.HelpName}} myminio/ > credentials.json
+
+  2. Copy the alias 'myminio' to the configuration folder '/tmp/mc'.
+     {{.Prompt}} {{.HelpName}} myminio/ | mc --config-dir /tmp/mc alias import myminio/
+`,
+}
+
+// aliasExportMessage holds the exported configuration of an alias.
+type aliasExportMessage struct {
+	Status string `json:"status,omitempty"`
+	aliasConfigV10
+}
+
+func (a aliasExportMessage) String() string {
+	jsonMessageBytes, e := json.MarshalIndent(a.aliasConfigV10, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func (a aliasExportMessage) JSON() string {
+	a.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(a, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// mainAliasExport is the handle for "mc alias export" command.
+func mainAliasExport(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		showCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1)
+	}
+	alias := cleanAlias(cliCtx.Args().Get(0))
+	if !isValidAlias(alias) {
+		fatalIf(errInvalidAlias(alias), "Invalid alias.")
+	}
+
+	aliasCfg, err := getAliasConfig(alias)
+	fatalIf(err.Trace(alias), "Unable to export alias `"+alias+"`.")
+
+	printMsg(aliasExportMessage{aliasConfigV10: *aliasCfg})
+	return nil
+}
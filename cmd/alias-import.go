@@ -148,6 +148,12 @@ func mainAliasImport(cli *cli.Context) error {
 	e = json.Unmarshal(input, &credentialsJSON)
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to parse input credentials")
 
+	// Credentials sealed by another configuration cannot be imported,
+	// they are sealed again with this configuration's key on save.
+	if credentialsJSON.Sealed != "" {
+		fatalIf(errInvalidArgument().Trace(args...), "Sealed credentials cannot be imported, use `mc alias export` to unseal them first.")
+	}
+
 	msg := importAlias(alias, credentialsJSON)
 	msg.op = cli.Command.Name
 
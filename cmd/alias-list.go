@@ -99,7 +99,7 @@ func printAliases(aliases ...aliasMessage) {
 			// Format properly for alignment based on alias length only in non json mode.
 			alias.Alias = fmt.Sprintf("%-*.*s", maxAlias, maxAlias, alias.Alias)
 		}
-		if alias.AccessKey == "" || alias.SecretKey == "" {
+		if !alias.Sealed && (alias.AccessKey == "" || alias.SecretKey == "") {
 			alias.AccessKey = ""
 			alias.SecretKey = ""
 			alias.API = ""
@@ -130,6 +130,7 @@ func listAliases(alias string, deprecated bool) (aliases []aliasMessage) {
 				AccessKey:   v.AccessKey,
 				SecretKey:   v.SecretKey,
 				API:         v.API,
+				Sealed:      v.Sealed != "",
 			}
 
 			if deprecated {
@@ -151,6 +152,7 @@ func listAliases(alias string, deprecated bool) (aliases []aliasMessage) {
 			AccessKey:   v.AccessKey,
 			SecretKey:   v.SecretKey,
 			API:         v.API,
+			Sealed:      v.Sealed != "",
 		}
 
 		if deprecated {
@@ -0,0 +1,84 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var aliasLockCmd = cli.Command{
+	Name:            "lock",
+	Usage:           "stop the agent holding the key of sealed credentials",
+	Action:          mainAliasLock,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Stop the agent of the current shell session.
+     {{.Prompt}} eval $({{.HelpName}})
+`,
+}
+
+// aliasLockMessage prints shell commands forgetting the agent socket.
+type aliasLockMessage struct {
+	Status string `json:"status"`
+	Socket string `json:"socket"`
+}
+
+func (l aliasLockMessage) String() string {
+	return fmt.Sprintf("unset %s;\necho Agent stopped;", mcEnvAgentSocket)
+}
+
+func (l aliasLockMessage) JSON() string {
+	l.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(l, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// mainAliasLock is the handle for "mc alias lock" command.
+func mainAliasLock(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.Args()...), "Incorrect number of arguments for alias lock command.")
+	}
+
+	socket := os.Getenv(mcEnvAgentSocket)
+	if socket == "" {
+		fatalIf(errInvalidArgument().Trace(), mcEnvAgentSocket+" is not set, no agent to stop.")
+	}
+	err := sealAgentClient{socket: socket}.stop()
+	fatalIf(err.Trace(socket), "Unable to stop the agent.")
+
+	printMsg(aliasLockMessage{Socket: socket})
+	return nil
+}
@@ -42,6 +42,12 @@ var aliasSubcommands = []cli.Command{
 	aliasListCmd,
 	aliasRemoveCmd,
 	aliasImportCmd,
+	aliasExportCmd,
+	aliasSealCmd,
+	aliasUnsealCmd,
+	aliasUnlockCmd,
+	aliasLockCmd,
+	aliasAgentCmd,
 }
 
 var aliasCmd = cli.Command{
@@ -72,6 +78,7 @@ type aliasMessage struct {
 	SecretKey   string `json:"secretKey,omitempty"`
 	API         string `json:"api,omitempty"`
 	Path        string `json:"path,omitempty"`
+	Sealed      bool   `json:"sealed,omitempty"`
 	// Deprecated field, replaced by Path
 	Lookup string `json:"lookup,omitempty"`
 }
@@ -96,7 +103,11 @@ func (h aliasMessage) String() string {
 		if path == "" {
 			path = h.Lookup
 		}
-		return t.buildRecord(h.Alias, h.URL, h.AccessKey, h.SecretKey, h.API, path)
+		secretKey := h.SecretKey
+		if h.Sealed {
+			secretKey = "(sealed)"
+		}
+		return t.buildRecord(h.Alias, h.URL, h.AccessKey, secretKey, h.API, path)
 	case "remove":
 		return console.Colorize("AliasMessage", "Removed `"+h.Alias+"` successfully.")
 	case "add": // add is deprecated
@@ -83,6 +83,12 @@ func mainAliasRemove(ctx *cli.Context, deprecated bool) error {
 // aliasMustExist confirms that a given alias is present in Aliases array, returns error if not found
 
 func aliasMustExist(alias string) {
+	// Sealed aliases exist without having to unseal them.
+	if conf, err := loadMcConfig(); err == nil {
+		if _, ok := conf.Aliases[alias]; ok {
+			return
+		}
+	}
 	hostConfig := mustGetHostConfig(alias)
 	if hostConfig == nil {
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
@@ -0,0 +1,174 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/env"
+	terminal "golang.org/x/term"
+)
+
+var aliasSealFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "keyfile",
+		Usage: "seal with the content of a keyfile instead of a passphrase",
+	},
+}
+
+var aliasSealCmd = cli.Command{
+	Name:            "seal",
+	Usage:           "encrypt the credentials of all aliases in the configuration file",
+	Action:          mainAliasSeal,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           append(aliasSealFlags, globalFlags...),
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+  Secret keys and session tokens are encrypted with a key derived from a
+  passphrase (scrypt) or from the content of a keyfile. Aliases added later
+  are sealed as well. Sealed credentials are unlocked once per shell session
+  with 'mc alias unlock', by setting MC_CONFIG_PASSPHRASE, or automatically
+  when the keyfile is readable (MC_CONFIG_KEYFILE overrides its path).
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Seal all credentials with a passphrase.
+     {{.Prompt}} {{.HelpName}}
+
+  2. Seal all credentials with a keyfile.
+     {{.Prompt}} head -c 32 /dev/urandom > ~/.mc-key
+     {{.Prompt}} {{.HelpName}} --keyfile ~/.mc-key
+`,
+}
+
+// aliasSealMessage is printed after sealing or unsealing the config.
+type aliasSealMessage struct {
+	op      string
+	Status  string `json:"status"`
+	KDF     string `json:"kdf,omitempty"`
+	KeyFile string `json:"keyFile,omitempty"`
+	Aliases int    `json:"aliases"`
+}
+
+func (s aliasSealMessage) String() string {
+	if s.op == "unseal" {
+		return console.Colorize("AliasMessage", fmt.Sprintf("Unsealed the credentials of %d aliases.", s.Aliases))
+	}
+	with := "a passphrase"
+	if s.KDF == sealKDFKeyFile {
+		with = "keyfile `" + s.KeyFile + "`"
+	}
+	return console.Colorize("AliasMessage", fmt.Sprintf("Sealed the credentials of %d aliases with %s.", s.Aliases, with))
+}
+
+func (s aliasSealMessage) JSON() string {
+	s.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// readNewSealPassphrase reads the passphrase of a config being sealed,
+// asking twice on a terminal.
+func readNewSealPassphrase() ([]byte, *probe.Error) {
+	if passphrase := env.Get(mcEnvConfigPassphrase, ""); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	passphrase, err := readSealPassphrase("Enter new config passphrase: ")
+	if err != nil {
+		return nil, err.Trace()
+	}
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		confirm, err := readSealPassphrase("Confirm config passphrase: ")
+		if err != nil {
+			return nil, err.Trace()
+		}
+		if !bytes.Equal(passphrase, confirm) {
+			return nil, probe.NewError(errors.New("passphrases do not match"))
+		}
+	}
+	return passphrase, nil
+}
+
+// mainAliasSeal is the handle for "mc alias seal" command.
+func mainAliasSeal(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.Args()...), "Incorrect number of arguments for alias seal command.")
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+	if conf.Seal != nil {
+		fatalIf(errInvalidArgument().Trace(), "Configuration file is already sealed.")
+	}
+
+	var (
+		kdf     = sealKDFScrypt
+		keyFile = cliCtx.String("keyfile")
+		secret  []byte
+	)
+	if keyFile != "" {
+		kdf = sealKDFKeyFile
+		absKeyFile, e := filepath.Abs(keyFile)
+		fatalIf(probe.NewError(e).Trace(keyFile), "Unable to locate the keyfile.")
+		keyFile = absKeyFile
+		secret, err = readSealSecret(&sealConfigV10{KDF: kdf, KeyFile: keyFile}, true)
+		fatalIf(err.Trace(keyFile), "Unable to read the keyfile.")
+	} else {
+		secret, err = readNewSealPassphrase()
+		fatalIf(err.Trace(), "Unable to read the passphrase.")
+	}
+
+	seal, key, err := newSealConfig(kdf, keyFile, secret)
+	fatalIf(err.Trace(), "Unable to derive the sealing key.")
+
+	// Seal every alias with the new key before saving.
+	conf.Seal = seal
+	globalConfigSealer = key
+	err = sealMcConfig(conf)
+	fatalIf(err.Trace(), "Unable to seal the credentials.")
+	err = saveMcConfig(conf)
+	fatalIf(err.Trace(), "Unable to save the sealed configuration file.")
+
+	var sealed int
+	for _, aliasCfg := range conf.Aliases {
+		if aliasCfg.Sealed != "" {
+			sealed++
+		}
+	}
+	printMsg(aliasSealMessage{op: "seal", KDF: kdf, KeyFile: keyFile, Aliases: sealed})
+	return nil
+}
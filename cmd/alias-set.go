@@ -27,6 +27,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -51,6 +52,14 @@ var aliasSetFlags = []cli.Flag{
 		Name:  "api",
 		Usage: "API signature. Valid options are '[S3v4, S3v2]'",
 	},
+	cli.StringFlag{
+		Name:  "client-cert",
+		Usage: "client certificate presented to servers requiring mTLS",
+	},
+	cli.StringFlag{
+		Name:  "client-key",
+		Usage: "private key of the client certificate",
+	},
 }
 
 var aliasSetCmd = cli.Command{
@@ -97,6 +106,8 @@ EXAMPLES:
      {{.Prompt}} echo -e "BKIKJAA5BMMU2RHO6IBB\nV8f1CwQqAcwo80UEIJEjc5gVQUSSx5ohQ9GSrr12" | \
                  {{.HelpName}} mys3 https://s3.amazonaws.com --api "s3v4" --path "off"
      {{.EnableHistory}}
+  6. Add a MinIO deployment requiring mTLS under "myminio" alias, prompting for keys.
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com --client-cert client.crt --client-key client.key
 `,
 }
 
@@ -179,18 +190,20 @@ func setAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
 
 // probeS3Signature - auto probe S3 server signature: issue a Stat call
 // using v4 signature then v2 in case of failure.
-func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, peerCert *x509.Certificate) (string, *probe.Error) {
+func probeS3Signature(ctx context.Context, aliasCfg *aliasConfigV10, peerCert *x509.Certificate) (string, *probe.Error) {
 	probeBucketName := randString(60, rand.NewSource(time.Now().UnixNano()), "probe-bucket-sign-")
 	// Test s3 connection for API auto probe
 	s3Config := &Config{
 		// S3 connection parameters
 		Insecure:          globalInsecure,
-		AccessKey:         accessKey,
-		SecretKey:         secretKey,
-		HostURL:           urlJoinPath(url, probeBucketName),
+		AccessKey:         aliasCfg.AccessKey,
+		SecretKey:         aliasCfg.SecretKey,
+		HostURL:           urlJoinPath(aliasCfg.URL, probeBucketName),
 		Debug:             globalDebug,
 		ConnReadDeadline:  globalConnReadDeadline,
 		ConnWriteDeadline: globalConnWriteDeadline,
+		ClientCert:        aliasCfg.ClientCert,
+		ClientKey:         aliasCfg.ClientKey,
 	}
 	if peerCert != nil {
 		configurePeerCertificate(s3Config, peerCert)
@@ -234,13 +247,8 @@ func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, pee
 
 // BuildS3Config constructs an S3 Config and does
 // signature auto-probe when needed.
-func BuildS3Config(ctx context.Context, url, alias, accessKey, secretKey, api, path string, peerCert *x509.Certificate) (*Config, *probe.Error) {
-	s3Config := NewS3Config(url, &aliasConfigV10{
-		AccessKey: accessKey,
-		SecretKey: secretKey,
-		URL:       url,
-		Path:      path,
-	})
+func BuildS3Config(ctx context.Context, alias, api string, aliasCfg *aliasConfigV10, peerCert *x509.Certificate) (*Config, *probe.Error) {
+	s3Config := NewS3Config(aliasCfg.URL, aliasCfg)
 
 	if peerCert != nil {
 		configurePeerCertificate(s3Config, peerCert)
@@ -253,9 +261,9 @@ func BuildS3Config(ctx context.Context, url, alias, accessKey, secretKey, api, p
 		return s3Config, nil
 	}
 	// Probe S3 signature version
-	api, err := probeS3Signature(ctx, accessKey, secretKey, url, peerCert)
+	api, err := probeS3Signature(ctx, aliasCfg, peerCert)
 	if err != nil {
-		return nil, err.Trace(url, accessKey, secretKey, api, path)
+		return nil, err.Trace(aliasCfg.URL, aliasCfg.AccessKey, aliasCfg.SecretKey, api, aliasCfg.Path)
 	}
 
 	s3Config.Signature = api
@@ -263,6 +271,29 @@ func BuildS3Config(ctx context.Context, url, alias, accessKey, secretKey, api, p
 	return s3Config, nil
 }
 
+// fetchAliasClientCert - returns the absolute paths of the client
+// certificate and key of an alias, and the loaded key pair.
+func fetchAliasClientCert(ctx *cli.Context) (string, string, *tls.Certificate) {
+	clientCert, clientKey := ctx.String("client-cert"), ctx.String("client-key")
+	if clientCert == "" && clientKey == "" {
+		return "", "", nil
+	}
+	if clientCert == "" || clientKey == "" {
+		fatalIf(errInvalidArgument().Trace(clientCert, clientKey),
+			"Both --client-cert and --client-key are required for mTLS.")
+	}
+
+	var e error
+	clientCert, e = filepath.Abs(clientCert)
+	fatalIf(probe.NewError(e).Trace(clientCert), "Unable to locate the client certificate.")
+	clientKey, e = filepath.Abs(clientKey)
+	fatalIf(probe.NewError(e).Trace(clientKey), "Unable to locate the client key.")
+
+	keyPair, e := tls.LoadX509KeyPair(clientCert, clientKey)
+	fatalIf(probe.NewError(e).Trace(clientCert, clientKey), "Unable to load the client certificate.")
+	return clientCert, clientKey, &keyPair
+}
+
 // fetchAliasKeys - returns the user accessKey and secretKey
 func fetchAliasKeys(args cli.Args) (string, string) {
 	accessKey := ""
@@ -333,21 +364,41 @@ func mainAliasSet(cli *cli.Context, deprecated bool) error {
 	ctx, cancelAliasAdd := context.WithCancel(globalContext)
 	defer cancelAliasAdd()
 
+	clientCert, clientKey, keyPair := fetchAliasClientCert(cli)
+
 	if !globalInsecure && !globalJSON && term.IsTerminal(int(os.Stdout.Fd())) {
-		peerCert, err = promptTrustSelfSignedCert(ctx, url, alias)
+		peerCert, err = promptTrustSelfSignedCert(ctx, url, alias, keyPair)
 		fatalIf(err.Trace(cli.Args()...), "Unable to initialize new alias from the provided credentials.")
 	}
 
-	s3Config, err := BuildS3Config(ctx, url, alias, accessKey, secretKey, api, path, peerCert)
+	s3Config, err := BuildS3Config(ctx, alias, api, &aliasConfigV10{
+		URL:        url,
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		Path:       path,
+		ClientCert: clientCert,
+		ClientKey:  clientKey,
+	}, peerCert)
 	fatalIf(err.Trace(cli.Args()...), "Unable to initialize new alias from the provided credentials.")
 
-	msg := setAlias(alias, aliasConfigV10{
-		URL:       s3Config.HostURL,
-		AccessKey: s3Config.AccessKey,
-		SecretKey: s3Config.SecretKey,
-		API:       s3Config.Signature,
-		Path:      path,
-	}) // Add an alias with specified credentials.
+	aliasCfg := aliasConfigV10{
+		URL:        s3Config.HostURL,
+		AccessKey:  s3Config.AccessKey,
+		SecretKey:  s3Config.SecretKey,
+		API:        s3Config.Signature,
+		Path:       path,
+		ClientCert: clientCert,
+		ClientKey:  clientKey,
+	}
+	// Public keys pinned with 'mc certs pin' stay valid as long as the
+	// alias points to the same server.
+	if mcCfg, err := loadMcConfig(); err == nil {
+		if prevCfg, ok := mcCfg.Aliases[alias]; ok && prevCfg.URL == aliasCfg.URL {
+			aliasCfg.PinnedKeys = prevCfg.PinnedKeys
+		}
+	}
+
+	msg := setAlias(alias, aliasCfg) // Add an alias with specified credentials.
 
 	msg.op = "set"
 	if deprecated {
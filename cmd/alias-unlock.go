@@ -0,0 +1,200 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+const sealAgentStartTimeout = 5 * time.Second
+
+var aliasUnlockFlags = []cli.Flag{
+	cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "stop the agent and forget the key after this duration",
+		Value: time.Hour,
+	},
+}
+
+var aliasUnlockCmd = cli.Command{
+	Name:            "unlock",
+	Usage:           "start an agent holding the key of sealed credentials",
+	Action:          mainAliasUnlock,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           append(aliasUnlockFlags, globalFlags...),
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS]
+
+  The agent listens on a private local socket, similar to ssh-agent, and
+  prints the shell commands exporting MC_AGENT_SOCK. Commands run from a
+  shell where this variable is set use the agent to unseal credentials.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Unlock sealed credentials for the current shell session.
+     {{.Prompt}} eval $({{.HelpName}})
+
+  2. Unlock sealed credentials for 10 minutes.
+     {{.Prompt}} eval $({{.HelpName}} --timeout 10m)
+`,
+}
+
+// aliasAgentCmd runs the agent, it is started by 'mc alias unlock'.
+var aliasAgentCmd = cli.Command{
+	Name:   "agent",
+	Hidden: true,
+	Action: mainAliasAgent,
+	Before: setGlobalsFromContext,
+	Flags: append([]cli.Flag{
+		cli.StringFlag{Name: "socket"},
+		cli.DurationFlag{Name: "timeout"},
+	}, globalFlags...),
+}
+
+// aliasUnlockMessage prints shell commands exporting the agent socket.
+type aliasUnlockMessage struct {
+	Status string    `json:"status"`
+	Socket string    `json:"socket"`
+	PID    int       `json:"pid,omitempty"`
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+func (u aliasUnlockMessage) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s=%s; export %s;\n", mcEnvAgentSocket, u.Socket, mcEnvAgentSocket)
+	if u.PID > 0 {
+		fmt.Fprintf(&sb, "echo Agent pid %d;", u.PID)
+	}
+	return sb.String()
+}
+
+func (u aliasUnlockMessage) JSON() string {
+	u.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(u, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// startSealAgent starts the agent in the background and waits until
+// it answers on its socket.
+func startSealAgent(seal *sealConfigV10, key sealKey, timeout time.Duration) (socket string, pid int, err *probe.Error) {
+	socket, err = newSealAgentSocket()
+	if err != nil {
+		return "", 0, err.Trace()
+	}
+
+	exe, e := os.Executable()
+	if e != nil {
+		return "", 0, probe.NewError(e)
+	}
+	agentCmd := exec.Command(exe, "alias", "agent",
+		"--config-dir", mustGetMcConfigDir(),
+		"--socket", socket,
+		"--timeout", timeout.String())
+	// The key is handed over on standard input, never on the command line.
+	agentCmd.Stdin = strings.NewReader(seal.ID + " " + hex.EncodeToString(key) + "\n")
+	if e = agentCmd.Start(); e != nil {
+		return "", 0, probe.NewError(e)
+	}
+
+	agent := sealAgentClient{socket: socket, id: seal.ID}
+	deadline := time.Now().Add(sealAgentStartTimeout)
+	for {
+		if err = agent.ping(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			agentCmd.Process.Kill()
+			return "", 0, probe.NewError(errors.New("agent did not start in time"))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	pid = agentCmd.Process.Pid
+	agentCmd.Process.Release()
+	return socket, pid, nil
+}
+
+// mainAliasUnlock is the handle for "mc alias unlock" command.
+func mainAliasUnlock(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.Args()...), "Incorrect number of arguments for alias unlock command.")
+	}
+	timeout := cliCtx.Duration("timeout")
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+	if conf.Seal == nil {
+		fatalIf(errInvalidArgument().Trace(), "Configuration file is not sealed.")
+	}
+
+	// Reuse an agent already unlocked for this configuration.
+	if socket := os.Getenv(mcEnvAgentSocket); socket != "" {
+		if (sealAgentClient{socket: socket, id: conf.Seal.ID}).ping() == nil {
+			printMsg(aliasUnlockMessage{Socket: socket})
+			return nil
+		}
+	}
+
+	key, err := unlockSealKey(conf.Seal, true)
+	fatalIf(err.Trace(), "Unable to unlock the configuration file.")
+
+	socket, pid, err := startSealAgent(conf.Seal, key, timeout)
+	fatalIf(err.Trace(), "Unable to start the agent.")
+
+	msg := aliasUnlockMessage{Socket: socket, PID: pid}
+	if timeout > 0 {
+		msg.Expiry = UTCNow().Add(timeout)
+	}
+	printMsg(msg)
+	return nil
+}
+
+// mainAliasAgent is the handle for the hidden "mc alias agent" command.
+func mainAliasAgent(cliCtx *cli.Context) error {
+	line, e := bufio.NewReader(os.Stdin).ReadString('\n')
+	fatalIf(probe.NewError(e), "Unable to read the agent key.")
+
+	id, hexKey, ok := strings.Cut(strings.TrimSpace(line), " ")
+	if !ok {
+		fatalIf(errInvalidArgument().Trace(), "Unable to read the agent key.")
+	}
+	key, e := hex.DecodeString(hexKey)
+	fatalIf(probe.NewError(e), "Unable to read the agent key.")
+
+	err := runSealAgent(cliCtx.String("socket"), id, key, cliCtx.Duration("timeout"))
+	fatalIf(err.Trace(), "Unable to run the agent.")
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var aliasUnsealCmd = cli.Command{
+	Name:            "unseal",
+	Usage:           "store the credentials of all aliases unencrypted again",
+	Action:          mainAliasUnseal,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Decrypt all credentials and stop sealing new ones.
+     {{.Prompt}} {{.HelpName}}
+`,
+}
+
+// mainAliasUnseal is the handle for "mc alias unseal" command.
+func mainAliasUnseal(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.Args()...), "Incorrect number of arguments for alias unseal command.")
+	}
+	console.SetColor("AliasMessage", color.New(color.FgGreen))
+
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+	if conf.Seal == nil {
+		fatalIf(errInvalidArgument().Trace(), "Configuration file is not sealed.")
+	}
+
+	sealer, err := getConfigSealer(conf.Seal, true)
+	fatalIf(err.Trace(), "Unable to unlock the configuration file.")
+
+	var unsealed int
+	for alias, aliasCfg := range conf.Aliases {
+		if aliasCfg.Sealed == "" {
+			continue
+		}
+		err = unsealAliasConfig(sealer, alias, &aliasCfg)
+		fatalIf(err.Trace(alias), "Unable to unseal the credentials of `"+alias+"`.")
+		conf.Aliases[alias] = aliasCfg
+		unsealed++
+	}
+	conf.Seal = nil
+
+	err = saveMcConfig(conf)
+	fatalIf(err.Trace(), "Unable to save the unsealed configuration file.")
+
+	printMsg(aliasSealMessage{op: "unseal", Aliases: unsealed})
+	return nil
+}
@@ -0,0 +1,554 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	jsoncolor "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+	"github.com/minio/pkg/bucket/policy/condition"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/wildcard"
+)
+
+// Access levels reported by 'anonymous audit'.
+const (
+	anonymousAccessPublic      = "public"
+	anonymousAccessConditional = "conditional"
+	anonymousAccessNone        = "none"
+)
+
+// anonymousAuditKey is the object name used to evaluate, and probe,
+// access to a prefix, it replaces the wildcards of resource patterns.
+const anonymousAuditKey = "mc-anonymous-audit"
+
+// anonymousAuditStatement is a bucket policy statement as written,
+// Not* elements included, which the server side parser rejects.
+type anonymousAuditStatement struct {
+	index       int
+	sid         string
+	effect      string
+	anonymous   bool
+	actions     []string
+	notAction   bool
+	resources   []string
+	notResource bool
+	conditions  condition.Functions
+	condKeys    []string
+	condErr     error
+	// Listing prefixes allowed by s3:prefix conditions.
+	listPrefixes []string
+}
+
+// policyStringList decodes a policy element which is either a string
+// or a list of strings.
+func policyStringList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []string{s}, nil
+	}
+	var l []string
+	if e := json.Unmarshal(raw, &l); e != nil {
+		return nil, e
+	}
+	return l, nil
+}
+
+// policyPrincipalIsAnyone tells whether a principal element includes
+// everyone, i.e. "*" or {"AWS": "*"}.
+func policyPrincipalIsAnyone(raw json.RawMessage) (bool, error) {
+	if l, e := policyStringList(raw); e == nil {
+		for _, p := range l {
+			if p == "*" {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	var m map[string]json.RawMessage
+	if e := json.Unmarshal(raw, &m); e != nil {
+		return false, e
+	}
+	l, e := policyStringList(m["AWS"])
+	if e != nil {
+		return false, e
+	}
+	for _, p := range l {
+		if p == "*" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// parseAnonymousAuditPolicy parses the statements of a bucket policy.
+func parseAnonymousAuditPolicy(policyJSON string) ([]anonymousAuditStatement, error) {
+	var raw struct {
+		Statement json.RawMessage
+	}
+	if e := json.Unmarshal([]byte(policyJSON), &raw); e != nil {
+		return nil, e
+	}
+	type rawStatement struct {
+		Sid          string
+		Effect       string
+		Principal    json.RawMessage
+		NotPrincipal json.RawMessage
+		Action       json.RawMessage
+		NotAction    json.RawMessage
+		Resource     json.RawMessage
+		NotResource  json.RawMessage
+		Condition    json.RawMessage
+	}
+	var rawStatements []rawStatement
+	if e := json.Unmarshal(raw.Statement, &rawStatements); e != nil {
+		var single rawStatement
+		if e = json.Unmarshal(raw.Statement, &single); e != nil {
+			return nil, e
+		}
+		rawStatements = []rawStatement{single}
+	}
+
+	statements := make([]anonymousAuditStatement, 0, len(rawStatements))
+	for i, rs := range rawStatements {
+		st := anonymousAuditStatement{index: i + 1, sid: rs.Sid, effect: rs.Effect}
+		var e error
+		switch {
+		case len(rs.Principal) > 0:
+			st.anonymous, e = policyPrincipalIsAnyone(rs.Principal)
+		case len(rs.NotPrincipal) > 0:
+			// Anonymous users are never named, the statement applies
+			// to them unless everyone is excluded.
+			var anyone bool
+			anyone, e = policyPrincipalIsAnyone(rs.NotPrincipal)
+			st.anonymous = !anyone
+		}
+		if e != nil {
+			return nil, fmt.Errorf("statement #%d: invalid principal: %w", i+1, e)
+		}
+		if st.actions, e = policyStringList(rs.Action); e == nil && len(rs.NotAction) > 0 {
+			st.actions, e = policyStringList(rs.NotAction)
+			st.notAction = true
+		}
+		if e != nil {
+			return nil, fmt.Errorf("statement #%d: invalid action: %w", i+1, e)
+		}
+		if st.resources, e = policyStringList(rs.Resource); e == nil && len(rs.NotResource) > 0 {
+			st.resources, e = policyStringList(rs.NotResource)
+			st.notResource = true
+		}
+		if e != nil {
+			return nil, fmt.Errorf("statement #%d: invalid resource: %w", i+1, e)
+		}
+		if len(rs.Condition) > 0 && string(rs.Condition) != "null" {
+			var conds map[string]map[string]json.RawMessage
+			if e = json.Unmarshal(rs.Condition, &conds); e != nil {
+				return nil, fmt.Errorf("statement #%d: invalid condition: %w", i+1, e)
+			}
+			keys := make(map[string]struct{})
+			for _, kvs := range conds {
+				for k, v := range kvs {
+					keys[k] = struct{}{}
+					if strings.EqualFold(k, "s3:prefix") {
+						prefixes, _ := policyStringList(v)
+						st.listPrefixes = append(st.listPrefixes, prefixes...)
+					}
+				}
+			}
+			for k := range keys {
+				st.condKeys = append(st.condKeys, k)
+			}
+			sort.Strings(st.condKeys)
+			// Conditions unknown to MinIO are never satisfied.
+			st.condErr = json.Unmarshal(rs.Condition, &st.conditions)
+		}
+		statements = append(statements, st)
+	}
+	return statements, nil
+}
+
+func (st anonymousAuditStatement) matchAction(action string) bool {
+	matched := false
+	for _, pattern := range st.actions {
+		if wildcard.Match(strings.ToLower(pattern), strings.ToLower(action)) {
+			matched = true
+			break
+		}
+	}
+	return matched != st.notAction
+}
+
+func (st anonymousAuditStatement) matchResource(resource string) bool {
+	matched := false
+	for _, pattern := range st.resources {
+		if wildcard.Match(pattern, resource) {
+			matched = true
+			break
+		}
+	}
+	return matched != st.notResource
+}
+
+// anonymousAuditAccess is the anonymous access to a prefix for one
+// kind of request.
+type anonymousAuditAccess struct {
+	Level      string                 `json:"level"`
+	Conditions []string               `json:"conditions,omitempty"`
+	Statements []policyStatementMatch `json:"statements,omitempty"`
+	Probe      string                 `json:"probe,omitempty"`
+}
+
+// evaluateAnonymousAccess evaluates an anonymous request. Allow statements
+// whose conditions are not met by a plain anonymous request make the access
+// conditional, a matching deny statement overrides any allow.
+func evaluateAnonymousAccess(bucket string, statements []anonymousAuditStatement, action, objectName string, values map[string][]string) anonymousAuditAccess {
+	resource := "arn:aws:s3:::" + bucket
+	if objectName != "" {
+		resource += "/" + objectName
+	}
+	var allowed, denied, conditional bool
+	var access anonymousAuditAccess
+	condKeys := make(map[string]struct{})
+	for _, st := range statements {
+		if !st.anonymous || !st.matchAction(action) || !st.matchResource(resource) {
+			continue
+		}
+		met := st.condErr == nil && st.conditions.Evaluate(values)
+		if st.effect == "Deny" {
+			if !met {
+				continue
+			}
+			denied = true
+		} else if met {
+			allowed = true
+		} else {
+			conditional = true
+			for _, k := range st.condKeys {
+				condKeys[k] = struct{}{}
+			}
+		}
+		access.Statements = append(access.Statements, policyStatementMatch{
+			Policy:    bucket,
+			Statement: st.index,
+			SID:       st.sid,
+			Effect:    st.effect,
+		})
+	}
+	switch {
+	case denied:
+		access.Level = anonymousAccessNone
+	case allowed:
+		access.Level = anonymousAccessPublic
+	case conditional:
+		access.Level = anonymousAccessConditional
+		for k := range condKeys {
+			access.Conditions = append(access.Conditions, k)
+		}
+		sort.Strings(access.Conditions)
+	default:
+		access.Level = anonymousAccessNone
+	}
+	return access
+}
+
+// anonymousAuditPrefixes returns the object name patterns the policy
+// grants access to in bucket, the whole bucket included.
+func anonymousAuditPrefixes(bucket string, statements []anonymousAuditStatement) []string {
+	patterns := map[string]struct{}{"*": {}}
+	for _, st := range statements {
+		if !st.anonymous || st.effect != "Allow" || st.notResource {
+			continue
+		}
+		for _, resource := range st.resources {
+			bucketPattern, objectPattern := parsePolicyResource(resource)
+			if objectPattern == "" || !wildcard.Match(bucketPattern, bucket) {
+				continue
+			}
+			patterns[objectPattern] = struct{}{}
+		}
+		for _, prefix := range st.listPrefixes {
+			if !strings.HasSuffix(prefix, "*") {
+				prefix += "*"
+			}
+			patterns[prefix] = struct{}{}
+		}
+	}
+	prefixes := make([]string, 0, len(patterns))
+	for p := range patterns {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// anonymousAuditSample returns an object name matching pattern and the
+// listing prefix preceding its first wildcard.
+func anonymousAuditSample(pattern string) (object, listPrefix string) {
+	listPrefix = pattern
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		listPrefix = pattern[:i]
+	}
+	object = strings.NewReplacer("*", anonymousAuditKey, "?", "a").Replace(pattern)
+	return object, listPrefix
+}
+
+// anonymousAuditMessage is the effective anonymous access to a prefix.
+type anonymousAuditMessage struct {
+	Status string               `json:"status"`
+	Bucket string               `json:"bucket"`
+	Prefix string               `json:"prefix"`
+	Read   anonymousAuditAccess `json:"read"`
+	Write  anonymousAuditAccess `json:"write"`
+	Delete anonymousAuditAccess `json:"delete"`
+	List   anonymousAuditAccess `json:"list"`
+}
+
+// auditBucketPolicy computes the anonymous access matrix of a bucket,
+// only prefixes with some anonymous access are returned.
+func auditBucketPolicy(bucket, policyJSON string) ([]anonymousAuditMessage, error) {
+	statements, e := parseAnonymousAuditPolicy(policyJSON)
+	if e != nil {
+		return nil, e
+	}
+	values, err := newPolicyConditionValues("", "", nil)
+	if err != nil {
+		return nil, err.ToGoError()
+	}
+	var msgs []anonymousAuditMessage
+	for _, pattern := range anonymousAuditPrefixes(bucket, statements) {
+		object, listPrefix := anonymousAuditSample(pattern)
+		listValues := make(map[string][]string, len(values)+1)
+		for k, v := range values {
+			listValues[k] = v
+		}
+		listValues[condition.S3Prefix.Name()] = []string{listPrefix}
+
+		msg := anonymousAuditMessage{
+			Bucket: bucket,
+			Prefix: pattern,
+			Read:   evaluateAnonymousAccess(bucket, statements, "s3:GetObject", object, values),
+			Write:  evaluateAnonymousAccess(bucket, statements, "s3:PutObject", object, values),
+			Delete: evaluateAnonymousAccess(bucket, statements, "s3:DeleteObject", object, values),
+			List:   evaluateAnonymousAccess(bucket, statements, "s3:ListBucket", "", listValues),
+		}
+		if msg.Read.Level == anonymousAccessNone && msg.Write.Level == anonymousAccessNone &&
+			msg.Delete.Level == anonymousAccessNone && msg.List.Level == anonymousAccessNone {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+var anonymousAuditTable = newPrettyTable(" | ",
+	Field{"Bucket", 24},
+	Field{"Prefix", 30},
+	Field{"Read", 24},
+	Field{"Write", 12},
+	Field{"Delete", 12},
+	Field{"List", 24},
+	Field{"Conditions", 40},
+)
+
+func anonymousAuditHeader() string {
+	return console.Colorize("AuditHeader", anonymousAuditTable.buildRow(
+		"Bucket", "Prefix", "Read", "Write", "Delete", "List", "Conditions"))
+}
+
+func (a anonymousAuditAccess) cell() string {
+	switch {
+	case a.Level == anonymousAccessNone:
+		return "-"
+	case a.Probe != "":
+		return a.Level + " (" + a.Probe + ")"
+	}
+	return a.Level
+}
+
+func (m anonymousAuditMessage) String() string {
+	theme := "AuditConditional"
+	condKeys := make(map[string]struct{})
+	for _, a := range []anonymousAuditAccess{m.Read, m.Write, m.Delete, m.List} {
+		if a.Level == anonymousAccessPublic {
+			theme = "AuditPublic"
+		}
+		for _, k := range a.Conditions {
+			condKeys[k] = struct{}{}
+		}
+	}
+	conds := make([]string, 0, len(condKeys))
+	for k := range condKeys {
+		conds = append(conds, k)
+	}
+	sort.Strings(conds)
+	return console.Colorize(theme, anonymousAuditTable.buildRow(
+		m.Bucket, m.Prefix, m.Read.cell(), m.Write.cell(), m.Delete.cell(), m.List.cell(), strings.Join(conds, ",")))
+}
+
+func (m anonymousAuditMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := jsoncolor.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// anonymousAuditSummary is printed once all buckets are audited.
+type anonymousAuditSummary struct {
+	Status        string `json:"status"`
+	Buckets       int    `json:"buckets"`
+	PublicBuckets int    `json:"publicBuckets"`
+	Prefixes      int    `json:"prefixes"`
+}
+
+func (s anonymousAuditSummary) String() string {
+	return console.Colorize("Anonymous", fmt.Sprintf("%d bucket(s) audited, %d with anonymous access on %d prefix(es).",
+		s.Buckets, s.PublicBuckets, s.Prefixes))
+}
+
+func (s anonymousAuditSummary) JSON() string {
+	s.Status = "success"
+	jsonMessageBytes, e := jsoncolor.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// probeAnonymousRequest sends an unauthenticated GET and tells whether
+// the server let it through. A missing object is an allowed request.
+func probeAnonymousRequest(ctx context.Context, client *http.Client, url string) string {
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if e != nil {
+		return "error"
+	}
+	resp, e := client.Do(req)
+	if e != nil {
+		return "unreachable"
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusNotFound:
+		return "confirmed"
+	case resp.StatusCode == http.StatusForbidden:
+		return "denied"
+	}
+	return "HTTP " + resp.Status
+}
+
+// probeAnonymousAccess confirms read and list access with unauthenticated
+// requests, writes and deletes are never probed.
+func probeAnonymousAccess(ctx context.Context, client *http.Client, endpoint string, msg *anonymousAuditMessage) {
+	object, listPrefix := anonymousAuditSample(msg.Prefix)
+	base := strings.TrimSuffix(endpoint, "/") + "/" + s3utils.EncodePath(msg.Bucket)
+	if msg.Read.Level != anonymousAccessNone {
+		msg.Read.Probe = probeAnonymousRequest(ctx, client, base+"/"+s3utils.EncodePath(object))
+	}
+	if msg.List.Level != anonymousAccessNone {
+		msg.List.Probe = probeAnonymousRequest(ctx, client, base+"/?list-type=2&max-keys=1&prefix="+s3utils.EncodePath(listPrefix))
+	}
+}
+
+// runAnonymousAuditCmd audits the bucket policies of all buckets of an
+// alias, or of a single bucket.
+func runAnonymousAuditCmd(args []string, probeAccess bool) {
+	ctx, cancelAnonymousAudit := context.WithCancel(globalContext)
+	defer cancelAnonymousAudit()
+
+	console.SetColor("AuditHeader", color.New(color.Bold, color.FgCyan))
+	console.SetColor("AuditPublic", color.New(color.Bold, color.FgRed))
+	console.SetColor("AuditConditional", color.New(color.FgYellow))
+
+	targetURL := args[0]
+	alias, path := url2Alias(targetURL)
+	_, _, aliasCfg, err := expandAlias(targetURL)
+	fatalIf(err.Trace(targetURL), "Unable to find the alias.")
+	if aliasCfg == nil {
+		fatalIf(errInvalidAliasedURL(targetURL), "No such alias `"+targetURL+"` found.")
+	}
+
+	var buckets []string
+	if bucket, _, _ := strings.Cut(strings.Trim(path, "/"), "/"); bucket != "" {
+		buckets = []string{bucket}
+	} else {
+		clnt, err := newClient(alias)
+		fatalIf(err.Trace(alias), "Unable to initialize target `"+alias+"`.")
+		for content := range clnt.List(ctx, ListOptions{ShowDir: DirNone}) {
+			fatalIf(content.Err.Trace(alias), "Unable to list buckets.")
+			buckets = append(buckets, strings.Trim(content.URL.Path, "/"))
+		}
+	}
+
+	var probeClient *http.Client
+	if probeAccess {
+		probeClient = httpClient(30 * time.Second)
+		if globalInsecure {
+			probeClient.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+		}
+	}
+
+	if !globalJSON {
+		console.Println(anonymousAuditHeader())
+	}
+	summary := anonymousAuditSummary{}
+	failed := false
+	for _, bucket := range buckets {
+		bucketURL := alias + "/" + bucket
+		_, policyJSON, err := doGetAccess(ctx, bucketURL)
+		if err != nil {
+			errorIf(err.Trace(bucketURL), "Unable to get the anonymous policy of `"+bucketURL+"`.")
+			failed = true
+			continue
+		}
+		summary.Buckets++
+		if policyJSON == "" {
+			continue
+		}
+		msgs, e := auditBucketPolicy(bucket, policyJSON)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(bucketURL), "Unable to parse the anonymous policy of `"+bucketURL+"`.")
+			failed = true
+			continue
+		}
+		if len(msgs) > 0 {
+			summary.PublicBuckets++
+		}
+		for _, msg := range msgs {
+			if probeAccess {
+				probeAnonymousAccess(ctx, probeClient, aliasCfg.URL, &msg)
+			}
+			summary.Prefixes++
+			printMsg(msg)
+		}
+	}
+	printMsg(summary)
+	if failed {
+		fatalIf(errDummy().Trace(targetURL), "Unable to audit all buckets.")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAuditBucketPolicy(t *testing.T) {
+	type row struct {
+		prefix                    string
+		read, write, delete, list string
+	}
+	testCases := []struct {
+		name   string
+		policy string
+		want   []row
+		conds  []string
+	}{
+		{
+			name: "download",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetBucketLocation","s3:ListBucket"],"Resource":["arn:aws:s3:::pub"]},
+				{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::pub/*"]}]}`,
+			want: []row{{"*", "public", "none", "none", "public"}},
+		},
+		{
+			name: "prefix upload with a deny",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:*","Resource":"arn:aws:s3:::pub/incoming/*"},
+				{"Effect":"Deny","Principal":"*","Action":"s3:DeleteObject","Resource":"arn:aws:s3:::pub/*"}]}`,
+			want: []row{{"incoming/*", "public", "public", "none", "none"}},
+		},
+		{
+			name: "conditional",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::pub/*",
+				 "Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`,
+			want:  []row{{"*", "conditional", "none", "none", "none"}},
+			conds: []string{"aws:SourceIp"},
+		},
+		{
+			name: "NotPrincipal and NotAction",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","NotPrincipal":{"AWS":"arn:aws:iam::1:root"},"NotAction":["s3:PutObject","s3:DeleteObject"],"Resource":"arn:aws:s3:::pub/docs/*"},
+				{"Effect":"Allow","NotPrincipal":{"AWS":"*"},"Action":"s3:PutObject","Resource":"arn:aws:s3:::pub/*"}]}`,
+			want: []row{{"docs/*", "public", "none", "none", "none"}},
+		},
+		{
+			name: "NotResource",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","NotResource":"arn:aws:s3:::pub/private/*"}]}`,
+			want: []row{{"*", "public", "none", "none", "none"}},
+		},
+		{
+			name: "listing of a prefix",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":"*","Action":"s3:ListBucket","Resource":"arn:aws:s3:::pub",
+				 "Condition":{"StringEquals":{"s3:prefix":["public/"]}}}]}`,
+			want:  []row{{"*", "none", "none", "none", "conditional"}, {"public/*", "none", "none", "none", "public"}},
+			conds: []string{"s3:prefix"},
+		},
+		{
+			name: "authenticated only",
+			policy: `{"Version":"2012-10-17","Statement":[
+				{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::1:root"},"Action":"s3:*","Resource":"arn:aws:s3:::pub/*"}]}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msgs, e := auditBucketPolicy("pub", tc.policy)
+			if e != nil {
+				t.Fatal(e)
+			}
+			var got []row
+			var conds []string
+			for _, m := range msgs {
+				got = append(got, row{m.Prefix, m.Read.Level, m.Write.Level, m.Delete.Level, m.List.Level})
+				conds = append(conds, m.Read.Conditions...)
+				conds = append(conds, m.List.Conditions...)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+			if !reflect.DeepEqual(conds, tc.conds) {
+				t.Errorf("got conditions %v, want %v", conds, tc.conds)
+			}
+		})
+	}
+
+	if _, e := auditBucketPolicy("pub", `{"Statement":[{"Principal":{"AWS":1}}]}`); e == nil {
+		t.Error("an invalid principal must be reported")
+	}
+}
+
+func TestAnonymousAuditSample(t *testing.T) {
+	testCases := []struct{ pattern, object, listPrefix string }{
+		{"*", anonymousAuditKey, ""},
+		{"docs/*", "docs/" + anonymousAuditKey, "docs/"},
+		{"a/*/b?.txt", "a/" + anonymousAuditKey + "/ba.txt", "a/"},
+		{"file.txt", "file.txt", "file.txt"},
+	}
+	for _, tc := range testCases {
+		object, listPrefix := anonymousAuditSample(tc.pattern)
+		if object != tc.object || listPrefix != tc.listPrefix {
+			t.Errorf("%s: got %s %s, want %s %s", tc.pattern, object, listPrefix, tc.object, tc.listPrefix)
+		}
+	}
+}
+
+func TestProbeAnonymousAccess(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("probes must be unauthenticated")
+		}
+		requests = append(requests, r.URL.RequestURI())
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/pub/docs/"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+
+	msg := anonymousAuditMessage{
+		Bucket: "pub",
+		Prefix: "docs/*",
+		Read:   anonymousAuditAccess{Level: anonymousAccessPublic},
+		Write:  anonymousAuditAccess{Level: anonymousAccessPublic},
+		Delete: anonymousAuditAccess{Level: anonymousAccessNone},
+		List:   anonymousAuditAccess{Level: anonymousAccessConditional},
+	}
+	probeAnonymousAccess(context.Background(), ts.Client(), ts.URL, &msg)
+	if msg.Read.Probe != "confirmed" || msg.List.Probe != "denied" || msg.Write.Probe != "" {
+		t.Errorf("unexpected probes %+v", msg)
+	}
+	want := []string{"/pub/docs/" + anonymousAuditKey, "/pub/?list-type=2&max-keys=1&prefix=docs/"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
+}
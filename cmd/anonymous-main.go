@@ -37,6 +37,10 @@ var anonymousFlags = []cli.Flag{
 		Name:  "recursive, r",
 		Usage: "list recursively",
 	},
+	cli.BoolFlag{
+		Name:  "probe",
+		Usage: "confirm the read and list access found by 'audit' with unauthenticated requests",
+	},
 }
 
 // Manage anonymous access to buckets and objects.
@@ -56,6 +60,7 @@ USAGE:
   {{.HelpName}} [FLAGS] get TARGET
   {{.HelpName}} [FLAGS] get-json TARGET
   {{.HelpName}} [FLAGS] list TARGET
+  {{.HelpName}} [FLAGS] audit TARGET
 {{if .VisibleFlags}}
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -93,6 +98,10 @@ EXAMPLES:
 
   9. List public object URLs recursively.
      {{.Prompt}} {{.HelpName}} --recursive links s3/shared/
+
+  10. Audit the anonymous access of all buckets, conditions and NotPrincipal, NotAction and NotResource
+      statements included, and confirm read and list access with unauthenticated requests.
+     {{.Prompt}} {{.HelpName}} --probe audit myminio
 `,
 }
 
@@ -224,6 +233,11 @@ func checkAnonymousSyntax(ctx *cli.Context) {
 		if argsLength != 2 {
 			showCommandHelpAndExit(ctx, "anonymous", 1)
 		}
+	case "audit":
+		// Always expect an alias or a bucket after audit cmd
+		if argsLength != 2 {
+			showCommandHelpAndExit(ctx, "anonymous", 1)
+		}
 	default:
 		showCommandHelpAndExit(ctx, "anonymous", 1)
 	}
@@ -491,6 +505,9 @@ func mainAnonymous(ctx *cli.Context) error {
 	case "links":
 		// anonymous links alias/bucket/prefix
 		runAnonymousLinksCmd(ctx.Args().Tail(), ctx.Bool("recursive"))
+	case "audit":
+		// anonymous audit alias[/bucket]
+		runAnonymousAuditCmd(ctx.Args().Tail(), ctx.Bool("probe"))
 	default:
 		// Shows command example and exit
 		showCommandHelpAndExit(ctx, "anonymous", 1)
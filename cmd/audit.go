@@ -0,0 +1,249 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+const globalMCAuditFile = "audit.log"
+
+// auditEntry is one line of the audit log, one entry is written for
+// every mutating call issued by mc.
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Alias     string    `json:"alias,omitempty"`
+	AccessKey string    `json:"accessKey,omitempty"`
+	Event     string    `json:"event"`
+	URL       string    `json:"url"`
+	Source    string    `json:"source,omitempty"`
+	VersionID string    `json:"versionId,omitempty"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+	Command   string    `json:"command"`
+}
+
+// auditSink receives marshaled audit entries.
+type auditSink interface {
+	Write(line []byte) error
+}
+
+// auditLogger appends audit entries to all configured sinks.
+type auditLogger struct {
+	mu    sync.Mutex
+	sinks []auditSink
+	cmd   string
+}
+
+var (
+	globalAuditLogger     *auditLogger
+	globalAuditLoggerOnce sync.Once
+)
+
+// getAuditLogger returns the audit logger configured in config.json,
+// nil is returned when the audit log is not enabled.
+func getAuditLogger() *auditLogger {
+	globalAuditLoggerOnce.Do(func() {
+		config, err := loadMcConfig()
+		if err != nil || config.Audit == nil || !config.Audit.Enable {
+			return
+		}
+		logger, err := newAuditLogger(*config.Audit)
+		fatalIf(err.Trace(), "Unable to initialize the audit log.")
+		globalAuditLogger = logger
+	})
+	return globalAuditLogger
+}
+
+// newAuditLogger opens all sinks of the audit configuration.
+func newAuditLogger(cfg auditConfigV10) (*auditLogger, *probe.Error) {
+	logger := &auditLogger{cmd: redactCommandLine(os.Args)}
+
+	file := cfg.File
+	if file == "" {
+		file = filepath.Join(mustGetMcConfigDir(), globalMCAuditFile)
+	}
+	sink, err := newAuditFileSink(file)
+	if err != nil {
+		return nil, err.Trace(file)
+	}
+	logger.sinks = append(logger.sinks, sink)
+
+	if cfg.Syslog {
+		sink, err := newAuditSyslogSink()
+		if err != nil {
+			return nil, err.Trace()
+		}
+		logger.sinks = append(logger.sinks, sink)
+	}
+	return logger, nil
+}
+
+// log writes an audit entry to all sinks, failures are reported but
+// do not abort the operation which has already been performed.
+func (l *auditLogger) log(entry auditEntry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	entry.Command = l.cmd
+	line, e := json.Marshal(entry)
+	if e != nil {
+		errorIf(probe.NewError(e), "Unable to marshal audit entry.")
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, sink := range l.sinks {
+		if e := sink.Write(line); e != nil {
+			errorIf(probe.NewError(e), "Unable to write audit entry.")
+		}
+	}
+}
+
+// logResult logs the outcome of an operation.
+func (l *auditLogger) logResult(entry auditEntry, err *probe.Error) {
+	entry.Result = "success"
+	if err != nil {
+		entry.Result = "failure"
+		entry.Error = err.ToGoError().Error()
+	}
+	l.log(entry)
+}
+
+// auditFileSink appends JSON lines to a local file.
+type auditFileSink struct {
+	f *os.File
+}
+
+func newAuditFileSink(file string) (*auditFileSink, *probe.Error) {
+	if e := os.MkdirAll(filepath.Dir(file), 0o700); e != nil {
+		return nil, probe.NewError(e)
+	}
+	f, e := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &auditFileSink{f: f}, nil
+}
+
+// Write appends the line in a single write call so concurrent mc
+// processes do not interleave their entries.
+func (s *auditFileSink) Write(line []byte) error {
+	_, e := s.f.Write(append(line, '\n'))
+	return e
+}
+
+// Flags and key=value arguments whose values must never be logged.
+var auditSecretFlags = []string{"--secret-key", "--password", "--client-secret"}
+
+// redactCommandLine returns the command line with credentials removed.
+func redactCommandLine(args []string) string {
+	redacted := make([]string, 0, len(args))
+	redactNext := false
+	for _, arg := range args {
+		if redactNext {
+			redacted = append(redacted, "*REDACTED*")
+			redactNext = false
+			continue
+		}
+		for _, flag := range auditSecretFlags {
+			if arg == flag {
+				redactNext = true
+			} else if strings.HasPrefix(arg, flag+"=") {
+				arg = flag + "=*REDACTED*"
+			}
+		}
+		if k, _, ok := strings.Cut(arg, "="); ok && !strings.HasPrefix(arg, "-") {
+			lk := strings.ToLower(k)
+			if strings.Contains(lk, "secret") || strings.Contains(lk, "password") {
+				arg = k + "=*REDACTED*"
+			}
+		}
+		redacted = append(redacted, arg)
+	}
+
+	// `mc admin user add ALIAS ACCESSKEY SECRETKEY`
+	for i := 0; i+2 < len(redacted); i++ {
+		if redacted[i] != "admin" || redacted[i+1] != "user" || redacted[i+2] != "add" {
+			continue
+		}
+		positional := 0
+		for j := i + 3; j < len(redacted); j++ {
+			if strings.HasPrefix(redacted[j], "-") {
+				continue
+			}
+			if positional++; positional == 3 {
+				redacted[j] = "*REDACTED*"
+			}
+		}
+		break
+	}
+	return strings.Join(redacted, " ")
+}
+
+// auditTransport logs every mutating admin API call.
+type auditTransport struct {
+	transport http.RoundTripper
+	logger    *auditLogger
+	alias     string
+	accessKey string
+}
+
+func newAuditTransport(transport http.RoundTripper, logger *auditLogger, alias, accessKey string) http.RoundTripper {
+	return &auditTransport{
+		transport: transport,
+		logger:    logger,
+		alias:     alias,
+		accessKey: accessKey,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, e := t.transport.RoundTrip(req)
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return resp, e
+	}
+
+	entry := auditEntry{
+		Alias:     t.alias,
+		AccessKey: t.accessKey,
+		Event:     "admin:" + filepath.Base(req.URL.Path),
+		URL:       req.URL.String(),
+		Result:    "success",
+	}
+	switch {
+	case e != nil:
+		entry.Result = "failure"
+		entry.Error = e.Error()
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		entry.Result = "failure"
+		entry.Error = resp.Status
+	}
+	t.logger.log(entry)
+	return resp, e
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactCommandLine(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"mc", "cp", "a", "b"}, "mc cp a b"},
+		{[]string{"mc", "admin", "user", "add", "myminio", "user1", "secret123"}, "mc admin user add myminio user1 *REDACTED*"},
+		{[]string{"mc", "--json", "admin", "user", "add", "myminio", "user1", "secret123"}, "mc --json admin user add myminio user1 *REDACTED*"},
+		{[]string{"mc", "admin", "user", "svcacct", "add", "--secret-key", "secret123", "myminio", "user1"}, "mc admin user svcacct add --secret-key *REDACTED* myminio user1"},
+		{[]string{"mc", "admin", "user", "svcacct", "add", "--secret-key=secret123", "myminio", "user1"}, "mc admin user svcacct add --secret-key=*REDACTED* myminio user1"},
+		{[]string{"mc", "admin", "idp", "set", "myminio", "openid", "client_secret=foo", "client_id=bar"}, "mc admin idp set myminio openid client_secret=*REDACTED* client_id=bar"},
+	}
+	for i, testCase := range testCases {
+		if got := redactCommandLine(testCase.args); got != testCase.expected {
+			t.Errorf("Test %d: expected `%s`, got `%s`", i+1, testCase.expected, got)
+		}
+	}
+}
+
+func TestAuditClient(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "audit.log")
+	logger, err := newAuditLogger(auditConfigV10{Enable: true, File: logFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objectPath := filepath.Join(dir, "object")
+	fsClnt, err := fsNew(objectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clnt := &auditClient{Client: fsClnt, logger: logger, alias: "local", accessKey: "minio"}
+
+	reader := strings.NewReader("hello")
+	if _, err = clnt.Put(context.Background(), reader, int64(reader.Len()), nil, PutOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = clnt.SetTags(context.Background(), "", "key=value"); err == nil {
+		t.Fatal("expected tagging to fail on the filesystem")
+	}
+
+	f, e := os.Open(logFile)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry auditEntry
+		if e = json.Unmarshal(scanner.Bytes(), &entry); e != nil {
+			t.Fatal(e)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].Event != "Put" || entries[0].Result != "success" || entries[0].Alias != "local" || entries[0].AccessKey != "minio" {
+		t.Errorf("unexpected audit entry %#v", entries[0])
+	}
+	if entries[1].Event != "SetTags" || entries[1].Result != "failure" || entries[1].Error == "" {
+		t.Errorf("unexpected audit entry %#v", entries[1])
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// completionCacheTTL is how long remote completion results are
+	// reused before the server is asked again.
+	completionCacheTTL = 30 * time.Second
+
+	// completionTimeout bounds every remote call made while completing,
+	// a slow or unreachable endpoint must never hang the shell.
+	completionTimeout = 3 * time.Second
+
+	completionCacheFile    = "completion-cache.json"
+	completionCacheVersion = "1"
+)
+
+// completionCacheEntry is a single cached remote completion result.
+type completionCacheEntry struct {
+	Expiry time.Time `json:"expiry"`
+	Values []string  `json:"values"`
+}
+
+// completionCache is persisted in the mc config directory since every
+// completion request runs in a new mc process.
+type completionCache struct {
+	Version string                          `json:"version"`
+	Entries map[string]completionCacheEntry `json:"entries"`
+}
+
+func completionCachePath() string {
+	configDir, err := getMcConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, completionCacheFile)
+}
+
+// loadCompletionCache reads the cache, an unreadable or outdated
+// cache is silently replaced by an empty one.
+func loadCompletionCache(path string) *completionCache {
+	cache := &completionCache{Version: completionCacheVersion}
+	if data, e := os.ReadFile(path); e == nil {
+		if e = json.Unmarshal(data, cache); e != nil || cache.Version != completionCacheVersion {
+			cache = &completionCache{Version: completionCacheVersion}
+		}
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]completionCacheEntry)
+	}
+	return cache
+}
+
+// save writes the cache atomically after dropping expired entries.
+func (c *completionCache) save(path string, now time.Time) {
+	for key, entry := range c.Entries {
+		if !now.Before(entry.Expiry) {
+			delete(c.Entries, key)
+		}
+	}
+	data, e := json.Marshal(c)
+	if e != nil {
+		return
+	}
+	if e = os.MkdirAll(filepath.Dir(path), 0o700); e != nil {
+		return
+	}
+	tmpFile, e := os.CreateTemp(filepath.Dir(path), completionCacheFile+".*")
+	if e != nil {
+		return
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, e = tmpFile.Write(data); e != nil {
+		tmpFile.Close()
+		return
+	}
+	if e = tmpFile.Close(); e != nil {
+		return
+	}
+	os.Rename(tmpFile.Name(), path)
+}
+
+// cachedCompletion returns the values cached under key, or calls fetch
+// with a context bounded by completionTimeout and caches its result.
+// Failed and timed out fetches are cached as well, so that repeatedly
+// pressing tab against a dead endpoint stays fast.
+func cachedCompletion(key string, fetch func(ctx context.Context) ([]string, error)) []string {
+	path := completionCachePath()
+	cache := loadCompletionCache(path)
+
+	now := UTCNow()
+	if entry, ok := cache.Entries[key]; ok && now.Before(entry.Expiry) {
+		return entry.Values
+	}
+
+	ctx, cancel := context.WithTimeout(globalContext, completionTimeout)
+	defer cancel()
+
+	values, e := fetch(ctx)
+	if e != nil && ctx.Err() == nil {
+		// Partial results are kept when the deadline was hit,
+		// any other failure completes nothing.
+		values = nil
+	}
+
+	if path != "" {
+		cache.Entries[key] = completionCacheEntry{
+			Expiry: now.Add(completionCacheTTL),
+			Values: values,
+		}
+		cache.save(path, now)
+	}
+	return values
+}
@@ -18,12 +18,14 @@
 package cmd
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/minio/cli"
+	"github.com/minio/madmin-go"
 	"github.com/posener/complete"
 )
 
@@ -61,48 +63,71 @@ func (fs fsComplete) Predict(a complete.Args) []string {
 func completeAdminConfigKeys(aliasPath string, keyPrefix string) (prediction []string) {
 	// Convert alias/bucket/incompl to alias/bucket/ to list its contents
 	parentDirPath := filepath.Dir(aliasPath) + "/"
-	clnt, err := newAdminClient(parentDirPath)
-	if err != nil {
-		return nil
-	}
-
-	h, e := clnt.HelpConfigKV(globalContext, "", "", false)
-	if e != nil {
-		return nil
-	}
+	keys := cachedCompletion("config-keys:"+parentDirPath, func(ctx context.Context) (keys []string, e error) {
+		loadMcConfig = loadMcConfigFactory()
+		clnt, err := newAdminClient(parentDirPath)
+		if err != nil {
+			return nil, err.ToGoError()
+		}
+		h, e := clnt.HelpConfigKV(ctx, "", "", false)
+		if e != nil {
+			return nil, e
+		}
+		for _, hkv := range h.KeysHelp {
+			keys = append(keys, hkv.Key)
+		}
+		return keys, nil
+	})
 
-	for _, hkv := range h.KeysHelp {
-		if strings.HasPrefix(hkv.Key, keyPrefix) {
-			prediction = append(prediction, hkv.Key)
+	for _, key := range keys {
+		if strings.HasPrefix(key, keyPrefix) {
+			prediction = append(prediction, key)
 		}
 	}
 
 	return prediction
 }
 
+// listS3PathCompletions lists the content of an S3 directory path
+// as completion candidates, directories end with a slash.
+func listS3PathCompletions(dirPath string) []string {
+	return cachedCompletion("ls:"+dirPath, func(ctx context.Context) (entries []string, e error) {
+		loadMcConfig = loadMcConfigFactory()
+		clnt, err := newClient(dirPath)
+		if err != nil {
+			return nil, err.ToGoError()
+		}
+
+		// Calculate alias from the path
+		alias := splitStr(dirPath, "/", 3)[0]
+
+		for content := range clnt.List(ctx, ListOptions{Recursive: false, ShowDir: DirFirst}) {
+			if content.Err != nil {
+				e = content.Err.ToGoError()
+				continue
+			}
+			cmplS3Path := alias + getKey(content)
+			if content.Type.IsDir() {
+				if !strings.HasSuffix(cmplS3Path, "/") {
+					cmplS3Path += "/"
+				}
+			}
+			entries = append(entries, cmplS3Path)
+		}
+		return entries, e
+	})
+}
+
 // Complete S3 path. If the prediction result is only one directory,
 // then recursively scans it. This is needed to satisfy posener/complete
 // (look at posener/complete.PredictFiles)
 func completeS3Path(s3Path string) (prediction []string) {
 	// Convert alias/bucket/incompl to alias/bucket/ to list its contents
 	parentDirPath := filepath.Dir(s3Path) + "/"
-	clnt, err := newClient(parentDirPath)
-	if err != nil {
-		return nil
-	}
-
-	// Calculate alias from the path
-	alias := splitStr(s3Path, "/", 3)[0]
 
 	// List dirPath content and only pick elements that corresponds
 	// to the path that we want to complete
-	for content := range clnt.List(globalContext, ListOptions{Recursive: false, ShowDir: DirFirst}) {
-		cmplS3Path := alias + getKey(content)
-		if content.Type.IsDir() {
-			if !strings.HasSuffix(cmplS3Path, "/") {
-				cmplS3Path += "/"
-			}
-		}
+	for _, cmplS3Path := range listS3PathCompletions(parentDirPath) {
 		if strings.HasPrefix(cmplS3Path, s3Path) {
 			prediction = append(prediction, cmplS3Path)
 		}
@@ -219,13 +244,130 @@ func (al aliasComplete) Predict(a complete.Args) (prediction []string) {
 	return
 }
 
+// positionalArgs returns the completed arguments which are not flags.
+func positionalArgs(a complete.Args) (args []string) {
+	for _, arg := range a.Completed {
+		if !strings.HasPrefix(arg, "-") {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// adminNameComplete completes an alias followed by the name of
+// an existing entity on that alias, such as a policy or a user.
+type adminNameComplete struct {
+	kind string
+	list func(ctx context.Context, clnt *madmin.AdminClient) ([]string, error)
+}
+
+func (an adminNameComplete) Predict(a complete.Args) (prediction []string) {
+	defer func() {
+		sort.Strings(prediction)
+	}()
+
+	args := positionalArgs(a)
+	switch len(args) {
+	case 0:
+		return aliasCompleter.Predict(a)
+	case 1:
+		alias := strings.TrimRight(args[0], "/")
+		names := cachedCompletion(an.kind+":"+alias, func(ctx context.Context) ([]string, error) {
+			loadMcConfig = loadMcConfigFactory()
+			clnt, err := newAdminClient(alias)
+			if err != nil {
+				return nil, err.ToGoError()
+			}
+			return an.list(ctx, clnt)
+		})
+		for _, name := range names {
+			if strings.HasPrefix(name, a.Last) {
+				prediction = append(prediction, name)
+			}
+		}
+	}
+	return
+}
+
+func listPolicyNames(ctx context.Context, clnt *madmin.AdminClient) (names []string, e error) {
+	policies, e := clnt.ListCannedPolicies(ctx)
+	for name := range policies {
+		names = append(names, name)
+	}
+	return names, e
+}
+
+func listUserNames(ctx context.Context, clnt *madmin.AdminClient) (names []string, e error) {
+	users, e := clnt.ListUsers(ctx)
+	for name := range users {
+		names = append(names, name)
+	}
+	return names, e
+}
+
+func listTierNames(ctx context.Context, clnt *madmin.AdminClient) (names []string, e error) {
+	tiers, e := clnt.ListTiers(ctx)
+	for _, tier := range tiers {
+		names = append(names, tier.Name)
+	}
+	return names, e
+}
+
+// versionIDComplete completes the value of --version-id with the
+// versions of the object found earlier on the command line.
+type versionIDComplete struct{}
+
+func (v versionIDComplete) Predict(a complete.Args) (prediction []string) {
+	args := positionalArgs(a)
+	var objectPath string
+	for i := len(args) - 1; i >= 0; i-- {
+		if strings.Contains(args[i], "/") && !strings.HasSuffix(args[i], "/") {
+			objectPath = args[i]
+			break
+		}
+	}
+	if objectPath == "" {
+		return nil
+	}
+
+	return cachedCompletion("versions:"+objectPath, func(ctx context.Context) (versions []string, e error) {
+		loadMcConfig = loadMcConfigFactory()
+		clnt, err := newClient(objectPath)
+		if err != nil {
+			return nil, err.ToGoError()
+		}
+		objectURLPath := clnt.GetURL().Path
+		for content := range clnt.List(ctx, ListOptions{WithOlderVersions: true, ShowDir: DirNone}) {
+			if content.Err != nil {
+				e = content.Err.ToGoError()
+				continue
+			}
+			if content.URL.Path == objectURLPath && content.VersionID != "" {
+				versions = append(versions, content.VersionID)
+			}
+		}
+		return versions, e
+	})
+}
+
 var (
 	adminConfigCompleter = adminConfigComplete{}
 	s3Completer          = s3Complete{}
 	aliasCompleter       = aliasComplete{}
 	fsCompleter          = fsComplete{}
+	versionIDCompleter   = versionIDComplete{}
+
+	policyNameCompleter = adminNameComplete{kind: "policies", list: listPolicyNames}
+	userNameCompleter   = adminNameComplete{kind: "users", list: listUserNames}
+	tierNameCompleter   = adminNameComplete{kind: "tiers", list: listTierNames}
 )
 
+// completeFlagValues maps flag names to the completer of their value.
+var completeFlagValues = map[string]complete.Predictor{
+	"version-id": versionIDCompleter,
+	"vid":        versionIDCompleter,
+}
+
 // The list of all commands supported by mc with their mapping
 // with their bash completer function
 var completeCmds = map[string]complete.Predictor{
@@ -247,9 +389,11 @@ var completeCmds = map[string]complete.Predictor{
 	"/tree":      complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
 	"/du":        complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
 
-	"/retention/set":   s3Completer,
-	"/retention/clear": s3Completer,
-	"/retention/info":  s3Completer,
+	"/retention/set":    s3Completer,
+	"/retention/clear":  s3Completer,
+	"/retention/info":   s3Completer,
+	"/retention/report": s3Completer,
+	"/retention/extend": s3Completer,
 
 	"/legalhold/set":   s3Completer,
 	"/legalhold/clear": s3Completer,
@@ -266,11 +410,12 @@ var completeCmds = map[string]complete.Predictor{
 	"/encrypt/info":  s3Complete{deepLevel: 2},
 	"/encrypt/clear": s3Complete{deepLevel: 2},
 
-	"/replicate/add":  s3Complete{deepLevel: 2},
-	"/replicate/edit": s3Complete{deepLevel: 2},
-	"/replicate/ls":   s3Complete{deepLevel: 2},
-	"/replicate/rm":   s3Complete{deepLevel: 2},
-	"/replicate/diff": s3Complete{deepLevel: 2},
+	"/replicate/add":   s3Complete{deepLevel: 2},
+	"/replicate/edit":  s3Complete{deepLevel: 2},
+	"/replicate/ls":    s3Complete{deepLevel: 2},
+	"/replicate/rm":    s3Complete{deepLevel: 2},
+	"/replicate/diff":  s3Complete{deepLevel: 2},
+	"/replicate/check": s3Complete{deepLevel: 2},
 
 	"/replicate/export":        s3Complete{deepLevel: 2},
 	"/replicate/import":        s3Complete{deepLevel: 2},
@@ -281,6 +426,12 @@ var completeCmds = map[string]complete.Predictor{
 	"/tag/list":   s3Completer,
 	"/tag/remove": s3Completer,
 	"/tag/set":    s3Completer,
+	"/tag/rename": s3Completer,
+
+	"/meta/ls":               s3Completer,
+	"/meta/set":              s3Completer,
+	"/meta/rm":               s3Completer,
+	"/meta/fix-content-type": s3Completer,
 
 	"/version/info":    s3Complete{deepLevel: 2},
 	"/version/enable":  s3Complete{deepLevel: 2},
@@ -302,8 +453,11 @@ var completeCmds = map[string]complete.Predictor{
 	"/ilm/export":  s3Complete{deepLevel: 2},
 	"/ilm/import":  s3Complete{deepLevel: 2},
 	"/ilm/restore": s3Completer,
+	"/ilm/preview": s3Complete{deepLevel: 2},
 
-	"/undo": s3Completer,
+	"/undo":       s3Completer,
+	"/restore-to": s3Completer,
+	"/history":    s3Completer,
 
 	// Admin API commands MinIO only.
 	"/admin/heal": s3Completer,
@@ -352,31 +506,34 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/idp/ls":   aliasCompleter,
 	"/admin/idp/rm":   aliasCompleter,
 
-	"/admin/policy/info":   aliasCompleter,
-	"/admin/policy/set":    aliasCompleter,
-	"/admin/policy/unset":  aliasCompleter,
-	"/admin/policy/update": aliasCompleter,
-	"/admin/policy/add":    aliasCompleter,
-	"/admin/policy/list":   aliasCompleter,
-	"/admin/policy/remove": aliasCompleter,
+	"/admin/policy/info":     policyNameCompleter,
+	"/admin/policy/set":      policyNameCompleter,
+	"/admin/policy/unset":    policyNameCompleter,
+	"/admin/policy/update":   policyNameCompleter,
+	"/admin/policy/add":      aliasCompleter,
+	"/admin/policy/list":     aliasCompleter,
+	"/admin/policy/remove":   policyNameCompleter,
+	"/admin/policy/simulate": aliasCompleter,
 
 	"/admin/user/add":     aliasCompleter,
-	"/admin/user/disable": aliasCompleter,
-	"/admin/user/enable":  aliasCompleter,
+	"/admin/user/disable": userNameCompleter,
+	"/admin/user/enable":  userNameCompleter,
 	"/admin/user/list":    aliasCompleter,
-	"/admin/user/remove":  aliasCompleter,
-	"/admin/user/info":    aliasCompleter,
-	"/admin/user/policy":  aliasCompleter,
-
-	"/admin/user/svcacct/add":     aliasCompleter,
-	"/admin/user/svcacct/list":    aliasCompleter,
-	"/admin/user/svcacct/ls":      aliasCompleter,
-	"/admin/user/svcacct/rm":      aliasCompleter,
-	"/admin/user/svcacct/info":    aliasCompleter,
-	"/admin/user/svcacct/edit":    aliasCompleter,
-	"/admin/user/svcacct/set":     aliasCompleter,
-	"/admin/user/svcacct/enable":  aliasCompleter,
-	"/admin/user/svcacct/disable": aliasCompleter,
+	"/admin/user/remove":  userNameCompleter,
+	"/admin/user/info":    userNameCompleter,
+	"/admin/user/policy":  userNameCompleter,
+
+	"/admin/user/svcacct/add":       userNameCompleter,
+	"/admin/user/svcacct/list":      userNameCompleter,
+	"/admin/user/svcacct/ls":        userNameCompleter,
+	"/admin/user/svcacct/rm":        aliasCompleter,
+	"/admin/user/svcacct/info":      aliasCompleter,
+	"/admin/user/svcacct/edit":      aliasCompleter,
+	"/admin/user/svcacct/set":       aliasCompleter,
+	"/admin/user/svcacct/enable":    aliasCompleter,
+	"/admin/user/svcacct/disable":   aliasCompleter,
+	"/admin/user/svcacct/rotate":    aliasCompleter,
+	"/admin/user/svcacct/inventory": aliasCompleter,
 
 	"/admin/group/add":     aliasCompleter,
 	"/admin/group/disable": aliasCompleter,
@@ -400,11 +557,11 @@ var completeCmds = map[string]complete.Predictor{
 	"/admin/subnet/register": aliasCompleter,
 
 	"/admin/tier/add":    nil,
-	"/admin/tier/edit":   nil,
-	"/admin/tier/ls":     nil,
-	"/admin/tier/info":   nil,
-	"/admin/tier/rm":     nil,
-	"/admin/tier/verify": nil,
+	"/admin/tier/edit":   tierNameCompleter,
+	"/admin/tier/ls":     aliasCompleter,
+	"/admin/tier/info":   tierNameCompleter,
+	"/admin/tier/rm":     tierNameCompleter,
+	"/admin/tier/verify": tierNameCompleter,
 
 	"/admin/replicate/add":    aliasCompleter,
 	"/admin/replicate/edit":   aliasCompleter,
@@ -414,13 +571,27 @@ var completeCmds = map[string]complete.Predictor{
 
 	"/admin/cluster/bucket/export": aliasCompleter,
 	"/admin/cluster/bucket/import": aliasCompleter,
+	"/admin/cluster/bucket/diff":   aliasCompleter,
 	"/admin/cluster/iam/export":    aliasCompleter,
 	"/admin/cluster/iam/import":    aliasCompleter,
+	"/admin/cluster/iam/diff":      aliasCompleter,
 
 	"/alias/set":    nil,
 	"/alias/list":   aliasCompleter,
 	"/alias/remove": aliasCompleter,
 	"/alias/import": nil,
+	"/alias/export": aliasCompleter,
+	"/alias/seal":   nil,
+	"/alias/unseal": nil,
+	"/alias/unlock": nil,
+	"/alias/lock":   nil,
+
+	"/certs/ls":      nil,
+	"/certs/add":     fsCompleter,
+	"/certs/rm":      nil,
+	"/certs/inspect": complete.PredictOr(fsCompleter, aliasCompleter),
+	"/certs/pin":     aliasCompleter,
+	"/certs/unpin":   aliasCompleter,
 
 	"/support/callhome":     aliasCompleter,
 	"/support/logs/enable":  aliasCompleter,
@@ -443,6 +614,7 @@ var completeCmds = map[string]complete.Predictor{
 	"/license/info":     aliasCompleter,
 	"/license/update":   aliasCompleter,
 
+	"/completion":     complete.PredictSet("bash", "zsh", "fish", "powershell"),
 	"/update":         nil,
 	"/ready":          aliasCompleter,
 	"/ping":           aliasCompleter,
@@ -467,7 +639,7 @@ func flagsToCompleteFlags(flags []cli.Flag) complete.Flags {
 			} else {
 				flagName = "--" + s
 			}
-			complFlags[flagName] = complete.PredictNothing
+			complFlags[flagName] = completeFlagValues[s]
 		}
 	}
 	return complFlags
@@ -494,7 +666,24 @@ func cmdToCompleteCmd(cmd cli.Command, parentPath string) complete.Command {
 	return complCmd
 }
 
-// Main function to answer to bash completion calls
+// completionConfigDir returns the value of --config-dir found in the
+// command line being completed, if any.
+func completionConfigDir(line string) string {
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		switch {
+		case field == "--config-dir" || field == "-C":
+			if i+1 < len(fields) {
+				return fields[i+1]
+			}
+		case strings.HasPrefix(field, "--config-dir="):
+			return strings.TrimPrefix(field, "--config-dir=")
+		}
+	}
+	return ""
+}
+
+// Main function to answer to shell completion calls
 func mainComplete() error {
 	// Recursively register all commands and subcommands
 	// along with global and local flags
@@ -509,6 +698,14 @@ func mainComplete() error {
 		}
 	}
 	complFlags := flagsToCompleteFlags(globalFlags)
+	complFlags["--config-dir"] = complete.PredictDirs("*")
+	complFlags["-C"] = complete.PredictDirs("*")
+
+	// Honor a custom configuration folder typed on the command line.
+	if configDir := completionConfigDir(os.Getenv("COMP_LINE")); configDir != "" {
+		setMcConfigDir(configDir)
+	}
+
 	mcComplete := complete.Command{
 		Sub:         complCmds,
 		GlobalFlags: complFlags,
@@ -18,10 +18,19 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/minio/cli"
+	"github.com/posener/complete"
 )
 
 func TestAutoCompletionCompletness(t *testing.T) {
@@ -58,3 +67,100 @@ func TestAutoCompletionCompletness(t *testing.T) {
 
 	}
 }
+
+func TestCompletionScripts(t *testing.T) {
+	for shell := range completionScripts {
+		script, err := generateCompletionScript(shell, "/usr/local/bin/mc.exe")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", shell, err)
+		}
+		if !strings.Contains(script, "__complete") {
+			t.Errorf("%s: script does not call back into mc", shell)
+		}
+		if strings.Contains(script, "{{") || strings.Contains(script, "mc.exe.exe") {
+			t.Errorf("%s: script is not rendered correctly:\n%s", shell, script)
+		}
+	}
+
+	script, _ := generateCompletionScript("bash", "my-mc")
+	if !strings.Contains(script, "-F _my_mc_completion my-mc") {
+		t.Errorf("unexpected bash registration:\n%s", script)
+	}
+	if _, err := generateCompletionScript("tcsh", "mc"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestCompletionCache(t *testing.T) {
+	defer setMcConfigDir(mcCustomConfigDir)
+	setMcConfigDir(t.TempDir())
+
+	var calls int
+	fetch := func(ctx context.Context) ([]string, error) {
+		calls++
+		return []string{"bucket/"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if got := cachedCompletion("ls:play/", fetch); !reflect.DeepEqual(got, []string{"bucket/"}) {
+			t.Fatalf("unexpected completion %v", got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected a single fetch, got %d", calls)
+	}
+
+	// Expire the entry, the next completion must hit the server again.
+	path := completionCachePath()
+	cache := loadCompletionCache(path)
+	entry := cache.Entries["ls:play/"]
+	entry.Expiry = UTCNow().Add(-time.Second)
+	cache.Entries["ls:play/"] = entry
+	data, e := json.Marshal(cache)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if e = os.WriteFile(path, data, 0o600); e != nil {
+		t.Fatal(e)
+	}
+	cachedCompletion("ls:play/", fetch)
+	if calls != 2 {
+		t.Fatalf("expected the expired entry to be fetched again, got %d fetches", calls)
+	}
+
+	// Failures are remembered too, without their partial values.
+	failures := 0
+	fail := func(ctx context.Context) ([]string, error) {
+		failures++
+		return []string{"partial"}, errors.New("Access Denied")
+	}
+	for i := 0; i < 2; i++ {
+		if got := cachedCompletion("users:play", fail); len(got) != 0 {
+			t.Fatalf("unexpected completion %v", got)
+		}
+	}
+	if failures != 1 {
+		t.Fatalf("expected a single failing fetch, got %d", failures)
+	}
+	if _, e = os.Stat(filepath.Join(mcCustomConfigDir, completionCacheFile)); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestCompletionHelpers(t *testing.T) {
+	a := complete.Args{Completed: []string{"info", "--json", "play/", "-r"}, Last: "po"}
+	if got := positionalArgs(a); !reflect.DeepEqual(got, []string{"info", "play/"}) {
+		t.Errorf("unexpected positional args %v", got)
+	}
+
+	for line, want := range map[string]string{
+		"mc ls play/":                   "",
+		"mc --config-dir /tmp/x ls ":    "/tmp/x",
+		"mc -C /tmp/y admin info ":      "/tmp/y",
+		"mc --config-dir=/tmp/z ls p/b": "/tmp/z",
+	} {
+		if got := completionConfigDir(line); got != want {
+			t.Errorf("%q: expected %q, got %q", line, want, got)
+		}
+	}
+}
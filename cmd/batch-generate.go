@@ -47,6 +47,9 @@ FLAGS:
 EXAMPLES:
   1. Generate a new batch 'replication' job definition:
      {{.Prompt}} {{.HelpName}} myminio replicate > replication.yaml
+
+  2. Generate a new batch 'mc-expire' job definition, executed from mc:
+     {{.Prompt}} {{.HelpName}} myminio mc-expire > expire.yaml
 `,
 }
 
@@ -57,6 +60,11 @@ func supportedJobTypes() string {
 		builder.WriteString(string(jobType))
 		builder.WriteString("\n")
 	}
+	for _, jobType := range clientSideJobTypes {
+		builder.WriteString("  - ")
+		builder.WriteString(string(jobType))
+		builder.WriteString(" (runs from mc)\n")
+	}
 	return builder.String()
 }
 
@@ -76,6 +84,11 @@ func mainBatchGenerate(ctx *cli.Context) error {
 	aliasedURL := args.Get(0)
 	jobType := args.Get(1)
 
+	if isClientSideJobType(jobType) {
+		fmt.Println(clientSideJobTemplate(madmin.BatchJobType(jobType)))
+		return nil
+	}
+
 	// Start a new MinIO Admin Client
 	adminClient, err := newAdminClient(aliasedURL)
 	fatalIf(err, "Unable to initialize admin connection.")
@@ -0,0 +1,326 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+// batchJobSource lists the objects selected by a batch job.
+type batchJobSource struct {
+	alias     string
+	root      Client
+	newClient func(urlStr string) (Client, *probe.Error)
+	matcher   batchJobMatcher
+}
+
+// newBatchJobSource prepares the listing of the job source, which is
+// either a bucket on aliasedURL or on the remote endpoint of the job.
+func newBatchJobSource(aliasedURL string, job batchJobRequest) (*batchJobSource, *probe.Error) {
+	ep, filter := job.source()
+	m, e := filter.matcher(time.Now())
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	src := &batchJobSource{matcher: m}
+
+	var base string
+	if ep.Endpoint != "" {
+		cfg := &aliasConfigV10{
+			URL:          ep.Endpoint,
+			AccessKey:    ep.Credentials.AccessKey,
+			SecretKey:    ep.Credentials.SecretKey,
+			SessionToken: ep.Credentials.SessionToken,
+			API:          "S3v4",
+			Path:         "auto",
+		}
+		base = ep.Endpoint
+		src.alias = strings.TrimPrefix(strings.TrimPrefix(ep.Endpoint, "https://"), "http://")
+		src.newClient = func(urlStr string) (Client, *probe.Error) {
+			return S3New(NewS3Config(urlStr, cfg))
+		}
+	} else {
+		alias, _, hostCfg, err := expandAlias(aliasedURL)
+		if err != nil {
+			return nil, err.Trace(aliasedURL)
+		}
+		if hostCfg == nil {
+			return nil, errInvalidAliasedURL(aliasedURL).Trace(aliasedURL)
+		}
+		base = hostCfg.URL
+		src.alias = alias
+		src.newClient = func(urlStr string) (Client, *probe.Error) {
+			return newClientFromAlias(alias, urlStr)
+		}
+	}
+
+	root, err := src.newClient(batchJobURL(base, ep.Bucket, ep.Prefix))
+	if err != nil {
+		return nil, err.Trace(ep.Bucket, ep.Prefix)
+	}
+	src.root = root
+	return src, nil
+}
+
+// walk calls fn for every object matching the job filter, stops at
+// the first listing error or error returned by fn.
+func (s *batchJobSource) walk(ctx context.Context, fn func(clnt Client, content *ClientContent) *probe.Error) *probe.Error {
+	opts := ListOptions{
+		Recursive:    true,
+		WithMetadata: len(s.matcher.metadata) > 0,
+		ShowDir:      DirNone,
+	}
+	for content := range s.root.List(ctx, opts) {
+		if content.Err != nil {
+			return content.Err
+		}
+		if content.Type.IsDir() || !s.matcher.matchContent(content) {
+			continue
+		}
+		clnt, err := s.newClient(content.URL.String())
+		if err != nil {
+			return err
+		}
+		if s.matcher.needTags() {
+			tags, err := clnt.GetTags(ctx, content.VersionID)
+			if err != nil {
+				return err.Trace(content.URL.String())
+			}
+			if !s.matcher.matchTags(tags) {
+				continue
+			}
+		}
+		if err = fn(clnt, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *batchJobSource) key(content *ClientContent) string {
+	return path.Join(s.alias, content.URL.Path)
+}
+
+// batchJobRetryPolicy honors the retry flags of a job running from mc.
+func batchJobRetryPolicy(r batchJobRetry) retryPolicy {
+	p := newRetryPolicy()
+	if r.Attempts > 0 {
+		p.maxRetries = r.Attempts
+	}
+	if d, e := time.ParseDuration(r.Delay); e == nil && d > 0 {
+		p.unit = d
+	}
+	return p
+}
+
+// batchDryRunMessage container for an object selected by a job.
+type batchDryRunMessage struct {
+	Status       string    `json:"status"`
+	Job          string    `json:"job"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+func (m batchDryRunMessage) String() string {
+	return fmt.Sprintf("%s %9s %s",
+		console.Colorize("Time", "["+m.LastModified.Local().Format(printDate)+"]"),
+		console.Colorize("Size", humanize.IBytes(uint64(m.Size))),
+		console.Colorize("Key", m.Key))
+}
+
+func (m batchDryRunMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// batchJobObjectMessage container for an object processed by a job running from mc.
+type batchJobObjectMessage struct {
+	Status    string `json:"status"`
+	Job       string `json:"job"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	Size      int64  `json:"size"`
+	Retries   int    `json:"retries,omitempty"`
+}
+
+func (m batchJobObjectMessage) String() string {
+	action := "Rotated key of"
+	if m.Job == string(batchJobExpire) {
+		action = "Expired"
+	}
+	return console.Colorize("BatchObject", fmt.Sprintf("%s `%s`.", action, m.Key))
+}
+
+func (m batchJobObjectMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// batchJobSummaryMessage container for the outcome of a dry-run or a job running from mc.
+type batchJobSummaryMessage struct {
+	Status  string `json:"status"`
+	Job     string `json:"job"`
+	DryRun  bool   `json:"dryRun,omitempty"`
+	Objects int64  `json:"objects"`
+	Size    int64  `json:"size"`
+	Failed  int64  `json:"failed,omitempty"`
+}
+
+func (m batchJobSummaryMessage) String() string {
+	if m.DryRun {
+		return console.Colorize("BatchSummary", fmt.Sprintf("'%s' job would select %d object(s), %s in total.",
+			m.Job, m.Objects, humanize.IBytes(uint64(m.Size))))
+	}
+	msg := fmt.Sprintf("'%s' job processed %d object(s), %s in total", m.Job, m.Objects, humanize.IBytes(uint64(m.Size)))
+	if m.Failed > 0 {
+		return console.Colorize("BatchFailed", fmt.Sprintf("%s, %d failed.", msg, m.Failed))
+	}
+	return console.Colorize("BatchSummary", msg+".")
+}
+
+func (m batchJobSummaryMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// dryRunBatchJob lists the objects the job would operate on.
+func dryRunBatchJob(ctx context.Context, src *batchJobSource, job batchJobRequest) (batchJobSummaryMessage, *probe.Error) {
+	summary := batchJobSummaryMessage{Job: string(job.Type()), DryRun: true}
+	err := src.walk(ctx, func(_ Client, content *ClientContent) *probe.Error {
+		summary.Objects++
+		summary.Size += content.Size
+		printMsg(batchDryRunMessage{
+			Job:          summary.Job,
+			Key:          src.key(content),
+			Size:         content.Size,
+			LastModified: content.Time,
+		})
+		return nil
+	})
+	return summary, err
+}
+
+// runClientSideBatchJob executes 'keyrotate' and 'expire' jobs from mc,
+// failures on individual objects are reported and counted.
+func runClientSideBatchJob(ctx context.Context, src *batchJobSource, job batchJobRequest) (batchJobSummaryMessage, *probe.Error) {
+	summary := batchJobSummaryMessage{Job: string(job.Type())}
+
+	var (
+		flags batchJobFlags
+		apply func(clnt Client, content *ClientContent) *probe.Error
+		// Copying an object onto itself again is harmless, removing
+		// an object without a version id may add extra delete markers.
+		idempotent bool
+	)
+	switch job.Type() {
+	case batchJobKeyRotate:
+		flags = job.KeyRotate.Flags
+		sse, e := job.KeyRotate.Encryption.serverSide()
+		if e != nil {
+			return summary, probe.NewError(e)
+		}
+		idempotent = true
+		apply = func(clnt Client, content *ClientContent) *probe.Error {
+			opts := CopyOptions{
+				versionID: content.VersionID,
+				size:      content.Size,
+				tgtSSE:    sse,
+			}
+			return clnt.Copy(ctx, content.URL.Path, opts, nil)
+		}
+	case batchJobExpire:
+		flags = job.Expire.Flags
+		apply = func(clnt Client, content *ClientContent) *probe.Error {
+			contentCh := make(chan *ClientContent, 1)
+			contentCh <- &ClientContent{URL: content.URL, VersionID: content.VersionID}
+			close(contentCh)
+			for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+				if result.Err != nil {
+					return result.Err
+				}
+			}
+			return nil
+		}
+	default:
+		return summary, probe.NewError(fmt.Errorf("'%s' jobs are executed by the server", job.Type()))
+	}
+
+	policy := batchJobRetryPolicy(flags.Retry)
+	err := src.walk(ctx, func(clnt Client, content *ClientContent) *probe.Error {
+		key := src.key(content)
+		attempts := 0
+		err := policy.do(ctx, idempotent, func() *probe.Error {
+			attempts++
+			return apply(clnt, content)
+		})
+		if err != nil {
+			errorIf(err.Trace(key), "Unable to process `"+key+"`.")
+			summary.Failed++
+			return nil
+		}
+		summary.Objects++
+		summary.Size += content.Size
+		printMsg(batchJobObjectMessage{
+			Job:       summary.Job,
+			Key:       key,
+			VersionID: content.VersionID,
+			Size:      content.Size,
+			Retries:   attempts - 1,
+		})
+		return nil
+	})
+	return summary, err
+}
+
+// isClientSideJobType returns true for job types executed from mc.
+func isClientSideJobType(jobType string) bool {
+	for _, t := range clientSideJobTypes {
+		if string(t) == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+// clientSideJobTemplate returns the template of a job type executed from mc.
+func clientSideJobTemplate(jobType madmin.BatchJobType) string {
+	switch jobType {
+	case batchJobKeyRotate:
+		return batchJobKeyRotateTemplate
+	case batchJobExpire:
+		return batchJobExpireTemplate
+	}
+	return ""
+}
@@ -0,0 +1,505 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/madmin-go"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+	"github.com/minio/pkg/wildcard"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Batch job types executed by mc itself instead of the server, they
+// reuse the same YAML layout and filters as the server side jobs. The
+// "mc-" prefix keeps them apart from job types of the server.
+const (
+	batchJobKeyRotate madmin.BatchJobType = "mc-keyrotate"
+	batchJobExpire    madmin.BatchJobType = "mc-expire"
+)
+
+var clientSideJobTypes = []madmin.BatchJobType{
+	batchJobKeyRotate,
+	batchJobExpire,
+}
+
+// batchJobKeyRotateTemplate provides a sample template for client side key rotation
+const batchJobKeyRotateTemplate = `mc-keyrotate:
+  apiVersion: v1
+  # objects to be re-encrypted, this job runs from mc and copies every object onto itself
+  bucket: BUCKET
+  prefix: PREFIX # 'PREFIX' is optional
+
+  encryption:
+    type: sse-s3 # valid values are "sse-s3" and "sse-kms"
+    # key: KMS-KEY-ID # required for "sse-kms"
+    # context: '{"project":"x"}' # optional JSON encoded KMS context for "sse-kms"
+
+  # NOTE: All flags are optional
+  flags:
+    filter:
+      newerThan: "7d" # match objects newer than this value (e.g. 7d10h31s)
+      olderThan: "7d" # match objects older than this value (e.g. 7d10h31s)
+      createdAfter: "date" # match objects created after "date"
+      createdBefore: "date" # match objects created before "date"
+      # tags:
+      #   - key: "name"
+      #     value: "pick*" # match objects with tag 'name', with all values starting with 'pick'
+      # metadata:
+      #   - key: "content-type"
+      #     value: "image/*" # match objects with 'content-type', with all values starting with 'image/'
+
+    retry:
+      attempts: 10 # number of retries for each object before giving up
+      delay: "500ms" # least amount of delay between each retry
+`
+
+// batchJobExpireTemplate provides a sample template for client side expiry
+const batchJobExpireTemplate = `mc-expire:
+  apiVersion: v1
+  # objects to be removed, this job runs from mc
+  bucket: BUCKET
+  prefix: PREFIX # 'PREFIX' is optional
+
+  # NOTE: filters are optional, without any filter every object under prefix is removed
+  flags:
+    filter:
+      olderThan: "30d" # match objects older than this value (e.g. 7d10h31s)
+      # newerThan: "7d" # match objects newer than this value (e.g. 7d10h31s)
+      # createdAfter: "date" # match objects created after "date"
+      # createdBefore: "date" # match objects created before "date"
+      # tags:
+      #   - key: "name"
+      #     value: "tmp*" # match objects with tag 'name', with all values starting with 'tmp'
+      # metadata:
+      #   - key: "content-type"
+      #     value: "image/*" # match objects with 'content-type', with all values starting with 'image/'
+
+    retry:
+      attempts: 10 # number of retries for each object before giving up
+      delay: "500ms" # least amount of delay between each retry
+`
+
+type batchJobKV struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+type batchJobFilter struct {
+	NewerThan     string       `yaml:"newerThan,omitempty"`
+	OlderThan     string       `yaml:"olderThan,omitempty"`
+	CreatedAfter  string       `yaml:"createdAfter,omitempty"`
+	CreatedBefore string       `yaml:"createdBefore,omitempty"`
+	Tags          []batchJobKV `yaml:"tags,omitempty"`
+	Metadata      []batchJobKV `yaml:"metadata,omitempty"`
+}
+
+type batchJobNotify struct {
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+}
+
+type batchJobRetry struct {
+	Attempts int    `yaml:"attempts,omitempty"`
+	Delay    string `yaml:"delay,omitempty"`
+}
+
+type batchJobFlags struct {
+	Filter batchJobFilter `yaml:"filter,omitempty"`
+	Notify batchJobNotify `yaml:"notify,omitempty"`
+	Retry  batchJobRetry  `yaml:"retry,omitempty"`
+}
+
+type batchJobCredentials struct {
+	AccessKey    string `yaml:"accessKey"`
+	SecretKey    string `yaml:"secretKey"`
+	SessionToken string `yaml:"sessionToken,omitempty"`
+}
+
+type batchJobEndpoint struct {
+	Type        string              `yaml:"type"`
+	Bucket      string              `yaml:"bucket"`
+	Prefix      string              `yaml:"prefix,omitempty"`
+	Endpoint    string              `yaml:"endpoint,omitempty"`
+	Credentials batchJobCredentials `yaml:"credentials,omitempty"`
+}
+
+type batchJobReplicate struct {
+	APIVersion string           `yaml:"apiVersion"`
+	Source     batchJobEndpoint `yaml:"source"`
+	Target     batchJobEndpoint `yaml:"target"`
+	Flags      batchJobFlags    `yaml:"flags,omitempty"`
+}
+
+type batchJobEncryption struct {
+	Type    string `yaml:"type"`
+	Key     string `yaml:"key,omitempty"`
+	Context string `yaml:"context,omitempty"`
+}
+
+type batchJobKeyRotateV1 struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Bucket     string             `yaml:"bucket"`
+	Prefix     string             `yaml:"prefix,omitempty"`
+	Encryption batchJobEncryption `yaml:"encryption"`
+	Flags      batchJobFlags      `yaml:"flags,omitempty"`
+}
+
+type batchJobExpireV1 struct {
+	APIVersion string        `yaml:"apiVersion"`
+	Bucket     string        `yaml:"bucket"`
+	Prefix     string        `yaml:"prefix,omitempty"`
+	Flags      batchJobFlags `yaml:"flags,omitempty"`
+}
+
+// batchJobRequest is the batch job YAML document, exactly one job type
+// must be present.
+type batchJobRequest struct {
+	Replicate *batchJobReplicate   `yaml:"replicate,omitempty"`
+	KeyRotate *batchJobKeyRotateV1 `yaml:"mc-keyrotate,omitempty"`
+	Expire    *batchJobExpireV1    `yaml:"mc-expire,omitempty"`
+}
+
+// parseBatchJob decodes a batch job definition. When strict, unknown
+// fields are rejected so that typos are reported before the job runs.
+func parseBatchJob(data []byte, strict bool) (batchJobRequest, error) {
+	var job batchJobRequest
+	unmarshal := yaml.Unmarshal
+	if strict {
+		unmarshal = yaml.UnmarshalStrict
+	}
+	if e := unmarshal(data, &job); e != nil {
+		return job, e
+	}
+	return job, nil
+}
+
+// Type returns the type of the job.
+func (j batchJobRequest) Type() madmin.BatchJobType {
+	switch {
+	case j.Replicate != nil:
+		return madmin.BatchJobReplicate
+	case j.KeyRotate != nil:
+		return batchJobKeyRotate
+	case j.Expire != nil:
+		return batchJobExpire
+	}
+	return ""
+}
+
+// isClientSide returns true for jobs executed by mc rather than the server.
+func (j batchJobRequest) isClientSide() bool {
+	return j.KeyRotate != nil || j.Expire != nil
+}
+
+// source returns the objects the job operates on along with its filter.
+func (j batchJobRequest) source() (batchJobEndpoint, batchJobFilter) {
+	switch {
+	case j.Replicate != nil:
+		return j.Replicate.Source, j.Replicate.Flags.Filter
+	case j.KeyRotate != nil:
+		return batchJobEndpoint{Bucket: j.KeyRotate.Bucket, Prefix: j.KeyRotate.Prefix}, j.KeyRotate.Flags.Filter
+	case j.Expire != nil:
+		return batchJobEndpoint{Bucket: j.Expire.Bucket, Prefix: j.Expire.Prefix}, j.Expire.Flags.Filter
+	}
+	return batchJobEndpoint{}, batchJobFilter{}
+}
+
+// batchJobErrors collects every problem found in a job definition.
+type batchJobErrors []string
+
+func (errs *batchJobErrors) add(field, format string, args ...interface{}) {
+	*errs = append(*errs, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (errs batchJobErrors) Error() string {
+	return "invalid batch job definition\n  - " + strings.Join(errs, "\n  - ")
+}
+
+// validate checks the job definition against the schema, returns nil
+// or a batchJobErrors listing every invalid field.
+func (j batchJobRequest) validate() error {
+	var errs batchJobErrors
+	n := 0
+	for _, set := range []bool{j.Replicate != nil, j.KeyRotate != nil, j.Expire != nil} {
+		if set {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return errors.New("no batch job found, expected one of " + strings.Join(allBatchJobTypes(), ", "))
+	case n > 1:
+		return errors.New("only one batch job can be defined per file")
+	}
+
+	switch {
+	case j.Replicate != nil:
+		r := j.Replicate
+		validateBatchAPIVersion(&errs, "replicate", r.APIVersion)
+		validateBatchEndpoint(&errs, "replicate.source", r.Source)
+		validateBatchEndpoint(&errs, "replicate.target", r.Target)
+		if r.Source.Endpoint != "" && r.Target.Endpoint != "" {
+			errs.add("replicate", "either source or target must be local, both have an endpoint")
+		}
+		if r.Source.Endpoint != "" && len(r.Flags.Filter.Tags) > 0 {
+			errs.add("replicate.flags.filter.tags", "tags are not supported when source is remote")
+		}
+		validateBatchFlags(&errs, "replicate.flags", r.Flags)
+	case j.KeyRotate != nil:
+		k := j.KeyRotate
+		validateBatchAPIVersion(&errs, string(batchJobKeyRotate), k.APIVersion)
+		validateBatchBucket(&errs, string(batchJobKeyRotate)+".bucket", k.Bucket)
+		if _, e := k.Encryption.serverSide(); e != nil {
+			errs.add(string(batchJobKeyRotate)+".encryption", "%v", e)
+		}
+		validateBatchFlags(&errs, string(batchJobKeyRotate)+".flags", k.Flags)
+		if k.Flags.Notify.Endpoint != "" {
+			errs.add(string(batchJobKeyRotate)+".flags.notify", "notifications are not supported by jobs running from mc")
+		}
+	case j.Expire != nil:
+		x := j.Expire
+		validateBatchAPIVersion(&errs, string(batchJobExpire), x.APIVersion)
+		validateBatchBucket(&errs, string(batchJobExpire)+".bucket", x.Bucket)
+		validateBatchFlags(&errs, string(batchJobExpire)+".flags", x.Flags)
+		if x.Flags.Notify.Endpoint != "" {
+			errs.add(string(batchJobExpire)+".flags.notify", "notifications are not supported by jobs running from mc")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func allBatchJobTypes() []string {
+	var types []string
+	for _, t := range madmin.SupportedJobTypes {
+		types = append(types, string(t))
+	}
+	for _, t := range clientSideJobTypes {
+		types = append(types, string(t))
+	}
+	return types
+}
+
+func validateBatchAPIVersion(errs *batchJobErrors, field, v string) {
+	if v != "v1" {
+		errs.add(field+".apiVersion", "unsupported version '%s', expected 'v1'", v)
+	}
+}
+
+func validateBatchBucket(errs *batchJobErrors, field, bucket string) {
+	if e := s3utils.CheckValidBucketNameStrict(bucket); e != nil {
+		errs.add(field, "%v", e)
+	}
+}
+
+func validateBatchURL(errs *batchJobErrors, field, u string) {
+	parsed, e := url.Parse(u)
+	if e != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		errs.add(field, "'%s' is not a valid http(s) URL", u)
+	}
+}
+
+func validateBatchEndpoint(errs *batchJobErrors, field string, ep batchJobEndpoint) {
+	if ep.Type != "minio" {
+		errs.add(field+".type", "unsupported type '%s', valid values are \"minio\"", ep.Type)
+	}
+	validateBatchBucket(errs, field+".bucket", ep.Bucket)
+	if ep.Endpoint == "" {
+		if ep.Credentials.AccessKey != "" || ep.Credentials.SecretKey != "" {
+			errs.add(field+".credentials", "credentials are only valid along with an endpoint")
+		}
+		return
+	}
+	validateBatchURL(errs, field+".endpoint", ep.Endpoint)
+	if ep.Credentials.AccessKey == "" || ep.Credentials.SecretKey == "" {
+		errs.add(field+".credentials", "accessKey and secretKey are required for a remote endpoint")
+	}
+}
+
+func validateBatchFlags(errs *batchJobErrors, field string, flags batchJobFlags) {
+	if _, e := flags.Filter.matcher(time.Now()); e != nil {
+		errs.add(field+".filter", "%v", e)
+	}
+	for i, kv := range flags.Filter.Tags {
+		if kv.Key == "" {
+			errs.add(fmt.Sprintf("%s.filter.tags[%d]", field, i), "key cannot be empty")
+		}
+	}
+	for i, kv := range flags.Filter.Metadata {
+		if kv.Key == "" {
+			errs.add(fmt.Sprintf("%s.filter.metadata[%d]", field, i), "key cannot be empty")
+		}
+	}
+	if flags.Notify.Endpoint != "" {
+		validateBatchURL(errs, field+".notify.endpoint", flags.Notify.Endpoint)
+	}
+	if flags.Retry.Attempts < 0 {
+		errs.add(field+".retry.attempts", "cannot be negative")
+	}
+	if flags.Retry.Delay != "" {
+		if d, e := time.ParseDuration(flags.Retry.Delay); e != nil || d < 0 {
+			errs.add(field+".retry.delay", "invalid duration '%s'", flags.Retry.Delay)
+		}
+	}
+}
+
+// serverSide returns the encryption to apply when rotating keys.
+func (e batchJobEncryption) serverSide() (encrypt.ServerSide, error) {
+	switch strings.ToLower(e.Type) {
+	case "sse-s3":
+		if e.Key != "" || e.Context != "" {
+			return nil, errors.New("key and context are only valid for \"sse-kms\"")
+		}
+		return encrypt.NewSSE(), nil
+	case "sse-kms":
+		if e.Key == "" {
+			return nil, errors.New("key is required for \"sse-kms\"")
+		}
+		var kmsCtx interface{}
+		if e.Context != "" {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(e.Context), &m); err != nil {
+				return nil, fmt.Errorf("context must be a JSON object: %v", err)
+			}
+			kmsCtx = m
+		}
+		return encrypt.NewSSEKMS(e.Key, kmsCtx)
+	}
+	return nil, fmt.Errorf("unsupported type '%s', valid values are \"sse-s3\" and \"sse-kms\"", e.Type)
+}
+
+// batchJobMatcher is a parsed batchJobFilter.
+type batchJobMatcher struct {
+	now                         time.Time
+	newerThan, olderThan        time.Duration
+	createdAfter, createdBefore time.Time
+	tags, metadata              []batchJobKV
+}
+
+func parseBatchJobDate(field, s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, e := time.Parse(layout, s); e == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: invalid date '%s', expected RFC3339 or YYYY-MM-DD", field, s)
+}
+
+// matcher parses the filter, 'now' is the reference for relative ages.
+func (f batchJobFilter) matcher(now time.Time) (m batchJobMatcher, e error) {
+	m = batchJobMatcher{now: now, tags: f.Tags, metadata: f.Metadata}
+	if f.NewerThan != "" {
+		d, e := ParseDuration(f.NewerThan)
+		if e != nil {
+			return m, fmt.Errorf("newerThan: invalid duration '%s'", f.NewerThan)
+		}
+		m.newerThan = time.Duration(d)
+	}
+	if f.OlderThan != "" {
+		d, e := ParseDuration(f.OlderThan)
+		if e != nil {
+			return m, fmt.Errorf("olderThan: invalid duration '%s'", f.OlderThan)
+		}
+		m.olderThan = time.Duration(d)
+	}
+	if m.newerThan > 0 && m.olderThan > 0 && m.olderThan >= m.newerThan {
+		return m, errors.New("olderThan must be smaller than newerThan, no object can match")
+	}
+	if f.CreatedAfter != "" {
+		if m.createdAfter, e = parseBatchJobDate("createdAfter", f.CreatedAfter); e != nil {
+			return m, e
+		}
+	}
+	if f.CreatedBefore != "" {
+		if m.createdBefore, e = parseBatchJobDate("createdBefore", f.CreatedBefore); e != nil {
+			return m, e
+		}
+	}
+	if !m.createdAfter.IsZero() && !m.createdBefore.IsZero() && !m.createdAfter.Before(m.createdBefore) {
+		return m, errors.New("createdAfter must be before createdBefore, no object can match")
+	}
+	return m, nil
+}
+
+// matchContent applies the time and metadata criteria.
+func (m batchJobMatcher) matchContent(content *ClientContent) bool {
+	age := m.now.Sub(content.Time)
+	if m.newerThan > 0 && age >= m.newerThan {
+		return false
+	}
+	if m.olderThan > 0 && age <= m.olderThan {
+		return false
+	}
+	if !m.createdAfter.IsZero() && !content.Time.After(m.createdAfter) {
+		return false
+	}
+	if !m.createdBefore.IsZero() && !content.Time.Before(m.createdBefore) {
+		return false
+	}
+	for _, kv := range m.metadata {
+		if !matchBatchMetadata(content, kv) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchBatchMetadata(content *ClientContent, kv batchJobKV) bool {
+	key := strings.ToLower(kv.Key)
+	for _, md := range []map[string]string{content.Metadata, content.UserMetadata} {
+		for k, v := range md {
+			k = strings.ToLower(k)
+			if k == key || strings.TrimPrefix(k, "x-amz-meta-") == key {
+				if wildcard.Match(kv.Value, v) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// needTags returns true when objects tags must be fetched to match.
+func (m batchJobMatcher) needTags() bool {
+	return len(m.tags) > 0
+}
+
+func (m batchJobMatcher) matchTags(tags map[string]string) bool {
+	for _, kv := range m.tags {
+		v, ok := tags[kv.Key]
+		if !ok || !wildcard.Match(kv.Value, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// batchJobURL returns the listing URL of the given bucket and prefix.
+func batchJobURL(base, bucket, prefix string) string {
+	return strings.TrimSuffix(base, "/") + "/" + bucket + "/" + strings.TrimPrefix(prefix, "/")
+}
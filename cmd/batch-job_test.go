@@ -0,0 +1,239 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+)
+
+func TestBatchJobValidate(t *testing.T) {
+	testCases := []struct {
+		yaml string
+		errs []string
+	}{
+		{
+			yaml: `
+replicate:
+  apiVersion: v1
+  source:
+    type: minio
+    bucket: photos
+    prefix: 2022/
+  target:
+    type: minio
+    bucket: backup
+    endpoint: https://backup.example.com:9000
+    credentials:
+      accessKey: minio
+      secretKey: minio123
+  flags:
+    filter:
+      olderThan: 7d
+      createdAfter: "2022-01-01"
+    retry:
+      attempts: 3
+      delay: 500ms
+`,
+		},
+		{
+			yaml: `
+replicate:
+  apiVersion: v2
+  source:
+    type: TYPE
+    bucket: BUCKET
+  target:
+    type: minio
+    bucket: backup
+    endpoint: backup.example.com
+  flags:
+    filter:
+      newerThan: 7x
+    retry:
+      delay: soon
+`,
+			errs: []string{
+				"replicate.apiVersion",
+				"replicate.source.type",
+				"replicate.source.bucket",
+				"replicate.target.endpoint",
+				"replicate.target.credentials",
+				"replicate.flags.filter: newerThan",
+				"replicate.flags.retry.delay",
+			},
+		},
+		{
+			// Typo in a field name is rejected.
+			yaml: `
+mc-expire:
+  apiVersion: v1
+  bucket: logs
+  flags:
+    filter:
+      olderThen: 30d
+`,
+			errs: []string{"olderThen"},
+		},
+		{
+			yaml: `
+mc-keyrotate:
+  apiVersion: v1
+  bucket: logs
+  encryption:
+    type: sse-kms
+`,
+			errs: []string{"mc-keyrotate.encryption: key is required"},
+		},
+		{
+			yaml: `
+mc-expire:
+  apiVersion: v1
+  bucket: logs
+  flags:
+    filter:
+      createdAfter: "2022-02-01"
+      createdBefore: "2022-01-01"
+`,
+			errs: []string{"createdAfter must be before createdBefore"},
+		},
+		{
+			yaml: "bucket: logs\n",
+			errs: []string{"bucket"},
+		},
+		{
+			yaml: "mc-expire: {}\n",
+			errs: []string{"mc-expire.apiVersion", "mc-expire.bucket"},
+		},
+	}
+	for i, testCase := range testCases {
+		job, e := parseBatchJob([]byte(testCase.yaml), true)
+		if e == nil {
+			e = job.validate()
+		}
+		if len(testCase.errs) == 0 {
+			if e != nil {
+				t.Fatalf("Test %d: unexpected error %v", i+1, e)
+			}
+			continue
+		}
+		if e == nil {
+			t.Fatalf("Test %d: expected errors %v", i+1, testCase.errs)
+		}
+		for _, want := range testCase.errs {
+			if !strings.Contains(e.Error(), want) {
+				t.Fatalf("Test %d: expected %q in %q", i+1, want, e.Error())
+			}
+		}
+	}
+}
+
+func TestBatchJobTemplates(t *testing.T) {
+	for _, tmpl := range []string{batchJobKeyRotateTemplate, batchJobExpireTemplate} {
+		tmpl = strings.ReplaceAll(tmpl, "BUCKET", "mybucket")
+		tmpl = strings.ReplaceAll(tmpl, "PREFIX", "prefix/")
+		tmpl = strings.ReplaceAll(tmpl, `createdAfter: "date"`, `createdAfter: "2022-01-01"`)
+		tmpl = strings.ReplaceAll(tmpl, `createdBefore: "date"`, `createdBefore: "2022-02-01"`)
+		tmpl = strings.ReplaceAll(tmpl, `newerThan: "7d"`, `newerThan: "14d"`)
+		job, e := parseBatchJob([]byte(tmpl), true)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if e = job.validate(); e != nil {
+			t.Fatal(e)
+		}
+		if !job.isClientSide() {
+			t.Fatalf("expected %s to run from mc", job.Type())
+		}
+	}
+}
+
+func TestBatchJobMatcher(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	m, e := batchJobFilter{
+		OlderThan:     "1d",
+		NewerThan:     "30d",
+		CreatedBefore: "2022-05-30",
+		Metadata:      []batchJobKV{{Key: "content-type", Value: "image/*"}},
+		Tags:          []batchJobKV{{Key: "project", Value: "pick*"}},
+	}.matcher(now)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	testCases := []struct {
+		modTime time.Time
+		ctype   string
+		match   bool
+	}{
+		{now.Add(-72 * time.Hour), "image/png", true},
+		{now.Add(-72 * time.Hour), "text/plain", false},
+		{now.Add(-48 * time.Hour), "image/png", false},
+		{now.Add(-12 * time.Hour), "image/png", false},
+		{now.Add(-40 * 24 * time.Hour), "image/png", false},
+		{now.Add(-24*time.Hour - time.Minute), "image/png", false},
+	}
+	for i, testCase := range testCases {
+		content := &ClientContent{Time: testCase.modTime, Metadata: map[string]string{"Content-Type": testCase.ctype}}
+		if got := m.matchContent(content); got != testCase.match {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, testCase.match, got)
+		}
+	}
+	if !m.needTags() || !m.matchTags(map[string]string{"project": "pickle"}) || m.matchTags(map[string]string{"project": "x"}) {
+		t.Fatal("unexpected tag match result")
+	}
+}
+
+func TestParseBatchJobLenient(t *testing.T) {
+	// Fields and job types of the server unknown to mc are only
+	// rejected by the strict parsing.
+	data := []byte(`
+replicate:
+  apiVersion: v1
+  source:
+    type: minio
+    bucket: src
+    snowball:
+      disable: true
+  target:
+    type: minio
+    bucket: dst
+`)
+	if _, e := parseBatchJob(data, true); e == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+	job, e := parseBatchJob(data, false)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if job.Type() != madmin.BatchJobReplicate || job.isClientSide() {
+		t.Fatalf("unexpected job type %s", job.Type())
+	}
+
+	// Server side job types named like the jobs of mc are not run from mc.
+	job, e = parseBatchJob([]byte("expire:\n  apiVersion: v1\n  bucket: logs\n"), false)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if job.isClientSide() {
+		t.Fatal("server side 'expire' job taken for a job running from mc")
+	}
+}
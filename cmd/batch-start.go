@@ -30,13 +30,20 @@ import (
 	"github.com/minio/pkg/console"
 )
 
+var batchStartFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "validate the job and list the objects it would select, without starting it",
+	},
+}
+
 var batchStartCmd = cli.Command{
 	Name:         "start",
 	Usage:        "start a new batch job",
 	Action:       mainBatchStart,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        globalFlags,
+	Flags:        append(batchStartFlags, globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -49,6 +56,12 @@ FLAGS:
 EXAMPLES:
   1. Start a new batch 'replication' job:
      {{.Prompt}} {{.HelpName}} myminio ./replication.yaml
+
+  2. Validate a batch 'replication' job and list the source objects its filters select:
+     {{.Prompt}} {{.HelpName}} --dry-run myminio ./replication.yaml
+
+  3. Re-encrypt objects with a new KMS key, 'mc-keyrotate' jobs run from mc:
+     {{.Prompt}} {{.HelpName}} myminio ./keyrotate.yaml
 `,
 }
 
@@ -84,21 +97,58 @@ func mainBatchStart(ctx *cli.Context) error {
 	checkBatchStartSyntax(ctx)
 
 	console.SetColor("BatchStart", color.New(color.FgGreen, color.Bold))
+	console.SetColor("BatchObject", color.New(color.FgGreen))
+	console.SetColor("BatchSummary", color.New(color.FgGreen, color.Bold))
+	console.SetColor("BatchFailed", color.New(color.FgRed, color.Bold))
+	console.SetColor("Time", color.New(color.FgGreen))
+	console.SetColor("Size", color.New(color.FgYellow))
+	console.SetColor("Key", color.New(color.Bold))
 
 	// Get the alias parameter from cli
 	args := ctx.Args()
 	aliasedURL := args.Get(0)
 
-	// Start a new MinIO Admin Client
-	adminClient, err := newAdminClient(aliasedURL)
-	fatalIf(err, "Unable to initialize admin connection.")
-
 	buf, e := ioutil.ReadFile(args.Get(1))
 	fatalIf(probe.NewError(e), "Unable to read %s", args.Get(1))
 
 	ctxt, cancel := context.WithCancel(globalContext)
 	defer cancel()
 
+	job, _ := parseBatchJob(buf, false)
+	if ctx.Bool("dry-run") || job.isClientSide() {
+		job, e = parseBatchJob(buf, true)
+		fatalIf(probe.NewError(e).Trace(args.Get(1)), "Unable to parse %s", args.Get(1))
+		fatalIf(probe.NewError(job.validate()).Trace(args.Get(1)), "Invalid batch job %s", args.Get(1))
+
+		src, err := newBatchJobSource(aliasedURL, job)
+		fatalIf(err, "Unable to initialize job source.")
+
+		var summary batchJobSummaryMessage
+		if ctx.Bool("dry-run") {
+			summary, err = dryRunBatchJob(ctxt, src, job)
+		} else {
+			summary, err = runClientSideBatchJob(ctxt, src, job)
+		}
+		fatalIf(err, "Unable to list objects selected by the job.")
+		printMsg(summary)
+		if summary.Failed > 0 {
+			return exitStatus(globalErrorExitStatus)
+		}
+		return nil
+	}
+
+	// The server validates its own jobs and may support fields or job
+	// types unknown to mc, local findings are only reported.
+	job, e = parseBatchJob(buf, true)
+	if e == nil {
+		e = job.validate()
+	}
+	errorIf(probe.NewError(e).Trace(args.Get(1)), "Batch job %s may be rejected by the server, use --dry-run to check it", args.Get(1))
+
+	// Start a new MinIO Admin Client
+	adminClient, err := newAdminClient(aliasedURL)
+	fatalIf(err, "Unable to initialize admin connection.")
+
 	res, e := adminClient.StartBatchJob(ctxt, string(buf))
 	fatalIf(probe.NewError(e), "Unable to start job")
 
@@ -0,0 +1,113 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var certsAddCmd = cli.Command{
+	Name:            "add",
+	Usage:           "trust the CA certificates of a PEM file",
+	Action:          mainCertsAdd,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} FILE [NAME]
+
+  NAME defaults to the base name of FILE, a '.crt' extension is added when missing.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Trust the certificate authority of a private MinIO deployment.
+     {{.Prompt}} {{.HelpName}} ./private-ca.pem
+
+  2. Trust a certificate under the name 'lab'.
+     {{.Prompt}} {{.HelpName}} /tmp/public.crt lab
+`,
+}
+
+// certsFileName returns the name a trusted certificate is stored with.
+func certsFileName(name string) (string, *probe.Error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", errInvalidArgument().Trace(name)
+	}
+	if filepath.Ext(name) != ".crt" {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".crt"
+	}
+	return name, nil
+}
+
+// mainCertsAdd is the handle for "mc certs add" command.
+func mainCertsAdd(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		showCommandHelpAndExit(ctx, ctx.Command.Name, 1) // last argument is exit code
+	}
+	setCertsColors()
+
+	args := ctx.Args()
+	file := args.Get(0)
+	name := filepath.Base(file)
+	if ctx.NArg() == 2 {
+		name = args.Get(1)
+	}
+	name, err := certsFileName(name)
+	fatalIf(err, "Invalid certificate name `"+name+"`.")
+
+	certificates, err := readCertificates(file)
+	fatalIf(err.Trace(file), "Unable to read certificates from `"+file+"`.")
+
+	// Only store the certificates, never private keys
+	// which may share the same PEM file.
+	var data []byte
+	var fingerprints []string
+	for _, cert := range certificates {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		fingerprints = append(fingerprints, publicKeyFingerprint(cert))
+	}
+
+	fatalIf(createCAsDir().Trace(), "Unable to create CAs folder.")
+	target := filepath.Join(mustGetCAsDir(), name)
+	f, e := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if os.IsExist(e) {
+		fatalIf(probe.NewError(e).Trace(target), "A certificate named `"+name+"` is already trusted, remove it first.")
+	}
+	fatalIf(probe.NewError(e).Trace(target), "Unable to create `"+target+"`.")
+	if _, e = f.Write(data); e == nil {
+		e = f.Close()
+	} else {
+		f.Close()
+	}
+	fatalIf(probe.NewError(e).Trace(target), "Unable to write `"+target+"`.")
+
+	printMsg(certsMessage{op: "add", Name: name, Fingerprints: fingerprints})
+	return nil
+}
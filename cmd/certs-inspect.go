@@ -0,0 +1,195 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var certsInspectCmd = cli.Command{
+	Name:            "inspect",
+	Usage:           "show the details of a certificate file or of the certificates of an alias",
+	Action:          mainCertsInspect,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} FILE | NAME | ALIAS
+
+  FILE is a PEM file, NAME a certificate of the CAs folder and ALIAS an https alias.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Inspect a PEM encoded certificate.
+     {{.Prompt}} {{.HelpName}} ./public.crt
+
+  2. Inspect the certificate chain presented by the server of alias 'myminio'.
+     {{.Prompt}} {{.HelpName}} myminio
+`,
+}
+
+// certsInspectMessage describes the certificates of a file or an alias.
+type certsInspectMessage struct {
+	Status       string            `json:"status"`
+	Alias        string            `json:"alias,omitempty"`
+	URL          string            `json:"url,omitempty"`
+	Trusted      bool              `json:"trusted"`
+	VerifyError  string            `json:"verifyError,omitempty"`
+	ClientCert   string            `json:"clientCert,omitempty"`
+	PinnedKeys   []string          `json:"pinnedKeys,omitempty"`
+	PinMatched   bool              `json:"pinMatched,omitempty"`
+	Certificates []certInfoMessage `json:"certificates"`
+}
+
+func (c certsInspectMessage) String() string {
+	var sb strings.Builder
+	if c.Alias != "" {
+		sb.WriteString(console.Colorize("CertsName", c.Alias+" ("+c.URL+")") + "\n")
+		trusted := console.Colorize("CertsDate", "yes")
+		if !c.Trusted {
+			trusted = console.Colorize("CertsExpired", "no, "+c.VerifyError)
+		}
+		sb.WriteString("Trusted     : " + trusted + "\n")
+		if c.ClientCert != "" {
+			sb.WriteString("Client Cert : " + c.ClientCert + "\n")
+		}
+		if len(c.PinnedKeys) > 0 {
+			matched := console.Colorize("CertsDate", "matched")
+			if !c.PinMatched {
+				matched = console.Colorize("CertsExpired", "not matched")
+			}
+			sb.WriteString("Pinned Keys : " + strings.Join(c.PinnedKeys, ", ") + " (" + matched + ")\n")
+		}
+		sb.WriteString("\n")
+	}
+	for i, cert := range c.Certificates {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(cert.String())
+	}
+	return sb.String()
+}
+
+func (c certsInspectMessage) JSON() string {
+	c.Status = "success"
+	for i := range c.Certificates {
+		c.Certificates[i].Status = ""
+	}
+	jsonMessageBytes, e := json.MarshalIndent(c, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// verifyCertificateChain verifies a chain presented by a server
+// against the system and the trusted CAs.
+func verifyCertificateChain(host string, peerCerts []*x509.Certificate) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range peerCerts[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, e := peerCerts[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         globalRootCAs,
+		Intermediates: intermediates,
+	})
+	return e
+}
+
+// mainCertsInspect is the handle for "mc certs inspect" command.
+func mainCertsInspect(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		showCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	setCertsColors()
+
+	arg := cliCtx.Args().Get(0)
+	path := arg
+	if _, e := os.Stat(path); e != nil && !strings.ContainsAny(arg, `/\`) {
+		if name, err := certsFileName(arg); err == nil {
+			for _, candidate := range []string{arg, name} {
+				if _, e = os.Stat(filepath.Join(mustGetCAsDir(), candidate)); e == nil {
+					path = filepath.Join(mustGetCAsDir(), candidate)
+					break
+				}
+			}
+		}
+	}
+
+	if _, e := os.Stat(path); e == nil {
+		certificates, err := readCertificates(path)
+		fatalIf(err.Trace(path), "Unable to read certificates from `"+arg+"`.")
+		msg := certsInspectMessage{Trusted: true}
+		for _, cert := range certificates {
+			msg.Certificates = append(msg.Certificates, newCertInfoMessage(filepath.Base(path), cert))
+		}
+		printMsg(msg)
+		return nil
+	}
+
+	alias := cleanAlias(arg)
+	_, aliasCfg := certsAliasConfig(alias)
+
+	ctx, cancel := context.WithTimeout(globalContext, 30*time.Second)
+	defer cancel()
+	peerCerts := fetchAliasCertificates(ctx, alias, aliasCfg)
+
+	msg := certsInspectMessage{
+		Alias:      alias,
+		URL:        aliasCfg.URL,
+		Trusted:    true,
+		ClientCert: aliasCfg.ClientCert,
+		PinnedKeys: aliasCfg.PinnedKeys,
+	}
+	u, e := url.Parse(aliasCfg.URL)
+	fatalIf(probe.NewError(e).Trace(aliasCfg.URL), "Unable to parse the URL of `"+alias+"`.")
+	if e = verifyCertificateChain(u.Hostname(), peerCerts); e != nil {
+		msg.Trusted, msg.VerifyError = false, e.Error()
+	}
+	if len(aliasCfg.PinnedKeys) > 0 {
+		msg.PinMatched = verifyPinnedKeys(alias, aliasCfg.PinnedKeys, peerCerts) == nil
+	}
+	for _, cert := range peerCerts {
+		info := newCertInfoMessage(cert.Subject.CommonName, cert)
+		for _, pinned := range aliasCfg.PinnedKeys {
+			if pinned == info.Fingerprint {
+				info.Pinned = true
+			}
+		}
+		msg.Certificates = append(msg.Certificates, info)
+	}
+	printMsg(msg)
+	return nil
+}
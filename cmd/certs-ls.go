@@ -0,0 +1,85 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var certsListCmd = cli.Command{
+	Name:            "ls",
+	Aliases:         []string{"list"},
+	Usage:           "list certificates trusted in addition to the system roots",
+	Action:          mainCertsList,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all trusted certificates in the CAs folder.
+     {{.Prompt}} {{.HelpName}}
+`,
+}
+
+// mainCertsList is the handle for "mc certs ls" command.
+func mainCertsList(ctx *cli.Context) error {
+	if ctx.NArg() != 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.Args()...), "Incorrect number of arguments for certs ls command.")
+	}
+	setCertsColors()
+
+	casDir, err := getCAsDir()
+	fatalIf(err.Trace(), "Unable to determine CAs folder.")
+	entries, e := os.ReadDir(casDir)
+	if os.IsNotExist(e) {
+		return nil
+	}
+	fatalIf(probe.NewError(e).Trace(casDir), "Unable to list the CAs folder.")
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		certificates, err := readCertificates(filepath.Join(casDir, entry.Name()))
+		if err != nil {
+			errorIf(err.Trace(entry.Name()), "Unable to read `"+entry.Name()+"`.")
+			continue
+		}
+		for _, cert := range certificates {
+			msg := newCertInfoMessage(entry.Name(), cert)
+			msg.op = "list"
+			printMsg(msg)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var certsSubcommands = []cli.Command{
+	certsListCmd,
+	certsAddCmd,
+	certsRemoveCmd,
+	certsInspectCmd,
+	certsPinCmd,
+	certsUnpinCmd,
+}
+
+var certsCmd = cli.Command{
+	Name:            "certs",
+	Usage:           "manage trusted certificates and per alias public key pins",
+	Action:          mainCerts,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	Subcommands:     certsSubcommands,
+}
+
+// mainCerts is the handle for "mc certs" command.
+func mainCerts(ctx *cli.Context) error {
+	commandNotFound(ctx, certsSubcommands)
+	return nil
+	// Sub-commands like ls, add and rm have their own main.
+}
+
+func setCertsColors() {
+	console.SetColor("CertsName", color.New(color.Bold))
+	console.SetColor("CertsDate", color.New(color.FgGreen))
+	console.SetColor("CertsExpired", color.New(color.FgRed, color.Bold))
+	console.SetColor("CertsFingerprint", color.New(color.FgYellow))
+	console.SetColor("CertsMessage", color.New(color.FgGreen))
+}
+
+// certsMessage reports a change to the trusted certificates or pins.
+type certsMessage struct {
+	op           string
+	Status       string   `json:"status"`
+	Name         string   `json:"name,omitempty"`
+	Alias        string   `json:"alias,omitempty"`
+	Fingerprints []string `json:"fingerprints,omitempty"`
+}
+
+func (c certsMessage) String() string {
+	switch c.op {
+	case "add":
+		return console.Colorize("CertsMessage", fmt.Sprintf("Added `%s` with %d certificate(s) to the trusted CAs.", c.Name, len(c.Fingerprints)))
+	case "rm":
+		return console.Colorize("CertsMessage", "Removed `"+c.Name+"` from the trusted CAs.")
+	case "pin", "unpin":
+		if len(c.Fingerprints) == 0 {
+			return console.Colorize("CertsMessage", "No public key is pinned for `"+c.Alias+"`.")
+		}
+		var sb strings.Builder
+		sb.WriteString(console.Colorize("CertsMessage", "Public keys pinned for `"+c.Alias+"`:"))
+		for _, fingerprint := range c.Fingerprints {
+			sb.WriteString("\n  " + console.Colorize("CertsFingerprint", fingerprint))
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+func (c certsMessage) JSON() string {
+	c.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(c, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// certInfoMessage describes a single certificate.
+type certInfoMessage struct {
+	op          string
+	Status      string    `json:"status,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Serial      string    `json:"serial"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	DNSNames    []string  `json:"dnsNames,omitempty"`
+	IPAddresses []string  `json:"ipAddresses,omitempty"`
+	KeyType     string    `json:"keyType"`
+	IsCA        bool      `json:"isCA"`
+	Fingerprint string    `json:"fingerprint"`
+	Expired     bool      `json:"expired"`
+	Pinned      bool      `json:"pinned,omitempty"`
+}
+
+func newCertInfoMessage(name string, cert *x509.Certificate) certInfoMessage {
+	msg := certInfoMessage{
+		Name:        name,
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Serial:      cert.SerialNumber.Text(16),
+		NotBefore:   cert.NotBefore.UTC(),
+		NotAfter:    cert.NotAfter.UTC(),
+		DNSNames:    cert.DNSNames,
+		KeyType:     publicKeyType(cert.PublicKey),
+		IsCA:        cert.IsCA,
+		Fingerprint: publicKeyFingerprint(cert),
+		Expired:     UTCNow().After(cert.NotAfter),
+	}
+	for _, ip := range cert.IPAddresses {
+		msg.IPAddresses = append(msg.IPAddresses, ip.String())
+	}
+	return msg
+}
+
+func publicKeyType(pub interface{}) string {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + pub.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return fmt.Sprintf("%T", pub)
+}
+
+func (c certInfoMessage) String() string {
+	date := console.Colorize("CertsDate", "["+c.NotAfter.Format(printDate)+"]")
+	if c.Expired {
+		date = console.Colorize("CertsExpired", "["+c.NotAfter.Format(printDate)+"]")
+	}
+	if c.op == "list" {
+		return fmt.Sprintf("%s %s %s %s", date, console.Colorize("CertsName", c.Name),
+			c.Subject, console.Colorize("CertsFingerprint", c.Fingerprint))
+	}
+
+	var sb strings.Builder
+	field := func(key, value string) {
+		sb.WriteString(fmt.Sprintf("%-12s: %s\n", key, value))
+	}
+	sb.WriteString(console.Colorize("CertsName", fmt.Sprintf("%-12s: %s", "Name", c.Name)) + "\n")
+	field("Subject", c.Subject)
+	field("Issuer", c.Issuer)
+	field("Serial", c.Serial)
+	field("Not Before", c.NotBefore.Format(printDate))
+	field("Not After", date)
+	if len(c.DNSNames) > 0 {
+		field("DNS Names", strings.Join(c.DNSNames, ", "))
+	}
+	if len(c.IPAddresses) > 0 {
+		field("IP Address", strings.Join(c.IPAddresses, ", "))
+	}
+	field("Key Type", c.KeyType)
+	field("CA", fmt.Sprint(c.IsCA))
+	fingerprint := console.Colorize("CertsFingerprint", c.Fingerprint)
+	if c.Pinned {
+		fingerprint += " (pinned)"
+	}
+	field("Fingerprint", fingerprint)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (c certInfoMessage) JSON() string {
+	c.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(c, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// certsAliasConfig returns the configuration of an alias without
+// unsealing its credentials, which are not needed for TLS.
+func certsAliasConfig(alias string) (*configV10, aliasConfigV10) {
+	conf, err := loadMcConfig()
+	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config version `"+globalMCConfigVersion+"`.")
+	aliasCfg, ok := conf.Aliases[alias]
+	if !ok {
+		fatalIf(errNoMatchingHost(alias).Trace(alias), "No such alias `"+alias+"` found.")
+	}
+	return conf, aliasCfg
+}
+
+// fetchAliasCertificates returns the certificate chain presented by the
+// server of an alias, using the alias client certificate for mTLS.
+func fetchAliasCertificates(ctx context.Context, alias string, aliasCfg aliasConfigV10) []*x509.Certificate {
+	var clientCert *tls.Certificate
+	if aliasCfg.ClientCert != "" || aliasCfg.ClientKey != "" {
+		keyPair, e := tls.LoadX509KeyPair(aliasCfg.ClientCert, aliasCfg.ClientKey)
+		fatalIf(probe.NewError(e).Trace(aliasCfg.ClientCert, aliasCfg.ClientKey), "Unable to load the client certificate of `"+alias+"`.")
+		clientCert = &keyPair
+	}
+	peerCerts, err := fetchPeerCertificateChain(ctx, aliasCfg.URL, clientCert)
+	fatalIf(err.Trace(alias), "Unable to fetch the certificates of `"+alias+"`.")
+	return peerCerts
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+var certsPinCmd = cli.Command{
+	Name:            "pin",
+	Usage:           "pin the public key of the server certificate of an alias",
+	Action:          mainCertsPin,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} ALIAS [FINGERPRINT...]
+
+  Without FINGERPRINT the public key of the certificate currently presented by
+  the server is pinned. Once pinned, connections to ALIAS fail unless a
+  certificate of the server chain carries one of the pinned public keys.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Pin the public key of the certificate presented by the server of alias 'myminio'.
+     {{.Prompt}} {{.HelpName}} myminio
+
+  2. Pin a public key ahead of a certificate rotation.
+     {{.Prompt}} {{.HelpName}} myminio sha256:3e4f7a0c1b5d8e2f9a6c4b1d7e0f3a5c8b2d6e9f1a4c7b0d3e6f9a2c5b8d1e4f
+`,
+}
+
+// addPinnedKeys adds fingerprints missing from the pinned keys.
+func addPinnedKeys(pinnedKeys []string, fingerprints ...string) []string {
+	for _, fingerprint := range fingerprints {
+		found := false
+		for _, pinned := range pinnedKeys {
+			if pinned == fingerprint {
+				found = true
+				break
+			}
+		}
+		if !found {
+			pinnedKeys = append(pinnedKeys, fingerprint)
+		}
+	}
+	return pinnedKeys
+}
+
+// mainCertsPin is the handle for "mc certs pin" command.
+func mainCertsPin(cliCtx *cli.Context) error {
+	if cliCtx.NArg() < 1 {
+		showCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	setCertsColors()
+
+	args := cliCtx.Args()
+	alias := cleanAlias(args.Get(0))
+	conf, aliasCfg := certsAliasConfig(alias)
+
+	var fingerprints []string
+	for _, arg := range args.Tail() {
+		fingerprint, err := parsePublicKeyFingerprint(arg)
+		fatalIf(err.Trace(arg), "Invalid public key fingerprint.")
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	if len(fingerprints) == 0 {
+		ctx, cancel := context.WithTimeout(globalContext, 30*time.Second)
+		defer cancel()
+		peerCerts := fetchAliasCertificates(ctx, alias, aliasCfg)
+		fingerprints = append(fingerprints, publicKeyFingerprint(peerCerts[0]))
+	}
+
+	aliasCfg.PinnedKeys = addPinnedKeys(aliasCfg.PinnedKeys, fingerprints...)
+	conf.Aliases[alias] = aliasCfg
+	err := saveMcConfig(conf)
+	fatalIf(err.Trace(alias), "Unable to update the public keys pinned for `"+alias+"`.")
+
+	printMsg(certsMessage{op: "pin", Alias: alias, Fingerprints: aliasCfg.PinnedKeys})
+	return nil
+}
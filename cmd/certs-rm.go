@@ -0,0 +1,77 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var certsRemoveCmd = cli.Command{
+	Name:            "rm",
+	Aliases:         []string{"remove"},
+	Usage:           "stop trusting a certificate of the CAs folder",
+	Action:          mainCertsRemove,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} NAME
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Remove the certificate trusted as 'lab.crt'.
+     {{.Prompt}} {{.HelpName}} lab
+`,
+}
+
+// mainCertsRemove is the handle for "mc certs rm" command.
+func mainCertsRemove(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		showCommandHelpAndExit(ctx, ctx.Command.Name, 1) // last argument is exit code
+	}
+	setCertsColors()
+
+	name := ctx.Args().Get(0)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		fatalIf(errInvalidArgument().Trace(name), "Invalid certificate name `"+name+"`.")
+	}
+
+	casDir := mustGetCAsDir()
+	for _, candidate := range []string{name, name + ".crt"} {
+		e := os.Remove(filepath.Join(casDir, candidate))
+		if os.IsNotExist(e) {
+			continue
+		}
+		fatalIf(probe.NewError(e).Trace(candidate), "Unable to remove `"+candidate+"`.")
+		printMsg(certsMessage{op: "rm", Name: candidate})
+		return nil
+	}
+	fatalIf(errInvalidArgument().Trace(name), "No trusted certificate named `"+name+"`.")
+	return nil
+}
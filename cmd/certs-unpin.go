@@ -0,0 +1,85 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+)
+
+var certsUnpinCmd = cli.Command{
+	Name:            "unpin",
+	Usage:           "remove public keys pinned for an alias",
+	Action:          mainCertsUnpin,
+	OnUsageError:    onUsageError,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} ALIAS [FINGERPRINT...]
+
+  Without FINGERPRINT all public keys pinned for ALIAS are removed.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Remove all pinned public keys of alias 'myminio'.
+     {{.Prompt}} {{.HelpName}} myminio
+
+  2. Remove a single pinned public key after a certificate rotation.
+     {{.Prompt}} {{.HelpName}} myminio sha256:3e4f7a0c1b5d8e2f9a6c4b1d7e0f3a5c8b2d6e9f1a4c7b0d3e6f9a2c5b8d1e4f
+`,
+}
+
+// mainCertsUnpin is the handle for "mc certs unpin" command.
+func mainCertsUnpin(cliCtx *cli.Context) error {
+	if cliCtx.NArg() < 1 {
+		showCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	setCertsColors()
+
+	args := cliCtx.Args()
+	alias := cleanAlias(args.Get(0))
+	conf, aliasCfg := certsAliasConfig(alias)
+
+	var pinnedKeys []string
+	if len(args.Tail()) > 0 {
+		remove := make(map[string]bool)
+		for _, arg := range args.Tail() {
+			fingerprint, err := parsePublicKeyFingerprint(arg)
+			fatalIf(err.Trace(arg), "Invalid public key fingerprint.")
+			remove[fingerprint] = true
+		}
+		for _, pinned := range aliasCfg.PinnedKeys {
+			if !remove[pinned] {
+				pinnedKeys = append(pinnedKeys, pinned)
+			}
+		}
+	}
+
+	aliasCfg.PinnedKeys = pinnedKeys
+	conf.Aliases[alias] = aliasCfg
+	err := saveMcConfig(conf)
+	fatalIf(err.Trace(alias), "Unable to update the public keys pinned for `"+alias+"`.")
+
+	printMsg(certsMessage{op: "unpin", Alias: alias, Fingerprints: aliasCfg.PinnedKeys})
+	return nil
+}
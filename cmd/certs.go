@@ -18,8 +18,19 @@
 package cmd
 
 import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/certs"
@@ -105,3 +116,139 @@ func loadRootCAs() {
 		fatalIf(probe.NewError(e), "Unable to load certificates.")
 	}
 }
+
+// publicKeyFingerprint returns the hex encoded SHA-256 of the public key
+// of a certificate, as shown when trusting a self-signed certificate.
+func publicKeyFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// parsePublicKeyFingerprint validates a public key fingerprint, an
+// optional 'sha256:' prefix and colons are accepted.
+func parsePublicKeyFingerprint(s string) (string, *probe.Error) {
+	fingerprint := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "sha256:"))
+	fingerprint = strings.ReplaceAll(fingerprint, ":", "")
+	if b, e := hex.DecodeString(fingerprint); e != nil || len(b) != sha256.Size {
+		return "", probe.NewError(fmt.Errorf("`%s` is not a SHA-256 public key fingerprint", s))
+	}
+	return fingerprint, nil
+}
+
+// verifyPinnedKeys succeeds when one certificate of the peer chain has
+// a public key pinned for the alias.
+func verifyPinnedKeys(alias string, pinnedKeys []string, peerCerts []*x509.Certificate) error {
+	for _, cert := range peerCerts {
+		fingerprint := publicKeyFingerprint(cert)
+		for _, pinned := range pinnedKeys {
+			if fingerprint == pinned {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no certificate presented by the server matches the public keys pinned for `%s`", alias)
+}
+
+// configureAliasTLS returns a copy of a TLS configuration with the client
+// certificate and the pinned public keys of an alias, tlsConfig itself
+// is left untouched as it may be shared with other clients.
+func configureAliasTLS(tlsConfig *tls.Config, config *Config) (*tls.Config, *probe.Error) {
+	if config.ClientCert == "" && config.ClientKey == "" && len(config.PinnedKeys) == 0 {
+		return tlsConfig, nil
+	}
+	tlsConfig = tlsConfig.Clone()
+	if config.ClientCert != "" || config.ClientKey != "" {
+		cert, e := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(config.ClientCert, config.ClientKey)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if len(config.PinnedKeys) > 0 {
+		alias, pinnedKeys := config.Alias, config.PinnedKeys
+		if alias == "" {
+			alias = config.HostURL
+		}
+		// Runs after the regular verification, and also when
+		// verification is disabled with --insecure.
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPinnedKeys(alias, pinnedKeys, cs.PeerCertificates)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// aliasTLSHash identifies the TLS settings of a config in client caches.
+func aliasTLSHash(config *Config) string {
+	return config.ClientCert + "\x00" + config.ClientKey + "\x00" + strings.Join(config.PinnedKeys, ",")
+}
+
+// readCertificates parses all PEM encoded certificates of a file.
+func readCertificates(path string) ([]*x509.Certificate, *probe.Error) {
+	data, e := os.ReadFile(path)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, e := x509.ParseCertificate(block.Bytes)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(path)
+		}
+		certificates = append(certificates, cert)
+	}
+	if len(certificates) == 0 {
+		return nil, probe.NewError(errors.New("no PEM encoded certificate found")).Trace(path)
+	}
+	return certificates, nil
+}
+
+// fetchPeerCertificateChain connects to a TLS endpoint and returns the
+// certificates presented by the server without verifying them. The
+// client certificate is presented to servers requiring mTLS.
+func fetchPeerCertificateChain(ctx context.Context, endpoint string, clientCert *tls.Certificate) ([]*x509.Certificate, *probe.Error) {
+	u, e := url.Parse(endpoint)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(endpoint)
+	}
+	if u.Scheme != "https" {
+		return nil, probe.NewError(fmt.Errorf("`%s` does not use TLS", endpoint))
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	var peerCerts []*x509.Certificate
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			peerCerts = cs.PeerCertificates
+			return nil
+		},
+	}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, e := dialer.DialContext(ctx, "tcp", host)
+	if e != nil && len(peerCerts) == 0 {
+		return nil, probe.NewError(e).Trace(endpoint)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	if len(peerCerts) == 0 {
+		return nil, probe.NewError(errors.New("server did not present a certificate")).Trace(endpoint)
+	}
+	return peerCerts, nil
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParsePublicKeyFingerprint(t *testing.T) {
+	const fingerprint = "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+	for _, s := range []string{
+		fingerprint,
+		"sha256:" + fingerprint,
+		strings.ToUpper(fingerprint),
+		"00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff",
+	} {
+		got, err := parsePublicKeyFingerprint(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if got != fingerprint {
+			t.Fatalf("%s: expected %s, got %s", s, fingerprint, got)
+		}
+	}
+	for _, s := range []string{"", "sha256:", fingerprint[2:], fingerprint + "00", "zz" + fingerprint[2:]} {
+		if _, err := parsePublicKeyFingerprint(s); err == nil {
+			t.Fatalf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestCertsFileName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		fail     bool
+	}{
+		{name: "lab", expected: "lab.crt"},
+		{name: "lab.crt", expected: "lab.crt"},
+		{name: "lab.pem", expected: "lab.crt"},
+		{name: "", fail: true},
+		{name: "..", fail: true},
+		{name: "../lab", fail: true},
+		{name: `a\b`, fail: true},
+	}
+	for _, testCase := range testCases {
+		got, err := certsFileName(testCase.name)
+		if testCase.fail != (err != nil) {
+			t.Fatalf("%q: unexpected error %v", testCase.name, err)
+		}
+		if got != testCase.expected {
+			t.Fatalf("%q: expected %q, got %q", testCase.name, testCase.expected, got)
+		}
+	}
+}
+
+func TestAliasTLSPinning(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverCert := server.Certificate()
+
+	// Certificates are read back from PEM files.
+	path := filepath.Join(t.TempDir(), "public.crt")
+	data := append([]byte("garbage\n"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Raw})...)
+	if e := os.WriteFile(path, data, 0o600); e != nil {
+		t.Fatal(e)
+	}
+	certificates, err := readCertificates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certificates) != 1 || publicKeyFingerprint(certificates[0]) != publicKeyFingerprint(serverCert) {
+		t.Fatalf("unexpected certificates read from %s", path)
+	}
+
+	peerCerts, err := fetchPeerCertificateChain(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !peerCerts[0].Equal(serverCert) {
+		t.Fatal("unexpected peer certificate")
+	}
+
+	get := func(pinnedKeys ...string) error {
+		shared := &tls.Config{InsecureSkipVerify: true}
+		tlsConfig, err := configureAliasTLS(shared, &Config{Alias: "test", PinnedKeys: pinnedKeys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shared.VerifyConnection != nil {
+			t.Fatal("the TLS configuration of the caller was modified")
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, e := client.Get(server.URL)
+		if e != nil {
+			return e
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	other := "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+	if e := get(); e != nil {
+		t.Fatalf("unpinned: %v", e)
+	}
+	if e := get(other, publicKeyFingerprint(serverCert)); e != nil {
+		t.Fatalf("pinned: %v", e)
+	}
+	if e := get(other); e == nil || !strings.Contains(e.Error(), "pinned") {
+		t.Fatalf("expected a pinning error, got %v", e)
+	}
+}
+
+func TestConfigureAliasTLSClientCert(t *testing.T) {
+	_, err := configureAliasTLS(&tls.Config{}, &Config{ClientCert: "/nonexistent/public.crt", ClientKey: "/nonexistent/private.key"})
+	if err == nil {
+		t.Fatal("expected an error for a missing client certificate")
+	}
+}
@@ -58,7 +58,7 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 
 		// Generate a hash out of s3Conf.
 		confHash := fnv.New32a()
-		confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey))
+		confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey + config.Alias + aliasTLSHash(config)))
 		confSum := confHash.Sum32()
 
 		// Lookup previous cache by hash.
@@ -91,6 +91,10 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 			if config.Insecure {
 				tlsConfig.InsecureSkipVerify = true
 			}
+			tlsConfig, err := configureAliasTLS(tlsConfig, config)
+			if err != nil {
+				return nil, err.Trace(config.HostURL)
+			}
 
 			var transport http.RoundTripper = &http.Transport{
 				Proxy:                 ieproxy.GetProxyFunc(),
@@ -108,6 +112,10 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 				transport = httptracer.GetNewTraceTransport(newTraceV4(), transport)
 			}
 
+			if logger := getAuditLogger(); logger != nil {
+				transport = newAuditTransport(transport, logger, config.Alias, config.AccessKey)
+			}
+
 			// Set custom transport.
 			api.SetCustomTransport(transport)
 
@@ -140,6 +148,7 @@ func newAdminClient(aliasedURL string) (*madmin.AdminClient, *probe.Error) {
 	}
 
 	s3Config := NewS3Config(urlStrFull, aliasCfg)
+	s3Config.Alias = alias
 
 	s3Client, err := s3AdminNew(s3Config)
 	if err != nil {
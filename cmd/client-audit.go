@@ -0,0 +1,258 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/minio-go/v7/pkg/replication"
+)
+
+// auditClient wraps a Client and writes an audit entry for every
+// mutating operation, read only operations are passed through.
+type auditClient struct {
+	Client
+	logger    *auditLogger
+	alias     string
+	accessKey string
+}
+
+// newAuditClient returns clnt wrapped with the audit log, if enabled.
+func newAuditClient(clnt Client, alias string, aliasCfg *aliasConfigV10) Client {
+	logger := getAuditLogger()
+	if logger == nil {
+		return clnt
+	}
+	c := &auditClient{Client: clnt, logger: logger, alias: alias}
+	if aliasCfg != nil {
+		c.accessKey = aliasCfg.AccessKey
+	}
+	return c
+}
+
+// unwrapClient returns the client implementation behind the audit log
+// and the undo journal.
+func unwrapClient(clnt Client) Client {
+	for {
+		switch c := clnt.(type) {
+		case *auditClient:
+			clnt = c.Client
+		case *journalClient:
+			return c.S3Client
+		default:
+			return clnt
+		}
+	}
+}
+
+// auditLog records an operation performed directly on the client
+// implementation, i.e. on an operation which is not part of Client.
+func auditLog(clnt Client, event, versionID string, err *probe.Error) {
+	if c, ok := clnt.(*auditClient); ok {
+		c.log(event, c.GetURL().String(), versionID, err)
+	}
+}
+
+func (c *auditClient) log(event, url, versionID string, err *probe.Error) {
+	c.logger.logResult(auditEntry{
+		Alias:     c.alias,
+		AccessKey: c.accessKey,
+		Event:     event,
+		URL:       url,
+		VersionID: versionID,
+	}, err)
+}
+
+// MakeBucket - audited MakeBucket
+func (c *auditClient) MakeBucket(ctx context.Context, region string, ignoreExisting, withLock bool) *probe.Error {
+	err := c.Client.MakeBucket(ctx, region, ignoreExisting, withLock)
+	c.log("MakeBucket", c.GetURL().String(), "", err)
+	return err
+}
+
+// RemoveBucket - audited RemoveBucket
+func (c *auditClient) RemoveBucket(ctx context.Context, forceRemove bool) *probe.Error {
+	err := c.Client.RemoveBucket(ctx, forceRemove)
+	c.log("RemoveBucket", c.GetURL().String(), "", err)
+	return err
+}
+
+// SetObjectLockConfig - audited SetObjectLockConfig
+func (c *auditClient) SetObjectLockConfig(ctx context.Context, mode minio.RetentionMode, validity uint64, unit minio.ValidityUnit) *probe.Error {
+	err := c.Client.SetObjectLockConfig(ctx, mode, validity, unit)
+	c.log("SetObjectLockConfig", c.GetURL().String(), "", err)
+	return err
+}
+
+// SetAccess - audited SetAccess
+func (c *auditClient) SetAccess(ctx context.Context, access string, isJSON bool) *probe.Error {
+	err := c.Client.SetAccess(ctx, access, isJSON)
+	c.log("SetAccess", c.GetURL().String(), "", err)
+	return err
+}
+
+// Copy - audited Copy
+func (c *auditClient) Copy(ctx context.Context, source string, opts CopyOptions, progress io.Reader) *probe.Error {
+	err := c.Client.Copy(ctx, source, opts, progress)
+	c.logger.logResult(auditEntry{
+		Alias:     c.alias,
+		AccessKey: c.accessKey,
+		Event:     "Copy",
+		URL:       c.GetURL().String(),
+		Source:    source,
+		VersionID: opts.versionID,
+	}, err)
+	return err
+}
+
+// Put - audited Put
+func (c *auditClient) Put(ctx context.Context, reader io.Reader, size int64, progress io.Reader, opts PutOptions) (int64, *probe.Error) {
+	n, err := c.Client.Put(ctx, reader, size, progress, opts)
+	c.log("Put", c.GetURL().String(), "", err)
+	return n, err
+}
+
+// PutPart - audited PutPart
+func (c *auditClient) PutPart(ctx context.Context, reader io.Reader, size int64, progress io.Reader, opts PutOptions) (int64, *probe.Error) {
+	n, err := c.Client.PutPart(ctx, reader, size, progress, opts)
+	c.log("PutPart", c.GetURL().String(), "", err)
+	return n, err
+}
+
+// PutObjectRetention - audited PutObjectRetention
+func (c *auditClient) PutObjectRetention(ctx context.Context, versionID string, mode minio.RetentionMode, retainUntilDate time.Time, bypassGovernance bool) *probe.Error {
+	err := c.Client.PutObjectRetention(ctx, versionID, mode, retainUntilDate, bypassGovernance)
+	c.log("PutObjectRetention", c.GetURL().String(), versionID, err)
+	return err
+}
+
+// PutObjectLegalHold - audited PutObjectLegalHold
+func (c *auditClient) PutObjectLegalHold(ctx context.Context, versionID string, hold minio.LegalHoldStatus) *probe.Error {
+	err := c.Client.PutObjectLegalHold(ctx, versionID, hold)
+	c.log("PutObjectLegalHold", c.GetURL().String(), versionID, err)
+	return err
+}
+
+// Remove - audited Remove, one entry is written per removed object.
+func (c *auditClient) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isBypass, isForceDel bool, contentCh <-chan *ClientContent) <-chan RemoveResult {
+	resultCh := make(chan RemoveResult)
+	go func() {
+		defer close(resultCh)
+		for result := range c.Client.Remove(ctx, isIncomplete, isRemoveBucket, isBypass, isForceDel, contentCh) {
+			c.log("Remove", c.removeResultURL(result), result.removedVersionID(), result.Err)
+			resultCh <- result
+		}
+	}()
+	return resultCh
+}
+
+// removeResultURL builds the URL of the object a remove result refers to.
+func (c *auditClient) removeResultURL(result RemoveResult) string {
+	u := c.GetURL()
+	if u.Type == objectStorage && (result.BucketName != "" || result.ObjectName != "") {
+		u.Path = string(u.Separator) + path.Join(result.BucketName, result.ObjectName)
+	} else if result.ObjectName != "" {
+		u.Path = result.ObjectName
+	}
+	return strings.TrimSuffix(u.String(), string(u.Separator))
+}
+
+// removedVersionID returns the version created or removed by the operation.
+func (r RemoveResult) removedVersionID() string {
+	if r.DeleteMarker {
+		return r.DeleteMarkerVersionID
+	}
+	return r.ObjectVersionID
+}
+
+// SetTags - audited SetTags
+func (c *auditClient) SetTags(ctx context.Context, versionID, tags string) *probe.Error {
+	err := c.Client.SetTags(ctx, versionID, tags)
+	c.log("SetTags", c.GetURL().String(), versionID, err)
+	return err
+}
+
+// DeleteTags - audited DeleteTags
+func (c *auditClient) DeleteTags(ctx context.Context, versionID string) *probe.Error {
+	err := c.Client.DeleteTags(ctx, versionID)
+	c.log("DeleteTags", c.GetURL().String(), versionID, err)
+	return err
+}
+
+// SetLifecycle - audited SetLifecycle
+func (c *auditClient) SetLifecycle(ctx context.Context, config *lifecycle.Configuration) *probe.Error {
+	err := c.Client.SetLifecycle(ctx, config)
+	c.log("SetLifecycle", c.GetURL().String(), "", err)
+	return err
+}
+
+// SetVersion - audited SetVersion
+func (c *auditClient) SetVersion(ctx context.Context, status string, prefixes []string, excludeFolders bool) *probe.Error {
+	err := c.Client.SetVersion(ctx, status, prefixes, excludeFolders)
+	c.log("SetVersion", c.GetURL().String(), "", err)
+	return err
+}
+
+// SetReplication - audited SetReplication
+func (c *auditClient) SetReplication(ctx context.Context, cfg *replication.Config, opts replication.Options) *probe.Error {
+	err := c.Client.SetReplication(ctx, cfg, opts)
+	c.log("SetReplication", c.GetURL().String(), "", err)
+	return err
+}
+
+// RemoveReplication - audited RemoveReplication
+func (c *auditClient) RemoveReplication(ctx context.Context) *probe.Error {
+	err := c.Client.RemoveReplication(ctx)
+	c.log("RemoveReplication", c.GetURL().String(), "", err)
+	return err
+}
+
+// ResetReplication - audited ResetReplication
+func (c *auditClient) ResetReplication(ctx context.Context, before time.Duration, arn string) (replication.ResyncTargetsInfo, *probe.Error) {
+	info, err := c.Client.ResetReplication(ctx, before, arn)
+	c.log("ResetReplication", c.GetURL().String(), "", err)
+	return info, err
+}
+
+// SetEncryption - audited SetEncryption
+func (c *auditClient) SetEncryption(ctx context.Context, algorithm, kmsKeyID string) *probe.Error {
+	err := c.Client.SetEncryption(ctx, algorithm, kmsKeyID)
+	c.log("SetEncryption", c.GetURL().String(), "", err)
+	return err
+}
+
+// DeleteEncryption - audited DeleteEncryption
+func (c *auditClient) DeleteEncryption(ctx context.Context) *probe.Error {
+	err := c.Client.DeleteEncryption(ctx)
+	c.log("DeleteEncryption", c.GetURL().String(), "", err)
+	return err
+}
+
+// Restore - audited Restore
+func (c *auditClient) Restore(ctx context.Context, versionID string, days int) *probe.Error {
+	err := c.Client.Restore(ctx, versionID, days)
+	c.log("Restore", c.GetURL().String(), versionID, err)
+	return err
+}
@@ -134,10 +134,14 @@ func (f *fsClient) GetURL() ClientURL {
 
 // Select replies a stream of query results.
 func (f *fsClient) Select(ctx context.Context, expression string, sse encrypt.ServerSide, opts SelectObjectOpts) (io.ReadCloser, *probe.Error) {
-	return nil, probe.NewError(APINotImplemented{
-		API:     "Select",
-		APIType: "filesystem",
-	})
+	fpath := f.PathURL.Path
+	reader, err := f.Get(ctx, GetOptions{})
+	if err != nil {
+		return nil, err.Trace(fpath)
+	}
+	// Local files are always queried by the local SQL engine.
+	in := selectObjectInputOpts(opts, fpath)
+	return localSelect(reader, expression, in, selectObjectOutputOpts(opts, in))
 }
 
 // Watches for all fs events on an input path.
@@ -145,6 +149,21 @@ func (f *fsClient) Watch(ctx context.Context, options WatchOptions) (*WatchObjec
 	eventChan := make(chan []EventInfo)
 	errorChan := make(chan *probe.Error)
 	doneChan := make(chan struct{})
+
+	if options.Poll {
+		wo := &WatchObject{
+			EventInfoChan: eventChan,
+			ErrorChan:     errorChan,
+			DoneChan:      doneChan,
+		}
+		go func() {
+			defer close(eventChan)
+			defer close(errorChan)
+			pollWatch(ctx, f, options, wo)
+		}()
+		return wo, nil
+	}
+
 	// Make the channel buffered to ensure no event is dropped. Notify will drop
 	// an event if the receiver is not able to keep up the sending pace.
 	in, out := PipeChan(1000)
@@ -1408,10 +1427,13 @@ func (f *fsClient) Restore(_ context.Context, _ string, _ int) *probe.Error {
 	})
 }
 
-// OD Get - not implemented
-func (f *fsClient) GetPart(ctx context.Context, _ int) (io.ReadCloser, *probe.Error) {
-	return nil, probe.NewError(APINotImplemented{
-		API:     "GetPart",
-		APIType: "filesystem",
-	})
+// GetPart - reads the whole file, files have no parts.
+func (f *fsClient) GetPart(ctx context.Context, part int) (io.ReadCloser, *probe.Error) {
+	if part > 0 {
+		return nil, probe.NewError(APINotImplemented{
+			API:     "GetPart",
+			APIType: "filesystem",
+		})
+	}
+	return f.Get(ctx, GetOptions{})
 }
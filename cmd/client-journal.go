@@ -0,0 +1,196 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+// journalClient wraps an S3Client and records the versions created by
+// uploads, copies and removals in the undo journal, so that a whole
+// mc run can be reverted later with 'mc undo --op'.
+type journalClient struct {
+	*S3Client
+	journal *undoJournal
+	alias   string
+}
+
+// newJournalClient returns clnt wrapped with the undo journal, if enabled.
+// Recording costs a HEAD request per changed object to find the version
+// it replaces, which is why the journal has to be turned on.
+func newJournalClient(clnt Client, alias string) Client {
+	s3Clnt, ok := clnt.(*S3Client)
+	if !ok || alias == "" {
+		return clnt
+	}
+	journal := getUndoJournal()
+	if journal == nil {
+		return clnt
+	}
+	return &journalClient{S3Client: s3Clnt, journal: journal, alias: alias}
+}
+
+// Buckets known to have versioning enabled, a single lookup is done
+// per bucket and process.
+var (
+	undoVersionedBuckets   = map[string]bool{}
+	undoVersionedBucketsMu sync.Mutex
+)
+
+// isVersioned returns true when the bucket has versioning enabled, so
+// that the versions created by an operation can be recorded.
+func (c *journalClient) isVersioned(ctx context.Context) bool {
+	bucket, _ := c.url2BucketAndObject()
+	key := c.alias + "/" + bucket
+
+	undoVersionedBucketsMu.Lock()
+	versioned, ok := undoVersionedBuckets[key]
+	undoVersionedBucketsMu.Unlock()
+	if !ok {
+		cfg, err := c.S3Client.GetVersion(ctx)
+		versioned = err == nil && cfg.Status == "Enabled"
+		undoVersionedBucketsMu.Lock()
+		undoVersionedBuckets[key] = versioned
+		undoVersionedBucketsMu.Unlock()
+	}
+	return versioned
+}
+
+// priorVersion returns the latest version of an object before it is
+// overwritten or removed, empty when the object does not exist.
+func (c *journalClient) priorVersion(ctx context.Context, bucket, object string) string {
+	versionID, _, e := latestObjectVersion(ctx, c.api, bucket, object)
+	if e != nil {
+		return ""
+	}
+	return versionID
+}
+
+// latestObjectVersion returns the latest version of an object, which
+// may be a delete marker, empty when the object has no version.
+func latestObjectVersion(ctx context.Context, api *minio.Client, bucket, object string) (versionID string, deleteMarker bool, e error) {
+	info, e := api.StatObject(ctx, bucket, object, minio.StatObjectOptions{})
+	switch {
+	case e == nil:
+		return info.VersionID, false, nil
+	case info.IsDeleteMarker:
+		return info.VersionID, true, nil
+	case minio.ToErrorResponse(e).Code == "NoSuchKey":
+		return "", false, nil
+	}
+	return "", false, e
+}
+
+// recordUpload records the version created by an upload or a copy.
+func (c *journalClient) recordUpload(ui minio.UploadInfo, prior string) {
+	// Uploads to buckets with versioning suspended replace the "null" version.
+	if ui.VersionID == "" || ui.VersionID == "null" {
+		return
+	}
+	c.journal.record(undoJournalEntry{
+		URL:            c.alias + "/" + ui.Bucket + "/" + ui.Key,
+		Action:         undoActionPut,
+		VersionID:      ui.VersionID,
+		PriorVersionID: prior,
+	})
+}
+
+// Put - journaled Put
+func (c *journalClient) Put(ctx context.Context, reader io.Reader, size int64, progress io.Reader, opts PutOptions) (int64, *probe.Error) {
+	if !c.isVersioned(ctx) {
+		return c.S3Client.Put(ctx, reader, size, progress, opts)
+	}
+	bucket, object := c.url2BucketAndObject()
+	prior := c.priorVersion(ctx, bucket, object)
+	ui, err := c.putObject(ctx, reader, size, progress, opts)
+	if err == nil {
+		c.recordUpload(ui, prior)
+	}
+	return ui.Size, err
+}
+
+// PutPart - journaled PutPart
+func (c *journalClient) PutPart(ctx context.Context, reader io.Reader, size int64, progress io.Reader, opts PutOptions) (int64, *probe.Error) {
+	return c.Put(ctx, reader, size, progress, opts)
+}
+
+// Copy - journaled Copy
+func (c *journalClient) Copy(ctx context.Context, source string, opts CopyOptions, progress io.Reader) *probe.Error {
+	if !c.isVersioned(ctx) {
+		return c.S3Client.Copy(ctx, source, opts, progress)
+	}
+	bucket, object := c.url2BucketAndObject()
+	prior := c.priorVersion(ctx, bucket, object)
+	ui, err := c.copyObject(ctx, source, opts, progress)
+	if err == nil {
+		c.recordUpload(ui, prior)
+	}
+	return err
+}
+
+// Remove - journaled Remove, only delete markers are recorded as
+// versions removed permanently cannot be restored.
+func (c *journalClient) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isBypass, isForceDel bool, contentCh <-chan *ClientContent) <-chan RemoveResult {
+	if isIncomplete || isRemoveBucket || !c.isVersioned(ctx) {
+		return c.S3Client.Remove(ctx, isIncomplete, isRemoveBucket, isBypass, isForceDel, contentCh)
+	}
+
+	// The latest versions are looked up before removal, so that the
+	// journal tells which version a delete marker hides.
+	var mu sync.Mutex
+	priors := map[string]string{}
+	priorCh := make(chan *ClientContent)
+	go func() {
+		defer close(priorCh)
+		for content := range contentCh {
+			if content.VersionID == "" {
+				bucket, object := c.splitPath(content.URL.Path)
+				prior := c.priorVersion(ctx, bucket, object)
+				mu.Lock()
+				priors[bucket+"/"+object] = prior
+				mu.Unlock()
+			}
+			priorCh <- content
+		}
+	}()
+
+	resultCh := make(chan RemoveResult)
+	go func() {
+		defer close(resultCh)
+		for result := range c.S3Client.Remove(ctx, isIncomplete, isRemoveBucket, isBypass, isForceDel, priorCh) {
+			if result.Err == nil && result.DeleteMarker && result.ObjectVersionID == "" && result.DeleteMarkerVersionID != "" {
+				mu.Lock()
+				prior := priors[result.BucketName+"/"+result.ObjectName]
+				mu.Unlock()
+				c.journal.record(undoJournalEntry{
+					URL:            c.alias + "/" + result.BucketName + "/" + result.ObjectName,
+					Action:         undoActionDelete,
+					VersionID:      result.DeleteMarkerVersionID,
+					PriorVersionID: prior,
+				})
+			}
+			resultCh <- result
+		}
+	}()
+	return resultCh
+}
@@ -147,7 +147,7 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 		}
 		// Generate a hash out of s3Conf.
 		confHash := fnv.New32a()
-		confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey + config.SessionToken))
+		confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey + config.SessionToken + aliasTLSHash(config)))
 		confSum := confHash.Sum32()
 
 		// Lookup previous cache by hash.
@@ -166,7 +166,19 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 			var transport http.RoundTripper
 
 			if config.Transport != nil {
-				transport = config.Transport
+				tr := config.Transport
+				if tr.TLSClientConfig != nil {
+					tlsConfig, err := configureAliasTLS(tr.TLSClientConfig, config)
+					if err != nil {
+						return nil, err.Trace(config.HostURL)
+					}
+					if tlsConfig != tr.TLSClientConfig {
+						// The transport is shared, keep it as it is.
+						tr = tr.Clone()
+						tr.TLSClientConfig = tlsConfig
+					}
+				}
+				transport = tr
 			} else {
 				tr := &http.Transport{
 					Proxy:                 http.ProxyFromEnvironment,
@@ -197,6 +209,10 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 					if config.Insecure {
 						tlsConfig.InsecureSkipVerify = true
 					}
+					tlsConfig, err := configureAliasTLS(tlsConfig, config)
+					if err != nil {
+						return nil, err.Trace(config.HostURL)
+					}
 					tr.TLSClientConfig = tlsConfig
 
 					// Because we create a custom TLSClientConfig, we have to opt-in to HTTP/2.
@@ -667,11 +683,34 @@ func (c *S3Client) Select(ctx context.Context, expression string, sse encrypt.Se
 
 	opts.InputSerialization = selectObjectInputOpts(selOpts, object)
 	opts.OutputSerialization = selectObjectOutputOpts(selOpts, opts.InputSerialization)
-	reader, e := c.api.SelectObjectContent(ctx, bucket, object, opts)
-	if e != nil {
-		return nil, probe.NewError(e)
+	if !selOpts.Local {
+		reader, e := c.api.SelectObjectContent(ctx, bucket, object, opts)
+		if e == nil {
+			return reader, nil
+		}
+		if !isSelectNotSupported(e) {
+			return nil, probe.NewError(e)
+		}
 	}
-	return reader, nil
+
+	// Server does not implement Select, stream the object and run the
+	// query locally instead.
+	reader, err := c.Get(ctx, GetOptions{SSE: sse})
+	if err != nil {
+		return nil, err.Trace(bucket, object)
+	}
+	return localSelect(reader, expression, opts.InputSerialization, opts.OutputSerialization)
+}
+
+// isSelectNotSupported returns true if the server rejected a Select
+// request because the API is not available.
+func isSelectNotSupported(e error) bool {
+	errResp := minio.ToErrorResponse(e)
+	switch errResp.Code {
+	case "NotImplemented", "MethodNotAllowed":
+		return true
+	}
+	return errResp.StatusCode == http.StatusNotImplemented || errResp.StatusCode == http.StatusMethodNotAllowed
 }
 
 func (c *S3Client) notificationToEventsInfo(ninfo notification.Info) []EventInfo {
@@ -702,6 +741,7 @@ func (c *S3Client) notificationToEventsInfo(ninfo notification.Info) []EventInfo
 					Host:         record.Source.Host,
 					Port:         record.Source.Port,
 					UserAgent:    record.Source.UserAgent,
+					ETag:         record.S3.Object.ETag,
 				}
 			} else if strings.HasPrefix(record.EventName, "s3:ObjectCreated:PutRetention") {
 				eventsInfo[i] = EventInfo{
@@ -713,6 +753,7 @@ func (c *S3Client) notificationToEventsInfo(ninfo notification.Info) []EventInfo
 					Host:         record.Source.Host,
 					Port:         record.Source.Port,
 					UserAgent:    record.Source.UserAgent,
+					ETag:         record.S3.Object.ETag,
 				}
 			} else if strings.HasPrefix(record.EventName, "s3:ObjectCreated:PutLegalHold") {
 				eventsInfo[i] = EventInfo{
@@ -724,6 +765,7 @@ func (c *S3Client) notificationToEventsInfo(ninfo notification.Info) []EventInfo
 					Host:         record.Source.Host,
 					Port:         record.Source.Port,
 					UserAgent:    record.Source.UserAgent,
+					ETag:         record.S3.Object.ETag,
 				}
 			} else {
 				eventsInfo[i] = EventInfo{
@@ -735,6 +777,7 @@ func (c *S3Client) notificationToEventsInfo(ninfo notification.Info) []EventInfo
 					Host:         record.Source.Host,
 					Port:         record.Source.Port,
 					UserAgent:    record.Source.UserAgent,
+					ETag:         record.S3.Object.ETag,
 				}
 			}
 		} else {
@@ -747,6 +790,7 @@ func (c *S3Client) notificationToEventsInfo(ninfo notification.Info) []EventInfo
 				Host:         record.Source.Host,
 				Port:         record.Source.Port,
 				UserAgent:    record.Source.UserAgent,
+				ETag:         record.S3.Object.ETag,
 			}
 		}
 	}
@@ -795,13 +839,28 @@ func (c *S3Client) Watch(ctx context.Context, options WatchOptions) (*WatchObjec
 		DoneChan:      make(chan struct{}),
 	}
 
+	if object != "" && options.Prefix == "" {
+		options.Prefix = object
+	}
+
+	if options.Poll {
+		if bucket == "" {
+			// Listing every bucket of the server is not a substitute
+			// for a server-wide watch.
+			return nil, probe.NewError(errors.New("polling requires a bucket")).Trace(c.GetURL().String())
+		}
+		go func() {
+			defer close(wo.EventInfoChan)
+			defer close(wo.ErrorChan)
+			pollWatch(ctx, c, options, wo)
+		}()
+		return wo, nil
+	}
+
 	listenCtx, listenCancel := context.WithCancel(ctx)
 
 	var eventsCh <-chan notification.Info
 	if bucket != "" {
-		if object != "" && options.Prefix == "" {
-			options.Prefix = object
-		}
 		eventsCh = c.api.ListenBucketNotification(listenCtx, bucket, options.Prefix, options.Suffix, events)
 	} else {
 		eventsCh = c.api.ListenNotification(listenCtx, "", "", events)
@@ -821,7 +880,7 @@ func (c *S3Client) Watch(ctx context.Context, options WatchOptions) (*WatchObjec
 				}
 				if notificationInfo.Err != nil {
 					var perr *probe.Error
-					if minio.ToErrorResponse(notificationInfo.Err).Code == "NotImplemented" {
+					if isWatchNotSupported(notificationInfo.Err) {
 						perr = probe.NewError(APINotImplemented{
 							API:     "Watch",
 							APIType: c.GetURL().String(),
@@ -829,6 +888,13 @@ func (c *S3Client) Watch(ctx context.Context, options WatchOptions) (*WatchObjec
 					} else {
 						perr = probe.NewError(notificationInfo.Err)
 					}
+					if _, ok := perr.ToGoError().(APINotImplemented); ok && bucket != "" {
+						// Not a MinIO server, fallback to listing the bucket
+						// periodically, server-wide watches cannot be polled.
+						listenCancel()
+						pollWatch(ctx, c, options, wo)
+						return
+					}
 					wo.Errors() <- perr
 				} else {
 					wo.Events() <- c.notificationToEventsInfo(notificationInfo)
@@ -884,9 +950,16 @@ func (c *S3Client) Get(ctx context.Context, opts GetOptions) (io.ReadCloser, *pr
 // such that large file sizes will be copied in multipart manner on server
 // side.
 func (c *S3Client) Copy(ctx context.Context, source string, opts CopyOptions, progress io.Reader) *probe.Error {
+	_, err := c.copyObject(ctx, source, opts, progress)
+	return err
+}
+
+// copyObject - server side copy, the version created is returned in
+// the upload info.
+func (c *S3Client) copyObject(ctx context.Context, source string, opts CopyOptions, progress io.Reader) (minio.UploadInfo, *probe.Error) {
 	dstBucket, dstObject := c.url2BucketAndObject()
 	if dstBucket == "" {
-		return probe.NewError(BucketNameEmpty{})
+		return minio.UploadInfo{}, probe.NewError(BucketNameEmpty{})
 	}
 
 	metadata := make(map[string]string, len(opts.metadata))
@@ -907,6 +980,7 @@ func (c *S3Client) Copy(ctx context.Context, source string, opts CopyOptions, pr
 		Object:     tokens[2],
 		Encryption: opts.srcSSE,
 		VersionID:  opts.versionID,
+		MatchETag:  opts.matchETag,
 	}
 
 	destOpts := minio.CopyDestOptions{
@@ -936,45 +1010,53 @@ func (c *S3Client) Copy(ctx context.Context, source string, opts CopyOptions, pr
 
 	// Assign metadata after irrelevant parts are delete above
 	destOpts.UserMetadata = metadata
-	destOpts.ReplaceMetadata = len(metadata) > 0
+	destOpts.ReplaceMetadata = len(metadata) > 0 || opts.replaceMetadata
 
+	var ui minio.UploadInfo
 	var e error
 	if opts.disableMultipart || opts.size < 64*1024*1024 {
-		_, e = c.api.CopyObject(ctx, destOpts, srcOpts)
+		ui, e = c.api.CopyObject(ctx, destOpts, srcOpts)
 	} else {
-		_, e = c.api.ComposeObject(ctx, destOpts, srcOpts)
+		ui, e = c.api.ComposeObject(ctx, destOpts, srcOpts)
 	}
 
 	if e != nil {
 		errResponse := minio.ToErrorResponse(e)
 		if errResponse.Code == "AccessDenied" {
-			return probe.NewError(PathInsufficientPermission{
+			return ui, probe.NewError(PathInsufficientPermission{
 				Path: c.targetURL.String(),
 			})
 		}
 		if errResponse.Code == "NoSuchBucket" {
-			return probe.NewError(BucketDoesNotExist{
+			return ui, probe.NewError(BucketDoesNotExist{
 				Bucket: dstBucket,
 			})
 		}
 		if errResponse.Code == "InvalidBucketName" {
-			return probe.NewError(BucketInvalid{
+			return ui, probe.NewError(BucketInvalid{
 				Bucket: dstBucket,
 			})
 		}
 		if errResponse.Code == "NoSuchKey" {
-			return probe.NewError(ObjectMissing{})
+			return ui, probe.NewError(ObjectMissing{})
 		}
-		return probe.NewError(e)
+		return ui, probe.NewError(e)
 	}
-	return nil
+	return ui, nil
 }
 
 // Put - upload an object with custom metadata.
 func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progress io.Reader, putOpts PutOptions) (int64, *probe.Error) {
+	ui, err := c.putObject(ctx, reader, size, progress, putOpts)
+	return ui.Size, err
+}
+
+// putObject - upload an object, the version created is returned
+// in the upload info.
+func (c *S3Client) putObject(ctx context.Context, reader io.Reader, size int64, progress io.Reader, putOpts PutOptions) (minio.UploadInfo, *probe.Error) {
 	bucket, object := c.url2BucketAndObject()
 	if bucket == "" {
-		return 0, probe.NewError(BucketNameEmpty{})
+		return minio.UploadInfo{}, probe.NewError(BucketNameEmpty{})
 	}
 
 	metadata := make(map[string]string, len(putOpts.metadata))
@@ -1018,7 +1100,7 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 	if ok {
 		tagsSet, e := tags.Parse(tagsHdr, true)
 		if e != nil {
-			return 0, probe.NewError(e)
+			return minio.UploadInfo{}, probe.NewError(e)
 		}
 		tagsMap = tagsSet.ToMap()
 		delete(metadata, "X-Amz-Tagging")
@@ -1076,42 +1158,42 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 	if e != nil {
 		errResponse := minio.ToErrorResponse(e)
 		if errResponse.Code == "UnexpectedEOF" || e == io.EOF {
-			return ui.Size, probe.NewError(UnexpectedEOF{
+			return ui, probe.NewError(UnexpectedEOF{
 				TotalSize:    size,
 				TotalWritten: ui.Size,
 			})
 		}
 		if errResponse.Code == "AccessDenied" {
-			return ui.Size, probe.NewError(PathInsufficientPermission{
+			return ui, probe.NewError(PathInsufficientPermission{
 				Path: c.targetURL.String(),
 			})
 		}
 		if errResponse.Code == "MethodNotAllowed" {
-			return ui.Size, probe.NewError(ObjectAlreadyExists{
+			return ui, probe.NewError(ObjectAlreadyExists{
 				Object: object,
 			})
 		}
 		if errResponse.Code == "XMinioObjectExistsAsDirectory" {
-			return ui.Size, probe.NewError(ObjectAlreadyExistsAsDirectory{
+			return ui, probe.NewError(ObjectAlreadyExistsAsDirectory{
 				Object: object,
 			})
 		}
 		if errResponse.Code == "NoSuchBucket" {
-			return ui.Size, probe.NewError(BucketDoesNotExist{
+			return ui, probe.NewError(BucketDoesNotExist{
 				Bucket: bucket,
 			})
 		}
 		if errResponse.Code == "InvalidBucketName" {
-			return ui.Size, probe.NewError(BucketInvalid{
+			return ui, probe.NewError(BucketInvalid{
 				Bucket: bucket,
 			})
 		}
 		if errResponse.Code == "NoSuchKey" {
-			return ui.Size, probe.NewError(ObjectMissing{})
+			return ui, probe.NewError(ObjectMissing{})
 		}
-		return ui.Size, probe.NewError(e)
+		return ui, probe.NewError(e)
 	}
-	return ui.Size, nil
+	return ui, nil
 }
 
 // PutPart - upload an object with custom metadata. (Same as Put)
@@ -1281,8 +1363,9 @@ func (c *S3Client) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isB
 						case removeStatus := <-statusCh:
 							if removeStatus.Err != nil {
 								resultCh <- RemoveResult{
-									BucketName: bucket,
-									Err:        probe.NewError(removeStatus.Err),
+									BucketName:         bucket,
+									RemoveObjectResult: removeStatus,
+									Err:                probe.NewError(removeStatus.Err),
 								}
 							} else {
 								resultCh <- RemoveResult{
@@ -1311,17 +1394,21 @@ func (c *S3Client) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isB
 		if statusCh != nil {
 			for removeStatus := range statusCh {
 				if removeStatus.Err != nil {
-					removeStatus.Err = errors.New(strings.Replace(
-						removeStatus.Err.Error(), "Object is WORM protected",
-						"Object, '"+removeStatus.ObjectName+" (Version ID="+
-							removeStatus.ObjectVersionID+")' is WORM protected", 1))
-
 					// If the removeStatus error message is:
 					// "Object is WORM protected and cannot be overwritten",
 					// it is too generic. We have the object's name and vid.
 					// Adding the object's name and version id into the error msg
+					// Other errors are kept as is, so they can be classified.
+					if strings.Contains(removeStatus.Err.Error(), "Object is WORM protected") {
+						removeStatus.Err = errors.New(strings.Replace(
+							removeStatus.Err.Error(), "Object is WORM protected",
+							"Object, '"+removeStatus.ObjectName+" (Version ID="+
+								removeStatus.ObjectVersionID+")' is WORM protected", 1))
+					}
 					resultCh <- RemoveResult{
-						Err: probe.NewError(removeStatus.Err),
+						BucketName:         prevBucket,
+						RemoveObjectResult: removeStatus,
+						Err:                probe.NewError(removeStatus.Err),
 					}
 				} else {
 					resultCh <- RemoveResult{
@@ -264,3 +264,40 @@ func (s *TestSuite) TestSelectCompressionType(c *C) {
 		c.Assert(cType, DeepEquals, test.compressionType)
 	}
 }
+
+// Test that Select falls back to a local query when the server does not
+// implement it.
+func (s *TestSuite) TestSelectLocalFallback(c *C) {
+	data := "name,power\nalpha,10\nbeta,25\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["location"]; ok {
+			w.Write([]byte("<LocationConstraint xmlns=\"http://doc.s3.amazonaws.com/2006-03-01\"></LocationConstraint>"))
+			return
+		}
+		if _, ok := r.URL.Query()["select"]; ok {
+			w.WriteHeader(http.StatusNotImplemented)
+			w.Write([]byte("<Error><Code>NotImplemented</Code><Message>A header you provided implies functionality that is not implemented</Message></Error>"))
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", UTCNow().Format(http.TimeFormat))
+		w.Write([]byte(data))
+	}))
+	defer server.Close()
+
+	conf := new(Config)
+	conf.HostURL = server.URL + "/bucket/power.csv"
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	s3c, err := S3New(conf)
+	c.Assert(err, IsNil)
+
+	reader, err := s3c.Select(context.Background(), "select s.name from S3Object s where s.power > 20", nil, SelectObjectOpts{})
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	var buffer bytes.Buffer
+	_, e := io.Copy(&buffer, reader)
+	c.Assert(e, IsNil)
+	c.Assert(buffer.String(), Equals, "beta\n")
+}
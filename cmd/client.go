@@ -94,6 +94,8 @@ type CopyOptions struct {
 	disableMultipart bool
 	isPreserve       bool
 	storageClass     string
+	matchETag        string
+	replaceMetadata  bool
 }
 
 // Client - client interface
@@ -213,6 +215,7 @@ type ClientContent struct {
 
 // Config - see http://docs.amazonwebservices.com/AmazonS3/latest/dev/index.html?RESTAuthentication.html
 type Config struct {
+	Alias             string
 	AccessKey         string
 	SecretKey         string
 	SessionToken      string
@@ -226,6 +229,9 @@ type Config struct {
 	ConnReadDeadline  time.Duration
 	ConnWriteDeadline time.Duration
 	Transport         *http.Transport
+	ClientCert        string
+	ClientKey         string
+	PinnedKeys        []string
 }
 
 // SelectObjectOpts - opts entered for select API
@@ -233,4 +239,6 @@ type SelectObjectOpts struct {
 	InputSerOpts    map[string]map[string]string
 	OutputSerOpts   map[string]map[string]string
 	CompressionType minio.SelectCompressionType
+	// Local evaluates the query on the client instead of the server.
+	Local bool
 }
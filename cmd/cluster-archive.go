@@ -0,0 +1,519 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/klauspost/compress/zip"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+// Layout of the IAM export zip produced by the server, every
+// category is a single JSON document keyed by entity name.
+const iamAssetsDir = "iam-assets"
+
+var iamArchiveFiles = []struct {
+	kind string
+	file string
+}{
+	{"policies", "policies.json"},
+	{"users", "users.json"},
+	{"groups", "groups.json"},
+	{"svcaccts", "svcaccts.json"},
+	{"user-mappings", "user_mappings.json"},
+	{"group-mappings", "group_mappings.json"},
+	{"sts-user-mappings", "stsuser_mappings.json"},
+	{"sts-group-mappings", "stsgroup_mappings.json"},
+}
+
+// iamArchiveKinds lists the categories accepted by '--only', the
+// 'mappings' shorthand expands to every policy mapping category.
+func iamArchiveKinds() []string {
+	kinds := make([]string, 0, len(iamArchiveFiles))
+	for _, f := range iamArchiveFiles {
+		kinds = append(kinds, f.kind)
+	}
+	return kinds
+}
+
+// Bucket metadata configs found in a bucket metadata export zip.
+var bucketArchiveKinds = []string{
+	"policy", "notification", "lifecycle", "bucket-encryption", "tagging",
+	"quota", "object-lock", "versioning", "replication", "bucket-targets",
+}
+
+// archiveKey identifies one entity inside an export archive.
+type archiveKey struct {
+	Kind string
+	Name string
+}
+
+// clusterArchive is the parsed content of an IAM or bucket metadata
+// export, each entity is kept as raw bytes for comparison and re-packing.
+type clusterArchive struct {
+	iam     bool
+	entries map[archiveKey][]byte
+	// Original zip entry of each bucket metadata config.
+	files map[archiveKey]string
+}
+
+func (a clusterArchive) keys() []archiveKey {
+	keys := make([]archiveKey, 0, len(a.entries))
+	for k := range a.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Kind != keys[j].Kind {
+			return keys[i].Kind < keys[j].Kind
+		}
+		return keys[i].Name < keys[j].Name
+	})
+	return keys
+}
+
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, e := zf.Open()
+	if e != nil {
+		return nil, e
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// parseIAMArchive reads an IAM export zip.
+func parseIAMArchive(r io.ReaderAt, size int64) (clusterArchive, error) {
+	zr, e := zip.NewReader(r, size)
+	if e != nil {
+		return clusterArchive{}, e
+	}
+	a := clusterArchive{iam: true, entries: make(map[archiveKey][]byte)}
+	for _, zf := range zr.File {
+		kind := ""
+		for _, f := range iamArchiveFiles {
+			if zf.Name == path.Join(iamAssetsDir, f.file) {
+				kind = f.kind
+				break
+			}
+		}
+		if kind == "" {
+			continue
+		}
+		data, e := readZipFile(zf)
+		if e != nil {
+			return clusterArchive{}, e
+		}
+		m := make(map[string]json.RawMessage)
+		if len(bytes.TrimSpace(data)) > 0 {
+			if e = json.Unmarshal(data, &m); e != nil {
+				return clusterArchive{}, fmt.Errorf("%s: %w", zf.Name, e)
+			}
+		}
+		for name, v := range m {
+			a.entries[archiveKey{Kind: kind, Name: name}] = v
+		}
+	}
+	return a, nil
+}
+
+// parseBucketArchive reads a bucket metadata export zip, whose
+// entries are laid out as 'bucket/config-file'.
+func parseBucketArchive(r io.ReaderAt, size int64) (clusterArchive, error) {
+	zr, e := zip.NewReader(r, size)
+	if e != nil {
+		return clusterArchive{}, e
+	}
+	a := clusterArchive{entries: make(map[archiveKey][]byte), files: make(map[archiveKey]string)}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		bucket, file := path.Split(zf.Name)
+		bucket = strings.Trim(bucket, "/")
+		if bucket == "" || strings.Contains(bucket, "/") {
+			continue
+		}
+		data, e := readZipFile(zf)
+		if e != nil {
+			return clusterArchive{}, e
+		}
+		k := archiveKey{Kind: strings.TrimSuffix(file, path.Ext(file)), Name: bucket}
+		a.entries[k] = data
+		a.files[k] = zf.Name
+	}
+	return a, nil
+}
+
+// loadClusterArchive reads an export either from a local zip file or,
+// when no such file exists, by exporting it live from the given alias.
+func loadClusterArchive(arg string, iam bool) (clusterArchive, *probe.Error) {
+	st, e := os.Stat(arg)
+	if e != nil || st.IsDir() {
+		return loadLiveClusterArchive(arg, iam)
+	}
+	data, e := os.ReadFile(arg)
+	if e != nil {
+		return clusterArchive{}, probe.NewError(e).Trace(arg)
+	}
+	a, e := parseClusterArchive(data, iam)
+	if e != nil {
+		return clusterArchive{}, probe.NewError(e).Trace(arg)
+	}
+	return a, nil
+}
+
+func parseClusterArchive(data []byte, iam bool) (clusterArchive, error) {
+	if iam {
+		return parseIAMArchive(bytes.NewReader(data), int64(len(data)))
+	}
+	return parseBucketArchive(bytes.NewReader(data), int64(len(data)))
+}
+
+// loadLiveClusterArchive exports the current IAM or bucket metadata of aliasedURL.
+func loadLiveClusterArchive(aliasedURL string, iam bool) (clusterArchive, *probe.Error) {
+	client, err := newAdminClient(aliasedURL)
+	if err != nil {
+		return clusterArchive{}, err.Trace(aliasedURL)
+	}
+	data, err := exportClusterArchive(client, aliasedURL, iam)
+	if err != nil {
+		return clusterArchive{}, err.Trace(aliasedURL)
+	}
+	a, e := parseClusterArchive(data, iam)
+	if e != nil {
+		return clusterArchive{}, probe.NewError(e).Trace(aliasedURL)
+	}
+	return a, nil
+}
+
+// exportClusterArchive downloads the current IAM or bucket metadata
+// export of the cluster into memory.
+func exportClusterArchive(client *madmin.AdminClient, aliasedURL string, iam bool) ([]byte, *probe.Error) {
+	var (
+		r io.ReadCloser
+		e error
+	)
+	if iam {
+		r, e = client.ExportIAM(context.Background())
+	} else {
+		_, bucket := url2Alias(path.Clean(aliasedURL))
+		r, e = client.ExportBucketMetadata(context.Background(), bucket)
+	}
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	defer r.Close()
+	data, e := io.ReadAll(r)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return data, nil
+}
+
+// sameArchiveEntry compares two entities, JSON documents are compared
+// semantically so that key order and whitespace do not matter.
+func sameArchiveEntry(a, b []byte) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) == nil && json.Unmarshal(b, &vb) == nil {
+		ca, _ := json.Marshal(va)
+		cb, _ := json.Marshal(vb)
+		return bytes.Equal(ca, cb)
+	}
+	return bytes.Equal(bytes.TrimSpace(a), bytes.TrimSpace(b))
+}
+
+const (
+	archiveAdded     = "added"
+	archiveRemoved   = "removed"
+	archiveChanged   = "changed"
+	archiveUnchanged = "unchanged"
+)
+
+// archiveDiff is a single difference between two exports.
+type archiveDiff struct {
+	Kind   string
+	Name   string
+	Change string
+}
+
+// diffClusterArchives returns what changed going from 'older' to 'newer'.
+func diffClusterArchives(older, newer clusterArchive) []archiveDiff {
+	var diffs []archiveDiff
+	for _, k := range older.keys() {
+		nv, ok := newer.entries[k]
+		switch {
+		case !ok:
+			diffs = append(diffs, archiveDiff{k.Kind, k.Name, archiveRemoved})
+		case !sameArchiveEntry(older.entries[k], nv):
+			diffs = append(diffs, archiveDiff{k.Kind, k.Name, archiveChanged})
+		}
+	}
+	for _, k := range newer.keys() {
+		if _, ok := older.entries[k]; !ok {
+			diffs = append(diffs, archiveDiff{k.Kind, k.Name, archiveAdded})
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Kind != diffs[j].Kind {
+			return diffs[i].Kind < diffs[j].Kind
+		}
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// planArchiveImport reports what importing 'src' on top of 'current'
+// would do, an import never removes entities so only additions,
+// changes and no-ops are reported.
+func planArchiveImport(current, src clusterArchive) []archiveDiff {
+	var plan []archiveDiff
+	for _, k := range src.keys() {
+		change := archiveAdded
+		if cv, ok := current.entries[k]; ok {
+			change = archiveChanged
+			if sameArchiveEntry(cv, src.entries[k]) {
+				change = archiveUnchanged
+			}
+		}
+		plan = append(plan, archiveDiff{k.Kind, k.Name, change})
+	}
+	return plan
+}
+
+// archiveSelector restricts an archive to a subset of categories and names.
+type archiveSelector struct {
+	// Kinds to keep, empty keeps every kind.
+	kinds map[string]bool
+	// Names to keep per kind, kinds missing here keep every name.
+	names map[string]map[string]bool
+}
+
+func newSet(values []string) map[string]bool {
+	s := make(map[string]bool, len(values))
+	for _, v := range values {
+		for _, v := range strings.Split(v, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				s[v] = true
+			}
+		}
+	}
+	return s
+}
+
+// newIAMSelector builds a selector from '--only', '--users', '--groups'
+// and '--policies'. When names are given without '--only', only the
+// categories those names apply to are selected.
+func newIAMSelector(only, users, groups, policies []string) (archiveSelector, *probe.Error) {
+	sel := archiveSelector{kinds: newSet(only), names: make(map[string]map[string]bool)}
+	if sel.kinds["mappings"] {
+		delete(sel.kinds, "mappings")
+		for _, k := range []string{"user-mappings", "group-mappings", "sts-user-mappings", "sts-group-mappings"} {
+			sel.kinds[k] = true
+		}
+	}
+	valid := newSet(iamArchiveKinds())
+	for k := range sel.kinds {
+		if !valid[k] {
+			return sel, probe.NewError(fmt.Errorf("unknown IAM category '%s', expected one of %s, mappings", k, strings.Join(iamArchiveKinds(), ", ")))
+		}
+	}
+	explicit := len(sel.kinds) > 0
+	restrict := func(names []string, kinds ...string) {
+		set := newSet(names)
+		if len(set) == 0 {
+			return
+		}
+		for _, k := range kinds {
+			sel.names[k] = set
+			if !explicit {
+				sel.kinds[k] = true
+			}
+		}
+	}
+	restrict(users, "users", "svcaccts", "user-mappings", "sts-user-mappings")
+	restrict(groups, "groups", "group-mappings", "sts-group-mappings")
+	restrict(policies, "policies")
+	return sel, nil
+}
+
+// newBucketSelector builds a selector from '--only' and '--buckets'.
+func newBucketSelector(only, buckets []string) (archiveSelector, *probe.Error) {
+	sel := archiveSelector{kinds: newSet(only), names: make(map[string]map[string]bool)}
+	valid := newSet(bucketArchiveKinds)
+	for k := range sel.kinds {
+		if !valid[k] {
+			return sel, probe.NewError(fmt.Errorf("unknown bucket metadata '%s', expected one of %s", k, strings.Join(bucketArchiveKinds, ", ")))
+		}
+	}
+	if set := newSet(buckets); len(set) > 0 {
+		for _, k := range bucketArchiveKinds {
+			sel.names[k] = set
+		}
+	}
+	return sel, nil
+}
+
+func (s archiveSelector) isEmpty() bool {
+	return len(s.kinds) == 0 && len(s.names) == 0
+}
+
+func (s archiveSelector) match(k archiveKey, value []byte) bool {
+	if len(s.kinds) > 0 && !s.kinds[k.Kind] {
+		return false
+	}
+	names, ok := s.names[k.Kind]
+	if !ok {
+		return true
+	}
+	if k.Kind == "svcaccts" {
+		// Service accounts are selected through their parent user.
+		var sa struct {
+			Parent string `json:"parent"`
+		}
+		if json.Unmarshal(value, &sa) == nil && names[sa.Parent] {
+			return true
+		}
+	}
+	return names[k.Name]
+}
+
+// filter returns the entities of the archive accepted by the selector.
+func (a clusterArchive) filter(s archiveSelector) clusterArchive {
+	out := clusterArchive{iam: a.iam, entries: make(map[archiveKey][]byte), files: a.files}
+	for k, v := range a.entries {
+		if s.match(k, v) {
+			out.entries[k] = v
+		}
+	}
+	return out
+}
+
+// zip packs the archive back into the layout expected by the server.
+func (a clusterArchive) zip() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if a.iam {
+		for _, f := range iamArchiveFiles {
+			m := make(map[string]json.RawMessage)
+			for k, v := range a.entries {
+				if k.Kind == f.kind {
+					m[k.Name] = v
+				}
+			}
+			if len(m) == 0 {
+				continue
+			}
+			data, e := json.Marshal(m)
+			if e != nil {
+				return nil, e
+			}
+			w, e := zw.Create(path.Join(iamAssetsDir, f.file))
+			if e != nil {
+				return nil, e
+			}
+			if _, e = w.Write(data); e != nil {
+				return nil, e
+			}
+		}
+	} else {
+		for _, k := range a.keys() {
+			w, e := zw.Create(a.files[k])
+			if e != nil {
+				return nil, e
+			}
+			if _, e = w.Write(a.entries[k]); e != nil {
+				return nil, e
+			}
+		}
+	}
+	if e := zw.Close(); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+// importClusterArchive filters an export with the given selector and either
+// prints what importing it into aliasedURL would change, or returns the
+// filtered archive ready to be uploaded.
+func importClusterArchive(aliasedURL, file string, iam, dryRun bool, sel archiveSelector) ([]byte, *probe.Error) {
+	src, err := loadClusterArchive(file, iam)
+	if err != nil {
+		return nil, err
+	}
+	src = src.filter(sel)
+	if dryRun {
+		setArchiveDiffColors()
+		current, err := loadLiveClusterArchive(aliasedURL, iam)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range planArchiveImport(current, src) {
+			printMsg(archiveDiffMessage{Type: d.Kind, Name: d.Name, Change: d.Change, DryRun: true})
+		}
+		return nil, nil
+	}
+	data, e := src.zip()
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return data, nil
+}
+
+// archiveDiffMessage container for an export diff or import plan entry.
+type archiveDiffMessage struct {
+	Status string `json:"status"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	DryRun bool   `json:"dryRun,omitempty"`
+}
+
+func (m archiveDiffMessage) String() string {
+	sign := map[string]string{
+		archiveAdded:     "+",
+		archiveRemoved:   "-",
+		archiveChanged:   "~",
+		archiveUnchanged: "=",
+	}[m.Change]
+	return console.Colorize("Archive"+m.Change, fmt.Sprintf("%s %-18s %s", sign, m.Type, m.Name))
+}
+
+func (m archiveDiffMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func setArchiveDiffColors() {
+	console.SetColor("Archive"+archiveAdded, color.New(color.FgGreen))
+	console.SetColor("Archive"+archiveRemoved, color.New(color.FgRed))
+	console.SetColor("Archive"+archiveChanged, color.New(color.FgYellow))
+	console.SetColor("Archive"+archiveUnchanged, color.New(color.Faint))
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/zip"
+)
+
+func makeTestZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, e := zw.Create(name)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if _, e = w.Write([]byte(content)); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e := zw.Close(); e != nil {
+		t.Fatal(e)
+	}
+	return buf.Bytes()
+}
+
+func mustParseArchive(t *testing.T, data []byte, iam bool) clusterArchive {
+	t.Helper()
+	a, e := parseClusterArchive(data, iam)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return a
+}
+
+func TestDiffIAMArchives(t *testing.T) {
+	older := mustParseArchive(t, makeTestZip(t, map[string]string{
+		"iam-assets/policies.json": `{"readonly":{"Version":"2012-10-17"},"old":{}}`,
+		"iam-assets/users.json":    `{"alice":{"secretKey":"x","status":"enabled"}}`,
+	}), true)
+	newer := mustParseArchive(t, makeTestZip(t, map[string]string{
+		"iam-assets/policies.json": `{"readonly": {"Version": "2012-10-17"}}`,
+		"iam-assets/users.json":    `{"alice":{"status":"disabled","secretKey":"x"},"bob":{}}`,
+	}), true)
+
+	got := diffClusterArchives(older, newer)
+	want := []archiveDiff{
+		{"policies", "old", archiveRemoved},
+		{"users", "alice", archiveChanged},
+		{"users", "bob", archiveAdded},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestIAMArchiveSelection(t *testing.T) {
+	a := mustParseArchive(t, makeTestZip(t, map[string]string{
+		"iam-assets/policies.json":      `{"readonly":{},"writeonly":{}}`,
+		"iam-assets/users.json":         `{"alice":{},"bob":{}}`,
+		"iam-assets/svcaccts.json":      `{"SA1":{"parent":"alice"},"SA2":{"parent":"bob"}}`,
+		"iam-assets/user_mappings.json": `{"alice":{"policy":"readonly"},"bob":{"policy":"writeonly"}}`,
+		"iam-assets/groups.json":        `{"devs":{}}`,
+	}), true)
+
+	testCases := []struct {
+		only, users, policies []string
+		want                  []archiveKey
+		fail                  bool
+	}{
+		{only: []string{"policies"}, want: []archiveKey{{"policies", "readonly"}, {"policies", "writeonly"}}},
+		{users: []string{"alice"}, want: []archiveKey{{"svcaccts", "SA1"}, {"user-mappings", "alice"}, {"users", "alice"}}},
+		{only: []string{"users"}, users: []string{"alice"}, want: []archiveKey{{"users", "alice"}}},
+		{only: []string{"mappings"}, want: []archiveKey{{"user-mappings", "alice"}, {"user-mappings", "bob"}}},
+		{policies: []string{"readonly,missing"}, want: []archiveKey{{"policies", "readonly"}}},
+		{only: []string{"buckets"}, fail: true},
+	}
+	for i, testCase := range testCases {
+		sel, err := newIAMSelector(testCase.only, testCase.users, nil, testCase.policies)
+		if testCase.fail {
+			if err == nil {
+				t.Fatalf("Test %d: expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: unexpected error %v", i+1, err)
+		}
+		got := a.filter(sel).keys()
+		if !reflect.DeepEqual(got, testCase.want) {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, testCase.want, got)
+		}
+	}
+}
+
+func TestBucketArchiveRoundTrip(t *testing.T) {
+	a := mustParseArchive(t, makeTestZip(t, map[string]string{
+		"photos/lifecycle.xml":     "<LifecycleConfiguration/>",
+		"photos/policy.json":       `{"Version":"2012-10-17"}`,
+		"docs/versioning.xml":      "<VersioningConfiguration/>",
+		"docs/bucket-targets.json": "[]",
+	}), false)
+
+	sel, err := newBucketSelector([]string{"lifecycle,policy"}, []string{"photos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, e := a.filter(sel).zip()
+	if e != nil {
+		t.Fatal(e)
+	}
+	b := mustParseArchive(t, data, false)
+	want := []archiveKey{{"lifecycle", "photos"}, {"policy", "photos"}}
+	if got := b.keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if b.files[archiveKey{"policy", "photos"}] != "photos/policy.json" {
+		t.Fatalf("unexpected file name %s", b.files[archiveKey{"policy", "photos"}])
+	}
+
+	current := mustParseArchive(t, makeTestZip(t, map[string]string{
+		"photos/lifecycle.xml": "<LifecycleConfiguration/>\n",
+	}), false)
+	plan := planArchiveImport(current, b)
+	wantPlan := []archiveDiff{
+		{"lifecycle", "photos", archiveUnchanged},
+		{"policy", "photos", archiveAdded},
+	}
+	if !reflect.DeepEqual(plan, wantPlan) {
+		t.Fatalf("expected %v, got %v", wantPlan, plan)
+	}
+}
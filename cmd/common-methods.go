@@ -630,16 +630,17 @@ func newClientFromAlias(alias, urlStr string) (Client, *probe.Error) {
 		if fsErr != nil {
 			return nil, fsErr.Trace(alias, urlStr)
 		}
-		return fsClient, nil
+		return newAuditClient(fsClient, "", nil), nil
 	}
 
 	s3Config := NewS3Config(urlStr, hostCfg)
+	s3Config.Alias = alias
 
 	s3Client, err := S3New(s3Config)
 	if err != nil {
 		return nil, err.Trace(alias, urlStr)
 	}
-	return s3Client, nil
+	return newAuditClient(newJournalClient(s3Client, alias), alias, hostCfg), nil
 }
 
 // urlRgx - verify if aliased url is real URL.
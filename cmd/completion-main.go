@@ -0,0 +1,220 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+var completionCmd = cli.Command{
+	Name:         "completion",
+	Usage:        "generate shell completion scripts",
+	Action:       mainCompletion,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        globalFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} SHELL
+
+SHELL:
+  One of bash, zsh, fish or powershell.
+
+  The generated script asks mc for completions of aliases, buckets, prefixes,
+  version IDs, admin config keys, policy names, users and tier names. Results
+  fetched from a server are cached for a few seconds in the configuration
+  folder, and a server which does not answer quickly completes nothing.
+{{if .VisibleFlags}}
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}{{end}}
+EXAMPLES:
+  1. Enable completion in the current bash session.
+     {{.Prompt}} source <({{.HelpName}} bash)
+
+  2. Enable completion for all zsh sessions, 'compinit' must be loaded.
+     {{.Prompt}} {{.HelpName}} zsh > "${fpath[1]}/_mc"
+
+  3. Enable completion for all fish sessions.
+     {{.Prompt}} {{.HelpName}} fish > ~/.config/fish/completions/mc.fish
+
+  4. Enable completion in the current PowerShell session.
+     {{.Prompt}} {{.HelpName}} powershell | Out-String | Invoke-Expression
+`,
+}
+
+const bashCompletionScript = `# bash completion for {{.Name}}, generated by '{{.Name}} completion bash'.
+#
+# Load it in the current shell with:
+#   source <({{.Name}} completion bash)
+
+_{{.Func}}_completion() {
+    local IFS=$'\n'
+    COMPREPLY=($(COMP_LINE="${COMP_LINE}" COMP_POINT="${COMP_POINT}" "${COMP_WORDS[0]}" __complete 2>/dev/null))
+    # Aliases and prefixes end with a slash and are completed further.
+    if [[ ${#COMPREPLY[@]} -eq 1 && "${COMPREPLY[0]}" == */ ]]; then
+        compopt -o nospace
+    fi
+}
+
+complete -o default -F _{{.Func}}_completion {{.Name}}
+`
+
+const zshCompletionScript = `#compdef {{.Name}}
+# zsh completion for {{.Name}}, generated by '{{.Name}} completion zsh'.
+#
+# Load it in the current shell with:
+#   source <({{.Name}} completion zsh)
+# or save it as '_{{.Name}}' in a directory of your $fpath.
+
+_{{.Func}}() {
+    local line="${words[1,CURRENT]}"
+    local -a candidates dirs others
+    local candidate
+    candidates=("${(@f)$(COMP_LINE="${line}" COMP_POINT="${#line}" "${words[1]}" __complete 2>/dev/null)}")
+    for candidate in "${candidates[@]}"; do
+        [[ -z "${candidate}" ]] && continue
+        # Aliases and prefixes end with a slash and are completed further.
+        if [[ "${candidate}" == */ ]]; then
+            dirs+=("${candidate}")
+        else
+            others+=("${candidate}")
+        fi
+    done
+    (( ${#dirs} )) && compadd -S '' -- "${dirs[@]}"
+    (( ${#others} )) && compadd -- "${others[@]}"
+    return 0
+}
+
+if [[ "${funcstack[1]}" == "_{{.Func}}" ]]; then
+    _{{.Func}} "$@"
+else
+    compdef _{{.Func}} {{.Name}}
+fi
+`
+
+const fishCompletionScript = `# fish completion for {{.Name}}, generated by '{{.Name}} completion fish'.
+#
+# Load it in the current shell with:
+#   {{.Name}} completion fish | source
+# or save it as ~/.config/fish/completions/{{.Name}}.fish
+
+function __{{.Func}}_complete
+    set -l line (commandline -cp)
+    set -l cmd (commandline -opc)[1]
+    env COMP_LINE="$line" COMP_POINT=(string length -- "$line") $cmd __complete 2>/dev/null
+end
+
+complete -c {{.Name}} -f -a '(__{{.Func}}_complete)'
+`
+
+const powershellCompletionScript = `# PowerShell completion for {{.Name}}, generated by '{{.Name}} completion powershell'.
+#
+# Load it in the current session with:
+#   {{.Name}} completion powershell | Out-String | Invoke-Expression
+# or add that line to your $PROFILE.
+
+Register-ArgumentCompleter -Native -CommandName '{{.Name}}', '{{.Name}}.exe' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $line = $commandAst.ToString()
+    $point = $cursorPosition - $commandAst.Extent.StartOffset
+    if ($line.Length -gt $point) {
+        $line = $line.Substring(0, $point)
+    }
+    # A cursor placed after a space starts a new, empty argument.
+    $line = $line.PadRight($point)
+
+    $env:COMP_LINE = $line
+    $env:COMP_POINT = $line.Length
+    $candidates = & $commandAst.CommandElements[0].Extent.Text __complete 2>$null
+    Remove-Item Env:\COMP_LINE, Env:\COMP_POINT -ErrorAction SilentlyContinue
+
+    foreach ($candidate in $candidates) {
+        if ($candidate -eq '') {
+            continue
+        }
+        $text = $candidate
+        if ($text -match '\s') {
+            $text = "'" + $text + "'"
+        }
+        [System.Management.Automation.CompletionResult]::new($text, $candidate, 'ParameterValue', $candidate)
+    }
+}
+`
+
+var completionScripts = map[string]string{
+	"bash":       bashCompletionScript,
+	"zsh":        zshCompletionScript,
+	"fish":       fishCompletionScript,
+	"powershell": powershellCompletionScript,
+}
+
+// completionScriptName returns the command name completed by the scripts
+// and a variant of it usable as a shell function name.
+func completionScriptName(progName string) (name, funcName string) {
+	name = strings.TrimSuffix(filepath.Base(progName), ".exe")
+	funcName = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	return name, funcName
+}
+
+// generateCompletionScript renders the completion script of a shell.
+func generateCompletionScript(shell, progName string) (string, *probe.Error) {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return "", probe.NewError(fmt.Errorf("'%s' is not a supported shell. Supported shells are: bash, zsh, fish, powershell", shell))
+	}
+	name, funcName := completionScriptName(progName)
+	var sb strings.Builder
+	e := template.Must(template.New(shell).Parse(script)).Execute(&sb, struct {
+		Name string
+		Func string
+	}{name, funcName})
+	if e != nil {
+		return "", probe.NewError(e)
+	}
+	return sb.String(), nil
+}
+
+// mainCompletion is the handle for "mc completion" command.
+func mainCompletion(cliCtx *cli.Context) error {
+	if len(cliCtx.Args()) != 1 {
+		showCommandHelpAndExit(cliCtx, "completion", 1) // last argument is exit code
+	}
+
+	script, err := generateCompletionScript(strings.ToLower(cliCtx.Args().Get(0)), os.Args[0])
+	fatalIf(err, "Unable to generate the completion script.")
+
+	fmt.Print(script)
+	return nil
+}
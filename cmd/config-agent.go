@@ -0,0 +1,259 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+const (
+	sealAgentOpPing   = "ping"
+	sealAgentOpSeal   = "seal"
+	sealAgentOpUnseal = "unseal"
+	sealAgentOpStop   = "stop"
+
+	sealAgentDialTimeout = 2 * time.Second
+)
+
+// sealAgentRequest is a single request sent to the agent, one JSON
+// document per line.
+type sealAgentRequest struct {
+	Op      string `json:"op"`
+	ID      string `json:"id"`
+	Context string `json:"context,omitempty"`
+	Data    string `json:"data,omitempty"`
+}
+
+// sealAgentResponse answers a sealAgentRequest.
+type sealAgentResponse struct {
+	Data  string `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// sealAgentServer holds the key of a sealed config in memory and
+// seals or unseals on behalf of mc commands, the key never leaves the
+// agent. Access is restricted by the permissions of the socket folder.
+type sealAgentServer struct {
+	id       string
+	listener net.Listener
+	stopOnce sync.Once
+
+	// mu guards the key, wiped once the agent is stopped, and the open
+	// connections, closed before the key is wiped.
+	mu      sync.RWMutex
+	key     sealKey
+	stopped bool
+	conns   map[net.Conn]struct{}
+}
+
+// newSealAgentSocket creates a private folder holding the agent socket.
+func newSealAgentSocket() (string, *probe.Error) {
+	dir, e := os.MkdirTemp("", "mc-agent-")
+	if e != nil {
+		return "", probe.NewError(e)
+	}
+	if e = os.Chmod(dir, 0o700); e != nil {
+		return "", probe.NewError(e)
+	}
+	return filepath.Join(dir, "agent.sock"), nil
+}
+
+// runSealAgent serves requests on socket until it is stopped or the
+// timeout expires.
+func runSealAgent(socket, id string, key sealKey, timeout time.Duration) *probe.Error {
+	listener, e := net.Listen("unix", socket)
+	if e != nil {
+		return probe.NewError(e).Trace(socket)
+	}
+	agent := &sealAgentServer{id: id, key: key, listener: listener, conns: make(map[net.Conn]struct{})}
+	defer os.RemoveAll(filepath.Dir(socket))
+
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, agent.stop)
+		defer timer.Stop()
+	}
+
+	for {
+		conn, e := listener.Accept()
+		if e != nil {
+			if errors.Is(e, net.ErrClosed) {
+				return nil
+			}
+			return probe.NewError(e)
+		}
+		go agent.serve(conn)
+	}
+}
+
+// stop closes the listener and all connections, then wipes the key once
+// pending seal and unseal requests are done.
+func (s *sealAgentServer) stop() {
+	s.stopOnce.Do(func() {
+		s.listener.Close()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.stopped = true
+		for conn := range s.conns {
+			conn.Close()
+		}
+		for i := range s.key {
+			s.key[i] = 0
+		}
+	})
+}
+
+func (s *sealAgentServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req sealAgentRequest
+		if e := json.Unmarshal(scanner.Bytes(), &req); e != nil {
+			enc.Encode(sealAgentResponse{Error: e.Error()})
+			return
+		}
+		resp := s.handle(req)
+		if e := enc.Encode(resp); e != nil {
+			return
+		}
+		if req.Op == sealAgentOpStop && resp.Error == "" {
+			s.stop()
+			return
+		}
+	}
+}
+
+func (s *sealAgentServer) handle(req sealAgentRequest) sealAgentResponse {
+	if req.Op == sealAgentOpStop {
+		return sealAgentResponse{}
+	}
+	if req.ID != s.id {
+		return sealAgentResponse{Error: "agent holds the key of another configuration"}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return sealAgentResponse{Error: "agent is stopped"}
+	}
+	switch req.Op {
+	case sealAgentOpPing:
+		return sealAgentResponse{}
+	case sealAgentOpSeal:
+		plaintext, e := base64.StdEncoding.DecodeString(req.Data)
+		if e != nil {
+			return sealAgentResponse{Error: e.Error()}
+		}
+		sealed, err := s.key.seal(req.Context, plaintext)
+		if err != nil {
+			return sealAgentResponse{Error: err.ToGoError().Error()}
+		}
+		return sealAgentResponse{Data: sealed}
+	case sealAgentOpUnseal:
+		plaintext, err := s.key.unseal(req.Context, req.Data)
+		if err != nil {
+			return sealAgentResponse{Error: err.ToGoError().Error()}
+		}
+		return sealAgentResponse{Data: base64.StdEncoding.EncodeToString(plaintext)}
+	}
+	return sealAgentResponse{Error: "unknown agent operation `" + req.Op + "`"}
+}
+
+// sealAgentClient implements configSealer through a running agent.
+type sealAgentClient struct {
+	socket string
+	id     string
+}
+
+func (c sealAgentClient) call(req sealAgentRequest) (string, *probe.Error) {
+	conn, e := net.DialTimeout("unix", c.socket, sealAgentDialTimeout)
+	if e != nil {
+		return "", probe.NewError(e).Trace(c.socket)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(sealAgentDialTimeout))
+
+	req.ID = c.id
+	if e = json.NewEncoder(conn).Encode(req); e != nil {
+		return "", probe.NewError(e)
+	}
+	var resp sealAgentResponse
+	if e = json.NewDecoder(conn).Decode(&resp); e != nil {
+		return "", probe.NewError(e)
+	}
+	if resp.Error != "" {
+		return "", probe.NewError(errors.New(resp.Error))
+	}
+	return resp.Data, nil
+}
+
+func (c sealAgentClient) ping() *probe.Error {
+	_, err := c.call(sealAgentRequest{Op: sealAgentOpPing})
+	return err
+}
+
+func (c sealAgentClient) stop() *probe.Error {
+	_, err := c.call(sealAgentRequest{Op: sealAgentOpStop})
+	return err
+}
+
+func (c sealAgentClient) seal(context string, plaintext []byte) (string, *probe.Error) {
+	return c.call(sealAgentRequest{
+		Op:      sealAgentOpSeal,
+		Context: context,
+		Data:    base64.StdEncoding.EncodeToString(plaintext),
+	})
+}
+
+func (c sealAgentClient) unseal(context, sealed string) ([]byte, *probe.Error) {
+	data, err := c.call(sealAgentRequest{Op: sealAgentOpUnseal, Context: context, Data: sealed})
+	if err != nil {
+		return nil, err
+	}
+	plaintext, e := base64.StdEncoding.DecodeString(data)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return plaintext, nil
+}
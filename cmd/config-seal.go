@@ -0,0 +1,373 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/env"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+	terminal "golang.org/x/term"
+)
+
+const (
+	mcEnvAgentSocket      = "MC_AGENT_SOCK"
+	mcEnvConfigPassphrase = "MC_CONFIG_PASSPHRASE"
+	mcEnvConfigKeyFile    = "MC_CONFIG_KEYFILE"
+
+	sealKDFScrypt  = "scrypt"
+	sealKDFKeyFile = "keyfile"
+
+	sealScryptN = 1 << 15
+	sealScryptR = 8
+	sealScryptP = 1
+
+	sealKeyLen        = 32
+	sealMinKeyFileLen = 16
+)
+
+// sealConfigV10 describes how the credentials of a sealed config are
+// protected. The key itself is never stored, only what is needed to
+// derive it again and to verify that it is the right one.
+type sealConfigV10 struct {
+	ID      string `json:"id"`
+	KDF     string `json:"kdf"`
+	Salt    string `json:"salt"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+	KeyFile string `json:"keyFile,omitempty"`
+	Check   string `json:"check"`
+}
+
+// sealedCredentials are the parts of an alias config which are sealed.
+type sealedCredentials struct {
+	SecretKey    string `json:"secretKey"`
+	SessionToken string `json:"sessionToken,omitempty"`
+}
+
+// configSealer seals and unseals data bound to an additional
+// authenticated context, either locally or through the agent.
+type configSealer interface {
+	seal(context string, plaintext []byte) (string, *probe.Error)
+	unseal(context, sealed string) ([]byte, *probe.Error)
+}
+
+// globalConfigSealer is set once the sealed config has been unlocked
+// by this process.
+var globalConfigSealer configSealer
+
+// errNoSealConfig is returned when an alias has sealed credentials but
+// the seal block was removed from the config file.
+var errNoSealConfig = errors.New("alias is sealed but the config has no seal parameters")
+
+// sealKey is a key derived from a passphrase or a keyfile.
+type sealKey []byte
+
+func (k sealKey) aead() (cipher.AEAD, *probe.Error) {
+	block, e := aes.NewCipher(k)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return aead, nil
+}
+
+func (k sealKey) seal(context string, plaintext []byte) (string, *probe.Error) {
+	aead, err := k.aead()
+	if err != nil {
+		return "", err.Trace()
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, e := io.ReadFull(rand.Reader, nonce); e != nil {
+		return "", probe.NewError(e)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, []byte(context))), nil
+}
+
+func (k sealKey) unseal(context, sealed string) ([]byte, *probe.Error) {
+	aead, err := k.aead()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	data, e := base64.StdEncoding.DecodeString(sealed)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, probe.NewError(errors.New("sealed data is truncated"))
+	}
+	plaintext, e := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(context))
+	if e != nil {
+		return nil, probe.NewError(errors.New("sealed data cannot be authenticated, wrong passphrase or keyfile"))
+	}
+	return plaintext, nil
+}
+
+// newSealConfig creates the seal description of a config about to be
+// sealed, together with the key derived from secret.
+func newSealConfig(kdf, keyFile string, secret []byte) (*sealConfigV10, sealKey, *probe.Error) {
+	id := make([]byte, 8)
+	salt := make([]byte, 16)
+	if _, e := io.ReadFull(rand.Reader, id); e != nil {
+		return nil, nil, probe.NewError(e)
+	}
+	if _, e := io.ReadFull(rand.Reader, salt); e != nil {
+		return nil, nil, probe.NewError(e)
+	}
+	seal := &sealConfigV10{
+		ID:      hex.EncodeToString(id),
+		KDF:     kdf,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		KeyFile: keyFile,
+	}
+	if kdf == sealKDFScrypt {
+		seal.N, seal.R, seal.P = sealScryptN, sealScryptR, sealScryptP
+	}
+	key, err := deriveSealKey(seal, secret)
+	if err != nil {
+		return nil, nil, err.Trace()
+	}
+	if seal.Check, err = key.seal(sealCheckContext(seal), []byte(seal.ID)); err != nil {
+		return nil, nil, err.Trace()
+	}
+	return seal, key, nil
+}
+
+func sealCheckContext(seal *sealConfigV10) string {
+	return "mc-seal-check:" + seal.ID
+}
+
+func sealAliasContext(alias string) string {
+	return "mc-alias:" + alias
+}
+
+// deriveSealKey derives the key of a sealed config from a passphrase
+// or the content of a keyfile.
+func deriveSealKey(seal *sealConfigV10, secret []byte) (sealKey, *probe.Error) {
+	salt, e := base64.StdEncoding.DecodeString(seal.Salt)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	switch seal.KDF {
+	case sealKDFScrypt:
+		if len(secret) == 0 {
+			return nil, probe.NewError(errors.New("passphrase cannot be empty"))
+		}
+		key, e := scrypt.Key(secret, salt, seal.N, seal.R, seal.P, sealKeyLen)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		return key, nil
+	case sealKDFKeyFile:
+		if len(secret) < sealMinKeyFileLen {
+			return nil, probe.NewError(fmt.Errorf("keyfile must contain at least %d bytes", sealMinKeyFileLen))
+		}
+		key := make([]byte, sealKeyLen)
+		if _, e = io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte("mc config seal")), key); e != nil {
+			return nil, probe.NewError(e)
+		}
+		return key, nil
+	}
+	return nil, probe.NewError(fmt.Errorf("unknown key derivation `%s`", seal.KDF))
+}
+
+// verifySealKey checks that key unseals the config described by seal.
+func verifySealKey(seal *sealConfigV10, key sealKey) *probe.Error {
+	id, err := key.unseal(sealCheckContext(seal), seal.Check)
+	if err != nil {
+		return err.Trace()
+	}
+	if string(id) != seal.ID {
+		return probe.NewError(errors.New("seal check does not match the config"))
+	}
+	return nil
+}
+
+// readSealPassphrase reads a passphrase from the terminal, or a line
+// from standard input when it is not a terminal.
+func readSealPassphrase(prompt string) ([]byte, *probe.Error) {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		line, e := bufio.NewReader(os.Stdin).ReadString('\n')
+		if e != nil && e != io.EOF {
+			return nil, probe.NewError(e)
+		}
+		return []byte(trimLineEnding(line)), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, e := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return passphrase, nil
+}
+
+func trimLineEnding(s string) string {
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// readSealSecret returns the passphrase or the keyfile content used
+// to derive the key of seal. Passphrases are only prompted for when
+// interactive is set.
+func readSealSecret(seal *sealConfigV10, interactive bool) ([]byte, *probe.Error) {
+	if seal.KDF == sealKDFKeyFile {
+		keyFile := env.Get(mcEnvConfigKeyFile, seal.KeyFile)
+		secret, e := os.ReadFile(keyFile)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(keyFile)
+		}
+		return secret, nil
+	}
+	if passphrase := os.Getenv(mcEnvConfigPassphrase); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	if !interactive {
+		return nil, errConfigSealed().Trace()
+	}
+	return readSealPassphrase("Enter config passphrase: ")
+}
+
+// unlockSealKey derives and verifies the key of seal.
+func unlockSealKey(seal *sealConfigV10, interactive bool) (sealKey, *probe.Error) {
+	if seal == nil {
+		return nil, probe.NewError(errNoSealConfig)
+	}
+	secret, err := readSealSecret(seal, interactive)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	key, err := deriveSealKey(seal, secret)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	if err = verifySealKey(seal, key); err != nil {
+		return nil, err.Trace()
+	}
+	return key, nil
+}
+
+// getConfigSealer returns the sealer of a sealed config, asking the
+// agent first and deriving the key locally otherwise.
+func getConfigSealer(seal *sealConfigV10, interactive bool) (configSealer, *probe.Error) {
+	if seal == nil {
+		return nil, probe.NewError(errNoSealConfig)
+	}
+	if globalConfigSealer != nil {
+		return globalConfigSealer, nil
+	}
+	if socket := os.Getenv(mcEnvAgentSocket); socket != "" {
+		agent := sealAgentClient{socket: socket, id: seal.ID}
+		if agent.ping() == nil {
+			globalConfigSealer = agent
+			return agent, nil
+		}
+	}
+	key, err := unlockSealKey(seal, interactive)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	globalConfigSealer = key
+	return key, nil
+}
+
+// sealAliasConfig moves the credentials of an alias config into its
+// sealed field.
+func sealAliasConfig(sealer configSealer, alias string, aliasCfg *aliasConfigV10) *probe.Error {
+	if aliasCfg.Sealed != "" || (aliasCfg.SecretKey == "" && aliasCfg.SessionToken == "") {
+		return nil
+	}
+	plaintext, e := json.Marshal(sealedCredentials{
+		SecretKey:    aliasCfg.SecretKey,
+		SessionToken: aliasCfg.SessionToken,
+	})
+	if e != nil {
+		return probe.NewError(e)
+	}
+	sealed, err := sealer.seal(sealAliasContext(alias), plaintext)
+	if err != nil {
+		return err.Trace(alias)
+	}
+	aliasCfg.Sealed = sealed
+	aliasCfg.SecretKey = ""
+	aliasCfg.SessionToken = ""
+	return nil
+}
+
+// unsealAliasConfig restores the credentials of a sealed alias config.
+func unsealAliasConfig(sealer configSealer, alias string, aliasCfg *aliasConfigV10) *probe.Error {
+	if aliasCfg.Sealed == "" {
+		return nil
+	}
+	plaintext, err := sealer.unseal(sealAliasContext(alias), aliasCfg.Sealed)
+	if err != nil {
+		return err.Trace(alias)
+	}
+	var creds sealedCredentials
+	if e := json.Unmarshal(plaintext, &creds); e != nil {
+		return probe.NewError(e).Trace(alias)
+	}
+	aliasCfg.SecretKey = creds.SecretKey
+	aliasCfg.SessionToken = creds.SessionToken
+	aliasCfg.Sealed = ""
+	return nil
+}
+
+// sealMcConfig seals the credentials of every alias added or changed
+// since the config was loaded, it is a no-op for unsealed configs.
+func sealMcConfig(config *configV10) *probe.Error {
+	if config.Seal == nil {
+		return nil
+	}
+	var sealer configSealer
+	for alias, aliasCfg := range config.Aliases {
+		if aliasCfg.Sealed != "" || (aliasCfg.SecretKey == "" && aliasCfg.SessionToken == "") {
+			continue
+		}
+		if sealer == nil {
+			var err *probe.Error
+			if sealer, err = getConfigSealer(config.Seal, true); err != nil {
+				return err.Trace()
+			}
+		}
+		if err := sealAliasConfig(sealer, alias, &aliasCfg); err != nil {
+			return err.Trace(alias)
+		}
+		config.Aliases[alias] = aliasCfg
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestSealAliasConfig(t *testing.T) {
+	for _, kdf := range []string{sealKDFScrypt, sealKDFKeyFile} {
+		secret := []byte("correct horse battery staple")
+		seal, key, err := newSealConfig(kdf, "", secret)
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+
+		// The key derived again must match the stored check.
+		derived, err := deriveSealKey(seal, secret)
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if err = verifySealKey(seal, derived); err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		wrong, err := deriveSealKey(seal, []byte("incorrect horse battery staple"))
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if verifySealKey(seal, wrong) == nil {
+			t.Fatalf("%s: a wrong secret must not verify", kdf)
+		}
+
+		aliasCfg := aliasConfigV10{
+			URL:          "https://play.min.io",
+			AccessKey:    "Q3AM3UQ867SPQQA43P2F",
+			SecretKey:    "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG",
+			SessionToken: "token",
+		}
+		sealed := aliasCfg
+		if err = sealAliasConfig(key, "play", &sealed); err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if sealed.SecretKey != "" || sealed.SessionToken != "" || sealed.Sealed == "" {
+			t.Fatalf("%s: credentials were not sealed: %+v", kdf, sealed)
+		}
+
+		// Sealed credentials are bound to their alias.
+		moved := sealed
+		if unsealAliasConfig(derived, "other", &moved) == nil {
+			t.Fatalf("%s: credentials must not unseal under another alias", kdf)
+		}
+		if err = unsealAliasConfig(derived, "play", &sealed); err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if !reflect.DeepEqual(sealed, aliasCfg) {
+			t.Fatalf("%s: expected %+v, got %+v", kdf, aliasCfg, sealed)
+		}
+	}
+
+	if _, _, err := newSealConfig(sealKDFKeyFile, "", []byte("short")); err == nil {
+		t.Fatal("expected a short keyfile to be rejected")
+	}
+}
+
+func TestSealAgent(t *testing.T) {
+	dir, e := os.MkdirTemp("", "mc-agent-")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+
+	seal, key, err := newSealConfig(sealKDFKeyFile, "", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := runSealAgent(socket, seal.ID, append(sealKey{}, key...), time.Minute); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	agent := sealAgentClient{socket: socket, id: seal.ID}
+	for i := 0; agent.ping() != nil; i++ {
+		if i == 100 {
+			t.Fatal("agent did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Data sealed by the agent unseals locally and the other way round.
+	sealed, err := agent.seal("ctx", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := key.unseal("ctx", sealed); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("unexpected local unseal %q: %v", plaintext, err)
+	}
+	sealed, err = key.seal("ctx", []byte("other secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := agent.unseal("ctx", sealed); err != nil || string(plaintext) != "other secret" {
+		t.Fatalf("unexpected agent unseal %q: %v", plaintext, err)
+	}
+
+	if (sealAgentClient{socket: socket, id: "another"}).ping() == nil {
+		t.Fatal("agent must reject requests for another configuration")
+	}
+
+	if err = agent.stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+	if agent.ping() == nil {
+		t.Fatal("agent still answers after being stopped")
+	}
+}
+
+func TestSealAgentStop(t *testing.T) {
+	dir, e := os.MkdirTemp("", "mc-agent-")
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer os.RemoveAll(dir)
+	listener, e := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	seal, key, err := newSealConfig(sealKDFKeyFile, "", []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := &sealAgentServer{id: seal.ID, key: key, listener: listener, conns: make(map[net.Conn]struct{})}
+
+	// An idle connection is closed by stop.
+	client, server := net.Pipe()
+	defer client.Close()
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		agent.serve(server)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				agent.handle(sealAgentRequest{Op: sealAgentOpSeal, ID: seal.ID, Context: "ctx"})
+			}
+		}()
+	}
+	agent.stop()
+	wg.Wait()
+
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Fatal("open connection not closed by stop")
+	}
+	if !bytes.Equal(key, make(sealKey, len(key))) {
+		t.Fatal("key not wiped by stop")
+	}
+	if resp := agent.handle(sealAgentRequest{Op: sealAgentOpSeal, ID: seal.ID, Context: "ctx"}); resp.Error == "" {
+		t.Fatal("expected a stopped agent to reject requests")
+	}
+}
+
+func TestSealedAliasWithoutSeal(t *testing.T) {
+	saved := loadMcConfig
+	defer func() { loadMcConfig = saved }()
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		conf := newMcConfig()
+		conf.Aliases["play"] = aliasConfigV10{
+			URL:       "https://play.min.io",
+			AccessKey: "Q3AM3UQ867SPQQA43P2F",
+			Sealed:    "sealed",
+		}
+		return conf, nil
+	}
+
+	_, err := getAliasConfig("play")
+	if err == nil || err.ToGoError() != errNoSealConfig {
+		t.Fatalf("expected %v, got %v", errNoSealConfig, err)
+	}
+	if _, err = unlockSealKey(nil, false); err == nil || err.ToGoError() != errNoSealConfig {
+		t.Fatalf("expected %v, got %v", errNoSealConfig, err)
+	}
+}
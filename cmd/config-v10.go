@@ -38,20 +38,33 @@ var (
 
 // aliasConfig configuration of an alias.
 type aliasConfigV10 struct {
-	URL          string `json:"url"`
-	AccessKey    string `json:"accessKey"`
-	SecretKey    string `json:"secretKey"`
-	SessionToken string `json:"sessionToken,omitempty"`
-	API          string `json:"api"`
-	Path         string `json:"path"`
-	License      string `json:"license,omitempty"`
-	APIKey       string `json:"apiKey,omitempty"`
+	URL          string   `json:"url"`
+	AccessKey    string   `json:"accessKey"`
+	SecretKey    string   `json:"secretKey"`
+	SessionToken string   `json:"sessionToken,omitempty"`
+	API          string   `json:"api"`
+	Path         string   `json:"path"`
+	License      string   `json:"license,omitempty"`
+	APIKey       string   `json:"apiKey,omitempty"`
+	Sealed       string   `json:"sealed,omitempty"`
+	ClientCert   string   `json:"clientCert,omitempty"`
+	ClientKey    string   `json:"clientKey,omitempty"`
+	PinnedKeys   []string `json:"pinnedKeys,omitempty"`
+}
+
+// auditConfigV10 configuration of the local audit log.
+type auditConfigV10 struct {
+	Enable bool   `json:"enable"`
+	File   string `json:"file,omitempty"`
+	Syslog bool   `json:"syslog,omitempty"`
 }
 
 // configV10 config version.
 type configV10 struct {
 	Version string                    `json:"version"`
 	Aliases map[string]aliasConfigV10 `json:"aliases"`
+	Audit   *auditConfigV10           `json:"audit,omitempty"`
+	Seal    *sealConfigV10            `json:"seal,omitempty"`
 }
 
 // newConfigV10 - new config version.
@@ -140,6 +140,11 @@ func saveMcConfig(config *configV10) *probe.Error {
 		return err.Trace(mustGetMcConfigDir())
 	}
 
+	// Credentials of a sealed config are never written in plaintext.
+	if err := sealMcConfig(config); err != nil {
+		return err.Trace(mustGetMcConfigPath())
+	}
+
 	// Save the config.
 	if err := saveConfigV10(config); err != nil {
 		return err.Trace(mustGetMcConfigPath())
@@ -185,6 +190,15 @@ func getAliasConfig(alias string) (*aliasConfigV10, *probe.Error) {
 	// if host is exact return quickly.
 	if _, ok := mcCfg.Aliases[alias]; ok {
 		hostCfg := mcCfg.Aliases[alias]
+		if hostCfg.Sealed != "" {
+			sealer, err := getConfigSealer(mcCfg.Seal, false)
+			if err != nil {
+				return nil, err.Trace(alias)
+			}
+			if err = unsealAliasConfig(sealer, alias, &hostCfg); err != nil {
+				return nil, err.Trace(alias)
+			}
+		}
 		return &hostCfg, nil
 	}
 
@@ -192,9 +206,23 @@ func getAliasConfig(alias string) (*aliasConfigV10, *probe.Error) {
 	return nil, errNoMatchingHost(alias).Trace(alias)
 }
 
+// isSealedAlias returns true if the credentials of alias are sealed.
+func isSealedAlias(alias string) bool {
+	mcCfg, err := loadMcConfig()
+	if err != nil {
+		return false
+	}
+	return mcCfg.Aliases[alias].Sealed != ""
+}
+
 // mustGetHostConfig retrieves host specific configuration such as access keys, signature type.
 func mustGetHostConfig(alias string) *aliasConfigV10 {
-	aliasCfg, _ := getAliasConfig(alias)
+	aliasCfg, err := getAliasConfig(alias)
+	// Sealed credentials which cannot be unsealed must not silently
+	// fall back to other sources.
+	if err != nil && isSealedAlias(alias) {
+		fatalIf(err, "Unable to unseal the credentials of `"+alias+"`.")
+	}
 	// If alias is not found,
 	// look for it in the environment variable.
 	if aliasCfg == nil {
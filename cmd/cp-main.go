@@ -90,6 +90,10 @@ var (
 			Name:  "tags",
 			Usage: "apply one or more tags to the uploaded objects",
 		},
+		cli.StringFlag{
+			Name:  "match-tags",
+			Usage: "copy only objects with tags matching an expression, e.g. 'project=x && tier!=cold'",
+		},
 		cli.StringFlag{
 			Name:  rmFlag,
 			Usage: "retention mode to be applied on the object (governance, compliance)",
@@ -201,6 +205,9 @@ EXAMPLES:
   20. Set tags to the uploaded objects
       {{.Prompt}} {{.HelpName}} -r --tags "category=prod&type=backup" ./data/ play/another-bucket/
 
+  21. Copy the objects of project 'x' which are not tagged with tier 'cold'
+      {{.Prompt}} {{.HelpName}} -r --match-tags "project=x && tier!=cold" play/mybucket/ s3/mybucket/
+
 `,
 }
 
@@ -269,7 +276,7 @@ func doCopy(ctx context.Context, cpURLs URLs, pg ProgressReader, encKeyDB map[st
 		})
 	}
 
-	urls := uploadSourceToTargetURL(ctx, cpURLs, pg, encKeyDB, preserve, isZip)
+	urls := uploadWithRetry(ctx, cpURLs, pg, encKeyDB, preserve, isZip)
 	if isMvCmd && urls.Error == nil {
 		rmManager.add(ctx, sourceAlias, sourceURL.String())
 	}
@@ -299,6 +306,8 @@ func doPrepareCopyURLs(ctx context.Context, session *sessionV8, cancelCopy conte
 	versionID := session.Header.CommandStringFlags["version-id"]
 	olderThan := session.Header.CommandStringFlags["older-than"]
 	newerThan := session.Header.CommandStringFlags["newer-than"]
+	matchTags, err := newTagFilter(session.Header.CommandStringFlags["match-tags"])
+	fatalIf(err, "Unable to parse --match-tags.")
 	encryptKeys := session.Header.CommandStringFlags["encrypt-key"]
 	encrypt := session.Header.CommandStringFlags["encrypt"]
 	encKeyDB, err := parseAndValidateEncryptionKeys(encryptKeys, encrypt)
@@ -321,6 +330,7 @@ func doPrepareCopyURLs(ctx context.Context, session *sessionV8, cancelCopy conte
 		newerThan:   newerThan,
 		timeRef:     parseRewindFlag(rewind),
 		versionID:   versionID,
+		tags:        matchTags,
 	}
 
 	URLsCh := prepareCopyURLs(ctx, opts)
@@ -437,6 +447,8 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 		newerThan := cli.String("newer-than")
 		rewind := cli.String("rewind")
 		versionID := cli.String("version-id")
+		matchTags, err := newTagFilter(cli.String("match-tags"))
+		fatalIf(err, "Unable to parse --match-tags.")
 
 		go func() {
 			totalBytes := int64(0)
@@ -450,6 +462,7 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 				timeRef:     parseRewindFlag(rewind),
 				versionID:   versionID,
 				isZip:       cli.Bool("zip"),
+				tags:        matchTags,
 			}
 			for cpURLs := range prepareCopyURLs(ctx, opts) {
 				if cpURLs.Error != nil {
@@ -634,6 +647,7 @@ loop:
 		} else if progressReader.ProgressBar.Get() > 0 {
 			progressReader.ProgressBar.Finish()
 		}
+		printRetrySummary()
 	} else {
 		if accntReader, ok := pg.(*accounter); ok {
 			printMsg(accntReader.Stat())
@@ -674,6 +688,8 @@ func mainCopy(cliCtx *cli.Context) error {
 	retentionDuration := cliCtx.String(rdFlag)
 	legalHold := strings.ToUpper(cliCtx.String(lhFlag))
 	tags := cliCtx.String("tags")
+	_, err = newTagFilter(cliCtx.String("match-tags"))
+	fatalIf(err, "Unable to parse --match-tags.")
 	sseKeys := os.Getenv("MC_ENCRYPT_KEY")
 	if key := cliCtx.String("encrypt-key"); key != "" {
 		sseKeys = key
@@ -702,6 +718,7 @@ func mainCopy(cliCtx *cli.Context) error {
 			session.Header.CommandStringFlags["newer-than"] = newerThan
 			session.Header.CommandStringFlags["storage-class"] = storageClass
 			session.Header.CommandStringFlags["tags"] = tags
+			session.Header.CommandStringFlags["match-tags"] = cliCtx.String("match-tags")
 			session.Header.CommandStringFlags[rmFlag] = retentionMode
 			session.Header.CommandStringFlags[rdFlag] = retentionDuration
 			session.Header.CommandStringFlags[lhFlag] = legalHold
@@ -57,6 +57,14 @@ func checkCopySyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[stri
 		fatalIf(errDummy().Trace(cliCtx.Args()...), "--zip and --rewind cannot be used together")
 	}
 
+	if cliCtx.String("match-tags") != "" {
+		for _, srcURL := range srcURLs {
+			if alias, _, _ := mustExpandAlias(srcURL); alias == "" {
+				fatalIf(errInvalidArgument().Trace(srcURL), "Tag filters are only supported on S3 aliases.")
+			}
+		}
+	}
+
 	// Verify if source(s) exists.
 	for _, srcURL := range srcURLs {
 		var err *probe.Error
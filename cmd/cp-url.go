@@ -229,6 +229,7 @@ type prepareCopyURLsOpts struct {
 	timeRef              time.Time
 	versionID            string
 	isZip                bool
+	tags                 *tagFilter
 }
 
 // prepareCopyURLs - prepares target and source clientURLs for copying.
@@ -271,6 +272,16 @@ func prepareCopyURLs(ctx context.Context, o prepareCopyURLsOpts) chan URLs {
 				continue
 			}
 
+			// Skip objects with tags not matching --match-tags if specified
+			if o.tags != nil && cpURLs.Error == nil {
+				match, err := o.tags.match(ctx, cpURLs.SourceAlias, cpURLs.SourceContent)
+				if err != nil {
+					cpURLs.Error = err.Trace(cpURLs.SourceContent.URL.String())
+				} else if !match {
+					continue
+				}
+			}
+
 			finalCopyURLsCh <- cpURLs
 		}
 	}()
@@ -130,12 +130,13 @@ func mainEventAdd(cliCtx *cli.Context) error {
 		fatalIf(err.Trace(), "Unable to parse the provided url.")
 	}
 
-	s3Client, ok := client.(*S3Client)
+	s3Client, ok := unwrapClient(client).(*S3Client)
 	if !ok {
 		fatalIf(errDummy().Trace(), "The provided url doesn't point to a S3 server.")
 	}
 
 	err = s3Client.AddNotificationConfig(ctx, arn, event, prefix, suffix, ignoreExisting)
+	auditLog(client, "AddNotification", "", err)
 	fatalIf(err, "Unable to enable notification on the specified bucket.")
 	printMsg(eventAddMessage{
 		ARN:    arn,
@@ -123,7 +123,7 @@ func mainEventRemove(cliCtx *cli.Context) error {
 		fatalIf(err.Trace(), "Unable to parse the provided url.")
 	}
 
-	s3Client, ok := client.(*S3Client)
+	s3Client, ok := unwrapClient(client).(*S3Client)
 	if !ok {
 		fatalIf(errDummy().Trace(), "The provided url doesn't point to a S3 server.")
 	}
@@ -134,6 +134,7 @@ func mainEventRemove(cliCtx *cli.Context) error {
 	suffix := cliCtx.String("suffix")
 
 	err = s3Client.RemoveNotificationConfig(ctx, arn, event, prefix, suffix)
+	auditLog(client, "RemoveNotification", "", err)
 	if err != nil {
 		fatalIf(err, "Unable to disable notification on the specified bucket.")
 	}
@@ -50,6 +50,11 @@ var globalFlags = []cli.Flag{
 		Name:  "insecure",
 		Usage: "disable SSL certificate verification",
 	},
+	cli.IntFlag{
+		Name:  "max-retries",
+		Usage: "maximum number of retries for transient failures, of each request and of each upload or removal",
+		Value: defaultMaxRetries,
+	},
 	cli.DurationFlag{
 		Name:   "conn-read-deadline",
 		Usage:  "custom connection READ deadline",
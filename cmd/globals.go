@@ -26,6 +26,7 @@ import (
 
 	"github.com/minio/cli"
 	"github.com/minio/madmin-go"
+	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/console"
 )
 
@@ -72,6 +73,8 @@ var (
 	globalConnReadDeadline  time.Duration
 	globalConnWriteDeadline time.Duration
 
+	globalMaxRetries = defaultMaxRetries // Max retries for transient failures set via command line
+
 	globalContext, globalCancel = context.WithCancel(context.Background())
 )
 
@@ -111,5 +114,17 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 
 	globalConnReadDeadline = ctx.Duration("conn-read-deadline")
 	globalConnWriteDeadline = ctx.Duration("conn-write-deadline")
+
+	if ctx.IsSet("max-retries") {
+		globalMaxRetries = ctx.Int("max-retries")
+	} else if ctx.GlobalIsSet("max-retries") {
+		globalMaxRetries = ctx.GlobalInt("max-retries")
+	}
+	if globalMaxRetries < 0 {
+		globalMaxRetries = 0
+	}
+	// Each request is retried by the S3 client as well, bound those
+	// retries too instead of multiplying them with ours.
+	minio.MaxRetry = globalMaxRetries + 1
 	return nil
 }
@@ -0,0 +1,122 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Maximum number of lines compared by diffTextLines, larger contents
+// are only reported as different.
+const historyMaxDiffLines = 4096
+
+// historyChange is a metadata entry or a tag changed between two
+// consecutive versions, Old is empty for added entries and New for
+// removed entries.
+type historyChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// String returns the change prefixed with '+' when added, '-' when
+// removed and '~' when modified.
+func (c historyChange) String() string {
+	switch {
+	case c.Old == "":
+		return "+ " + c.Name + ": " + c.New
+	case c.New == "":
+		return "- " + c.Name + ": " + c.Old
+	}
+	return "~ " + c.Name + ": " + c.Old + " -> " + c.New
+}
+
+// diffStringMaps returns the changes from oldMap to newMap sorted by name.
+func diffStringMaps(oldMap, newMap map[string]string) (changes []historyChange) {
+	for name, value := range newMap {
+		if oldValue, ok := oldMap[name]; !ok || oldValue != value {
+			changes = append(changes, historyChange{Name: name, Old: oldMap[name], New: value})
+		}
+	}
+	for name, value := range oldMap {
+		if _, ok := newMap[name]; !ok {
+			changes = append(changes, historyChange{Name: name, Old: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// isText reports whether data looks like a text document.
+func isText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
+
+// diffTextLines returns the lines removed from oldText prefixed with
+// '-' and the lines added by newText prefixed with '+', in order. ok is
+// false when the texts are too large to be compared line by line.
+func diffTextLines(oldText, newText string) (diff []string, ok bool) {
+	a, b := strings.Split(oldText, "\n"), strings.Split(newText, "\n")
+	if len(a) > historyMaxDiffLines || len(b) > historyMaxDiffLines {
+		return nil, false
+	}
+
+	// Skip the common head and tail, then compute the longest
+	// common subsequence of what is left.
+	head := 0
+	for head < len(a) && head < len(b) && a[head] == b[head] {
+		head++
+	}
+	tail := 0
+	for tail < len(a)-head && tail < len(b)-head && a[len(a)-1-tail] == b[len(b)-1-tail] {
+		tail++
+	}
+	a, b = a[head:len(a)-tail], b[head:len(b)-tail]
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "-"+a[i])
+			i++
+		default:
+			diff = append(diff, "+"+b[j])
+			j++
+		}
+	}
+	return diff, true
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var historyFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "no-content",
+		Usage: "do not diff the content of consecutive versions of text objects",
+	},
+	cli.StringFlag{
+		Name:  "max-content-size",
+		Value: "1MiB",
+		Usage: "diff the content of versions up to this size",
+	},
+	cli.IntFlag{
+		Name:  "top",
+		Value: 10,
+		Usage: "number of objects with the largest noncurrent size shown for a prefix",
+	},
+}
+
+var historyCmd = cli.Command{
+	Name:         "history",
+	Usage:        "show the version history of an object or a prefix",
+	Action:       mainHistory,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(historyFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+  For an object, show a timeline of its versions and delete markers with their
+  retention, legal hold and replication state, and the changes of the user
+  metadata, tags and text content between consecutive versions.
+
+  For a prefix, ending with '/', or a bucket, summarize the versions created
+  per day and the objects with the largest noncurrent size.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Show the history of an object
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/config.yaml
+
+  2. Show the history of an object without downloading its versions
+     {{.Prompt}} {{.HelpName}} --no-content myminio/mybucket/config.yaml
+
+  3. Summarize the versions created under a prefix
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/logs/
+`,
+}
+
+// historyVersionMessage describes a version or a delete marker of an
+// object and its changes from the previous version.
+type historyVersionMessage struct {
+	Status            string          `json:"status"`
+	Key               string          `json:"key"`
+	VersionID         string          `json:"versionId"`
+	Time              time.Time       `json:"lastModified"`
+	Size              int64           `json:"size"`
+	ETag              string          `json:"etag,omitempty"`
+	StorageClass      string          `json:"storageClass,omitempty"`
+	DeleteMarker      bool            `json:"deleteMarker,omitempty"`
+	IsLatest          bool            `json:"isLatest,omitempty"`
+	RetentionMode     string          `json:"retentionMode,omitempty"`
+	RetainUntil       *time.Time      `json:"retainUntil,omitempty"`
+	LegalHold         string          `json:"legalHold,omitempty"`
+	ReplicationStatus string          `json:"replicationStatus,omitempty"`
+	MetadataChanges   []historyChange `json:"metadataChanges,omitempty"`
+	TagChanges        []historyChange `json:"tagChanges,omitempty"`
+	ContentChanged    bool            `json:"contentChanged,omitempty"`
+	ContentDiff       []string        `json:"contentDiff,omitempty"`
+
+	userMetadata map[string]string
+	tags         map[string]string
+}
+
+// String colorized version history message.
+func (h historyVersionMessage) String() string {
+	var b strings.Builder
+	b.WriteString(console.Colorize("Time", "["+h.Time.Local().Format(printDate)+"] "))
+	if h.DeleteMarker {
+		b.WriteString(console.Colorize("HistoryDelete", "DEL "))
+		b.WriteString(console.Colorize("Size", fmt.Sprintf("%7s", "")))
+	} else {
+		b.WriteString(console.Colorize("HistoryPut", "PUT "))
+		b.WriteString(console.Colorize("Size", fmt.Sprintf("%7s", strings.Join(strings.Fields(humanize.IBytes(uint64(h.Size))), ""))))
+	}
+	b.WriteString(" v=" + h.VersionID)
+	if h.IsLatest {
+		b.WriteString(console.Colorize("HistoryLatest", " (latest)"))
+	}
+
+	var details []string
+	if h.ETag != "" {
+		details = append(details, "etag: "+h.ETag)
+	}
+	if h.StorageClass != "" {
+		details = append(details, "class: "+h.StorageClass)
+	}
+	if h.ReplicationStatus != "" {
+		details = append(details, "replication: "+h.ReplicationStatus)
+	}
+	if h.RetentionMode != "" {
+		retention := "retention: " + h.RetentionMode
+		if h.RetainUntil != nil {
+			retention += " until " + h.RetainUntil.Local().Format(printDate)
+		}
+		details = append(details, retention)
+	}
+	if h.LegalHold != "" {
+		details = append(details, "legal hold: "+h.LegalHold)
+	}
+	if len(details) > 0 {
+		b.WriteString("\n    " + strings.Join(details, ", "))
+	}
+
+	writeChanges := func(name string, changes []historyChange) {
+		if len(changes) == 0 {
+			return
+		}
+		b.WriteString("\n    " + name + ":")
+		for _, change := range changes {
+			b.WriteString("\n      " + console.Colorize("HistoryChange", change.String()))
+		}
+	}
+	writeChanges("metadata", h.MetadataChanges)
+	writeChanges("tags", h.TagChanges)
+	if h.ContentChanged {
+		if len(h.ContentDiff) == 0 {
+			b.WriteString("\n    content: changed")
+		} else {
+			b.WriteString("\n    content:")
+		}
+		for _, line := range h.ContentDiff {
+			color := "HistoryAdded"
+			if strings.HasPrefix(line, "-") {
+				color = "HistoryRemoved"
+			}
+			b.WriteString("\n      " + console.Colorize(color, line))
+		}
+	}
+	return b.String()
+}
+
+// JSON jsonified version history message.
+func (h historyVersionMessage) JSON() string {
+	h.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(h, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// newHistoryVersionMessage fetches the metadata and tags of a version.
+func newHistoryVersionMessage(ctx context.Context, clnt Client, version *ClientContent) (msg historyVersionMessage, err *probe.Error) {
+	msg = historyVersionMessage{
+		Key:          strings.TrimPrefix(strings.TrimPrefix(version.URL.Path, "/"), version.BucketName+"/"),
+		VersionID:    version.VersionID,
+		Time:         version.Time,
+		Size:         version.Size,
+		ETag:         version.ETag,
+		StorageClass: version.StorageClass,
+		DeleteMarker: version.IsDeleteMarker,
+		IsLatest:     version.IsLatest,
+	}
+	if version.IsDeleteMarker {
+		return msg, nil
+	}
+
+	stat, err := clnt.Stat(ctx, StatOptions{versionID: version.VersionID})
+	if err != nil {
+		return msg, err.Trace(version.VersionID)
+	}
+	msg.userMetadata = stat.UserMetadata
+	msg.ReplicationStatus = stat.ReplicationStatus
+	msg.RetentionMode = stat.Metadata[AmzObjectLockMode]
+	if until, e := time.Parse(time.RFC3339, stat.Metadata[AmzObjectLockRetainUntilDate]); e == nil {
+		msg.RetainUntil = &until
+	}
+	msg.LegalHold = stat.Metadata[AmzObjectLockLegalHold]
+	msg.tags, err = clnt.GetTags(ctx, version.VersionID)
+	if err != nil {
+		return msg, err.Trace(version.VersionID)
+	}
+	return msg, nil
+}
+
+// getVersionText downloads a version up to maxSize bytes, ok is false
+// when the version is larger or is not text.
+func getVersionText(ctx context.Context, clnt Client, versionID string, size, maxSize int64) (text string, ok bool, err *probe.Error) {
+	if size > maxSize {
+		return "", false, nil
+	}
+	reader, err := clnt.Get(ctx, GetOptions{VersionID: versionID})
+	if err != nil {
+		return "", false, err.Trace(versionID)
+	}
+	defer reader.Close()
+	data, e := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if e != nil {
+		return "", false, probe.NewError(e).Trace(versionID)
+	}
+	if int64(len(data)) > maxSize || !isText(data) {
+		return "", false, nil
+	}
+	return string(data), true, nil
+}
+
+// showObjectHistory prints the versions of an object from the oldest to
+// the latest, each compared to the previous version.
+func showObjectHistory(ctx context.Context, clnt Client, versions []*ClientContent, diffContent bool, maxContentSize int64) {
+	sortObjectVersions(versions)
+	var previous *historyVersionMessage
+	var previousText string
+	var previousIsText bool
+	for i := len(versions) - 1; i >= 0; i-- {
+		msg, err := newHistoryVersionMessage(ctx, clnt, versions[i])
+		fatalIf(err.Trace(clnt.GetURL().String()), "Unable to get the version details.")
+		if msg.DeleteMarker {
+			printMsg(msg)
+			continue
+		}
+
+		var text string
+		var isText bool
+		if previous != nil {
+			msg.MetadataChanges = diffStringMaps(previous.userMetadata, msg.userMetadata)
+			msg.TagChanges = diffStringMaps(previous.tags, msg.tags)
+			msg.ContentChanged = previous.ETag != msg.ETag || previous.Size != msg.Size
+		}
+		if diffContent && (previous == nil || msg.ContentChanged) {
+			text, isText, err = getVersionText(ctx, clnt, msg.VersionID, msg.Size, maxContentSize)
+			fatalIf(err.Trace(clnt.GetURL().String()), "Unable to download the version.")
+		} else if previous != nil {
+			text, isText = previousText, previousIsText
+		}
+		if msg.ContentChanged && isText && previousIsText {
+			if diff, ok := diffTextLines(previousText, text); ok {
+				msg.ContentDiff = diff
+			}
+		}
+		printMsg(msg)
+		previous, previousText, previousIsText = &msg, text, isText
+	}
+}
+
+// mainHistory is the handle for "mc history" command.
+func mainHistory(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		showCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	ctx, cancelHistory := context.WithCancel(globalContext)
+	defer cancelHistory()
+
+	console.SetColor("HistoryPut", color.New(color.FgGreen, color.Bold))
+	console.SetColor("HistoryDelete", color.New(color.FgRed, color.Bold))
+	console.SetColor("HistoryLatest", color.New(color.FgCyan))
+	console.SetColor("HistoryChange", color.New(color.FgYellow))
+	console.SetColor("HistoryAdded", color.New(color.FgGreen))
+	console.SetColor("HistoryRemoved", color.New(color.FgRed))
+	console.SetColor("HistoryHeader", color.New(color.Bold))
+	console.SetColor("Time", color.New(color.FgGreen))
+	console.SetColor("Size", color.New(color.FgYellow))
+
+	maxContentSize, e := humanize.ParseBytes(cliCtx.String("max-content-size"))
+	fatalIf(probe.NewError(e).Trace(cliCtx.String("max-content-size")), "Unable to parse the maximum content size.")
+	top := cliCtx.Int("top")
+
+	target := cliCtx.Args().Get(0)
+	alias, _, _ := mustExpandAlias(target)
+	if alias == "" {
+		fatalIf(errInvalidArgument().Trace(target), "History is only supported on S3 aliases.")
+	}
+	clnt, err := newClient(target)
+	fatalIf(err.Trace(target), "Unable to initialize target `"+target+"`.")
+
+	listCtx, cancelList := context.WithCancel(ctx)
+	defer cancelList()
+	contentCh := clnt.List(listCtx, ListOptions{
+		Recursive:         true,
+		WithOlderVersions: true,
+		WithDeleteMarkers: true,
+		ShowDir:           DirNone,
+	})
+
+	// Versions are listed by key, the versions of an object come
+	// before the objects sharing its name as prefix.
+	summary := newHistoryPrefixSummary(target, top)
+	targetURL := clnt.GetURL()
+	objectPath := targetURL.Path
+	_, key := url2BucketAndObject(&targetURL)
+	if key != "" && !strings.HasSuffix(objectPath, "/") {
+		var versions []*ClientContent
+		for content := range contentCh {
+			if content.Err != nil {
+				fatalIf(content.Err.Trace(target), "Unable to list `"+target+"`.")
+			}
+			if content.URL.Path != objectPath {
+				summary.add(content)
+				break
+			}
+			versions = append(versions, content)
+		}
+		if len(versions) > 0 {
+			// Stop listing the objects sharing the name as prefix.
+			cancelList()
+			go func() {
+				for range contentCh {
+				}
+			}()
+			showObjectHistory(ctx, clnt, versions, !cliCtx.Bool("no-content"), int64(maxContentSize))
+			return nil
+		}
+	}
+
+	for content := range contentCh {
+		if content.Err != nil {
+			fatalIf(content.Err.Trace(target), "Unable to list `"+target+"`.")
+		}
+		summary.add(content)
+	}
+	printMsg(summary.message())
+	return nil
+}
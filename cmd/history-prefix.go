@@ -0,0 +1,161 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+// historyDay counts the versions and delete markers created on a day.
+type historyDay struct {
+	Date          string `json:"date"`
+	Versions      int64  `json:"versions"`
+	DeleteMarkers int64  `json:"deleteMarkers"`
+	Size          int64  `json:"size"`
+}
+
+// historyNoncurrent is the noncurrent size of an object.
+type historyNoncurrent struct {
+	Key      string `json:"key"`
+	Versions int64  `json:"versions"`
+	Size     int64  `json:"size"`
+}
+
+// historyPrefixMessage summarizes the churn of a prefix.
+type historyPrefixMessage struct {
+	Status             string              `json:"status"`
+	Target             string              `json:"target"`
+	Objects            int64               `json:"objects"`
+	Versions           int64               `json:"versions"`
+	DeleteMarkers      int64               `json:"deleteMarkers"`
+	NoncurrentVersions int64               `json:"noncurrentVersions"`
+	NoncurrentSize     int64               `json:"noncurrentSize"`
+	Days               []historyDay        `json:"days"`
+	Largest            []historyNoncurrent `json:"largestNoncurrent"`
+}
+
+// String colorized prefix history message.
+func (h historyPrefixMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d object(s), %d version(s), %d delete marker(s)\n", h.Target, h.Objects, h.Versions, h.DeleteMarkers)
+	fmt.Fprintf(&b, "Noncurrent: %d version(s), %s\n", h.NoncurrentVersions, humanize.IBytes(uint64(h.NoncurrentSize)))
+	if len(h.Days) > 0 {
+		b.WriteString("\n" + console.Colorize("HistoryHeader", fmt.Sprintf("%-12s %10s %15s %10s", "Date", "Versions", "Delete markers", "Size")) + "\n")
+		for _, day := range h.Days {
+			fmt.Fprintf(&b, "%-12s %10d %15d %10s\n", day.Date, day.Versions, day.DeleteMarkers,
+				strings.Join(strings.Fields(humanize.IBytes(uint64(day.Size))), ""))
+		}
+	}
+	if len(h.Largest) > 0 {
+		b.WriteString("\n" + console.Colorize("HistoryHeader", "Largest noncurrent size:") + "\n")
+		for _, object := range h.Largest {
+			fmt.Fprintf(&b, "%10s %6d version(s) %s\n",
+				strings.Join(strings.Fields(humanize.IBytes(uint64(object.Size))), ""), object.Versions, object.Key)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// JSON jsonified prefix history message.
+func (h historyPrefixMessage) JSON() string {
+	h.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(h, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// historyPrefixSummary accumulates the versions of a prefix listed by key.
+type historyPrefixSummary struct {
+	msg     historyPrefixMessage
+	top     int
+	days    map[string]*historyDay
+	current historyNoncurrent
+	path    string
+}
+
+func newHistoryPrefixSummary(target string, top int) *historyPrefixSummary {
+	return &historyPrefixSummary{
+		msg:  historyPrefixMessage{Target: target},
+		top:  top,
+		days: map[string]*historyDay{},
+	}
+}
+
+// add counts a version or a delete marker.
+func (s *historyPrefixSummary) add(content *ClientContent) {
+	if content.URL.Path != s.path {
+		s.flushObject()
+		s.path = content.URL.Path
+		s.current = historyNoncurrent{Key: strings.TrimPrefix(strings.TrimPrefix(s.path, "/"), content.BucketName+"/")}
+		s.msg.Objects++
+	}
+
+	date := content.Time.UTC().Format("2006-01-02")
+	day, ok := s.days[date]
+	if !ok {
+		day = &historyDay{Date: date}
+		s.days[date] = day
+	}
+	if content.IsDeleteMarker {
+		s.msg.DeleteMarkers++
+		day.DeleteMarkers++
+		return
+	}
+	s.msg.Versions++
+	day.Versions++
+	day.Size += content.Size
+	if !content.IsLatest {
+		s.msg.NoncurrentVersions++
+		s.msg.NoncurrentSize += content.Size
+		s.current.Versions++
+		s.current.Size += content.Size
+	}
+}
+
+// flushObject keeps the current object when it is one of the objects
+// with the largest noncurrent size.
+func (s *historyPrefixSummary) flushObject() {
+	if s.current.Size == 0 || s.top <= 0 {
+		return
+	}
+	largest := append(s.msg.Largest, s.current)
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].Size > largest[j].Size })
+	if len(largest) > s.top {
+		largest = largest[:s.top]
+	}
+	s.msg.Largest = largest
+}
+
+// message returns the summary with the days sorted.
+func (s *historyPrefixSummary) message() historyPrefixMessage {
+	s.flushObject()
+	s.current = historyNoncurrent{}
+	s.msg.Days = make([]historyDay, 0, len(s.days))
+	for _, day := range s.days {
+		s.msg.Days = append(s.msg.Days, *day)
+	}
+	sort.Slice(s.msg.Days, func(i, j int) bool { return s.msg.Days[i].Date < s.msg.Days[j].Date })
+	return s.msg
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiffStringMaps(t *testing.T) {
+	changes := diffStringMaps(
+		map[string]string{"env": "dev", "owner": "ops", "tmp": "1"},
+		map[string]string{"env": "prod", "owner": "ops", "team": "data"},
+	)
+	expected := []historyChange{
+		{Name: "env", Old: "dev", New: "prod"},
+		{Name: "team", New: "data"},
+		{Name: "tmp", Old: "1"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected %v, got %v", expected, changes)
+	}
+	if changes := diffStringMaps(nil, nil); len(changes) != 0 {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+}
+
+func TestDiffTextLines(t *testing.T) {
+	testCases := []struct {
+		old, new string
+		diff     []string
+	}{
+		{"a\nb\nc", "a\nb\nc", nil},
+		{"a\nb\nc", "a\nx\nc", []string{"-b", "+x"}},
+		{"a\nb\nc", "a\nb\nc\nd", []string{"+d"}},
+		{"a\nb\nc", "b\nc", []string{"-a"}},
+		{"a\nb\nc\nd", "a\nc\nb\nd", []string{"-b", "+b"}},
+	}
+	for i, testCase := range testCases {
+		diff, ok := diffTextLines(testCase.old, testCase.new)
+		if !ok || !reflect.DeepEqual(diff, testCase.diff) {
+			t.Errorf("test %d: expected %q, got %q", i+1, testCase.diff, diff)
+		}
+	}
+	if _, ok := diffTextLines(strings.Repeat("a\n", historyMaxDiffLines+1), "a"); ok {
+		t.Fatal("expected large texts not to be compared")
+	}
+	if isText([]byte{'a', 0, 'b'}) || !isText([]byte("key: value\n")) {
+		t.Fatal("unexpected text detection")
+	}
+}
+
+func TestHistoryPrefixSummary(t *testing.T) {
+	day1 := time.Date(2023, 10, 11, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	version := func(key string, size int64, modTime time.Time, latest, deleteMarker bool) *ClientContent {
+		return &ClientContent{
+			URL:            ClientURL{Path: "/bucket/" + key},
+			BucketName:     "bucket",
+			Size:           size,
+			Time:           modTime,
+			IsLatest:       latest,
+			IsDeleteMarker: deleteMarker,
+		}
+	}
+	summary := newHistoryPrefixSummary("myminio/bucket", 1)
+	for _, content := range []*ClientContent{
+		version("a", 10, day2, true, false),
+		version("a", 20, day1, false, false),
+		version("b", 0, day2, true, true),
+		version("b", 50, day1, false, false),
+		version("c", 5, day1, true, false),
+	} {
+		summary.add(content)
+	}
+	msg := summary.message()
+	if msg.Objects != 3 || msg.Versions != 4 || msg.DeleteMarkers != 1 || msg.NoncurrentVersions != 2 || msg.NoncurrentSize != 70 {
+		t.Fatalf("unexpected summary %+v", msg)
+	}
+	expectedDays := []historyDay{
+		{Date: "2023-10-11", Versions: 3, Size: 75},
+		{Date: "2023-10-12", Versions: 1, DeleteMarkers: 1, Size: 10},
+	}
+	if !reflect.DeepEqual(msg.Days, expectedDays) {
+		t.Fatalf("expected %+v, got %+v", expectedDays, msg.Days)
+	}
+	if len(msg.Largest) != 1 || msg.Largest[0] != (historyNoncurrent{Key: "b", Versions: 1, Size: 50}) {
+		t.Fatalf("unexpected largest noncurrent %+v", msg.Largest)
+	}
+}
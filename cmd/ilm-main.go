@@ -31,6 +31,7 @@ var ilmSubcommands = []cli.Command{
 	ilmExportCmd,
 	ilmImportCmd,
 	ilmRestoreCmd,
+	ilmPreviewCmd,
 }
 
 var ilmCmd = cli.Command{
@@ -0,0 +1,532 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/cmd/ilm"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/pkg/console"
+)
+
+var ilmPreviewFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "rule",
+		Usage: "preview the lifecycle configuration or the rule of this JSON file instead of the bucket's",
+	},
+	cli.StringFlag{
+		Name:  "within",
+		Usage: "only report the actions due within this duration, e.g. 30d",
+	},
+	cli.BoolFlag{
+		Name:  "summary",
+		Usage: "only print the number of versions and bytes per rule and action",
+	},
+}
+
+var ilmPreviewCmd = cli.Command{
+	Name:         "preview",
+	Usage:        "preview which objects lifecycle rules would expire or transition and when",
+	Action:       mainILMPreview,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(ilmPreviewFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  List all objects and versions of a bucket, or of a prefix, and evaluate the enabled
+  lifecycle rules locally: prefix and tag filters, expiration and transition days and
+  dates, noncurrent version rules and newer noncurrent version counts. Nothing is changed.
+  The rule file is in the 'mc ilm export' format, a single rule is accepted too.
+  Incomplete multipart uploads are not previewed.
+
+EXAMPLES:
+  1. Preview the lifecycle rules of mybucket on alias 'myminio'.
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Preview the rules of lifecycle.json on mybucket before importing them.
+     {{.Prompt}} {{.HelpName}} --rule lifecycle.json myminio/mybucket
+
+  3. Show how many versions and bytes each rule would expire or transition in the next 30 days.
+     {{.Prompt}} {{.HelpName}} --within 30d --summary myminio/mybucket
+`,
+}
+
+// Actions previewed by 'ilm preview'.
+const (
+	ilmActionDelete             = "delete"
+	ilmActionExpire             = "expire"
+	ilmActionDeleteVersion      = "delete-version"
+	ilmActionRemoveDeleteMarker = "remove-delete-marker"
+	ilmActionTransition         = "transition"
+)
+
+// ilmPreviewVersion is an object version with its position in the
+// version history of its object.
+type ilmPreviewVersion struct {
+	Key            string
+	VersionID      string
+	ModTime        time.Time
+	Size           int64
+	StorageClass   string
+	IsLatest       bool
+	IsDeleteMarker bool
+	// Time the version became noncurrent, zero for the latest version.
+	SuccessorModTime time.Time
+	// Number of noncurrent versions newer than this one.
+	NewerNoncurrent int
+	// The version is the only one of its object.
+	OnlyVersion bool
+}
+
+// newILMPreviewVersions orders the versions of an object from the latest
+// to the oldest and computes when each one became noncurrent.
+func newILMPreviewVersions(key string, contents []*ClientContent) []ilmPreviewVersion {
+	sort.SliceStable(contents, func(i, j int) bool {
+		if contents[i].IsLatest != contents[j].IsLatest {
+			return contents[i].IsLatest
+		}
+		return contents[i].Time.After(contents[j].Time)
+	})
+	versions := make([]ilmPreviewVersion, len(contents))
+	for i, c := range contents {
+		versions[i] = ilmPreviewVersion{
+			Key:            key,
+			VersionID:      c.VersionID,
+			ModTime:        c.Time,
+			Size:           c.Size,
+			StorageClass:   c.StorageClass,
+			IsLatest:       i == 0,
+			IsDeleteMarker: c.IsDeleteMarker,
+			OnlyVersion:    len(contents) == 1,
+		}
+		if i > 0 {
+			versions[i].SuccessorModTime = contents[i-1].Time
+			versions[i].NewerNoncurrent = i - 1
+		}
+	}
+	return versions
+}
+
+// ilmExpectedExpiryTime returns the midnight UTC after the given number
+// of days, the same way the server schedules lifecycle actions.
+func ilmExpectedExpiryTime(t time.Time, days int) time.Time {
+	if days == 0 {
+		return t
+	}
+	return t.UTC().Add(time.Duration(days+1) * 24 * time.Hour).Truncate(24 * time.Hour)
+}
+
+// ilmRuleFilter returns the prefix and tags a rule applies to.
+func ilmRuleFilter(rule lifecycle.Rule) (prefix string, tags []lifecycle.Tag) {
+	prefix = rule.Prefix
+	if rule.RuleFilter.Prefix != "" {
+		prefix = rule.RuleFilter.Prefix
+	}
+	if rule.RuleFilter.And.Prefix != "" {
+		prefix = rule.RuleFilter.And.Prefix
+	}
+	if rule.RuleFilter.Tag.Key != "" {
+		tags = append(tags, rule.RuleFilter.Tag)
+	}
+	tags = append(tags, rule.RuleFilter.And.Tags...)
+	return prefix, tags
+}
+
+// ilmRuleMatches tells whether a rule applies to an object, tags are
+// only looked at when the rule filters on them.
+func ilmRuleMatches(rule lifecycle.Rule, key string, tags func() map[string]string) bool {
+	prefix, ruleTags := ilmRuleFilter(rule)
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	if len(ruleTags) == 0 {
+		return true
+	}
+	objectTags := tags()
+	for _, tag := range ruleTags {
+		if v, ok := objectTags[tag.Key]; !ok || v != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// ilmPreviewEvent is a lifecycle action due on an object version.
+type ilmPreviewEvent struct {
+	Rule         string
+	Action       string
+	StorageClass string
+	Due          time.Time
+}
+
+// evaluateILMRules returns the actions the enabled rules take on a
+// version: the earliest expiration and the earliest transition, unless
+// the version is expired first.
+func evaluateILMRules(rules []lifecycle.Rule, v ilmPreviewVersion, versioned bool, tags func() map[string]string) []ilmPreviewEvent {
+	var expiry, transition *ilmPreviewEvent
+	earliest := func(current **ilmPreviewEvent, ev ilmPreviewEvent) {
+		if *current == nil || ev.Due.Before((*current).Due) {
+			*current = &ev
+		}
+	}
+	transitions := func(storageClass string) bool {
+		return storageClass != "" && !v.IsDeleteMarker && !strings.EqualFold(storageClass, v.StorageClass)
+	}
+	for _, rule := range rules {
+		if rule.Status != "Enabled" || !ilmRuleMatches(rule, v.Key, tags) {
+			continue
+		}
+		switch {
+		case v.IsLatest && v.IsDeleteMarker:
+			// Delete markers without any version left are removed.
+			if v.OnlyVersion && rule.Expiration.IsDeleteMarkerExpirationEnabled() {
+				earliest(&expiry, ilmPreviewEvent{Rule: rule.ID, Action: ilmActionRemoveDeleteMarker, Due: v.ModTime})
+			}
+		case v.IsLatest:
+			action := ilmActionDelete
+			if versioned {
+				action = ilmActionExpire
+			}
+			switch {
+			case !rule.Expiration.IsDateNull():
+				earliest(&expiry, ilmPreviewEvent{Rule: rule.ID, Action: action, Due: rule.Expiration.Date.Time})
+			case rule.Expiration.Days > 0:
+				earliest(&expiry, ilmPreviewEvent{Rule: rule.ID, Action: action, Due: ilmExpectedExpiryTime(v.ModTime, int(rule.Expiration.Days))})
+			}
+			if sc := rule.Transition.StorageClass; transitions(sc) {
+				due := ilmExpectedExpiryTime(v.ModTime, int(rule.Transition.Days))
+				if !rule.Transition.IsDateNull() {
+					due = rule.Transition.Date.Time
+				}
+				earliest(&transition, ilmPreviewEvent{Rule: rule.ID, Action: ilmActionTransition, StorageClass: sc, Due: due})
+			}
+		default:
+			ne := rule.NoncurrentVersionExpiration
+			if (ne.NoncurrentDays > 0 || ne.NewerNoncurrentVersions > 0) && v.NewerNoncurrent >= ne.NewerNoncurrentVersions {
+				earliest(&expiry, ilmPreviewEvent{
+					Rule:   rule.ID,
+					Action: ilmActionDeleteVersion,
+					Due:    ilmExpectedExpiryTime(v.SuccessorModTime, int(ne.NoncurrentDays)),
+				})
+			}
+			nt := rule.NoncurrentVersionTransition
+			if transitions(nt.StorageClass) && v.NewerNoncurrent >= nt.NewerNoncurrentVersions {
+				earliest(&transition, ilmPreviewEvent{
+					Rule:         rule.ID,
+					Action:       ilmActionTransition,
+					StorageClass: nt.StorageClass,
+					Due:          ilmExpectedExpiryTime(v.SuccessorModTime, int(nt.NoncurrentDays)),
+				})
+			}
+		}
+	}
+	var events []ilmPreviewEvent
+	if transition != nil && (expiry == nil || transition.Due.Before(expiry.Due)) {
+		events = append(events, *transition)
+	}
+	if expiry != nil {
+		events = append(events, *expiry)
+	}
+	return events
+}
+
+// ilmPreviewMessage is a lifecycle action due on an object version.
+type ilmPreviewMessage struct {
+	Status         string    `json:"status"`
+	Key            string    `json:"key"`
+	VersionID      string    `json:"versionId,omitempty"`
+	IsLatest       bool      `json:"isLatest"`
+	IsDeleteMarker bool      `json:"isDeleteMarker,omitempty"`
+	Size           int64     `json:"size"`
+	Rule           string    `json:"rule"`
+	Action         string    `json:"action"`
+	StorageClass   string    `json:"storageClass,omitempty"`
+	Due            time.Time `json:"due"`
+	Overdue        bool      `json:"overdue,omitempty"`
+}
+
+var ilmPreviewTable = newPrettyTable(" | ",
+	Field{"Due", 10},
+	Field{"Action", 28},
+	Field{"Rule", 20},
+	Field{"Size", 10},
+	Field{"Object", -1},
+)
+
+func ilmPreviewHeader() string {
+	return console.Colorize(ilmThemeHeader, ilmPreviewTable.buildRow("Due", "Action", "Rule", "Size", "Object"))
+}
+
+func (m ilmPreviewMessage) String() string {
+	due := m.Due.Format("2006-01-02")
+	theme := ilmThemeRow
+	if m.Overdue {
+		due = "next scan"
+		theme = ilmThemeResultFailure
+	}
+	action := m.Action
+	if m.StorageClass != "" {
+		action += " to " + m.StorageClass
+	}
+	object := m.Key
+	if m.VersionID != "" {
+		object += " (" + m.VersionID + ")"
+	}
+	if m.IsDeleteMarker {
+		object += " [delete marker]"
+	}
+	return console.Colorize(theme, ilmPreviewTable.buildRow(due, action, m.Rule, humanize.IBytes(uint64(m.Size)), object))
+}
+
+func (m ilmPreviewMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// ilmPreviewSummary is the total of the versions a rule acts on.
+type ilmPreviewSummary struct {
+	Status       string `json:"status"`
+	Rule         string `json:"rule"`
+	Action       string `json:"action"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Versions     int64  `json:"versions"`
+	Size         int64  `json:"size"`
+}
+
+func (s ilmPreviewSummary) String() string {
+	action := s.Action
+	if s.StorageClass != "" {
+		action += " to " + s.StorageClass
+	}
+	return console.Colorize(ilmThemeResultSuccess, fmt.Sprintf("Rule `%s`: %s on %s version(s), %s.",
+		s.Rule, action, humanize.Comma(s.Versions), humanize.IBytes(uint64(s.Size))))
+}
+
+func (s ilmPreviewSummary) JSON() string {
+	s.Status = "success"
+	msgBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// readILMPreviewRules reads a lifecycle configuration, or a single rule,
+// from a JSON file and validates its rules.
+func readILMPreviewRules(path string) ([]lifecycle.Rule, *probe.Error) {
+	data, e := os.ReadFile(path)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	cfg := lifecycle.NewConfiguration()
+	if e = json.Unmarshal(data, cfg); e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	rules := cfg.Rules
+	if len(rules) == 0 {
+		var rule lifecycle.Rule
+		if e = json.Unmarshal(data, &rule); e != nil {
+			return nil, probe.NewError(e).Trace(path)
+		}
+		if rule.ID == "" && rule.Status == "" {
+			return nil, probe.NewError(fmt.Errorf("no lifecycle rule found in `%s`", path))
+		}
+		rules = []lifecycle.Rule{rule}
+	}
+	for _, rule := range rules {
+		if err := ilm.ValidateILMRule(rule); err != nil {
+			return nil, err.Trace(path, rule.ID)
+		}
+	}
+	return rules, nil
+}
+
+// ilmPreviewTotals sums the previewed actions per rule and action.
+type ilmPreviewTotals map[[3]string]*ilmPreviewSummary
+
+func (t ilmPreviewTotals) add(ev ilmPreviewEvent, size int64) {
+	k := [3]string{ev.Rule, ev.Action, ev.StorageClass}
+	s, ok := t[k]
+	if !ok {
+		s = &ilmPreviewSummary{Rule: ev.Rule, Action: ev.Action, StorageClass: ev.StorageClass}
+		t[k] = s
+	}
+	s.Versions++
+	s.Size += size
+}
+
+func (t ilmPreviewTotals) sorted() []ilmPreviewSummary {
+	summaries := make([]ilmPreviewSummary, 0, len(t))
+	for _, s := range t {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Rule != summaries[j].Rule {
+			return summaries[i].Rule < summaries[j].Rule
+		}
+		if summaries[i].Action != summaries[j].Action {
+			return summaries[i].Action < summaries[j].Action
+		}
+		return summaries[i].StorageClass < summaries[j].StorageClass
+	})
+	return summaries
+}
+
+// checkILMPreviewSyntax - validate arguments passed by user
+func checkILMPreviewSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, "preview", globalErrorExitStatus)
+	}
+}
+
+func mainILMPreview(cliCtx *cli.Context) error {
+	ctx, cancelILMPreview := context.WithCancel(globalContext)
+	defer cancelILMPreview()
+
+	checkILMPreviewSyntax(cliCtx)
+	setILMDisplayColorScheme()
+
+	urlStr := cliCtx.Args().Get(0)
+	alias, _ := url2Alias(urlStr)
+	client, err := newClient(urlStr)
+	fatalIf(err.Trace(urlStr), "Unable to initialize client for "+urlStr)
+	clientURL := client.GetURL()
+	if clientURL.Type != objectStorage {
+		fatalIf(errInvalidArgument().Trace(urlStr), "Lifecycle rules can only be previewed on buckets.")
+	}
+	bucket, _ := url2BucketAndObject(&clientURL)
+	if bucket == "" {
+		fatalIf(errInvalidArgument().Trace(urlStr), "A bucket is required to preview lifecycle rules.")
+	}
+
+	var rules []lifecycle.Rule
+	if path := cliCtx.String("rule"); path != "" {
+		rules, err = readILMPreviewRules(path)
+		fatalIf(err, "Unable to read the lifecycle rules to preview.")
+	} else {
+		bucketClient, err := newClient(alias + "/" + bucket)
+		fatalIf(err.Trace(urlStr), "Unable to initialize client for "+urlStr)
+		cfg, err := bucketClient.GetLifecycle(ctx)
+		fatalIf(err.Trace(urlStr), "Unable to get the lifecycle configuration of `"+urlStr+"`.")
+		rules = cfg.Rules
+	}
+
+	versioning, err := client.GetVersion(ctx)
+	fatalIf(err.Trace(urlStr), "Unable to get the versioning configuration of `"+urlStr+"`.")
+	versioned := versioning.Status == "Enabled" || versioning.Status == "Suspended"
+
+	now := UTCNow()
+	var horizon time.Time
+	if cliCtx.IsSet("within") {
+		d, e := ParseDuration(cliCtx.String("within"))
+		fatalIf(probe.NewError(e).Trace(cliCtx.String("within")), "Unable to parse --within.")
+		horizon = now.Add(time.Duration(d))
+	}
+	summaryOnly := cliCtx.Bool("summary")
+
+	totals := make(ilmPreviewTotals)
+	preview := func(key string, contents []*ClientContent) {
+		versions := newILMPreviewVersions(key, contents)
+		for i, v := range versions {
+			content := contents[i]
+			var tags map[string]string
+			getTags := func() map[string]string {
+				if tags != nil || v.IsDeleteMarker {
+					return tags
+				}
+				tags = map[string]string{}
+				clnt, err := newClientFromAlias(alias, content.URL.String())
+				if err == nil {
+					tags, err = clnt.GetTags(ctx, v.VersionID)
+				}
+				errorIf(err.Trace(content.URL.String()), "Unable to get the tags of `%s`.", key)
+				return tags
+			}
+			for _, ev := range evaluateILMRules(rules, v, versioned, getTags) {
+				if !horizon.IsZero() && ev.Due.After(horizon) {
+					continue
+				}
+				totals.add(ev, v.Size)
+				if summaryOnly {
+					continue
+				}
+				printMsg(ilmPreviewMessage{
+					Key:            key,
+					VersionID:      v.VersionID,
+					IsLatest:       v.IsLatest,
+					IsDeleteMarker: v.IsDeleteMarker,
+					Size:           v.Size,
+					Rule:           ev.Rule,
+					Action:         ev.Action,
+					StorageClass:   ev.StorageClass,
+					Due:            ev.Due,
+					Overdue:        !ev.Due.After(now),
+				})
+			}
+		}
+	}
+
+	if !globalJSON && !summaryOnly {
+		console.Println(ilmPreviewHeader())
+	}
+	opts := ListOptions{Recursive: true, WithOlderVersions: true, WithDeleteMarkers: true, ShowDir: DirNone}
+	var key string
+	var contents []*ClientContent
+	for content := range client.List(ctx, opts) {
+		if content.Err != nil {
+			errorIf(content.Err.Trace(urlStr), "Unable to list `%s`.", urlStr)
+			continue
+		}
+		if content.Type.IsDir() {
+			continue
+		}
+		_, contentKey := url2BucketAndObject(&content.URL)
+		if contentKey != key && len(contents) > 0 {
+			preview(key, contents)
+			contents = nil
+		}
+		key = contentKey
+		contents = append(contents, content)
+	}
+	if len(contents) > 0 {
+		preview(key, contents)
+	}
+
+	for _, s := range totals.sorted() {
+		printMsg(s)
+	}
+	return nil
+}
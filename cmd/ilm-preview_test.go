@@ -0,0 +1,247 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+)
+
+func TestILMExpectedExpiryTime(t *testing.T) {
+	modTime := time.Date(2022, 10, 3, 15, 4, 5, 0, time.UTC)
+	testCases := []struct {
+		days     int
+		expected time.Time
+	}{
+		{0, modTime},
+		{1, time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC)},
+		{30, time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, testCase := range testCases {
+		if got := ilmExpectedExpiryTime(modTime, testCase.days); !got.Equal(testCase.expected) {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.expected, got)
+		}
+	}
+}
+
+func TestNewILMPreviewVersions(t *testing.T) {
+	t1 := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	contents := []*ClientContent{
+		{VersionID: "v1", Time: t1},
+		{VersionID: "v3", Time: t1.Add(2 * time.Hour), IsLatest: true},
+		{VersionID: "v2", Time: t1.Add(time.Hour)},
+	}
+	versions := newILMPreviewVersions("a/b", contents)
+	expected := []struct {
+		versionID       string
+		latest          bool
+		successor       time.Time
+		newerNoncurrent int
+	}{
+		{"v3", true, time.Time{}, 0},
+		{"v2", false, t1.Add(2 * time.Hour), 0},
+		{"v1", false, t1.Add(time.Hour), 1},
+	}
+	for i, e := range expected {
+		v := versions[i]
+		if v.VersionID != e.versionID || v.IsLatest != e.latest || !v.SuccessorModTime.Equal(e.successor) || v.NewerNoncurrent != e.newerNoncurrent || v.OnlyVersion {
+			t.Errorf("Version %d: unexpected %+v", i, v)
+		}
+	}
+}
+
+func TestEvaluateILMRules(t *testing.T) {
+	modTime := time.Date(2022, 10, 3, 15, 0, 0, 0, time.UTC)
+	successor := modTime.Add(48 * time.Hour)
+	date := lifecycle.ExpirationDate{Time: time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)}
+	noTags := func() map[string]string { return nil }
+	prodTags := func() map[string]string { return map[string]string{"env": "prod", "team": "a"} }
+
+	latest := ilmPreviewVersion{Key: "logs/a.log", ModTime: modTime, Size: 10, IsLatest: true}
+	noncurrent := ilmPreviewVersion{Key: "logs/a.log", ModTime: modTime, Size: 10, SuccessorModTime: successor, NewerNoncurrent: 1}
+	marker := ilmPreviewVersion{Key: "logs/a.log", ModTime: modTime, IsLatest: true, IsDeleteMarker: true, OnlyVersion: true}
+
+	testCases := []struct {
+		rules     []lifecycle.Rule
+		version   ilmPreviewVersion
+		versioned bool
+		tags      func() map[string]string
+		expected  []ilmPreviewEvent
+	}{
+		// Expiration days on an unversioned bucket.
+		{
+			rules:    []lifecycle.Rule{{ID: "r1", Status: "Enabled", Expiration: lifecycle.Expiration{Days: 1}}},
+			version:  latest,
+			tags:     noTags,
+			expected: []ilmPreviewEvent{{Rule: "r1", Action: ilmActionDelete, Due: time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC)}},
+		},
+		// Expiration date on a versioned bucket.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", Expiration: lifecycle.Expiration{Date: date}}},
+			version:   latest,
+			versioned: true,
+			tags:      noTags,
+			expected:  []ilmPreviewEvent{{Rule: "r1", Action: ilmActionExpire, Due: date.Time}},
+		},
+		// Disabled rules and other prefixes are ignored.
+		{
+			rules: []lifecycle.Rule{
+				{ID: "r1", Status: "Disabled", Expiration: lifecycle.Expiration{Days: 1}},
+				{ID: "r2", Status: "Enabled", RuleFilter: lifecycle.Filter{Prefix: "data/"}, Expiration: lifecycle.Expiration{Days: 1}},
+			},
+			version: latest,
+			tags:    noTags,
+		},
+		// Tag filters.
+		{
+			rules: []lifecycle.Rule{
+				{ID: "r1", Status: "Enabled", RuleFilter: lifecycle.Filter{Tag: lifecycle.Tag{Key: "env", Value: "dev"}}, Expiration: lifecycle.Expiration{Days: 1}},
+				{ID: "r2", Status: "Enabled", RuleFilter: lifecycle.Filter{And: lifecycle.And{Prefix: "logs/", Tags: []lifecycle.Tag{{Key: "env", Value: "prod"}}}}, Expiration: lifecycle.Expiration{Days: 10}},
+			},
+			version:  latest,
+			tags:     prodTags,
+			expected: []ilmPreviewEvent{{Rule: "r2", Action: ilmActionDelete, Due: time.Date(2022, 10, 14, 0, 0, 0, 0, time.UTC)}},
+		},
+		// The earliest expiration wins, an earlier transition is kept.
+		{
+			rules: []lifecycle.Rule{
+				{ID: "r1", Status: "Enabled", Expiration: lifecycle.Expiration{Days: 30}},
+				{ID: "r2", Status: "Enabled", Expiration: lifecycle.Expiration{Days: 20}},
+				{ID: "r3", Status: "Enabled", Transition: lifecycle.Transition{Days: 5, StorageClass: "WARM"}},
+			},
+			version: latest,
+			tags:    noTags,
+			expected: []ilmPreviewEvent{
+				{Rule: "r3", Action: ilmActionTransition, StorageClass: "WARM", Due: time.Date(2022, 10, 9, 0, 0, 0, 0, time.UTC)},
+				{Rule: "r2", Action: ilmActionDelete, Due: time.Date(2022, 10, 24, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		// A transition after the expiration never happens.
+		{
+			rules: []lifecycle.Rule{
+				{ID: "r1", Status: "Enabled", Expiration: lifecycle.Expiration{Days: 5}, Transition: lifecycle.Transition{Days: 10, StorageClass: "WARM"}},
+			},
+			version:  latest,
+			tags:     noTags,
+			expected: []ilmPreviewEvent{{Rule: "r1", Action: ilmActionDelete, Due: time.Date(2022, 10, 9, 0, 0, 0, 0, time.UTC)}},
+		},
+		// Versions already in the storage class are not transitioned.
+		{
+			rules:   []lifecycle.Rule{{ID: "r1", Status: "Enabled", Transition: lifecycle.Transition{Days: 5, StorageClass: "WARM"}}},
+			version: ilmPreviewVersion{Key: "a", ModTime: modTime, IsLatest: true, StorageClass: "WARM"},
+			tags:    noTags,
+		},
+		// Noncurrent expiration counts from the successor.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{NoncurrentDays: 1}}},
+			version:   noncurrent,
+			versioned: true,
+			tags:      noTags,
+			expected:  []ilmPreviewEvent{{Rule: "r1", Action: ilmActionDeleteVersion, Due: time.Date(2022, 10, 7, 0, 0, 0, 0, time.UTC)}},
+		},
+		// Newer noncurrent versions are retained.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{NoncurrentDays: 1, NewerNoncurrentVersions: 2}}},
+			version:   noncurrent,
+			versioned: true,
+			tags:      noTags,
+		},
+		// Noncurrent transition.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", NoncurrentVersionTransition: lifecycle.NoncurrentVersionTransition{NoncurrentDays: 2, StorageClass: "WARM"}}},
+			version:   noncurrent,
+			versioned: true,
+			tags:      noTags,
+			expected:  []ilmPreviewEvent{{Rule: "r1", Action: ilmActionTransition, StorageClass: "WARM", Due: time.Date(2022, 10, 8, 0, 0, 0, 0, time.UTC)}},
+		},
+		// Noncurrent rules do not apply to the latest version.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", NoncurrentVersionExpiration: lifecycle.NoncurrentVersionExpiration{NoncurrentDays: 1}}},
+			version:   latest,
+			versioned: true,
+			tags:      noTags,
+		},
+		// Expired object delete markers.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", Expiration: lifecycle.Expiration{DeleteMarker: true}}},
+			version:   marker,
+			versioned: true,
+			tags:      noTags,
+			expected:  []ilmPreviewEvent{{Rule: "r1", Action: ilmActionRemoveDeleteMarker, Due: modTime}},
+		},
+		// Delete markers with older versions are kept.
+		{
+			rules:     []lifecycle.Rule{{ID: "r1", Status: "Enabled", Expiration: lifecycle.Expiration{DeleteMarker: true}}},
+			version:   ilmPreviewVersion{Key: "a", ModTime: modTime, IsLatest: true, IsDeleteMarker: true},
+			versioned: true,
+			tags:      noTags,
+		},
+	}
+
+	for i, testCase := range testCases {
+		events := evaluateILMRules(testCase.rules, testCase.version, testCase.versioned, testCase.tags)
+		if len(events) != len(testCase.expected) {
+			t.Fatalf("Test %d: expected %+v, got %+v", i+1, testCase.expected, events)
+		}
+		for j, ev := range events {
+			e := testCase.expected[j]
+			if ev.Rule != e.Rule || ev.Action != e.Action || ev.StorageClass != e.StorageClass || !ev.Due.Equal(e.Due) {
+				t.Errorf("Test %d: expected %+v, got %+v", i+1, e, ev)
+			}
+		}
+	}
+}
+
+func TestReadILMPreviewRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if e := os.WriteFile(path, []byte(data), 0o600); e != nil {
+			t.Fatal(e)
+		}
+		return path
+	}
+
+	cfg := write("config.json", `{"Rules":[{"ID":"r1","Status":"Enabled","Filter":{"Prefix":"logs/"},"Expiration":{"Days":30}},{"ID":"r2","Status":"Enabled","Expiration":{"Days":60}}]}`)
+	rules, err := readILMPreviewRules(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].RuleFilter.Prefix != "logs/" || rules[1].Expiration.Days != 60 {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	single := write("rule.json", `{"ID":"r1","Status":"Enabled","Expiration":{"Days":30}}`)
+	rules, err = readILMPreviewRules(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].ID != "r1" {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	for _, data := range []string{`{}`, `not json`, `{"ID":"r1","Status":"Enabled","Expiration":{"Days":30},"NoncurrentVersionTransition":{"NoncurrentDays":2}}`} {
+		if _, err = readILMPreviewRules(write("bad.json", data)); err == nil {
+			t.Errorf("expected an error for %s", data)
+		}
+	}
+}
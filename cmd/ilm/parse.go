@@ -146,6 +146,12 @@ func validateTranExpCurdate(rule lifecycle.Rule) error {
 	return e
 }
 
+// ValidateILMRule checks the S3 compatibility of a rule which is not
+// built from command line options, e.g. a rule to preview.
+func ValidateILMRule(rule lifecycle.Rule) *probe.Error {
+	return validateILMRule(rule)
+}
+
 // Check S3 compatibility for the new rule and some other basic checks.
 func validateILMRule(rule lifecycle.Rule) *probe.Error {
 	if e := validateRuleAction(rule); e != nil {
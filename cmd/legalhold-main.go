@@ -109,7 +109,7 @@ func getBucketLockStatus(ctx context.Context, aliasedURL string) (status string,
 	}
 
 	// Remove the prefix/object from the aliased url and reconstruct the client
-	switch c := clnt.(type) {
+	switch c := unwrapClient(clnt).(type) {
 	case *S3Client:
 		_, object := c.url2BucketAndObject()
 		if object != "" {
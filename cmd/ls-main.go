@@ -60,6 +60,10 @@ var (
 			Name:  "zip",
 			Usage: "list files inside zip archive (MinIO servers only)",
 		},
+		cli.StringFlag{
+			Name:  "tags",
+			Usage: "list objects with tags matching an expression, e.g. 'project=x && tier!=cold'",
+		},
 	}
 )
 
@@ -112,6 +116,9 @@ EXAMPLES:
   
   10. List all objects on mybucket, for the GLACIER storage class
      {{.Prompt}} {{.HelpName}} --storage-class 'GLACIER' s3/mybucket 
+
+  11. List all objects of project 'x' on mybucket which are not tagged with tier 'cold'.
+     {{.Prompt}} {{.HelpName}} --recursive --tags 'project=x && tier!=cold' s3/mybucket
 `,
 }
 
@@ -183,6 +190,11 @@ func checkListSyntax(ctx context.Context, cliCtx *cli.Context) ([]string, doList
 		fatalIf(errInvalidArgument().Trace(args...), "Zip file listing can only be performed on the latest version")
 	}
 	storageClasss := cliCtx.String("storage-class")
+	tags, err := newTagFilter(cliCtx.String("tags"))
+	fatalIf(err.Trace(args...), "Unable to parse --tags.")
+	if tags != nil && isIncomplete {
+		fatalIf(errInvalidArgument().Trace(args...), "--tags cannot be specified with --incomplete.")
+	}
 	opts := doListOptions{
 		timeRef:           timeRef,
 		isRecursive:       isRecursive,
@@ -191,6 +203,7 @@ func checkListSyntax(ctx context.Context, cliCtx *cli.Context) ([]string, doList
 		withOlderVersions: withOlderVersions,
 		listZip:           listZip,
 		filter:            storageClasss,
+		tags:              tags,
 	}
 	return args, opts
 }
@@ -228,6 +241,12 @@ func mainList(cliCtx *cli.Context) error {
 				fatalIf(err.Trace(targetURL), "Unable to initialize target `"+targetURL+"`.")
 			}
 		}
+		if opts.tags != nil {
+			opts.alias, _, _ = mustExpandAlias(targetURL)
+			if opts.alias == "" {
+				fatalIf(errInvalidArgument().Trace(targetURL), "Tag filters are only supported on S3 aliases.")
+			}
+		}
 		if e := doList(ctx, clnt, opts); e != nil {
 			cErr = e
 		}
@@ -208,6 +208,8 @@ type doListOptions struct {
 	withOlderVersions bool
 	listZip           bool
 	filter            string
+	tags              *tagFilter
+	alias             string
 }
 
 // doList - list all entities inside a folder.
@@ -239,6 +241,18 @@ func doList(ctx context.Context, clnt Client, o doListOptions) error {
 			continue
 		}
 
+		if o.tags != nil && !content.Type.IsDir() {
+			match, err := o.tags.match(ctx, o.alias, content)
+			if err != nil {
+				errorIf(err.Trace(content.URL.String()), "Unable to get object tags.")
+				cErr = exitStatus(globalErrorExitStatus)
+				continue
+			}
+			if !match {
+				continue
+			}
+		}
+
 		if lastPath != content.URL.Path {
 			// Print any object in the current list before reinitializing it
 			printObjectVersions(clnt.GetURL(), perObjectVersions, o.withOlderVersions, o.isSummary)
@@ -97,7 +97,7 @@ func init() {
 func Main(args []string) error {
 	if len(args) > 1 {
 		switch args[1] {
-		case "mc", filepath.Base(args[0]):
+		case "mc", filepath.Base(args[0]), "__complete":
 			mainComplete()
 			return nil
 		}
@@ -446,13 +446,18 @@ var appCmds = []cli.Command{
 	eventCmd,
 	watchCmd,
 	undoCmd,
+	restoreToCmd,
+	historyCmd,
 	anonymousCmd,
 	policyCmd,
 	tagCmd,
+	metaCmd,
 	diffCmd,
 	replicateCmd,
 	adminCmd,
 	configCmd,
+	certsCmd,
+	completionCmd,
 	updateCmd,
 	readyCmd,
 	pingCmd,
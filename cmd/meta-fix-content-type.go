@@ -0,0 +1,114 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"path"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/mimedb"
+)
+
+var metaFixContentTypeFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "fix the content type of all objects under a prefix",
+	},
+	cli.BoolFlag{
+		Name:  "all",
+		Usage: "also replace content types which are not generic",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show the changes without applying them",
+	},
+}
+
+var metaFixContentTypeCmd = cli.Command{
+	Name:         "fix-content-type",
+	Usage:        "set the content type of object(s) from their extension",
+	Action:       mainMetaFixContentType,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(metaFixContentTypeFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [COMMAND FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Set the Content-Type of objects guessed from their extension. By default only
+  objects without a content type or with a generic one like 'application/octet-stream'
+  are changed, use --all to replace any content type not matching the extension.
+  Objects with an unknown extension are left unchanged.
+
+EXAMPLES:
+  1. Show the content types which would be fixed under a prefix.
+     {{.Prompt}} {{.HelpName}} --recursive --dry-run myminio/website/
+
+  2. Fix the content types of all objects under a prefix.
+     {{.Prompt}} {{.HelpName}} --recursive myminio/website/
+`,
+}
+
+// isGenericContentType reports whether a content type carries no
+// information about the object, as set by uploads which did not guess it.
+func isGenericContentType(contentType string) bool {
+	switch contentType {
+	case "", "application/octet-stream", "binary/octet-stream", "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// fixContentType sets the content type guessed from the extension of
+// the object name, only generic content types are replaced unless all.
+func fixContentType(all bool) metaMutation {
+	return func(name string, metadata map[string]string) {
+		guessed := mimedb.TypeByExtension(path.Ext(name))
+		if isGenericContentType(guessed) {
+			return
+		}
+		if current := metadata["Content-Type"]; all || isGenericContentType(current) {
+			metadata["Content-Type"] = guessed
+		}
+	}
+}
+
+func mainMetaFixContentType(cliCtx *cli.Context) error {
+	ctx, cancelMetaFixContentType := context.WithCancel(globalContext)
+	defer cancelMetaFixContentType()
+
+	if len(cliCtx.Args()) != 1 {
+		showCommandHelpAndExit(cliCtx, "fix-content-type", globalErrorExitStatus)
+	}
+
+	console.SetColor("Name", color.New(color.FgGreen))
+	console.SetColor("Change", color.New(color.FgYellow))
+
+	targetURL := cliCtx.Args().Get(0)
+	mutate := fixContentType(cliCtx.Bool("all"))
+	return runMetaUpdate(ctx, targetURL, "", cliCtx.Bool("recursive"), cliCtx.Bool("dry-run"), mutate)
+}
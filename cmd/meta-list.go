@@ -0,0 +1,173 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var metaListFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "version-id, vid",
+		Usage: "list metadata of a specific object version",
+	},
+	cli.StringFlag{
+		Name:  "rewind",
+		Usage: "list metadata of the object version at specified time",
+	},
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "list metadata of all versions of an object",
+	},
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "list metadata of all objects under a prefix",
+	},
+}
+
+var metaListCmd = cli.Command{
+	Name:         "ls",
+	Usage:        "list metadata of object(s)",
+	Action:       mainMetaList,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(metaListFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [COMMAND FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  List the standard headers, the storage class and the user metadata of objects.
+
+EXAMPLES:
+  1. List the metadata of an object.
+     {{.Prompt}} {{.HelpName}} myminio/testbucket/testobject
+
+  2. List the metadata of all versions of an object.
+     {{.Prompt}} {{.HelpName}} --versions myminio/testbucket/testobject
+
+  3. List the metadata of all objects under a prefix in JSON format.
+     {{.Prompt}} {{.HelpName}} --recursive --json myminio/testbucket/prefix/
+`,
+}
+
+// metaListMessage is the metadata of an object version.
+type metaListMessage struct {
+	Status    string            `json:"status"`
+	URL       string            `json:"url"`
+	VersionID string            `json:"versionID,omitempty"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// String colorized metadata of an object.
+func (m metaListMessage) String() string {
+	name := m.URL
+	if m.VersionID != "" {
+		name += " (" + m.VersionID + ")"
+	}
+	keys := sortedMetadataKeys(m.Metadata)
+	maxKeyLen := 0
+	for _, k := range keys {
+		maxKeyLen = max(maxKeyLen, len(k))
+	}
+
+	strs := []string{console.Colorize("Name", name)}
+	for _, k := range keys {
+		strs = append(strs, fmt.Sprintf("  %s%*s %s", console.Colorize("Key", k), maxKeyLen-len(k)+1, ":", console.Colorize("Value", m.Metadata[k])))
+	}
+	if len(keys) == 0 {
+		strs = append(strs, "  No metadata found")
+	}
+	return strings.Join(strs, "\n")
+}
+
+// JSON jsonified metadata of an object.
+func (m metaListMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+func parseMetaListSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef time.Time, withVersions, recursive bool) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, "ls", globalErrorExitStatus)
+	}
+
+	targetURL = ctx.Args().Get(0)
+	versionID = ctx.String("version-id")
+	withVersions = ctx.Bool("versions")
+	recursive = ctx.Bool("recursive")
+	rewind := ctx.String("rewind")
+
+	if versionID != "" && (rewind != "" || withVersions || recursive) {
+		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --rewind, --versions or --recursive flags at the same time")
+	}
+
+	timeRef = parseRewindFlag(rewind)
+	return
+}
+
+func mainMetaList(cliCtx *cli.Context) error {
+	ctx, cancelMetaList := context.WithCancel(globalContext)
+	defer cancelMetaList()
+
+	console.SetColor("Name", color.New(color.Bold, color.FgCyan))
+	console.SetColor("Key", color.New(color.FgGreen))
+	console.SetColor("Value", color.New(color.FgYellow))
+
+	targetURL, versionID, timeRef, withVersions, recursive := parseMetaListSyntax(cliCtx)
+	if timeRef.IsZero() && withVersions {
+		timeRef = time.Now().UTC()
+	}
+
+	clnt := newMetaClient(targetURL)
+
+	var cErr error
+	forEachObjectTarget(ctx, clnt, targetURL, versionID, timeRef, withVersions, recursive, func(clnt Client, versionID string) {
+		content, err := clnt.Stat(ctx, StatOptions{versionID: versionID})
+		if err == nil && content.Type.IsDir() {
+			err = probe.NewError(fmt.Errorf("`%s` is not an object", clnt.GetURL().String()))
+		}
+		if err != nil {
+			errorIf(err.Trace(clnt.GetURL().String()), "Unable to get metadata of `"+clnt.GetURL().String()+"`.")
+			cErr = exitStatus(globalErrorExitStatus)
+			return
+		}
+		printMsg(metaListMessage{
+			Status:    "success",
+			URL:       clnt.GetURL().String(),
+			VersionID: versionID,
+			Metadata:  getEditableMetadata(content),
+		})
+	})
+	return cErr
+}
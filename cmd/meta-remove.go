@@ -0,0 +1,104 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/console"
+)
+
+var metaRemoveFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "version-id, vid",
+		Usage: "remove the metadata starting from a specific object version",
+	},
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "remove the metadata of all objects under a prefix",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show the changes without applying them",
+	},
+}
+
+var metaRemoveCmd = cli.Command{
+	Name:         "rm",
+	Usage:        "remove metadata of existing object(s)",
+	Action:       mainMetaRemove,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(metaRemoveFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [COMMAND FLAGS] TARGET KEY [KEY...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Remove metadata entries of existing objects, keeping the other entries. Objects
+  without the entries are left unchanged, the others are rewritten like with
+  'mc meta set'.
+
+EXAMPLES:
+  1. Remove the Content-Disposition header of an object.
+     {{.Prompt}} {{.HelpName}} myminio/testbucket/report.pdf Content-Disposition
+
+  2. Remove the user metadata 'owner' and 'reviewed' of all objects under a prefix.
+     {{.Prompt}} {{.HelpName}} --recursive myminio/testbucket/reports/ owner reviewed
+`,
+}
+
+func parseMetaRemoveSyntax(ctx *cli.Context) (targetURL, versionID string, recursive, dryRun bool, keys []string) {
+	args := ctx.Args()
+	if len(args) < 2 {
+		showCommandHelpAndExit(ctx, "rm", globalErrorExitStatus)
+	}
+
+	targetURL = args.Get(0)
+	versionID = ctx.String("version-id")
+	recursive = ctx.Bool("recursive")
+	dryRun = ctx.Bool("dry-run")
+	if versionID != "" && recursive {
+		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --recursive flags at the same time")
+	}
+
+	for _, arg := range args.Tail() {
+		key, err := metaKey(arg)
+		fatalIf(err.Trace(arg), "Unable to parse metadata key.")
+		keys = append(keys, key)
+	}
+	return
+}
+
+func mainMetaRemove(cliCtx *cli.Context) error {
+	ctx, cancelMetaRemove := context.WithCancel(globalContext)
+	defer cancelMetaRemove()
+
+	console.SetColor("Name", color.New(color.FgGreen))
+	console.SetColor("Change", color.New(color.FgYellow))
+
+	targetURL, versionID, recursive, dryRun, keys := parseMetaRemoveSyntax(cliCtx)
+	return runMetaUpdate(ctx, targetURL, versionID, recursive, dryRun, removeMetadata(keys))
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var metaSetFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "version-id, vid",
+		Usage: "change the metadata starting from a specific object version",
+	},
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "change the metadata of all objects under a prefix",
+	},
+	cli.StringFlag{
+		Name:  "storage-class, sc",
+		Usage: "change the storage class of object(s)",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show the changes without applying them",
+	},
+}
+
+var metaSetCmd = cli.Command{
+	Name:         "set",
+	Usage:        "set metadata of existing object(s)",
+	Action:       mainMetaSet,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(metaSetFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [COMMAND FLAGS] TARGET [METADATA]
+
+METADATA:
+  Entries formatted like "key1=value1;key2=value2". Cache-Control, Content-Disposition,
+  Content-Encoding, Content-Language, Content-Type, Expires and X-Amz-Website-Redirect-Location
+  are set as headers, other keys are set as user metadata.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Set metadata entries of existing objects, keeping the other entries. Objects are
+  rewritten onto themselves with a server side copy, their tags, retention, legal hold
+  and encryption are kept. On versioned buckets a new version is created, older
+  versions are unchanged. With --version-id the new version is copied from the
+  specified version.
+
+EXAMPLES:
+  1. Set the Cache-Control header of an object.
+     {{.Prompt}} {{.HelpName}} myminio/testbucket/index.html "Cache-Control=max-age=3600"
+
+  2. Set user metadata of all objects under a prefix, showing the changes first.
+     {{.Prompt}} {{.HelpName}} --recursive --dry-run myminio/testbucket/reports/ "owner=finance;reviewed=yes"
+     {{.Prompt}} {{.HelpName}} --recursive myminio/testbucket/reports/ "owner=finance;reviewed=yes"
+
+  3. Move all objects under a prefix to another storage class.
+     {{.Prompt}} {{.HelpName}} --recursive --storage-class REDUCED_REDUNDANCY myminio/testbucket/archive/
+`,
+}
+
+// parseMetadataEntries parses and normalizes entries formatted like
+// 'key1=value1;key2=value2'.
+func parseMetadataEntries(s string) (map[string]string, *probe.Error) {
+	entries, err := getMetaDataEntry(s)
+	if err != nil {
+		return nil, err.Trace(s)
+	}
+	metadata := make(map[string]string, len(entries))
+	for k, v := range entries {
+		key, err := metaKey(k)
+		if err != nil {
+			return nil, err.Trace(s)
+		}
+		metadata[key] = v
+	}
+	return metadata, nil
+}
+
+func parseMetaSetSyntax(ctx *cli.Context) (targetURL, versionID string, recursive, dryRun bool, mutate metaMutation) {
+	args := ctx.Args()
+	storageClass := strings.ToUpper(ctx.String("storage-class"))
+	if len(args) < 1 || len(args) > 2 || (len(args) == 1 && storageClass == "") {
+		showCommandHelpAndExit(ctx, "set", globalErrorExitStatus)
+	}
+
+	targetURL = args.Get(0)
+	versionID = ctx.String("version-id")
+	recursive = ctx.Bool("recursive")
+	dryRun = ctx.Bool("dry-run")
+	if versionID != "" && recursive {
+		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --recursive flags at the same time")
+	}
+
+	var mutations []metaMutation
+	if len(args) == 2 {
+		metadata, err := parseMetadataEntries(args.Get(1))
+		fatalIf(err, "Unable to parse metadata.")
+		mutations = append(mutations, setMetadata(metadata))
+	}
+	if storageClass != "" {
+		mutations = append(mutations, setStorageClass(storageClass))
+	}
+	mutate = chainMetadata(mutations...)
+	return
+}
+
+func mainMetaSet(cliCtx *cli.Context) error {
+	ctx, cancelMetaSet := context.WithCancel(globalContext)
+	defer cancelMetaSet()
+
+	console.SetColor("Name", color.New(color.FgGreen))
+	console.SetColor("Change", color.New(color.FgYellow))
+
+	targetURL, versionID, recursive, dryRun, mutate := parseMetaSetSyntax(cliCtx)
+	return runMetaUpdate(ctx, targetURL, versionID, recursive, dryRun, mutate)
+}
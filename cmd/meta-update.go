@@ -0,0 +1,316 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/tags"
+	"github.com/minio/pkg/console"
+	"golang.org/x/net/http/httpguts"
+)
+
+// Objects larger than this are copied with a multipart copy which does
+// not carry over the object tags.
+const metaMaxSingleCopySize = 5 << 30
+
+// metaStandardHeaders are the standard headers which can be changed with
+// 'mc meta', other keys are user metadata.
+var metaStandardHeaders = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Type",
+	"Expires",
+	"X-Amz-Website-Redirect-Location",
+}
+
+func isMetaStandardHeader(key string) bool {
+	for _, header := range metaStandardHeaders {
+		if header == key {
+			return true
+		}
+	}
+	return false
+}
+
+// metaKey returns the header of a metadata key, keys which are not
+// standard headers are user metadata and get the 'X-Amz-Meta-' prefix.
+func metaKey(key string) (string, *probe.Error) {
+	key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+	switch {
+	case key == "":
+		return "", probe.NewError(errors.New("empty metadata key"))
+	case !httpguts.ValidHeaderFieldName(key):
+		return "", probe.NewError(fmt.Errorf("`%s` is not a valid metadata key", key))
+	case isMetaStandardHeader(key), strings.HasPrefix(key, "X-Amz-Meta-") && key != "X-Amz-Meta-":
+		return key, nil
+	case strings.HasPrefix(key, "X-Amz-"):
+		return "", probe.NewError(fmt.Errorf("`%s` cannot be changed with 'mc meta'", key))
+	}
+	return "X-Amz-Meta-" + key, nil
+}
+
+// getEditableMetadata returns the standard headers, the user metadata
+// and the storage class of an object.
+func getEditableMetadata(content *ClientContent) map[string]string {
+	metadata := map[string]string{}
+	for k, v := range content.Metadata {
+		k = http.CanonicalHeaderKey(k)
+		if isMetaStandardHeader(k) || strings.HasPrefix(k, "X-Amz-Meta-") || k == "X-Amz-Storage-Class" {
+			metadata[k] = v
+		}
+	}
+	if !content.Expires.IsZero() {
+		metadata["Expires"] = content.Expires.UTC().Format(http.TimeFormat)
+	}
+	return metadata
+}
+
+// getObjectSSE returns the SSE-S3 or SSE-KMS settings of an object so a
+// copy is encrypted the same way.
+func getObjectSSE(content *ClientContent) encrypt.ServerSide {
+	metadata := map[string]string{}
+	for k, v := range content.Metadata {
+		metadata[http.CanonicalHeaderKey(k)] = v
+	}
+	switch metadata["X-Amz-Server-Side-Encryption"] {
+	case "AES256":
+		return encrypt.NewSSE()
+	case "aws:kms":
+		sse, e := encrypt.NewSSEKMS(metadata["X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"], nil)
+		if e == nil {
+			return sse
+		}
+	}
+	return nil
+}
+
+// metaMutation modifies the metadata of an object in place, name is the
+// object path.
+type metaMutation func(name string, metadata map[string]string)
+
+// setMetadata sets metadata entries, keys must be normalized with metaKey.
+func setMetadata(entries map[string]string) metaMutation {
+	return func(_ string, metadata map[string]string) {
+		for k, v := range entries {
+			metadata[k] = v
+		}
+	}
+}
+
+// removeMetadata removes metadata entries, missing keys are ignored.
+func removeMetadata(keys []string) metaMutation {
+	return func(_ string, metadata map[string]string) {
+		for _, k := range keys {
+			delete(metadata, k)
+		}
+	}
+}
+
+// setStorageClass changes the storage class.
+func setStorageClass(storageClass string) metaMutation {
+	return func(_ string, metadata map[string]string) {
+		metadata["X-Amz-Storage-Class"] = storageClass
+	}
+}
+
+// chainMetadata applies several mutations in order.
+func chainMetadata(mutations ...metaMutation) metaMutation {
+	return func(name string, metadata map[string]string) {
+		for _, mutate := range mutations {
+			mutate(name, metadata)
+		}
+	}
+}
+
+// metaUpdateMessage is the result of a metadata change.
+type metaUpdateMessage struct {
+	Status    string          `json:"status"`
+	Name      string          `json:"name"`
+	VersionID string          `json:"versionID,omitempty"`
+	DryRun    bool            `json:"dryRun,omitempty"`
+	Changes   []historyChange `json:"changes"`
+}
+
+// String colorized metadata change.
+func (m metaUpdateMessage) String() string {
+	name := m.Name
+	if m.VersionID != "" {
+		name += " (" + m.VersionID + ")"
+	}
+	var b strings.Builder
+	if m.DryRun {
+		b.WriteString(console.Colorize("Name", "Metadata to change for "+name+":"))
+	} else {
+		b.WriteString(console.Colorize("Name", "Metadata changed for "+name+":"))
+	}
+	for _, change := range m.Changes {
+		b.WriteString("\n  " + console.Colorize("Change", change.String()))
+	}
+	return b.String()
+}
+
+// JSON jsonified metadata change.
+func (m metaUpdateMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// updateMetadata applies a mutation to the metadata of an object version
+// and rewrites the object onto itself with a server side copy replacing
+// its metadata. The copy is made from the exact version and ETag read,
+// so a concurrent overwrite makes it fail instead of being reverted.
+// Retention, legal hold, encryption and tags are kept. On versioned
+// buckets the result is a new version, older versions are unchanged.
+// changed is false when the mutation has no effect.
+func updateMetadata(ctx context.Context, clnt Client, versionID string, mutate metaMutation, dryRun bool) (msg metaUpdateMessage, changed bool, err *probe.Error) {
+	content, err := clnt.Stat(ctx, StatOptions{versionID: versionID})
+	if err != nil {
+		return msg, false, err.Trace(clnt.GetURL().String())
+	}
+	if content.Type.IsDir() {
+		return msg, false, probe.NewError(fmt.Errorf("`%s` is not an object", clnt.GetURL().String()))
+	}
+
+	current := getEditableMetadata(content)
+	updated := make(map[string]string, len(current))
+	for k, v := range current {
+		updated[k] = v
+	}
+	mutate(content.URL.Path, updated)
+	if v, ok := updated["X-Amz-Storage-Class"]; ok && v == "" {
+		delete(updated, "X-Amz-Storage-Class")
+	}
+
+	msg = metaUpdateMessage{
+		Status:    "success",
+		Name:      clnt.GetURL().String(),
+		VersionID: versionID,
+		DryRun:    dryRun,
+		Changes:   diffStringMaps(current, updated),
+	}
+	if len(msg.Changes) == 0 || dryRun {
+		return msg, len(msg.Changes) > 0, nil
+	}
+
+	// Multipart copies lose the tags, they are set again after the copy.
+	var objectTags map[string]string
+	if content.Size > metaMaxSingleCopySize {
+		objectTags, err = clnt.GetTags(ctx, content.VersionID)
+		if err != nil && minio.ToErrorResponse(err.ToGoError()).Code != "NoSuchTagSet" {
+			return msg, false, err.Trace(clnt.GetURL().String())
+		}
+	}
+
+	opts := CopyOptions{
+		versionID:       content.VersionID,
+		size:            content.Size,
+		tgtSSE:          getObjectSSE(content),
+		metadata:        map[string]string{},
+		storageClass:    updated["X-Amz-Storage-Class"],
+		matchETag:       content.ETag,
+		replaceMetadata: true,
+	}
+	for k, v := range updated {
+		if k != "X-Amz-Storage-Class" {
+			opts.metadata[k] = v
+		}
+	}
+	for k, v := range content.Metadata {
+		switch k = http.CanonicalHeaderKey(k); k {
+		case AmzObjectLockMode, AmzObjectLockRetainUntilDate, AmzObjectLockLegalHold:
+			opts.metadata[k] = v
+		}
+	}
+	if err = clnt.Copy(ctx, content.URL.Path, opts, nil); err != nil {
+		return msg, false, err.Trace(clnt.GetURL().String())
+	}
+
+	if len(objectTags) > 0 {
+		t, e := tags.NewTags(objectTags, true)
+		if e != nil {
+			return msg, true, probe.NewError(e).Trace(clnt.GetURL().String())
+		}
+		if err = clnt.SetTags(ctx, "", t.String()); err != nil {
+			return msg, true, err.Trace(clnt.GetURL().String())
+		}
+	}
+	return msg, true, nil
+}
+
+// newMetaClient initializes the client of a 'mc meta' target, only S3
+// aliases are supported.
+func newMetaClient(targetURL string) Client {
+	clnt, err := newClient(targetURL)
+	fatalIf(err.Trace(targetURL), "Unable to initialize target `"+targetURL+"`.")
+	if clnt.GetURL().Type != objectStorage {
+		fatalIf(errInvalidArgument().Trace(targetURL), "Metadata can only be changed on S3 aliases.")
+	}
+	return clnt
+}
+
+// runMetaUpdate applies a mutation to the target, or to all objects
+// under it when recursive. Failures are reported and the remaining
+// objects are still processed.
+func runMetaUpdate(ctx context.Context, targetURL, versionID string, recursive, dryRun bool, mutate metaMutation) error {
+	clnt := newMetaClient(targetURL)
+
+	var cErr error
+	forEachObjectTarget(ctx, clnt, targetURL, versionID, time.Time{}, false, recursive, func(clnt Client, versionID string) {
+		msg, changed, err := updateMetadata(ctx, clnt, versionID, mutate, dryRun)
+		if err != nil {
+			errorIf(err, "Unable to change metadata of `"+clnt.GetURL().String()+"`.")
+			cErr = exitStatus(globalErrorExitStatus)
+			return
+		}
+		if changed {
+			printMsg(msg)
+		}
+	})
+	return cErr
+}
+
+// sortedMetadataKeys returns the keys of metadata in display order,
+// standard headers first.
+func sortedMetadataKeys(metadata map[string]string) []string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ui, uj := strings.HasPrefix(keys[i], "X-Amz-Meta-"), strings.HasPrefix(keys[j], "X-Amz-Meta-")
+		if ui != uj {
+			return uj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+func TestMetaKey(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+		fail     bool
+	}{
+		{"content-type", "Content-Type", false},
+		{" Cache-Control ", "Cache-Control", false},
+		{"owner", "X-Amz-Meta-Owner", false},
+		{"x-amz-meta-owner", "X-Amz-Meta-Owner", false},
+		{"x-amz-website-redirect-location", "X-Amz-Website-Redirect-Location", false},
+		{"x-amz-storage-class", "", true},
+		{"x-amz-meta-", "", true},
+		{"", "", true},
+		{"bad key", "", true},
+	}
+	for i, testCase := range testCases {
+		key, err := metaKey(testCase.key)
+		if testCase.fail != (err != nil) {
+			t.Fatalf("Test %d: expected failure %v, got %v", i+1, testCase.fail, err)
+		}
+		if key != testCase.expected {
+			t.Fatalf("Test %d: expected %q, got %q", i+1, testCase.expected, key)
+		}
+	}
+}
+
+func TestMetaMutations(t *testing.T) {
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	content := &ClientContent{
+		Metadata: map[string]string{
+			"Content-Type":                    "application/octet-stream",
+			"X-Amz-Meta-Owner":                "finance",
+			"X-Amz-Storage-Class":             "STANDARD",
+			"X-Amz-Tagging-Count":             "2",
+			"X-Amz-Object-Lock-Mode":          "GOVERNANCE",
+			"X-Amz-Server-Side-Encryption":    "AES256",
+			"x-amz-meta-reviewed":             "yes",
+			"X-Amz-Replication-Status":        "COMPLETED",
+			"X-Amz-Website-Redirect-Location": "/index.html",
+		},
+		Expires: expires,
+	}
+	metadata := getEditableMetadata(content)
+	expected := map[string]string{
+		"Content-Type":                    "application/octet-stream",
+		"X-Amz-Meta-Owner":                "finance",
+		"X-Amz-Meta-Reviewed":             "yes",
+		"X-Amz-Storage-Class":             "STANDARD",
+		"X-Amz-Website-Redirect-Location": "/index.html",
+		"Expires":                         "Fri, 02 Jan 2026 03:04:05 GMT",
+	}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatalf("expected %v, got %v", expected, metadata)
+	}
+	if sse := getObjectSSE(content); sse == nil || sse.Type() != encrypt.S3 {
+		t.Fatalf("expected SSE-S3 encryption, got %v", sse)
+	}
+
+	chainMetadata(
+		setMetadata(map[string]string{"Cache-Control": "no-cache", "X-Amz-Meta-Owner": "sales"}),
+		removeMetadata([]string{"X-Amz-Meta-Reviewed", "X-Amz-Meta-Missing"}),
+		setStorageClass("REDUCED_REDUNDANCY"),
+		fixContentType(false),
+	)("/bucket/report.pdf", metadata)
+	changes := diffStringMaps(expected, metadata)
+	expectedChanges := []historyChange{
+		{Name: "Cache-Control", New: "no-cache"},
+		{Name: "Content-Type", Old: "application/octet-stream", New: "application/pdf"},
+		{Name: "X-Amz-Meta-Owner", Old: "finance", New: "sales"},
+		{Name: "X-Amz-Meta-Reviewed", Old: "yes"},
+		{Name: "X-Amz-Storage-Class", Old: "STANDARD", New: "REDUCED_REDUNDANCY"},
+	}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Fatalf("expected %v, got %v", expectedChanges, changes)
+	}
+}
+
+func TestFixContentType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		current  string
+		all      bool
+		expected string
+	}{
+		{"index.html", "application/octet-stream", false, "text/html"},
+		{"index.html", "", false, "text/html"},
+		{"index.html", "text/plain", false, "text/plain"},
+		{"index.html", "text/plain", true, "text/html"},
+		{"data.unknown-ext", "application/octet-stream", true, "application/octet-stream"},
+		{"noext", "binary/octet-stream", true, "binary/octet-stream"},
+	}
+	for i, testCase := range testCases {
+		metadata := map[string]string{}
+		if testCase.current != "" {
+			metadata["Content-Type"] = testCase.current
+		}
+		fixContentType(testCase.all)("/bucket/"+testCase.name, metadata)
+		if metadata["Content-Type"] != testCase.expected {
+			t.Fatalf("Test %d: expected %q, got %q", i+1, testCase.expected, metadata["Content-Type"])
+		}
+	}
+}
+
+func TestSortedMetadataKeys(t *testing.T) {
+	keys := sortedMetadataKeys(map[string]string{
+		"X-Amz-Meta-B": "", "Content-Type": "", "X-Amz-Meta-A": "", "Cache-Control": "",
+	})
+	expected := []string{"Cache-Control", "Content-Type", "X-Amz-Meta-A", "X-Amz-Meta-B"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	until := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	var copyHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+		case http.MethodHead:
+			w.Header().Set("Content-Length", "11")
+			w.Header().Set("Last-Modified", UTCNow().Format(http.TimeFormat))
+			w.Header().Set("ETag", `"9af2f8218b150c351ad802c6f3d66abe"`)
+			w.Header().Set("x-amz-version-id", "v1")
+			w.Header().Set("Content-Type", "binary/octet-stream")
+			w.Header().Set("X-Amz-Meta-Owner", "finance")
+			w.Header().Set("X-Amz-Object-Lock-Mode", "GOVERNANCE")
+			w.Header().Set("X-Amz-Object-Lock-Retain-Until-Date", until)
+			w.Header().Set("X-Amz-Server-Side-Encryption", "AES256")
+			w.Header().Set("X-Amz-Tagging-Count", "1")
+		case http.MethodPut:
+			copyHeader = r.Header.Clone()
+			w.Write([]byte(`<CopyObjectResult><ETag>"9af2f8218b150c351ad802c6f3d66abe"</ETag><LastModified>2026-01-02T03:04:05.000Z</LastModified></CopyObjectResult>`))
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	conf := new(Config)
+	conf.HostURL = server.URL + "/bucket/index.html"
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	s3c, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	msg, changed, err := updateMetadata(ctx, s3c, "", fixContentType(false), true)
+	if err != nil || !changed || !msg.DryRun || copyHeader != nil {
+		t.Fatalf("unexpected dry run result %v %v %v %v", msg, changed, err, copyHeader)
+	}
+	if _, changed, err = updateMetadata(ctx, s3c, "", removeMetadata([]string{"X-Amz-Meta-Missing"}), false); err != nil || changed || copyHeader != nil {
+		t.Fatalf("expected no change, got %v %v %v", changed, err, copyHeader)
+	}
+
+	if _, changed, err = updateMetadata(ctx, s3c, "", fixContentType(false), false); err != nil || !changed {
+		t.Fatalf("expected a change, got %v %v", changed, err)
+	}
+	expected := map[string]string{
+		"X-Amz-Copy-Source":                   "bucket/index.html?versionId=v1",
+		"X-Amz-Copy-Source-If-Match":          "9af2f8218b150c351ad802c6f3d66abe",
+		"X-Amz-Metadata-Directive":            "REPLACE",
+		"Content-Type":                        "text/html",
+		"X-Amz-Meta-Owner":                    "finance",
+		"X-Amz-Object-Lock-Mode":              "GOVERNANCE",
+		"X-Amz-Object-Lock-Retain-Until-Date": until,
+		"X-Amz-Server-Side-Encryption":        "AES256",
+		"X-Amz-Tagging-Directive":             "",
+	}
+	for k, v := range expected {
+		if got := copyHeader.Get(k); got != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got)
+		}
+	}
+}
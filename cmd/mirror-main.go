@@ -101,6 +101,10 @@ var (
 			Name:  "exclude",
 			Usage: "exclude object(s) that match specified object name pattern",
 		},
+		cli.StringFlag{
+			Name:  "match-tags",
+			Usage: "mirror only objects with tags matching an expression, e.g. 'project=x && tier!=cold'",
+		},
 		cli.StringFlag{
 			Name:  "older-than",
 			Usage: "filter object(s) older than value in duration string (e.g. 7d10h31s)",
@@ -202,6 +206,9 @@ EXAMPLES:
   16. Cross mirror between sites in a active-active deployment.
       Site-A: {{.Prompt}} {{.HelpName}} --active-active siteA siteB
       Site-B: {{.Prompt}} {{.HelpName}} --active-active siteB siteA
+
+  17. Mirror the objects of project 'x' which are not tagged with tier 'cold'.
+      {{.Prompt}} {{.HelpName}} --match-tags "project=x && tier!=cold" minio/data s3/archive
 `,
 }
 
@@ -468,7 +475,7 @@ func (mj *mirrorJob) doMirror(ctx context.Context, sURLs URLs) URLs {
 	sURLs.DisableMultipart = mj.opts.disableMultipart
 
 	now := time.Now()
-	ret := uploadSourceToTargetURL(ctx, sURLs, mj.status, mj.opts.encKeyDB, mj.opts.isMetadata, false)
+	ret := uploadWithRetry(ctx, sURLs, mj.status, mj.opts.encKeyDB, mj.opts.isMetadata, false)
 	if ret.Error == nil {
 		durationMs := time.Since(now) / time.Millisecond
 		mirrorReplicationDurations.With(prometheus.Labels{"object_size": convertSizeToTag(sURLs.SourceContent.Size)}).Observe(float64(durationMs))
@@ -607,6 +614,16 @@ func (mj *mirrorJob) watchMirrorEvents(ctx context.Context, events []EventInfo)
 				// to avoid copying it.
 				continue
 			}
+			if mj.opts.tags != nil {
+				match, err := mj.opts.tags.match(ctx, sourceAlias, mirrorURL.SourceContent)
+				if err != nil {
+					mj.statusCh <- mirrorURL.WithError(err.Trace(eventPath))
+					continue
+				}
+				if !match {
+					continue
+				}
+			}
 			mj.parallel.queueTask(func() URLs {
 				return mj.doMirrorWatch(ctx, targetPath, tgtSSE, mirrorURL)
 			}, mirrorURL.SourceContent.Size)
@@ -712,6 +729,16 @@ func (mj *mirrorJob) startMirror(ctx context.Context) {
 				if isNewer(sURLs.SourceContent.Time, mj.opts.newerThan) {
 					continue
 				}
+				if mj.opts.tags != nil {
+					match, err := mj.opts.tags.match(ctx, sURLs.SourceAlias, sURLs.SourceContent)
+					if err != nil {
+						mj.statusCh <- sURLs.WithError(err.Trace(sURLs.SourceContent.URL.String()))
+						continue
+					}
+					if !match {
+						continue
+					}
+				}
 			}
 
 			if sURLs.SourceContent != nil {
@@ -862,6 +889,9 @@ func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dst
 		fatalIf(err, "Unable to parse attribute %v", cli.String("attr"))
 	}
 
+	tags, err := newTagFilter(cli.String("match-tags"))
+	fatalIf(err, "Unable to parse --match-tags.")
+
 	srcClt, err := newClient(srcURL)
 	fatalIf(err, "Unable to initialize `"+srcURL+"`.")
 
@@ -895,6 +925,7 @@ func runMirror(ctx context.Context, cancelMirror context.CancelFunc, srcURL, dst
 		newerThan:        cli.String("newer-than"),
 		storageClass:     cli.String("storage-class"),
 		userMetadata:     userMetadata,
+		tags:             tags,
 		encKeyDB:         encKeyDB,
 		activeActive:     isWatch,
 	}
@@ -51,7 +51,7 @@ func checkMirrorSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[st
 		errorIf(errInvalidArgument().Trace(URLs...), "`--force` is deprecated, please use `--overwrite` instead for the same functionality.")
 	}
 
-	_, expandedSourcePath, _ := mustExpandAlias(srcURL)
+	srcAlias, expandedSourcePath, _ := mustExpandAlias(srcURL)
 	srcClient := newClientURL(expandedSourcePath)
 	_, expandedTargetPath, _ := mustExpandAlias(tgtURL)
 	destClient := newClientURL(expandedTargetPath)
@@ -64,6 +64,10 @@ func checkMirrorSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[st
 		}
 	}
 
+	if cliCtx.String("match-tags") != "" && srcAlias == "" {
+		fatalIf(errInvalidArgument().Trace(srcURL), "Tag filters are only supported on S3 aliases.")
+	}
+
 	/****** Generic rules *******/
 	if !cliCtx.Bool("watch") && !cliCtx.Bool("active-active") && !cliCtx.Bool("multi-master") {
 		_, srcContent, err := url2Stat(ctx, srcURL, "", false, encKeyDB, time.Time{}, false)
@@ -211,6 +215,7 @@ type mirrorOptions struct {
 	olderThan, newerThan              string
 	storageClass                      string
 	userMetadata                      map[string]string
+	tags                              *tagFilter
 }
 
 // Prepares urls that need to be copied or removed based on requested options.
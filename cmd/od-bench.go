@@ -0,0 +1,606 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// odSizeDist is the distribution of object sizes written by a benchmark,
+// either a fixed size, a uniform range or a weighted list of sizes.
+type odSizeDist struct {
+	spec     string
+	min, max int64
+	sizes    []int64
+	weights  []int
+	total    int
+}
+
+// parseOdSizeDist parses "1MiB", "64KiB-4MiB" or "4KiB:70,1MiB:25,64MiB:5".
+func parseOdSizeDist(spec string) (*odSizeDist, error) {
+	d := &odSizeDist{spec: spec}
+	parseSize := func(s string) (int64, error) {
+		n, e := humanize.ParseBytes(strings.TrimSpace(s))
+		if e != nil {
+			return 0, fmt.Errorf("invalid size '%s'", s)
+		}
+		if n == 0 {
+			return 0, fmt.Errorf("size '%s' must be greater than zero", s)
+		}
+		return int64(n), nil
+	}
+
+	if lo, hi, ok := strings.Cut(spec, "-"); ok && !strings.Contains(spec, ",") {
+		var e error
+		if d.min, e = parseSize(lo); e != nil {
+			return nil, e
+		}
+		if d.max, e = parseSize(hi); e != nil {
+			return nil, e
+		}
+		if d.min > d.max {
+			return nil, fmt.Errorf("invalid size range '%s'", spec)
+		}
+		return d, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		s, w, ok := strings.Cut(entry, ":")
+		size, e := parseSize(s)
+		if e != nil {
+			return nil, e
+		}
+		weight := 1
+		if ok {
+			if weight, e = strconv.Atoi(strings.TrimSpace(w)); e != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid weight '%s' for size '%s'", w, s)
+			}
+		}
+		d.sizes = append(d.sizes, size)
+		d.weights = append(d.weights, weight)
+		d.total += weight
+	}
+	return d, nil
+}
+
+// pick returns a random size from the distribution.
+func (d *odSizeDist) pick(rnd *rand.Rand) int64 {
+	if len(d.sizes) == 0 {
+		if d.max == d.min {
+			return d.min
+		}
+		return d.min + rnd.Int63n(d.max-d.min+1)
+	}
+	n := rnd.Intn(d.total)
+	for i, w := range d.weights {
+		if n < w {
+			return d.sizes[i]
+		}
+		n -= w
+	}
+	return d.sizes[len(d.sizes)-1]
+}
+
+// largest returns the largest size of the distribution.
+func (d *odSizeDist) largest() int64 {
+	largest := d.max
+	for _, s := range d.sizes {
+		if s > largest {
+			largest = s
+		}
+	}
+	return largest
+}
+
+// odBenchConfig holds the operands of a benchmark run.
+type odBenchConfig struct {
+	target      string
+	concurrency int
+	duration    time.Duration
+	readPct     int
+	sizes       *odSizeDist
+	objects     int
+	cleanup     bool
+	seed        int64
+}
+
+// parseOdBenchConfig reads the benchmark operands.
+func parseOdBenchConfig(args argKVS) (cfg odBenchConfig, e error) {
+	cfg = odBenchConfig{
+		target:      args.Get("bench"),
+		concurrency: 4,
+		duration:    30 * time.Second,
+		readPct:     50,
+		cleanup:     true,
+		seed:        time.Now().UnixNano(),
+	}
+	if v := args.Get("concurrent"); v != "" {
+		if cfg.concurrency, e = strconv.Atoi(v); e != nil || cfg.concurrency < 1 {
+			return cfg, fmt.Errorf("invalid concurrent=%s, must be at least 1", v)
+		}
+	}
+	if v := args.Get("duration"); v != "" {
+		if cfg.duration, e = time.ParseDuration(v); e != nil || cfg.duration <= 0 {
+			return cfg, fmt.Errorf("invalid duration=%s", v)
+		}
+	}
+	if v := args.Get("ratio"); v != "" {
+		r, w, ok := strings.Cut(v, ":")
+		reads, e1 := strconv.Atoi(r)
+		writes, e2 := strconv.Atoi(w)
+		if !ok || e1 != nil || e2 != nil || reads < 0 || writes < 0 || reads+writes == 0 {
+			return cfg, fmt.Errorf("invalid ratio=%s, expected READ:WRITE such as 70:30", v)
+		}
+		cfg.readPct = reads * 100 / (reads + writes)
+	}
+	size := args.Get("size")
+	if size == "" {
+		size = "1MiB"
+	}
+	if cfg.sizes, e = parseOdSizeDist(size); e != nil {
+		return cfg, e
+	}
+	cfg.objects = cfg.concurrency * 4
+	if v := args.Get("objects"); v != "" {
+		if cfg.objects, e = strconv.Atoi(v); e != nil || cfg.objects < 1 {
+			return cfg, fmt.Errorf("invalid objects=%s, must be at least 1", v)
+		}
+	}
+	if v := args.Get("cleanup"); v != "" {
+		if cfg.cleanup, e = strconv.ParseBool(v); e != nil {
+			return cfg, fmt.Errorf("invalid cleanup=%s", v)
+		}
+	}
+	if v := args.Get("seed"); v != "" {
+		if cfg.seed, e = strconv.ParseInt(v, 10, 64); e != nil {
+			return cfg, fmt.Errorf("invalid seed=%s", v)
+		}
+	}
+	return cfg, nil
+}
+
+// odPatternReader endlessly repeats a buffer of random data.
+type odPatternReader struct {
+	buf []byte
+	off int
+}
+
+func (r *odPatternReader) Read(p []byte) (int, error) {
+	n := copy(p, r.buf[r.off:])
+	r.off = (r.off + n) % len(r.buf)
+	return n, nil
+}
+
+// odTTFBReader records the time the first byte was read.
+type odTTFBReader struct {
+	io.Reader
+	start time.Time
+	ttfb  time.Duration
+}
+
+func (r *odTTFBReader) Read(p []byte) (int, error) {
+	n, e := r.Reader.Read(p)
+	if n > 0 && r.ttfb == 0 {
+		r.ttfb = time.Since(r.start)
+	}
+	return n, e
+}
+
+// odOpStats collects the samples of one operation type.
+type odOpStats struct {
+	mu         sync.Mutex
+	bytes      int64
+	errors     int64
+	firstError string
+	latencies  []time.Duration
+	ttfbs      []time.Duration
+}
+
+func (s *odOpStats) record(size int64, latency, ttfb time.Duration, err *probe.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if s.errors == 0 {
+			s.firstError = err.ToGoError().Error()
+		}
+		s.errors++
+		return
+	}
+	s.bytes += size
+	s.latencies = append(s.latencies, latency)
+	if ttfb > 0 {
+		s.ttfbs = append(s.ttfbs, ttfb)
+	}
+}
+
+// odPercentile returns the p-th percentile of sorted samples.
+func odPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// odBenchLatency holds latency statistics in milliseconds.
+type odBenchLatency struct {
+	Avg float64 `json:"avgMs"`
+	P50 float64 `json:"p50Ms"`
+	P90 float64 `json:"p90Ms"`
+	P99 float64 `json:"p99Ms"`
+	Max float64 `json:"maxMs"`
+}
+
+func newOdBenchLatency(samples []time.Duration) *odBenchLatency {
+	if len(samples) == 0 {
+		return nil
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return &odBenchLatency{
+		Avg: ms(sum / time.Duration(len(sorted))),
+		P50: ms(odPercentile(sorted, 0.50)),
+		P90: ms(odPercentile(sorted, 0.90)),
+		P99: ms(odPercentile(sorted, 0.99)),
+		Max: ms(sorted[len(sorted)-1]),
+	}
+}
+
+func (l *odBenchLatency) String() string {
+	return fmt.Sprintf("avg %.2fms, p50 %.2fms, p90 %.2fms, p99 %.2fms, max %.2fms", l.Avg, l.P50, l.P90, l.P99, l.Max)
+}
+
+// odBenchOpResult summarizes one operation type.
+type odBenchOpResult struct {
+	Operations  int64           `json:"operations"`
+	Errors      int64           `json:"errors"`
+	FirstError  string          `json:"firstError,omitempty"`
+	Bytes       int64           `json:"bytes"`
+	OpsPerSec   float64         `json:"opsPerSec"`
+	BytesPerSec float64         `json:"bytesPerSec"`
+	Latency     *odBenchLatency `json:"latency,omitempty"`
+	TTFB        *odBenchLatency `json:"ttfb,omitempty"`
+}
+
+func (s *odOpStats) result(elapsed time.Duration) *odBenchOpResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.latencies) == 0 && s.errors == 0 {
+		return nil
+	}
+	ops := int64(len(s.latencies))
+	return &odBenchOpResult{
+		Operations:  ops,
+		Errors:      s.errors,
+		FirstError:  s.firstError,
+		Bytes:       s.bytes,
+		OpsPerSec:   float64(ops) / elapsed.Seconds(),
+		BytesPerSec: float64(s.bytes) / elapsed.Seconds(),
+		Latency:     newOdBenchLatency(s.latencies),
+		TTFB:        newOdBenchLatency(s.ttfbs),
+	}
+}
+
+// odBenchMessage is the result of 'mc od bench=...'.
+type odBenchMessage struct {
+	Status      string           `json:"status"`
+	Type        string           `json:"type"`
+	Target      string           `json:"target"`
+	Concurrency int              `json:"concurrency"`
+	Duration    string           `json:"duration"`
+	Ratio       string           `json:"ratio"`
+	Sizes       string           `json:"sizes"`
+	Objects     int              `json:"objects"`
+	Seed        int64            `json:"seed"`
+	Elapsed     int64            `json:"elapsed"`
+	Read        *odBenchOpResult `json:"read,omitempty"`
+	Write       *odBenchOpResult `json:"write,omitempty"`
+}
+
+func (o odBenchMessage) String() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "Benchmark: %s, Concurrency: %d, Duration: %s, Read:Write: %s, Sizes: %s\n",
+		o.Target, o.Concurrency, o.Duration, o.Ratio, o.Sizes)
+	for _, op := range []struct {
+		name string
+		res  *odBenchOpResult
+	}{{"Read", o.Read}, {"Write", o.Write}} {
+		if op.res == nil {
+			continue
+		}
+		fmt.Fprintf(&s, "%s: %d ops, %.1f ops/s, %s/s, Errors: %d\n", op.name, op.res.Operations,
+			op.res.OpsPerSec, humanize.IBytes(uint64(op.res.BytesPerSec)), op.res.Errors)
+		if op.res.Latency != nil {
+			fmt.Fprintf(&s, "  Latency: %s\n", op.res.Latency)
+		}
+		if op.res.TTFB != nil {
+			fmt.Fprintf(&s, "  TTFB:    %s\n", op.res.TTFB)
+		}
+		if op.res.FirstError != "" {
+			fmt.Fprintf(&s, "  First error: %s\n", op.res.FirstError)
+		}
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+func (o odBenchMessage) JSON() string {
+	odMessageBytes, e := json.MarshalIndent(o, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(odMessageBytes)
+}
+
+// odBench runs a benchmark against cfg.target.
+type odBench struct {
+	cfg         odBenchConfig
+	alias       string
+	targetURL   string
+	prefix      string
+	data        []byte
+	read, write odOpStats
+
+	mu   sync.Mutex
+	keys []string
+	seq  int64
+}
+
+func newOdBench(cfg odBenchConfig) (*odBench, *probe.Error) {
+	alias, targetURL, _, err := expandAlias(cfg.target)
+	if err != nil {
+		return nil, err.Trace(cfg.target)
+	}
+	rnd := rand.New(rand.NewSource(cfg.seed))
+	dataSize := cfg.sizes.largest()
+	if dataSize > 4<<20 {
+		dataSize = 4 << 20
+	}
+	data := make([]byte, dataSize)
+	rnd.Read(data)
+	return &odBench{
+		cfg:       cfg,
+		alias:     alias,
+		targetURL: strings.TrimSuffix(targetURL, "/"),
+		prefix:    fmt.Sprintf("od-bench-%x-", cfg.seed&0xffffffff),
+		data:      data,
+	}, nil
+}
+
+func (b *odBench) objectURL(key string) string {
+	return b.targetURL + "/" + key
+}
+
+func (b *odBench) newKey() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	return b.prefix + strconv.FormatInt(b.seq, 10)
+}
+
+func (b *odBench) addKey(key string) {
+	b.mu.Lock()
+	b.keys = append(b.keys, key)
+	b.mu.Unlock()
+}
+
+func (b *odBench) randomKey(rnd *rand.Rand) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.keys) == 0 {
+		return ""
+	}
+	return b.keys[rnd.Intn(len(b.keys))]
+}
+
+// put uploads a new object, returning its key and latency.
+func (b *odBench) put(ctx context.Context, rnd *rand.Rand) (string, int64, time.Duration, *probe.Error) {
+	key := b.newKey()
+	size := b.cfg.sizes.pick(rnd)
+	clnt, err := newClientFromAlias(b.alias, b.objectURL(key))
+	if err != nil {
+		return key, 0, 0, err
+	}
+	reader := io.LimitReader(&odPatternReader{buf: b.data, off: rnd.Intn(len(b.data))}, size)
+	start := time.Now()
+	n, err := clnt.PutPart(ctx, reader, size, nil, PutOptions{})
+	return key, n, time.Since(start), err
+}
+
+// get downloads an object, returning the bytes read, latency and time
+// to first byte.
+func (b *odBench) get(ctx context.Context, key string) (int64, time.Duration, time.Duration, *probe.Error) {
+	clnt, err := newClientFromAlias(b.alias, b.objectURL(key))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	start := time.Now()
+	reader, err := clnt.GetPart(ctx, 0)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer reader.Close()
+	r := &odTTFBReader{Reader: reader, start: start}
+	n, e := io.Copy(io.Discard, r)
+	if e != nil {
+		return n, 0, 0, probe.NewError(e)
+	}
+	return n, time.Since(start), r.ttfb, nil
+}
+
+// prepare uploads the objects read by the benchmark.
+func (b *odBench) prepare(ctx context.Context) *probe.Error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var perr *probe.Error
+	work := make(chan int)
+	for w := 0; w < b.cfg.concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(b.cfg.seed + int64(w) + 1))
+			for range work {
+				key, _, _, err := b.put(ctx, rnd)
+				if err != nil {
+					once.Do(func() { perr = err.Trace(b.objectURL(key)) })
+					continue
+				}
+				b.addKey(key)
+			}
+		}(w)
+	}
+	for i := 0; i < b.cfg.objects; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return perr
+}
+
+// run executes operations until the duration has passed.
+func (b *odBench) run(ctx context.Context) time.Duration {
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.duration)
+	defer cancel()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < b.cfg.concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(b.cfg.seed + int64(b.cfg.concurrency+w) + 1))
+			for ctx.Err() == nil {
+				key := ""
+				if rnd.Intn(100) < b.cfg.readPct {
+					key = b.randomKey(rnd)
+				}
+				if key != "" {
+					n, latency, ttfb, err := b.get(ctx, key)
+					// Operations interrupted by the end of the run are not counted.
+					if ctx.Err() == nil {
+						b.read.record(n, latency, ttfb, err)
+					}
+					continue
+				}
+				key, n, latency, err := b.put(ctx, rnd)
+				if err == nil {
+					b.addKey(key)
+				}
+				if ctx.Err() == nil {
+					b.write.record(n, latency, 0, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// cleanup removes all objects written by the benchmark.
+func (b *odBench) cleanup(ctx context.Context) *probe.Error {
+	clnt, err := newClientFromAlias(b.alias, b.targetURL)
+	if err != nil {
+		return err.Trace(b.targetURL)
+	}
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		// Every key ever used is removed, failed uploads may leave
+		// objects behind as well.
+		b.mu.Lock()
+		last := b.seq
+		b.mu.Unlock()
+		for seq := int64(1); seq <= last; seq++ {
+			key := b.prefix + strconv.FormatInt(seq, 10)
+			select {
+			case contentCh <- &ClientContent{URL: *newClientURL(b.objectURL(key))}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var rerr *probe.Error
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil && rerr == nil {
+			switch result.Err.ToGoError().(type) {
+			case ObjectMissing, PathNotFound:
+			default:
+				rerr = result.Err
+			}
+		}
+	}
+	return rerr
+}
+
+// odBenchmark runs the benchmark mode of 'mc od'.
+func odBenchmark(ctx context.Context, args argKVS) (message, error) {
+	cfg, e := parseOdBenchConfig(args)
+	if e != nil {
+		return nil, e
+	}
+	b, err := newOdBench(cfg)
+	if err != nil {
+		return nil, err.ToGoError()
+	}
+	if cfg.cleanup {
+		defer func() {
+			errorIf(b.cleanup(context.Background()), "Unable to remove benchmark objects.")
+		}()
+	}
+
+	if cfg.readPct > 0 {
+		if err = b.prepare(ctx); err != nil {
+			return nil, err.ToGoError()
+		}
+	}
+	elapsed := b.run(ctx)
+
+	return odBenchMessage{
+		Status:      "success",
+		Type:        "benchmark",
+		Target:      cfg.target,
+		Concurrency: cfg.concurrency,
+		Duration:    cfg.duration.String(),
+		Ratio:       fmt.Sprintf("%d:%d", cfg.readPct, 100-cfg.readPct),
+		Sizes:       cfg.sizes.spec,
+		Objects:     cfg.objects,
+		Seed:        cfg.seed,
+		Elapsed:     elapsed.Milliseconds(),
+		Read:        b.read.result(elapsed),
+		Write:       b.write.result(elapsed),
+	}, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestParseOdSizeDist(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	testCases := []struct {
+		spec     string
+		min, max int64
+		fail     bool
+	}{
+		{"1MiB", 1 << 20, 1 << 20, false},
+		{"4KiB-64KiB", 4 << 10, 64 << 10, false},
+		{"4KiB:70,1MiB:30", 4 << 10, 1 << 20, false},
+		{"1KiB,2KiB", 1 << 10, 2 << 10, false},
+		{"64KiB-4KiB", 0, 0, true},
+		{"0", 0, 0, true},
+		{"1MiB:x", 0, 0, true},
+		{"abc", 0, 0, true},
+	}
+	for i, tc := range testCases {
+		d, err := parseOdSizeDist(tc.spec)
+		if (err != nil) != tc.fail {
+			t.Fatalf("case %d: %s: unexpected error state %v", i+1, tc.spec, err)
+		}
+		if tc.fail {
+			continue
+		}
+		for n := 0; n < 100; n++ {
+			if size := d.pick(rnd); size < tc.min || size > tc.max {
+				t.Errorf("case %d: %s: size %d out of range", i+1, tc.spec, size)
+			}
+		}
+	}
+
+	// Weights are honored.
+	d, _ := parseOdSizeDist("1KiB:90,2KiB:10")
+	var small int
+	for n := 0; n < 1000; n++ {
+		if d.pick(rnd) == 1<<10 {
+			small++
+		}
+	}
+	if small < 850 || small > 950 {
+		t.Errorf("expected about 900 small sizes, got %d", small)
+	}
+}
+
+func TestParseOdBenchConfig(t *testing.T) {
+	var args argKVS
+	args.Set("bench", "play/bucket")
+	args.Set("ratio", "70:30")
+	args.Set("concurrent", "8")
+	cfg, err := parseOdBenchConfig(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.readPct != 70 || cfg.concurrency != 8 || cfg.objects != 32 || cfg.duration != 30*time.Second || !cfg.cleanup {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for _, kv := range [][2]string{
+		{"ratio", "70"}, {"ratio", "0:0"}, {"concurrent", "0"}, {"duration", "-1s"},
+		{"objects", "x"}, {"cleanup", "maybe"}, {"size", "1MiB-"},
+	} {
+		var args argKVS
+		args.Set("bench", "play/bucket")
+		args.Set(kv[0], kv[1])
+		if _, err := parseOdBenchConfig(args); err == nil {
+			t.Errorf("%s=%s: expected an error", kv[0], kv[1])
+		}
+	}
+}
+
+func TestOdPercentile(t *testing.T) {
+	var samples []time.Duration
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	if p := odPercentile(samples, 0.5); p != 50*time.Millisecond {
+		t.Errorf("expected p50 of 50ms, got %s", p)
+	}
+	if p := odPercentile(samples, 0.99); p != 99*time.Millisecond {
+		t.Errorf("expected p99 of 99ms, got %s", p)
+	}
+	l := newOdBenchLatency(samples)
+	if l.Avg != 50.5 || l.Max != 100 {
+		t.Errorf("unexpected latency %+v", l)
+	}
+	if odPercentile(nil, 0.5) != 0 || newOdBenchLatency(nil) != nil {
+		t.Error("expected empty results without samples")
+	}
+}
+
+func TestOdBenchmarkFS(t *testing.T) {
+	// Local paths match no alias of an empty configuration.
+	saved := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) { return newMcConfig(), nil }
+	defer func() { loadMcConfig = saved }()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "bench")
+	if err := os.Mkdir(target, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	var args argKVS
+	args.Set("bench", target)
+	args.Set("duration", "300ms")
+	args.Set("concurrent", "2")
+	args.Set("size", "1KiB-8KiB")
+	args.Set("ratio", "50:50")
+	msg, err := odBenchmark(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := msg.(odBenchMessage)
+	if res.Read == nil || res.Read.Operations == 0 || res.Write == nil || res.Write.Operations == 0 {
+		t.Fatalf("expected reads and writes, got %+v", res)
+	}
+	if res.Read.Errors != 0 || res.Write.Errors != 0 {
+		t.Errorf("unexpected errors %s %s", res.Read.FirstError, res.Write.FirstError)
+	}
+	if res.Read.TTFB == nil || res.Read.Latency.P50 > res.Read.Latency.Max {
+		t.Errorf("unexpected read latency %+v", res.Read)
+	}
+
+	entries, _ := os.ReadDir(target)
+	if len(entries) != 0 {
+		t.Errorf("expected benchmark objects to be removed, found %d", len(entries))
+	}
+}
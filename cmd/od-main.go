@@ -33,7 +33,7 @@ import (
 // make a bucket.
 var odCmd = cli.Command{
 	Name:         "od",
-	Usage:        "measure single stream upload and download",
+	Usage:        "measure single stream upload and download, or benchmark transfers",
 	Action:       mainOD,
 	Before:       setGlobalsFromContext,
 	OnUsageError: onUsageError,
@@ -50,6 +50,17 @@ OPERANDS:
   size=      size of each part. If not specified, will be calculated from the source stream size.
   parts=     number of parts to upload. If not specified, will calculated from the source file size.
   skip=      number of parts to skip.
+
+BENCHMARK OPERANDS:
+  bench=       target prefix to run a benchmark against, enables the benchmark mode.
+  concurrent=  number of concurrent operations (default: 4).
+  duration=    duration of the benchmark (default: 30s).
+  ratio=       ratio of reads to writes as READ:WRITE (default: 50:50).
+  size=        object sizes, a fixed size, a range such as 64KiB-4MiB or weighted
+               sizes such as 4KiB:70,1MiB:25,64MiB:5 (default: 1MiB).
+  objects=     number of objects uploaded before the benchmark to be read (default: 4 x concurrent).
+  cleanup=     remove the benchmark objects when done (default: true).
+  seed=        seed of the random sizes and operations, to repeat a run.
 {{if .VisibleFlags}}
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -63,6 +74,13 @@ EXAMPLES:
 
   3. Upload a full file to a bucket in 5 parts.
       {{.HelpName}} if=file.txt of=play/my-bucket/file.txt parts=5
+
+  4. Benchmark a bucket for one minute with 16 concurrent operations, 70% reads and
+     object sizes between 64KiB and 4MiB, saving the results as JSON.
+      {{.HelpName}} bench=play/my-bucket/bench concurrent=16 duration=1m ratio=70:30 size=64KiB-4MiB --json > run1.json
+
+  5. Benchmark writes of mixed object sizes to a local disk.
+      {{.HelpName}} bench=/mnt/data/bench ratio=0:100 size=4KiB:70,1MiB:25,64MiB:5 duration=30s
 `,
 }
 
@@ -172,6 +190,13 @@ func mainOD(cliCtx *cli.Context) error {
 		kvsArgs.Set(kv[0], kv[1])
 	}
 
+	if kvsArgs.Get("bench") != "" {
+		message, e := odBenchmark(ctx, kvsArgs)
+		fatalIf(probe.NewError(e), "Unable to run benchmark")
+		printMsg(message)
+		return nil
+	}
+
 	// Get content from source.
 	odURLs, e := getOdUrls(ctx, kvsArgs)
 	fatalIf(probe.NewError(e), "Unable to get source and target URLs")
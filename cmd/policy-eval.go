@@ -0,0 +1,212 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/bucket/policy"
+	"github.com/minio/pkg/bucket/policy/condition"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+// policyRequest describes a request evaluated against policies.
+type policyRequest struct {
+	AccountName string              `json:"account,omitempty"`
+	Groups      []string            `json:"groups,omitempty"`
+	Action      string              `json:"action"`
+	BucketName  string              `json:"bucket,omitempty"`
+	ObjectName  string              `json:"object,omitempty"`
+	Conditions  map[string][]string `json:"conditions,omitempty"`
+}
+
+// policyStatementMatch identifies a statement which matched a request.
+type policyStatementMatch struct {
+	Policy    string `json:"policy"`
+	Statement int    `json:"statement"`
+	SID       string `json:"sid,omitempty"`
+	Effect    string `json:"effect"`
+}
+
+func (m policyStatementMatch) String() string {
+	s := fmt.Sprintf("statement #%d", m.Statement)
+	if m.SID != "" {
+		s += " (" + m.SID + ")"
+	}
+	return s + " of `" + m.Policy + "`"
+}
+
+// policyDecision is the outcome of a policy evaluation, an explicit
+// deny overrides any allow, no match at all is an implicit deny.
+type policyDecision struct {
+	Allowed bool                   `json:"allowed"`
+	Denied  bool                   `json:"explicitDeny"`
+	Matches []policyStatementMatch `json:"matches,omitempty"`
+}
+
+// decidingStatement returns the statement which decided the request.
+func (d policyDecision) decidingStatement() (policyStatementMatch, bool) {
+	for _, m := range d.Matches {
+		if d.Denied && m.Effect == string(policy.Deny) {
+			return m, true
+		}
+		if !d.Denied && d.Allowed && m.Effect == string(policy.Allow) {
+			return m, true
+		}
+	}
+	return policyStatementMatch{}, false
+}
+
+// merge combines decisions of policies evaluated for the same request.
+func (d policyDecision) merge(o policyDecision) policyDecision {
+	d.Denied = d.Denied || o.Denied
+	d.Allowed = !d.Denied && (d.Allowed || o.Allowed)
+	d.Matches = append(d.Matches, o.Matches...)
+	return d
+}
+
+// namedIAMPolicy is an IAM policy along with where it came from.
+type namedIAMPolicy struct {
+	Name   string
+	Policy *iampolicy.Policy
+}
+
+// evaluateIAMPolicies evaluates a request against IAM policies and
+// reports every statement matching the request.
+func evaluateIAMPolicies(policies []namedIAMPolicy, req policyRequest) policyDecision {
+	args := iampolicy.Args{
+		AccountName:     req.AccountName,
+		Groups:          req.Groups,
+		Action:          iampolicy.Action(req.Action),
+		BucketName:      req.BucketName,
+		ObjectName:      req.ObjectName,
+		ConditionValues: req.Conditions,
+	}
+
+	var d policyDecision
+	for _, p := range policies {
+		for i, st := range p.Policy.Statements {
+			// Statement.IsAllowed returns false for a matching deny statement.
+			allowed := st.IsAllowed(args)
+			if (st.Effect == policy.Deny && allowed) || (st.Effect == policy.Allow && !allowed) {
+				continue
+			}
+			d.Matches = append(d.Matches, policyStatementMatch{
+				Policy:    p.Name,
+				Statement: i + 1,
+				SID:       string(st.SID),
+				Effect:    string(st.Effect),
+			})
+			if st.Effect == policy.Deny {
+				d.Denied = true
+			} else {
+				d.Allowed = true
+			}
+		}
+	}
+	d.Allowed = d.Allowed && !d.Denied
+	return d
+}
+
+// evaluateBucketPolicy evaluates a request against a bucket policy,
+// an empty account name is evaluated as an anonymous request.
+func evaluateBucketPolicy(name string, p *policy.Policy, req policyRequest) policyDecision {
+	args := policy.Args{
+		AccountName:     req.AccountName,
+		Groups:          req.Groups,
+		Action:          policy.Action(req.Action),
+		BucketName:      req.BucketName,
+		ObjectName:      req.ObjectName,
+		ConditionValues: req.Conditions,
+	}
+	if args.AccountName == "" {
+		args.AccountName = "*"
+	}
+
+	var d policyDecision
+	for i, st := range p.Statements {
+		allowed := st.IsAllowed(args)
+		if (st.Effect == policy.Deny && allowed) || (st.Effect == policy.Allow && !allowed) {
+			continue
+		}
+		d.Matches = append(d.Matches, policyStatementMatch{
+			Policy:    name,
+			Statement: i + 1,
+			SID:       string(st.SID),
+			Effect:    string(st.Effect),
+		})
+		if st.Effect == policy.Deny {
+			d.Denied = true
+		} else {
+			d.Allowed = true
+		}
+	}
+	d.Allowed = d.Allowed && !d.Denied
+	return d
+}
+
+// parsePolicyResource splits a resource of the form
+// [arn:aws:s3:::]bucket[/object] into bucket and object names.
+func parsePolicyResource(resource string) (bucket, object string) {
+	resource = strings.TrimPrefix(resource, iampolicy.ResourceARNPrefix)
+	resource = strings.TrimPrefix(resource, "/")
+	bucket, object, _ = strings.Cut(resource, "/")
+	return bucket, object
+}
+
+// newPolicyConditionValues builds the condition values of a simulated
+// request. The values are keyed like the server does, i.e. with the
+// `aws:`, `s3:`, `jwt:` and `ldap:` prefixes removed.
+func newPolicyConditionValues(account, sourceIP string, kvs []string) (map[string][]string, *probe.Error) {
+	now := time.Now().UTC()
+	values := map[string][]string{
+		condition.AWSCurrentTime.Name():     {now.Format(time.RFC3339)},
+		condition.AWSEpochTime.Name():       {strconv.FormatInt(now.Unix(), 10)},
+		condition.AWSSecureTransport.Name(): {"true"},
+	}
+	if account != "" {
+		values[condition.AWSUsername.Name()] = []string{account}
+		values[condition.AWSUserID.Name()] = []string{account}
+	}
+	if sourceIP != "" {
+		if net.ParseIP(sourceIP) == nil {
+			return nil, probe.NewError(fmt.Errorf("invalid source IP `%s`", sourceIP))
+		}
+		values[condition.AWSSourceIP.Name()] = []string{sourceIP}
+	}
+	// Explicit values replace the defaults above.
+	explicit := map[string]bool{}
+	for _, kv := range kvs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, probe.NewError(fmt.Errorf("invalid condition `%s`, expected key=value", kv))
+		}
+		name := condition.KeyName(k).Name()
+		if !explicit[name] {
+			explicit[name] = true
+			values[name] = nil
+		}
+		values[name] = append(values[name], v)
+	}
+	return values, nil
+}
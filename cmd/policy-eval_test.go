@@ -0,0 +1,125 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/minio/pkg/bucket/policy"
+	iampolicy "github.com/minio/pkg/iam/policy"
+)
+
+const testIAMPolicy = `{
+ "Version": "2012-10-17",
+ "Statement": [
+  {
+   "Sid": "ReadAll",
+   "Effect": "Allow",
+   "Action": ["s3:GetObject"],
+   "Resource": ["arn:aws:s3:::mybucket/*"]
+  },
+  {
+   "Sid": "NoSecrets",
+   "Effect": "Deny",
+   "Action": ["s3:*"],
+   "Resource": ["arn:aws:s3:::mybucket/secret/*"]
+  },
+  {
+   "Sid": "UploadFromOffice",
+   "Effect": "Allow",
+   "Action": ["s3:PutObject"],
+   "Resource": ["arn:aws:s3:::mybucket/uploads/*"],
+   "Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/8"}}
+  }
+ ]
+}`
+
+const testBucketPolicy = `{
+ "Version": "2012-10-17",
+ "Statement": [
+  {
+   "Effect": "Allow",
+   "Principal": {"AWS": ["*"]},
+   "Action": ["s3:GetObject"],
+   "Resource": ["arn:aws:s3:::mybucket/public/*"]
+  }
+ ]
+}`
+
+func TestEvaluateIAMPolicies(t *testing.T) {
+	p, e := iampolicy.ParseConfig(strings.NewReader(testIAMPolicy))
+	if e != nil {
+		t.Fatal(e)
+	}
+	policies := []namedIAMPolicy{{Name: "test", Policy: p}}
+
+	testCases := []struct {
+		action, resource, sourceIP string
+		allowed, denied            bool
+		deciding                   string
+	}{
+		{"s3:GetObject", "mybucket/photos/a.jpg", "", true, false, "ReadAll"},
+		{"s3:GetObject", "arn:aws:s3:::mybucket/secret/key", "", false, true, "NoSecrets"},
+		{"s3:PutObject", "mybucket/uploads/a.txt", "10.1.2.3", true, false, "UploadFromOffice"},
+		{"s3:PutObject", "mybucket/uploads/a.txt", "192.168.1.1", false, false, ""},
+		{"s3:DeleteObject", "mybucket/photos/a.jpg", "", false, false, ""},
+	}
+	for i, testCase := range testCases {
+		conditions, err := newPolicyConditionValues("james", testCase.sourceIP, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bucket, object := parsePolicyResource(testCase.resource)
+		d := evaluateIAMPolicies(policies, policyRequest{
+			AccountName: "james",
+			Action:      testCase.action,
+			BucketName:  bucket,
+			ObjectName:  object,
+			Conditions:  conditions,
+		})
+		if d.Allowed != testCase.allowed || d.Denied != testCase.denied {
+			t.Errorf("Test %d: expected allowed=%v denied=%v, got %v %v", i+1, testCase.allowed, testCase.denied, d.Allowed, d.Denied)
+		}
+		m, ok := d.decidingStatement()
+		if ok != (testCase.deciding != "") || m.SID != testCase.deciding {
+			t.Errorf("Test %d: expected deciding statement `%s`, got `%s`", i+1, testCase.deciding, m.SID)
+		}
+	}
+}
+
+func TestEvaluateBucketPolicy(t *testing.T) {
+	p, e := policy.ParseConfig(strings.NewReader(testBucketPolicy), "mybucket")
+	if e != nil {
+		t.Fatal(e)
+	}
+	d := evaluateBucketPolicy("bucket", p, policyRequest{Action: "s3:GetObject", BucketName: "mybucket", ObjectName: "public/index.html"})
+	if !d.Allowed {
+		t.Errorf("expected anonymous download of a public object to be allowed")
+	}
+	d = evaluateBucketPolicy("bucket", p, policyRequest{Action: "s3:GetObject", BucketName: "mybucket", ObjectName: "private/data"})
+	if d.Allowed {
+		t.Errorf("expected anonymous download of a private object to be denied")
+	}
+	if merged := d.merge(policyDecision{Allowed: true}); !merged.Allowed {
+		t.Errorf("expected an allow in another policy to allow the request")
+	}
+	if merged := d.merge(policyDecision{Denied: true}); merged.Allowed || !merged.Denied {
+		t.Errorf("expected an explicit deny to override allows")
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/replication"
+	"github.com/minio/pkg/console"
+)
+
+var replicateCheckFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "canary",
+		Usage: "write a test object for each enabled rule and wait until it is replicated",
+	},
+	cli.StringFlag{
+		Name:  "timeout",
+		Usage: "maximum time to wait for the replication of a test object",
+		Value: "2m",
+	},
+}
+
+var replicateCheckCmd = cli.Command{
+	Name:         "check",
+	Usage:        "check server side replication configuration and remote targets",
+	Action:       mainReplicateCheck,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(globalFlags, replicateCheckFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Check the replication rules of a bucket for invalid rules, priority collisions,
+  overlapping rules and missing remote targets. Each remote target is checked to be
+  reachable and, when an alias is configured for its endpoint, to have versioning
+  enabled. With --canary a test object matching the filter of each enabled rule is
+  written and the replication latency is reported, test objects are removed afterwards.
+  --canary is refused on buckets with a COMPLIANCE default retention, test objects
+  locked by a GOVERNANCE default retention are removed bypassing it.
+
+EXAMPLES:
+  1. Check the replication configuration of bucket "mybucket" for alias "myminio".
+     {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Check the replication configuration and measure the replication latency of each rule.
+     {{.Prompt}} {{.HelpName}} --canary --timeout 5m myminio/mybucket
+`,
+}
+
+// replicateCheckMessage is a finding of 'mc replicate check'.
+type replicateCheckMessage struct {
+	Status string `json:"status"`
+	replicateCheckFinding
+}
+
+func (m replicateCheckMessage) String() string {
+	var subject []string
+	if m.RuleID != "" {
+		subject = append(subject, "rule `"+m.RuleID+"`")
+	}
+	if m.Target != "" {
+		subject = append(subject, "target `"+m.Target+"`")
+	}
+	msg := m.Message
+	if len(subject) > 0 {
+		msg = strings.Join(subject, ", ") + ": " + msg
+	}
+	switch m.Level {
+	case replicateCheckError:
+		return console.Colorize("CheckError", fmt.Sprintf("%-8s", "ERROR")) + msg
+	case replicateCheckWarning:
+		return console.Colorize("CheckWarning", fmt.Sprintf("%-8s", "WARNING")) + msg
+	}
+	return console.Colorize("CheckOK", fmt.Sprintf("%-8s", "OK")) + msg
+}
+
+func (m replicateCheckMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// replicateCanaryMessage is the result of the replication of a test object.
+type replicateCanaryMessage struct {
+	Status            string        `json:"status"`
+	RuleID            string        `json:"ruleID"`
+	Target            string        `json:"target"`
+	Object            string        `json:"object"`
+	ReplicationStatus string        `json:"replicationStatus"`
+	Latency           time.Duration `json:"latency"`
+	Error             string        `json:"error,omitempty"`
+}
+
+func (m replicateCanaryMessage) String() string {
+	subject := "rule `" + m.RuleID + "`, target `" + m.Target + "`: "
+	switch {
+	case m.Error != "":
+		return console.Colorize("CheckError", fmt.Sprintf("%-8s", "ERROR")) + subject + m.Error
+	case m.ReplicationStatus != "COMPLETED":
+		return console.Colorize("CheckError", fmt.Sprintf("%-8s", "ERROR")) + subject +
+			fmt.Sprintf("test object `%s` not replicated, status %s", m.Object, m.ReplicationStatus)
+	}
+	return console.Colorize("CheckOK", fmt.Sprintf("%-8s", "OK")) + subject +
+		fmt.Sprintf("test object replicated in %s", m.Latency.Round(time.Millisecond))
+}
+
+func (m replicateCanaryMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// checkReplicateCheckSyntax - validate all the passed arguments
+func checkReplicateCheckSyntax(ctx *cli.Context) time.Duration {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, "check", 1) // last argument is exit code
+	}
+	timeout, e := time.ParseDuration(ctx.String("timeout"))
+	if e != nil || timeout <= 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.String("timeout")), "Invalid --timeout value.")
+	}
+	return timeout
+}
+
+// findAliasForEndpoint returns the alias configured for a host:port
+// endpoint, or an empty string.
+func findAliasForEndpoint(endpoint string, secure bool) string {
+	mcCfg, err := loadMcConfig()
+	if err != nil {
+		return ""
+	}
+	scheme, port := "http", "80"
+	if secure {
+		scheme, port = "https", "443"
+	}
+	if _, _, e := net.SplitHostPort(endpoint); e != nil {
+		endpoint = net.JoinHostPort(endpoint, port)
+	}
+
+	aliases := make([]string, 0, len(mcCfg.Aliases))
+	for alias := range mcCfg.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		u, e := url.Parse(mcCfg.Aliases[alias].URL)
+		if e != nil || u.Scheme != scheme {
+			continue
+		}
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), port)
+		}
+		if strings.EqualFold(host, endpoint) {
+			return alias
+		}
+	}
+	return ""
+}
+
+// checkEndpointReachable sends a request to the liveness probe of an
+// endpoint, any response means the endpoint is reachable.
+func checkEndpointReachable(ctx context.Context, endpoint string, secure bool) error {
+	scheme := "http"
+	if secure {
+		scheme = "https"
+	}
+	req, e := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+endpoint+"/minio/health/live", nil)
+	if e != nil {
+		return e
+	}
+	resp, e := httpClient(10 * time.Second).Do(req)
+	if e != nil {
+		return e
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// checkReplicationTarget verifies that the bucket of a remote target is
+// reachable and versioned, and locked when the source bucket is.
+func checkReplicationTarget(ctx context.Context, target madmin.BucketTarget, sourceLocked bool) replicateCheckFinding {
+	finding := replicateCheckFinding{Level: replicateCheckError, Target: target.Arn}
+	alias := findAliasForEndpoint(target.Endpoint, target.Secure)
+	if alias == "" {
+		if e := checkEndpointReachable(ctx, target.Endpoint, target.Secure); e != nil {
+			finding.Message = fmt.Sprintf("endpoint `%s` is not reachable: %v", target.Endpoint, e)
+			return finding
+		}
+		finding.Level = replicateCheckWarning
+		finding.Message = fmt.Sprintf("endpoint `%s` is reachable, no alias is configured for it to check the versioning of bucket `%s`", target.Endpoint, target.TargetBucket)
+		return finding
+	}
+
+	targetURL := alias + "/" + target.TargetBucket
+	clnt, err := newClient(targetURL)
+	if err != nil {
+		finding.Message = fmt.Sprintf("unable to initialize `%s`: %v", targetURL, err.ToGoError())
+		return finding
+	}
+	versioning, err := clnt.GetVersion(ctx)
+	if err != nil {
+		finding.Message = fmt.Sprintf("unable to get the versioning of `%s`: %v", targetURL, err.ToGoError())
+		return finding
+	}
+	if versioning.Status != "Enabled" {
+		finding.Message = fmt.Sprintf("versioning is not enabled on `%s`", targetURL)
+		return finding
+	}
+	if sourceLocked {
+		locked, err := isBucketLockEnabled(ctx, targetURL)
+		if err != nil {
+			finding.Message = fmt.Sprintf("unable to get the object lock configuration of `%s`: %v", targetURL, err.ToGoError())
+			return finding
+		}
+		if !locked {
+			finding.Message = fmt.Sprintf("object locking is enabled on the source bucket but not on `%s`", targetURL)
+			return finding
+		}
+	}
+	finding.Level = replicateCheckOK
+	finding.Message = fmt.Sprintf("`%s` is reachable and versioned", targetURL)
+	return finding
+}
+
+// runReplicationCanary writes a test object matching the filter of a
+// rule and waits until its replication completes or fails. The test
+// object is removed afterwards, also from the target when deletes are
+// not replicated and an alias is configured for the target endpoint.
+// bypass removes test objects locked by a GOVERNANCE default retention.
+func runReplicationCanary(ctx context.Context, bucketURL string, rule replication.Rule, arn string, target *madmin.BucketTarget, timeout time.Duration, bypass bool) replicateCanaryMessage {
+	msg := replicateCanaryMessage{RuleID: rule.ID, Target: arn}
+	object := rule.Prefix() + "mc-replicate-check-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	msg.Object = object
+
+	clnt, err := newClient(bucketURL + "/" + object)
+	if err == nil {
+		if _, ok := unwrapClient(clnt).(*S3Client); !ok {
+			err = probe.NewError(errors.New("test objects can only be written to S3 aliases"))
+		}
+	}
+	if err != nil {
+		msg.Error = err.ToGoError().Error()
+		return msg
+	}
+	s3Clnt := unwrapClient(clnt).(*S3Client)
+
+	metadata := map[string]string{}
+	if t := rule.Tags(); t != "" {
+		metadata["X-Amz-Tagging"] = t
+	}
+	data := "mc replicate check\n"
+	ui, err := s3Clnt.putObject(ctx, strings.NewReader(data), int64(len(data)), nil, PutOptions{metadata: metadata})
+	if err != nil {
+		msg.Error = "unable to write test object: " + err.ToGoError().Error()
+		return msg
+	}
+	start := time.Now()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+poll:
+	for {
+		select {
+		case <-ctx.Done():
+			msg.Error = ctx.Err().Error()
+			break poll
+		case <-deadline.C:
+			break poll
+		case <-ticker.C:
+		}
+		content, err := s3Clnt.Stat(ctx, StatOptions{versionID: ui.VersionID})
+		if err != nil {
+			msg.Error = "unable to get test object status: " + err.ToGoError().Error()
+			break poll
+		}
+		msg.ReplicationStatus = content.ReplicationStatus
+		if content.ReplicationStatus == "COMPLETED" || content.ReplicationStatus == "FAILED" {
+			msg.Latency = time.Since(start)
+			break poll
+		}
+	}
+	if msg.ReplicationStatus == "" {
+		msg.ReplicationStatus = "PENDING"
+	}
+
+	removeVersion := func(clnt Client, versionID string) *probe.Error {
+		contentCh := make(chan *ClientContent, 1)
+		contentCh <- &ClientContent{URL: clnt.GetURL(), VersionID: versionID}
+		close(contentCh)
+		for result := range clnt.Remove(ctx, false, false, bypass, false, contentCh) {
+			if result.Err != nil {
+				return result.Err
+			}
+		}
+		return nil
+	}
+	if err = removeVersion(s3Clnt, ui.VersionID); err != nil {
+		errorIf(err.Trace(clnt.GetURL().String()), "Unable to remove test object.")
+	}
+	if msg.ReplicationStatus == "COMPLETED" && rule.DeleteReplication.Status != replication.Enabled && target != nil {
+		alias := findAliasForEndpoint(target.Endpoint, target.Secure)
+		if alias == "" {
+			errorIf(errDummy().Trace(arn), "Unable to remove test object `%s` from the target, no alias is configured for `%s`.", object, target.Endpoint)
+			return msg
+		}
+		replicaClnt, err := newClient(alias + "/" + target.TargetBucket + "/" + object)
+		if err == nil {
+			err = removeVersion(replicaClnt, ui.VersionID)
+		}
+		if err != nil {
+			errorIf(err.Trace(alias+"/"+target.TargetBucket+"/"+object), "Unable to remove test object from the target.")
+		}
+	}
+	return msg
+}
+
+func mainReplicateCheck(cliCtx *cli.Context) error {
+	ctx, cancelReplicateCheck := context.WithCancel(globalContext)
+	defer cancelReplicateCheck()
+
+	console.SetColor("CheckOK", color.New(color.Bold, color.FgGreen))
+	console.SetColor("CheckWarning", color.New(color.Bold, color.FgYellow))
+	console.SetColor("CheckError", color.New(color.Bold, color.FgRed))
+
+	timeout := checkReplicateCheckSyntax(cliCtx)
+
+	aliasedURL := cliCtx.Args().Get(0)
+	client, err := newClient(aliasedURL)
+	fatalIf(err, "Unable to initialize connection.")
+	rCfg, err := client.GetReplication(ctx)
+	fatalIf(err.Trace(aliasedURL), "Unable to get replication configuration")
+	if rCfg.Empty() {
+		fatalIf(probe.NewError(errors.New("replication configuration not set")).Trace(aliasedURL),
+			"Unable to check replication configuration")
+	}
+
+	var cErr error
+	report := func(finding replicateCheckFinding) {
+		if finding.Level == replicateCheckError {
+			cErr = exitStatus(globalErrorExitStatus)
+		}
+		printMsg(replicateCheckMessage{replicateCheckFinding: finding})
+	}
+
+	versioning, err := client.GetVersion(ctx)
+	fatalIf(err.Trace(aliasedURL), "Unable to get bucket versioning info")
+	if versioning.Status != "Enabled" {
+		report(replicateCheckFinding{Level: replicateCheckError, Message: "versioning is not enabled on the source bucket"})
+	}
+	sourceLocked, err := isBucketLockEnabled(ctx, aliasedURL)
+	fatalIf(err.Trace(aliasedURL), "Unable to get the object lock configuration")
+
+	// Remote targets are only known to MinIO, they are listed with the
+	// admin API and not checked when it is not available.
+	var targets map[string]madmin.BucketTarget
+	splits := splitStr(filepath.ToSlash(aliasedURL), "/", 3)
+	alias, bucket := splits[0], splits[1]
+	if bucket == "" {
+		fatalIf(errInvalidArgument(), "bucket not specified in `"+aliasedURL+"`.")
+	}
+	if adminClient, err := newAdminClient(aliasedURL); err == nil {
+		if bucketTargets, e := adminClient.ListRemoteTargets(ctx, bucket, string(madmin.ReplicationService)); e == nil {
+			targets = map[string]madmin.BucketTarget{}
+			for _, target := range bucketTargets {
+				targets[target.Arn] = target
+			}
+		} else {
+			report(replicateCheckFinding{Level: replicateCheckWarning, Message: fmt.Sprintf("unable to list remote targets, they are not checked: %v", e)})
+		}
+	}
+
+	for _, finding := range lintReplicationConfig(rCfg, targets) {
+		report(finding)
+	}
+
+	checked := map[string]bool{}
+	for _, rule := range rCfg.Rules {
+		arn := replicationRuleARN(rCfg, rule)
+		target, ok := targets[arn]
+		if !ok || checked[arn] {
+			continue
+		}
+		checked[arn] = true
+		report(checkReplicationTarget(ctx, target, sourceLocked))
+	}
+
+	if cliCtx.Bool("canary") {
+		// Test objects inherit the default retention of a locked bucket,
+		// they can only be removed when it can be bypassed.
+		var bypass bool
+		if sourceLocked {
+			_, mode, _, _, err := client.GetObjectLockConfig(ctx)
+			fatalIf(err.Trace(aliasedURL), "Unable to get the object lock configuration")
+			switch mode {
+			case minio.Compliance:
+				fatalIf(errInvalidArgument().Trace(aliasedURL),
+					"Unable to run --canary, test objects cannot be removed from a bucket with a COMPLIANCE default retention.")
+			case minio.Governance:
+				bypass = true
+				report(replicateCheckFinding{
+					Level:   replicateCheckWarning,
+					Message: "test objects are locked by the GOVERNANCE default retention of the bucket, they are removed bypassing it",
+				})
+			}
+		}
+		for _, rule := range rCfg.Rules {
+			if rule.Status != replication.Enabled {
+				continue
+			}
+			arn := replicationRuleARN(rCfg, rule)
+			var target *madmin.BucketTarget
+			if t, ok := targets[arn]; ok {
+				target = &t
+			}
+			msg := runReplicationCanary(ctx, alias+"/"+bucket, rule, arn, target, timeout, bypass)
+			if msg.Error != "" || msg.ReplicationStatus != "COMPLETED" {
+				cErr = exitStatus(globalErrorExitStatus)
+			}
+			printMsg(msg)
+		}
+	}
+	return cErr
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/replication"
+)
+
+func TestLintReplicationConfig(t *testing.T) {
+	const (
+		arn1 = "arn:minio:replication::1:target1"
+		arn2 = "arn:minio:replication::2:target2"
+		arn3 = "arn:minio:replication::3:missing"
+	)
+	rule := func(id string, priority int, arn, prefix string, tags ...replication.Tag) replication.Rule {
+		r := replication.Rule{ID: id, Priority: priority, Status: replication.Enabled, Destination: replication.Destination{Bucket: arn}}
+		r.Filter.And = replication.And{Prefix: prefix, Tags: tags}
+		return r
+	}
+	disabled := rule("disabled", 6, arn1, "")
+	disabled.Status = replication.Disabled
+	tagged := rule("tagged", 7, arn2, "", replication.Tag{Key: "a", Value: "1"})
+	tagged.DeleteMarkerReplication.Status = replication.Enabled
+
+	cfg := replication.Config{Rules: []replication.Rule{
+		rule("docs", 1, arn1, "docs/"),
+		rule("all", 2, arn1, ""),
+		rule("docs-other-target", 3, arn2, "docs/"),
+		rule("logs", 3, arn1, "logs/"),
+		rule("tag-a1", 4, arn2, "data/", replication.Tag{Key: "a", Value: "1"}),
+		rule("tag-a2", 5, arn2, "data/", replication.Tag{Key: "a", Value: "2"}),
+		rule("missing", 8, arn3, "missing/"),
+		disabled,
+		tagged,
+	}}
+	targets := map[string]madmin.BucketTarget{arn1: {Arn: arn1}, arn2: {Arn: arn2}}
+
+	var got []string
+	for _, finding := range lintReplicationConfig(cfg, targets) {
+		got = append(got, finding.Level+" "+finding.RuleID+": "+finding.Message)
+	}
+	expected := []string{
+		"error missing: remote target `" + arn3 + "` does not exist",
+		"warning tagged: delete markers have no tags, they never match the tag filter and are not replicated",
+		"warning disabled: rule is disabled",
+		"warning tag-a1: never applies, all its objects match rule `tagged` which has a higher priority for the same target",
+		"warning docs-other-target: overlaps with rule `tagged` which has different settings and a higher priority for the same target",
+		"error logs: priority 3 is also used by rule `docs-other-target`",
+		"warning docs: never applies, all its objects match rule `all` which has a higher priority for the same target",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	// Legacy configurations set the target ARN as role.
+	legacy := replication.Config{Role: arn3, Rules: []replication.Rule{rule("legacy", 1, "arn:aws:s3:::bucket", "")}}
+	findings := lintReplicationConfig(legacy, targets)
+	if len(findings) != 1 || findings[0].Target != arn3 {
+		t.Fatalf("unexpected findings %v", findings)
+	}
+	if findings = lintReplicationConfig(legacy, nil); len(findings) != 0 {
+		t.Fatalf("expected no finding without targets, got %v", findings)
+	}
+}
+
+func TestFindAliasForEndpoint(t *testing.T) {
+	savedConfig := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["site1"] = aliasConfigV10{URL: "https://site1.example.com"}
+		config.Aliases["site2"] = aliasConfigV10{URL: "http://site2.example.com:9000"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = savedConfig }()
+
+	testCases := []struct {
+		endpoint string
+		secure   bool
+		alias    string
+	}{
+		{"site1.example.com", true, "site1"},
+		{"site1.example.com:443", true, "site1"},
+		{"site1.example.com", false, ""},
+		{"site2.example.com:9000", false, "site2"},
+		{"site2.example.com", false, ""},
+	}
+	for i, testCase := range testCases {
+		if alias := findAliasForEndpoint(testCase.endpoint, testCase.secure); alias != testCase.alias {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.alias, alias)
+		}
+	}
+}
+
+// canaryHandler is a minimal S3 server reporting every object as
+// replicated after a few status checks.
+type canaryHandler struct {
+	mu       sync.Mutex
+	heads    int
+	put      http.Header
+	putPath  string
+	deletes  []string
+	bypass   []string
+	versions map[string]bool
+}
+
+func (h *canaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet:
+		w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+	case r.Method == http.MethodPut:
+		h.put, h.putPath = r.Header.Clone(), r.URL.Path
+		w.Header().Set("x-amz-version-id", "v1")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	case r.Method == http.MethodHead:
+		h.heads++
+		w.Header().Set("x-amz-version-id", r.URL.Query().Get("versionId"))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		w.Header().Set("X-Amz-Replication-Status", "PENDING")
+		if h.heads > 1 {
+			w.Header().Set("X-Amz-Replication-Status", "COMPLETED")
+		}
+	case r.Method == http.MethodPost || r.Method == http.MethodDelete:
+		h.deletes = append(h.deletes, r.URL.Path)
+		h.bypass = append(h.bypass, r.Header.Get("X-Amz-Bypass-Governance-Retention"))
+		w.Write([]byte(`<DeleteResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></DeleteResult>`))
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestReplicationCanary(t *testing.T) {
+	handler := &canaryHandler{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	savedConfig := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["source"] = aliasConfigV10{URL: server.URL, AccessKey: "minio", SecretKey: "minio123", API: "S3v4", Path: "on"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = savedConfig }()
+
+	rule := replication.Rule{ID: "docs", Priority: 1, Status: replication.Enabled}
+	rule.Filter.And = replication.And{Prefix: "docs/", Tags: []replication.Tag{{Key: "a", Value: "1"}}}
+	msg := runReplicationCanary(context.Background(), "source/bucket", rule, "arn", nil, time.Minute, false)
+	if msg.Error != "" || msg.ReplicationStatus != "COMPLETED" || msg.Latency <= 0 {
+		t.Fatalf("unexpected result %#v", msg)
+	}
+	if !strings.HasPrefix(handler.putPath, "/bucket/docs/mc-replicate-check-") || handler.put.Get("X-Amz-Tagging") != "a=1" {
+		t.Fatalf("unexpected test object %s %v", handler.putPath, handler.put)
+	}
+	if len(handler.deletes) != 1 || handler.bypass[0] != "" {
+		t.Fatalf("expected the test object to be removed, got %v", handler.deletes)
+	}
+
+	// Test objects locked by a GOVERNANCE retention are removed bypassing it.
+	handler.heads = 0
+	if msg = runReplicationCanary(context.Background(), "source/bucket", rule, "arn", nil, time.Minute, true); msg.Error != "" {
+		t.Fatalf("unexpected result %#v", msg)
+	}
+	if len(handler.deletes) != 2 || handler.bypass[1] != "true" {
+		t.Fatalf("expected the test object to be removed bypassing governance, got %v %v", handler.deletes, handler.bypass)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/minio/madmin-go"
+	"github.com/minio/minio-go/v7/pkg/replication"
+)
+
+// Levels of the findings of 'mc replicate check'.
+const (
+	replicateCheckOK      = "ok"
+	replicateCheckWarning = "warning"
+	replicateCheckError   = "error"
+)
+
+// replicateCheckFinding is a problem found in a replication
+// configuration or a successful verification.
+type replicateCheckFinding struct {
+	Level   string `json:"level"`
+	RuleID  string `json:"ruleID,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Message string `json:"message"`
+}
+
+// replicationRuleARN returns the remote target ARN of a rule, older
+// configurations set it as the role of the whole configuration.
+func replicationRuleARN(cfg replication.Config, rule replication.Rule) string {
+	if cfg.Role != "" && !strings.HasPrefix(cfg.Role, "arn:aws:iam") {
+		return cfg.Role
+	}
+	return rule.Destination.Bucket
+}
+
+// replicationRuleTags returns the tags of a rule filter.
+func replicationRuleTags(rule replication.Rule) map[string]string {
+	tags := map[string]string{}
+	if !rule.Filter.Tag.IsEmpty() {
+		tags[rule.Filter.Tag.Key] = rule.Filter.Tag.Value
+	}
+	for _, tag := range rule.Filter.And.Tags {
+		tags[tag.Key] = tag.Value
+	}
+	return tags
+}
+
+// replicationFiltersOverlap reports whether an object can match the
+// filters of both rules: their prefixes overlap and no tag key is
+// required with different values.
+func replicationFiltersOverlap(a, b replication.Rule) bool {
+	pa, pb := a.Prefix(), b.Prefix()
+	if !strings.HasPrefix(pa, pb) && !strings.HasPrefix(pb, pa) {
+		return false
+	}
+	tb := replicationRuleTags(b)
+	for k, v := range replicationRuleTags(a) {
+		if w, ok := tb[k]; ok && w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// replicationFilterContains reports whether every object matching the
+// filter of inner also matches the filter of outer.
+func replicationFilterContains(outer, inner replication.Rule) bool {
+	if !strings.HasPrefix(inner.Prefix(), outer.Prefix()) {
+		return false
+	}
+	innerTags := replicationRuleTags(inner)
+	for k, v := range replicationRuleTags(outer) {
+		if w, ok := innerTags[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sameReplicationSettings reports whether two rules replicate objects
+// the same way.
+func sameReplicationSettings(a, b replication.Rule) bool {
+	return a.DeleteMarkerReplication.Status == b.DeleteMarkerReplication.Status &&
+		a.DeleteReplication.Status == b.DeleteReplication.Status &&
+		a.ExistingObjectReplication.Status == b.ExistingObjectReplication.Status &&
+		a.SourceSelectionCriteria.ReplicaModifications.Status == b.SourceSelectionCriteria.ReplicaModifications.Status &&
+		a.Destination.StorageClass == b.Destination.StorageClass
+}
+
+// lintReplicationConfig checks a replication configuration locally.
+// Rules are compared in decreasing priority as the rule with the highest
+// priority applies when several rules match an object. targets are the
+// remote targets of the bucket by ARN, the existence of the targets is
+// not checked when nil.
+func lintReplicationConfig(cfg replication.Config, targets map[string]madmin.BucketTarget) (findings []replicateCheckFinding) {
+	add := func(level string, rule replication.Rule, format string, args ...interface{}) {
+		findings = append(findings, replicateCheckFinding{
+			Level:   level,
+			RuleID:  rule.ID,
+			Target:  replicationRuleARN(cfg, rule),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	rules := make([]replication.Rule, len(cfg.Rules))
+	copy(rules, cfg.Rules)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority > rules[j].Priority })
+
+	for i, rule := range rules {
+		if e := rule.Validate(); e != nil {
+			add(replicateCheckError, rule, "invalid rule: %v", e)
+		}
+		if rule.Status == replication.Disabled {
+			add(replicateCheckWarning, rule, "rule is disabled")
+		}
+
+		arn := replicationRuleARN(cfg, rule)
+		switch {
+		case arn == "":
+			add(replicateCheckError, rule, "rule has no destination")
+		case targets != nil && strings.HasPrefix(arn, "arn:minio:replication"):
+			if _, ok := targets[arn]; !ok {
+				add(replicateCheckError, rule, "remote target `%s` does not exist", arn)
+			}
+		}
+
+		if rule.DeleteMarkerReplication.Status == replication.Enabled && len(replicationRuleTags(rule)) > 0 {
+			add(replicateCheckWarning, rule, "delete markers have no tags, they never match the tag filter and are not replicated")
+		}
+
+		for _, other := range rules[:i] {
+			if rule.ID != "" && other.ID == rule.ID {
+				add(replicateCheckError, rule, "ID is also used by another rule")
+			}
+			if other.Priority == rule.Priority {
+				add(replicateCheckError, rule, "priority %d is also used by rule `%s`", rule.Priority, other.ID)
+				continue
+			}
+			if other.Status != replication.Enabled || rule.Status != replication.Enabled || replicationRuleARN(cfg, other) != arn {
+				continue
+			}
+			switch {
+			case replicationFilterContains(other, rule):
+				add(replicateCheckWarning, rule, "never applies, all its objects match rule `%s` which has a higher priority for the same target", other.ID)
+			case replicationFiltersOverlap(rule, other) && !sameReplicationSettings(rule, other):
+				add(replicateCheckWarning, rule, "overlaps with rule `%s` which has different settings and a higher priority for the same target", other.ID)
+			}
+		}
+	}
+	return findings
+}
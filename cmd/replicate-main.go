@@ -29,6 +29,7 @@ var replicateSubcommands = []cli.Command{
 	replicateImportCmd,
 	replicateRemoveCmd,
 	replicateDiffCmd,
+	replicateCheckCmd,
 }
 
 var replicateCmd = cli.Command{
@@ -37,12 +37,12 @@ var replicateStatusCmd = cli.Command{
 	Action:       mainReplicateStatus,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        globalFlags,
+	Flags:        append(append(globalFlags, replicationWatchFlags...), replicationWatchSizeFlags...),
 	CustomHelpTemplate: `NAME:
    {{.HelpName}} - {{.Usage}}
 
 USAGE:
-   {{.HelpName}} TARGET
+   {{.HelpName}} [FLAGS] TARGET
 
 FLAGS:
    {{range .VisibleFlags}}{{.}}
@@ -50,6 +50,16 @@ FLAGS:
 EXAMPLES:
   1. Get server side replication metrics for bucket "mybucket" for alias "myminio".
        {{.Prompt}} {{.HelpName}} myminio/mybucket
+
+  2. Watch the replication backlog of "mybucket" and exit with status 2 when more than 10GiB are pending.
+       {{.Prompt}} {{.HelpName}} --watch --max-pending-size 10GiB --exit-on-alert myminio/mybucket
+
+  3. Poll every minute and post an alert to a webhook when replication fails or the backlog keeps growing.
+       {{.Prompt}} {{.HelpName}} --watch --interval 1m --max-failed-count 0 --max-pending-growth 50MiB \
+          --webhook https://alerts.example.com/replication myminio/mybucket
+
+  4. Run a script when an alert fires or resolves.
+       {{.Prompt}} {{.HelpName}} --watch --max-pending-count 10000 --exec "notify.sh {alert} {state} {target} {value}" myminio/mybucket
 `,
 }
 
@@ -195,6 +205,20 @@ func mainReplicateStatus(cliCtx *cli.Context) error {
 	// Create a new Client
 	client, err := newClient(aliasedURL)
 	fatalIf(err, "Unable to initialize connection.")
+
+	if cliCtx.Bool("watch") {
+		alerter, err := newReplicationAlerter(cliCtx, aliasedURL)
+		fatalIf(err, "Unable to parse the alert flags.")
+		return watchReplicationLag(ctx, aliasedURL, cliCtx.Duration("interval"), alerter, cliCtx.Bool("exit-on-alert"),
+			func(ctx context.Context) ([]replicationLag, *probe.Error) {
+				metrics, err := client.GetReplicationMetrics(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return bucketReplicationLags(metrics), nil
+			})
+	}
+
 	replicateStatus, err := client.GetReplicationMetrics(ctx)
 	fatalIf(err.Trace(args...), "Unable to get replication status")
 
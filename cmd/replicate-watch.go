@@ -0,0 +1,556 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/google/shlex"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/madmin-go"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/replication"
+	"github.com/minio/pkg/console"
+)
+
+// replicationAlertExitStatus is the exit status of --watch --exit-on-alert
+// when a threshold is crossed, distinct from the error exit status.
+const replicationAlertExitStatus = 2
+
+// replicationLagWindow is the number of samples the trends are computed on.
+const replicationLagWindow = 6
+
+// Alerts raised by the replication lag monitor.
+const (
+	replicationAlertPendingSize   = "pending-size"
+	replicationAlertPendingCount  = "pending-count"
+	replicationAlertFailedCount   = "failed-count"
+	replicationAlertPendingGrowth = "pending-growth"
+)
+
+// replicationWatchFlags are shared by 'replicate status' and 'admin replicate status'.
+var replicationWatchFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "watch, w",
+		Usage: "poll the replication status and report lag trends until interrupted",
+	},
+	cli.DurationFlag{
+		Name:  "interval",
+		Usage: "polling interval of --watch",
+		Value: 10 * time.Second,
+	},
+	cli.Uint64Flag{
+		Name:  "max-pending-count",
+		Usage: "alert when more operations than this are pending replication",
+	},
+	cli.StringFlag{
+		Name:  "exec",
+		Usage: "run a command when an alert fires or resolves, {alert}, {state}, {target}, {value}, {threshold} and {time} are substituted",
+	},
+	cli.StringFlag{
+		Name:  "webhook",
+		Usage: "POST alerts as JSON to this URL",
+	},
+	cli.StringFlag{
+		Name:  "webhook-token",
+		Usage: "value of the Authorization header sent to the webhook",
+	},
+	cli.BoolFlag{
+		Name:  "exit-on-alert",
+		Usage: "exit with status 2 as soon as an alert fires",
+	},
+}
+
+// replicationWatchSizeFlags only apply to bucket replication, site
+// replication status has no object metrics.
+var replicationWatchSizeFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "max-pending-size",
+		Usage: "alert when more than this size is pending replication, e.g. 10GiB",
+	},
+	cli.Uint64Flag{
+		Name:  "max-failed-count",
+		Usage: "alert when more operations than this failed over the last polls",
+	},
+	cli.StringFlag{
+		Name:  "max-pending-growth",
+		Usage: "alert when the pending size grows faster than this per second, e.g. 50MiB",
+	},
+}
+
+// replicationLag is the replication backlog of a target, along with
+// its trend over the last polls.
+type replicationLag struct {
+	Target            string  `json:"target"`
+	PendingSize       uint64  `json:"pendingSize"`
+	PendingCount      uint64  `json:"pendingCount"`
+	FailedSize        uint64  `json:"failedSize"`
+	FailedCount       uint64  `json:"failedCount"`
+	ReplicatedSize    uint64  `json:"replicatedSize"`
+	RecentFailedCount uint64  `json:"recentFailedCount"`
+	PendingSizeRate   float64 `json:"pendingSizeRate"`
+	PendingCountRate  float64 `json:"pendingCountRate"`
+	ReplicationRate   float64 `json:"replicationRate"`
+
+	// Site replication only reports entity counts.
+	countsOnly bool
+}
+
+// bucketReplicationLags returns the lag of all targets of a bucket, the
+// first entry being the summary across targets.
+func bucketReplicationLags(m replication.Metrics) []replicationLag {
+	lags := []replicationLag{{
+		Target:         "all",
+		PendingSize:    m.PendingSize,
+		PendingCount:   m.PendingCount,
+		FailedSize:     m.FailedSize,
+		FailedCount:    m.FailedCount,
+		ReplicatedSize: m.ReplicatedSize,
+	}}
+	arns := make([]string, 0, len(m.Stats))
+	for arn := range m.Stats {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+	for _, arn := range arns {
+		st := m.Stats[arn]
+		lags = append(lags, replicationLag{
+			Target:         arn,
+			PendingSize:    st.PendingSize,
+			PendingCount:   st.PendingCount,
+			FailedSize:     st.FailedSize,
+			FailedCount:    st.FailedCount,
+			ReplicatedSize: st.ReplicatedSize,
+		})
+	}
+	return lags
+}
+
+// siteReplicationLags returns, for every site, the number of buckets,
+// policies, users and groups not yet replicated to it.
+func siteReplicationLags(info madmin.SRStatusInfo) []replicationLag {
+	missing := func(max, replicated int) uint64 {
+		if max > replicated {
+			return uint64(max - replicated)
+		}
+		return 0
+	}
+	var lags []replicationLag
+	for dID, peer := range info.Sites {
+		ss := info.StatsSummary[dID]
+		name := peer.Name
+		if name == "" {
+			name = dID
+		}
+		lags = append(lags, replicationLag{
+			Target: name,
+			PendingCount: missing(info.MaxBuckets, ss.ReplicatedBuckets) +
+				missing(info.MaxPolicies, ss.ReplicatedIAMPolicies) +
+				missing(info.MaxUsers, ss.ReplicatedUsers) +
+				missing(info.MaxGroups, ss.ReplicatedGroups),
+			countsOnly: true,
+		})
+	}
+	sort.Slice(lags, func(i, j int) bool { return lags[i].Target < lags[j].Target })
+	return lags
+}
+
+type replicationLagSample struct {
+	time time.Time
+	lag  replicationLag
+}
+
+// replicationLagTracker keeps the last samples of every target to
+// compute the lag trends.
+type replicationLagTracker struct {
+	samples map[string][]replicationLagSample
+}
+
+func newReplicationLagTracker() *replicationLagTracker {
+	return &replicationLagTracker{samples: make(map[string][]replicationLagSample)}
+}
+
+// perSecond returns the rate of change between two counter values.
+func perSecond(newer, older uint64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return (float64(newer) - float64(older)) / elapsed.Seconds()
+}
+
+// update records the lags polled at now and fills their trends, comparing
+// them with the oldest sample still in the window. Counters going backwards,
+// as after a server restart, are not reported as negative throughput.
+func (t *replicationLagTracker) update(now time.Time, lags []replicationLag) []replicationLag {
+	seen := make(map[string]bool, len(lags))
+	for i := range lags {
+		lag := &lags[i]
+		seen[lag.Target] = true
+		samples := t.samples[lag.Target]
+		if len(samples) > 0 {
+			oldest := samples[0]
+			elapsed := now.Sub(oldest.time)
+			lag.PendingSizeRate = perSecond(lag.PendingSize, oldest.lag.PendingSize, elapsed)
+			lag.PendingCountRate = perSecond(lag.PendingCount, oldest.lag.PendingCount, elapsed)
+			if lag.ReplicatedSize > oldest.lag.ReplicatedSize {
+				lag.ReplicationRate = perSecond(lag.ReplicatedSize, oldest.lag.ReplicatedSize, elapsed)
+			}
+			if lag.FailedCount > oldest.lag.FailedCount {
+				lag.RecentFailedCount = lag.FailedCount - oldest.lag.FailedCount
+			}
+		}
+		samples = append(samples, replicationLagSample{time: now, lag: *lag})
+		if len(samples) > replicationLagWindow {
+			samples = samples[len(samples)-replicationLagWindow:]
+		}
+		t.samples[lag.Target] = samples
+	}
+	// Forget targets which were removed.
+	for target := range t.samples {
+		if !seen[target] {
+			delete(t.samples, target)
+		}
+	}
+	return lags
+}
+
+// replicationThreshold is an alert threshold set on the command line.
+type replicationThreshold struct {
+	alert string
+	limit float64
+}
+
+// value returns the lag value compared with the threshold.
+func (t replicationThreshold) value(lag replicationLag) float64 {
+	switch t.alert {
+	case replicationAlertPendingSize:
+		return float64(lag.PendingSize)
+	case replicationAlertPendingCount:
+		return float64(lag.PendingCount)
+	case replicationAlertFailedCount:
+		return float64(lag.RecentFailedCount)
+	case replicationAlertPendingGrowth:
+		return lag.PendingSizeRate
+	}
+	return 0
+}
+
+// formatReplicationAlertValue formats a value of an alert for humans.
+func formatReplicationAlertValue(alert string, v float64) string {
+	switch alert {
+	case replicationAlertPendingSize:
+		return humanize.IBytes(uint64(v))
+	case replicationAlertPendingGrowth:
+		if v < 0 {
+			return "-" + humanize.IBytes(uint64(-v)) + "/s"
+		}
+		return humanize.IBytes(uint64(v)) + "/s"
+	}
+	return humanize.Comma(int64(v))
+}
+
+// parseReplicationThresholds reads the alert thresholds of the command line.
+func parseReplicationThresholds(ctx *cli.Context) ([]replicationThreshold, *probe.Error) {
+	var thresholds []replicationThreshold
+	for _, name := range []string{"max-pending-size", "max-pending-growth"} {
+		if !ctx.IsSet(name) {
+			continue
+		}
+		size, e := humanize.ParseBytes(ctx.String(name))
+		if e != nil {
+			return nil, probe.NewError(e).Trace(name, ctx.String(name))
+		}
+		alert := replicationAlertPendingSize
+		if name == "max-pending-growth" {
+			alert = replicationAlertPendingGrowth
+		}
+		thresholds = append(thresholds, replicationThreshold{alert: alert, limit: float64(size)})
+	}
+	if ctx.IsSet("max-pending-count") {
+		thresholds = append(thresholds, replicationThreshold{
+			alert: replicationAlertPendingCount,
+			limit: float64(ctx.Uint64("max-pending-count")),
+		})
+	}
+	if ctx.IsSet("max-failed-count") {
+		thresholds = append(thresholds, replicationThreshold{
+			alert: replicationAlertFailedCount,
+			limit: float64(ctx.Uint64("max-failed-count")),
+		})
+	}
+	return thresholds, nil
+}
+
+// replicationAlertMessage is printed, and sent to the alert actions,
+// when a threshold is crossed and when the lag is back under it.
+type replicationAlertMessage struct {
+	Status    string    `json:"status"`
+	Time      time.Time `json:"time"`
+	URL       string    `json:"url"`
+	Alert     string    `json:"alert"`
+	State     string    `json:"state"`
+	Target    string    `json:"target"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+}
+
+func (m replicationAlertMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func (m replicationAlertMessage) String() string {
+	theme := "Failed"
+	if m.State == "resolved" {
+		theme = "Replicated"
+	}
+	return console.Colorize(theme, fmt.Sprintf("%s alert %s %s on `%s`: %s, threshold %s",
+		m.Time.Format(printDate), m.Alert, m.State, m.Target,
+		formatReplicationAlertValue(m.Alert, m.Value),
+		formatReplicationAlertValue(m.Alert, m.Threshold)))
+}
+
+// replicationAlerter tracks which alerts are firing so that they are
+// only reported when they fire and when they resolve, not on every poll.
+type replicationAlerter struct {
+	url        string
+	thresholds []replicationThreshold
+	execArgs   []string
+	webhook    *watchWebhookAction
+	firing     map[string]bool
+}
+
+func newReplicationAlerter(ctx *cli.Context, url string) (*replicationAlerter, *probe.Error) {
+	thresholds, err := parseReplicationThresholds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a := &replicationAlerter{
+		url:        url,
+		thresholds: thresholds,
+		firing:     make(map[string]bool),
+	}
+	if cmdline := ctx.String("exec"); cmdline != "" {
+		args, e := shlex.Split(cmdline)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(cmdline)
+		}
+		if len(args) == 0 {
+			return nil, errInvalidArgument().Trace(cmdline)
+		}
+		a.execArgs = args
+	}
+	if endpoint := ctx.String("webhook"); endpoint != "" {
+		a.webhook = newWatchWebhookAction(endpoint, ctx.String("webhook-token"))
+	}
+	return a, nil
+}
+
+// evaluate compares the lags with the thresholds and returns the alerts
+// which changed state.
+func (a *replicationAlerter) evaluate(now time.Time, lags []replicationLag) (alerts []replicationAlertMessage) {
+	for _, lag := range lags {
+		for _, t := range a.thresholds {
+			if lag.countsOnly && t.alert != replicationAlertPendingCount {
+				continue
+			}
+			key := t.alert + "\x00" + lag.Target
+			value := t.value(lag)
+			crossed := value > t.limit
+			if crossed == a.firing[key] {
+				continue
+			}
+			state := "firing"
+			if crossed {
+				a.firing[key] = true
+			} else {
+				delete(a.firing, key)
+				state = "resolved"
+			}
+			alerts = append(alerts, replicationAlertMessage{
+				Time:      now,
+				URL:       a.url,
+				Alert:     t.alert,
+				State:     state,
+				Target:    lag.Target,
+				Value:     value,
+				Threshold: t.limit,
+			})
+		}
+	}
+	return alerts
+}
+
+// notify runs the alert command and posts the alert to the webhook.
+func (a *replicationAlerter) notify(ctx context.Context, alert replicationAlertMessage) *probe.Error {
+	if len(a.execArgs) > 0 {
+		r := strings.NewReplacer(
+			"{alert}", alert.Alert,
+			"{state}", alert.State,
+			"{target}", alert.Target,
+			"{value}", strconv.FormatFloat(alert.Value, 'f', -1, 64),
+			"{threshold}", strconv.FormatFloat(alert.Threshold, 'f', -1, 64),
+			"{time}", alert.Time.Format(time.RFC3339),
+		)
+		args := make([]string, len(a.execArgs))
+		for i, arg := range a.execArgs {
+			args[i] = r.Replace(arg)
+		}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		var out, stderr bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		if e := cmd.Run(); e != nil {
+			if stderr.Len() > 0 {
+				e = fmt.Errorf("%v: %s", e, strings.TrimSpace(stderr.String()))
+			}
+			return probe.NewError(e).Trace(args...)
+		}
+		if out.Len() > 0 {
+			console.PrintC(out.String())
+		}
+	}
+	if a.webhook != nil {
+		alert.Status = "success"
+		body, e := json.Marshal(alert)
+		if e != nil {
+			return probe.NewError(e)
+		}
+		return a.webhook.send(ctx, body)
+	}
+	return nil
+}
+
+// replicationLagMessage is printed on every poll of --watch.
+type replicationLagMessage struct {
+	Status  string           `json:"status"`
+	Time    time.Time        `json:"time"`
+	URL     string           `json:"url"`
+	Targets []replicationLag `json:"targets"`
+}
+
+func (m replicationLagMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+var replicationLagTable = newPrettyTable(" | ",
+	Field{"Time", 8},
+	Field{"Target", 40},
+	Field{"Pending", 12},
+	Field{"PendingCount", 12},
+	Field{"Failed", 10},
+	Field{"Trend", 16},
+	Field{"Throughput", 14},
+)
+
+// replicationLagHeader is printed once before the rows of --watch.
+func replicationLagHeader() string {
+	return console.Colorize("TgtHeaders", replicationLagTable.buildRow(
+		"Time", "Target", "Pending", "Pending #", "Failed #", "Backlog trend", "Throughput"))
+}
+
+// replicationTrend shows whether the backlog grows or shrinks.
+func replicationTrend(lag replicationLag) string {
+	rate, format := lag.PendingSizeRate, func(v float64) string { return humanize.IBytes(uint64(v)) + "/s" }
+	if lag.countsOnly || (rate == 0 && lag.PendingCountRate != 0) {
+		rate, format = lag.PendingCountRate, func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) + " op/s" }
+	}
+	switch {
+	case rate > 0:
+		return "↑ " + format(rate)
+	case rate < 0:
+		return "↓ " + format(-rate)
+	}
+	return "="
+}
+
+func (m replicationLagMessage) String() string {
+	var rows []string
+	for _, lag := range m.Targets {
+		pending, failed, throughput := humanize.IBytes(lag.PendingSize), humanize.Comma(int64(lag.RecentFailedCount)), humanize.IBytes(uint64(lag.ReplicationRate))+"/s"
+		if lag.countsOnly {
+			pending, failed, throughput = "-", "-", "-"
+		}
+		theme := "Replica"
+		if lag.RecentFailedCount > 0 {
+			theme = "Failed"
+		}
+		rows = append(rows, console.Colorize(theme, replicationLagTable.buildRow(
+			m.Time.Format("15:04:05"), lag.Target, pending, humanize.Comma(int64(lag.PendingCount)),
+			failed, replicationTrend(lag), throughput)))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// watchReplicationLag polls fetch every interval, prints the lags with
+// their trends and raises the alerts, until the context is canceled or,
+// with exitOnAlert, an alert fires.
+func watchReplicationLag(ctx context.Context, url string, interval time.Duration, alerter *replicationAlerter, exitOnAlert bool, fetch func(context.Context) ([]replicationLag, *probe.Error)) error {
+	if interval <= 0 {
+		fatalIf(errInvalidArgument().Trace(interval.String()), "Polling interval must be positive.")
+	}
+	console.SetColor("TgtHeaders", color.New(color.Bold, color.FgCyan))
+	console.SetColor("Replica", color.New(color.FgCyan))
+	console.SetColor("Replicated", color.New(color.FgGreen))
+	console.SetColor("Failed", color.New(color.Bold, color.FgRed))
+	if !globalJSON {
+		console.Println(replicationLagHeader())
+	}
+	tracker := newReplicationLagTracker()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		lags, err := fetch(ctx)
+		if err != nil {
+			errorIf(err.Trace(url), "Unable to get replication status.")
+		} else {
+			now := time.Now().UTC()
+			lags = tracker.update(now, lags)
+			printMsg(replicationLagMessage{Time: now, URL: url, Targets: lags})
+			fired := false
+			for _, alert := range alerter.evaluate(now, lags) {
+				printMsg(alert)
+				errorIf(alerter.notify(ctx, alert).Trace(alert.Alert, alert.Target), "Unable to notify the replication alert.")
+				fired = fired || alert.State == "firing"
+			}
+			if fired && exitOnAlert {
+				return exitStatus(replicationAlertExitStatus)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
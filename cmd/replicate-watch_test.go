@@ -0,0 +1,158 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/minio/madmin-go"
+	"github.com/minio/minio-go/v7/pkg/replication"
+)
+
+func TestReplicationLagTracker(t *testing.T) {
+	tracker := newReplicationLagTracker()
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	metrics := func(pending, replicated, failed uint64) replication.Metrics {
+		return replication.Metrics{
+			PendingSize: pending, PendingCount: pending / 100, ReplicatedSize: replicated, FailedCount: failed,
+			Stats: map[string]replication.TargetMetrics{
+				"arn:minio:replication::1:target": {PendingSize: pending, ReplicatedSize: replicated, FailedCount: failed},
+			},
+		}
+	}
+
+	lags := tracker.update(start, bucketReplicationLags(metrics(1000, 0, 1)))
+	if len(lags) != 2 || lags[0].Target != "all" || lags[1].Target != "arn:minio:replication::1:target" {
+		t.Fatalf("unexpected targets %+v", lags)
+	}
+	if lags[0].PendingSizeRate != 0 || lags[0].RecentFailedCount != 0 {
+		t.Fatalf("first sample must not have a trend: %+v", lags[0])
+	}
+
+	lags = tracker.update(start.Add(10*time.Second), bucketReplicationLags(metrics(2000, 500, 3)))
+	if lags[0].PendingSizeRate != 100 || lags[0].PendingCountRate != 1 {
+		t.Errorf("expected a 100 B/s and 1 op/s growth, got %+v", lags[0])
+	}
+	if lags[0].ReplicationRate != 50 || lags[0].RecentFailedCount != 2 {
+		t.Errorf("expected a 50 B/s throughput and 2 recent failures, got %+v", lags[0])
+	}
+
+	// Counters reset by a server restart.
+	lags = tracker.update(start.Add(20*time.Second), bucketReplicationLags(metrics(0, 0, 0)))
+	if lags[0].PendingSizeRate != -50 || lags[0].ReplicationRate != 0 || lags[0].RecentFailedCount != 0 {
+		t.Errorf("unexpected trend after a reset %+v", lags[0])
+	}
+
+	// Trends are computed on a sliding window.
+	for i := 3; i < 20; i++ {
+		tracker.update(start.Add(time.Duration(i)*10*time.Second), bucketReplicationLags(metrics(0, 0, 0)))
+	}
+	if n := len(tracker.samples["all"]); n != replicationLagWindow {
+		t.Errorf("expected %d samples, got %d", replicationLagWindow, n)
+	}
+
+	// Removed targets are forgotten.
+	tracker.update(start.Add(time.Hour), bucketReplicationLags(replication.Metrics{}))
+	if _, ok := tracker.samples["arn:minio:replication::1:target"]; ok {
+		t.Error("samples of a removed target are kept")
+	}
+}
+
+func TestSiteReplicationLags(t *testing.T) {
+	info := madmin.SRStatusInfo{
+		Enabled:    true,
+		MaxBuckets: 10,
+		MaxUsers:   5,
+		MaxGroups:  2,
+		Sites: map[string]madmin.PeerInfo{
+			"id-b": {Name: "siteb"},
+			"id-a": {Name: "sitea"},
+		},
+		StatsSummary: map[string]madmin.SRSiteSummary{
+			"id-a": {ReplicatedBuckets: 10, ReplicatedUsers: 5, ReplicatedGroups: 2},
+			"id-b": {ReplicatedBuckets: 7, ReplicatedUsers: 4, ReplicatedGroups: 3},
+		},
+	}
+	lags := siteReplicationLags(info)
+	if len(lags) != 2 || lags[0].Target != "sitea" || lags[1].Target != "siteb" {
+		t.Fatalf("unexpected sites %+v", lags)
+	}
+	if lags[0].PendingCount != 0 || lags[1].PendingCount != 4 {
+		t.Errorf("expected 0 and 4 pending entities, got %d and %d", lags[0].PendingCount, lags[1].PendingCount)
+	}
+	if !lags[0].countsOnly {
+		t.Error("site lags must only report counts")
+	}
+}
+
+func TestReplicationAlerter(t *testing.T) {
+	var received []replicationAlertMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert replicationAlertMessage
+		if e := json.NewDecoder(r.Body).Decode(&alert); e != nil {
+			t.Error(e)
+		}
+		received = append(received, alert)
+	}))
+	defer ts.Close()
+
+	a := &replicationAlerter{
+		url: "myminio/bucket",
+		thresholds: []replicationThreshold{
+			{alert: replicationAlertPendingSize, limit: 1000},
+			{alert: replicationAlertFailedCount, limit: 0},
+		},
+		webhook: newWatchWebhookAction(ts.URL, ""),
+		firing:  make(map[string]bool),
+	}
+	now := time.Now()
+
+	if alerts := a.evaluate(now, []replicationLag{{Target: "all", PendingSize: 10}}); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+	alerts := a.evaluate(now, []replicationLag{{Target: "all", PendingSize: 2000, RecentFailedCount: 1}})
+	if len(alerts) != 2 || alerts[0].Alert != replicationAlertPendingSize || alerts[0].State != "firing" ||
+		alerts[1].Alert != replicationAlertFailedCount || alerts[1].Value != 1 {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+	// Alerts are not raised again while firing.
+	if alerts := a.evaluate(now, []replicationLag{{Target: "all", PendingSize: 3000, RecentFailedCount: 1}}); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+	alerts = a.evaluate(now, []replicationLag{{Target: "all", PendingSize: 3000}})
+	if len(alerts) != 1 || alerts[0].Alert != replicationAlertFailedCount || alerts[0].State != "resolved" {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+
+	// Site replication lags only have counts.
+	if alerts := a.evaluate(now, []replicationLag{{Target: "site", countsOnly: true, RecentFailedCount: 5}}); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts %+v", alerts)
+	}
+
+	if err := a.notify(context.Background(), alerts[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 || received[0].State != "resolved" || received[0].Target != "all" {
+		t.Errorf("unexpected webhook deliveries %+v", received)
+	}
+}
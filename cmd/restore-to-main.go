@@ -0,0 +1,497 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	jsoncolor "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+const (
+	globalMCRestoreToDir = "restore-to"
+
+	// restoreToResumeTolerance is how far apart a duration may be from
+	// the time of the interrupted restore it resumes.
+	restoreToResumeTolerance = time.Hour
+)
+
+var restoreToFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show the planned changes without applying them",
+	},
+	cli.BoolFlag{
+		Name:  "fresh",
+		Usage: "discard the progress of an interrupted restore and start over",
+	},
+}
+
+var restoreToCmd = cli.Command{
+	Name:         "restore-to",
+	Usage:        "restore a prefix of a versioned bucket to its state at a point in time",
+	Action:       mainRestoreTo,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(restoreToFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TIME TARGET
+
+  TIME is a date (e.g. 2023.10.12T10:15) in the local time zone or a duration
+  before now (e.g. 7d10h).
+
+  For each object under TARGET, the version current at TIME is copied back as
+  the latest version, objects which did not exist at TIME are removed with a
+  delete marker and objects unchanged since TIME are left alone. No version is
+  ever deleted, and the changes are recorded in the undo journal when
+  MC_UNDO_JOURNAL=on.
+
+  An interrupted restore resumes where it stopped when run again with the same
+  TIME. A duration resumes it when it is at most an hour apart from the
+  original one, otherwise the restore must be started over with --fresh.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Show what restoring the prefix 'reports/' to its state of yesterday would change
+     {{.Prompt}} {{.HelpName}} --dry-run 1d myminio/finance/reports/
+
+  2. Restore the bucket 'finance' to its state at a given date
+     {{.Prompt}} {{.HelpName}} 2023.10.12T10:15 myminio/finance
+`,
+}
+
+const (
+	restoreToActionRestore = "restore"
+	restoreToActionDelete  = "delete"
+)
+
+// restoreToMessage describes the change planned or applied to an object.
+type restoreToMessage struct {
+	Status    string    `json:"status"`
+	Key       string    `json:"key"`
+	Action    string    `json:"action"`
+	VersionID string    `json:"versionId,omitempty"`
+	Time      time.Time `json:"versionTime,omitempty"`
+	DryRun    bool      `json:"dryRun,omitempty"`
+}
+
+// String colorized restore message.
+func (r restoreToMessage) String() string {
+	var msg string
+	switch r.Action {
+	case restoreToActionRestore:
+		msg = console.Colorize("RestoreToRestore", "Restore ") + "`" + r.Key + "` to vid=" + r.VersionID +
+			" (" + r.Time.Local().Format(printDate) + ")"
+	case restoreToActionDelete:
+		msg = console.Colorize("RestoreToDelete", "Delete  ") + "`" + r.Key + "`, created after the restore time"
+	}
+	if r.DryRun {
+		return "[dry-run] " + msg
+	}
+	return msg
+}
+
+// JSON jsonified restore message.
+func (r restoreToMessage) JSON() string {
+	r.Status = "success"
+	jsonMessageBytes, e := jsoncolor.MarshalIndent(r, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// restoreToSummaryMessage summarizes a restore.
+type restoreToSummaryMessage struct {
+	Status    string    `json:"status"`
+	Target    string    `json:"target"`
+	Time      time.Time `json:"time"`
+	Restored  int       `json:"restored"`
+	Deleted   int       `json:"deleted"`
+	Untouched int       `json:"untouched"`
+	Resumed   int       `json:"resumed,omitempty"`
+	Failed    int       `json:"failed,omitempty"`
+	DryRun    bool      `json:"dryRun,omitempty"`
+}
+
+// String colorized summary message.
+func (r restoreToSummaryMessage) String() string {
+	msg := fmt.Sprintf("Restored `%s` to %s: %d object(s) restored, %d deleted, %d untouched",
+		r.Target, r.Time.Local().Format(printDate), r.Restored, r.Deleted, r.Untouched)
+	if r.DryRun {
+		msg = fmt.Sprintf("Restoring `%s` to %s would restore %d object(s), delete %d and leave %d untouched",
+			r.Target, r.Time.Local().Format(printDate), r.Restored, r.Deleted, r.Untouched)
+	}
+	if r.Resumed > 0 {
+		msg += fmt.Sprintf(", %d already done before the interruption", r.Resumed)
+	}
+	if r.Failed > 0 {
+		return console.Colorize("RestoreToFailed", msg+fmt.Sprintf(", %d failed.", r.Failed))
+	}
+	return console.Colorize("RestoreToSummary", msg+".")
+}
+
+// JSON jsonified summary message.
+func (r restoreToSummaryMessage) JSON() string {
+	r.Status = "success"
+	jsonMessageBytes, e := jsoncolor.MarshalIndent(r, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// parseRestoreTime parses a date in the local time zone or a duration
+// before now, relative is true for durations.
+func parseRestoreTime(s string) (t time.Time, relative bool, err *probe.Error) {
+	for _, format := range rewindSupportedFormat {
+		if t, e := time.ParseInLocation(format, s, time.Local); e == nil {
+			return t, false, nil
+		}
+	}
+	duration, e := ParseDuration(s)
+	if e != nil {
+		return t, false, probe.NewError(fmt.Errorf("`%s` is neither a date nor a duration", s))
+	}
+	if duration < 0 {
+		return t, false, probe.NewError(fmt.Errorf("negative duration `%s` is not supported", s))
+	}
+	return time.Now().Add(-time.Duration(duration)).Truncate(time.Second), true, nil
+}
+
+// planRestoreTo returns the change restoring an object to its state at
+// timeRef, versions are sorted latest first. An empty action means the
+// object is left alone.
+func planRestoreTo(versions []*ClientContent, timeRef time.Time) (action string, restore *ClientContent) {
+	if len(versions) == 0 {
+		return "", nil
+	}
+	latest := versions[0]
+	for _, version := range versions {
+		if !version.Time.After(timeRef) {
+			restore = version
+			break
+		}
+	}
+	existedThen := restore != nil && !restore.IsDeleteMarker
+	switch {
+	case existedThen && (latest.IsDeleteMarker || !sameRestoreToContent(latest, restore)):
+		return restoreToActionRestore, restore
+	case !existedThen && !latest.IsDeleteMarker:
+		return restoreToActionDelete, nil
+	}
+	return "", nil
+}
+
+// sameRestoreToContent returns true when the latest version already has
+// the content of the version to restore, e.g. once it was restored.
+func sameRestoreToContent(latest, restore *ClientContent) bool {
+	if latest.VersionID == restore.VersionID {
+		return true
+	}
+	return latest.ETag != "" && latest.ETag == restore.ETag && latest.Size == restore.Size
+}
+
+// restoreToState tracks the objects already processed by a restore so
+// that an interrupted restore can be resumed. The state is a JSON lines
+// file, a header followed by one line per processed object.
+type restoreToState struct {
+	path string
+	f    *os.File
+	Time time.Time       // restore time, zero when nothing was started
+	done map[string]bool // processed object paths
+}
+
+type restoreToStateEntry struct {
+	Target string     `json:"target,omitempty"`
+	Time   *time.Time `json:"time,omitempty"`
+	Key    string     `json:"key,omitempty"`
+}
+
+// restoreToStatePath returns the state file of a restore target.
+func restoreToStatePath(target string) (string, *probe.Error) {
+	dir, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	sum := sha256.Sum256([]byte(target))
+	return filepath.Join(dir, globalMCRestoreToDir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// loadRestoreToState returns the progress of an interrupted restore, the
+// state has a zero time when there is none.
+func loadRestoreToState(path string) (*restoreToState, *probe.Error) {
+	state := &restoreToState{path: path, done: map[string]bool{}}
+	f, e := os.Open(path)
+	if os.IsNotExist(e) {
+		return state, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry restoreToStateEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			// A line may be truncated when mc was killed.
+			continue
+		}
+		if entry.Time != nil {
+			state.Time = *entry.Time
+		}
+		if entry.Key != "" {
+			state.done[entry.Key] = true
+		}
+	}
+	if e = scanner.Err(); e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	return state, nil
+}
+
+// start creates a new state file for a restore of target to timeRef.
+func (s *restoreToState) start(target string, timeRef time.Time) *probe.Error {
+	if e := os.MkdirAll(filepath.Dir(s.path), 0o700); e != nil {
+		return probe.NewError(e)
+	}
+	f, e := os.OpenFile(s.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	s.f, s.Time, s.done = f, timeRef, map[string]bool{}
+	return s.write(restoreToStateEntry{Target: target, Time: &timeRef})
+}
+
+// canResume returns true when a restore to timeRef continues the
+// interrupted one. A duration cannot be repeated exactly, it continues
+// it within restoreToResumeTolerance and fails beyond, so that another
+// time is never used silently.
+func (s *restoreToState) canResume(timeRef time.Time, relative bool) (bool, *probe.Error) {
+	if s.Time.IsZero() {
+		return false, nil
+	}
+	if !relative {
+		return s.Time.Equal(timeRef), nil
+	}
+	drift := timeRef.Sub(s.Time)
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift <= restoreToResumeTolerance {
+		return true, nil
+	}
+	return false, probe.NewError(fmt.Errorf("a restore to %s was interrupted, use --fresh to start over or pass `%s` as TIME to resume it",
+		s.Time.Local().Format(printDate), s.Time.Local().Format("2006.01.02T15:04:05")))
+}
+
+// resume appends to the state file of an interrupted restore.
+func (s *restoreToState) resume() *probe.Error {
+	f, e := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	s.f = f
+	return nil
+}
+
+// markDone records an object as processed.
+func (s *restoreToState) markDone(key string) *probe.Error {
+	s.done[key] = true
+	return s.write(restoreToStateEntry{Key: key})
+}
+
+func (s *restoreToState) write(entry restoreToStateEntry) *probe.Error {
+	line, e := json.Marshal(entry)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e = s.f.Write(append(line, '\n')); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	return nil
+}
+
+// finish removes the state file once all objects are processed.
+func (s *restoreToState) finish() {
+	s.f.Close()
+	os.Remove(s.path)
+}
+
+// applyRestoreTo copies a version back as the latest version or adds a
+// delete marker to an object.
+func applyRestoreTo(ctx context.Context, clnt Client, alias, action string, object, restore *ClientContent) *probe.Error {
+	if action == restoreToActionDelete {
+		contentCh := make(chan *ClientContent, 1)
+		contentCh <- &ClientContent{URL: object.URL}
+		close(contentCh)
+		for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+			if result.Err != nil {
+				return result.Err.Trace(object.URL.String())
+			}
+		}
+		return nil
+	}
+
+	if restore.StorageClass == s3StorageClassGlacier {
+		return probe.NewError(fmt.Errorf("version %s is transitioned and must be restored first with 'mc ilm restore'", restore.VersionID))
+	}
+	targetClnt, err := newClientFromAlias(alias, object.URL.String())
+	if err != nil {
+		return err.Trace(object.URL.String())
+	}
+	return targetClnt.Copy(ctx, filepath.ToSlash(restore.URL.Path), CopyOptions{
+		versionID: restore.VersionID,
+		size:      restore.Size,
+		metadata:  map[string]string{},
+	}, nil)
+}
+
+// mainRestoreTo is the handle for "mc restore-to" command.
+func mainRestoreTo(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 2 {
+		showCommandHelpAndExit(cliCtx, cliCtx.Command.Name, 1) // last argument is exit code
+	}
+	ctx, cancelRestoreTo := context.WithCancel(globalContext)
+	defer cancelRestoreTo()
+
+	console.SetColor("RestoreToRestore", color.New(color.FgGreen, color.Bold))
+	console.SetColor("RestoreToDelete", color.New(color.FgRed, color.Bold))
+	console.SetColor("RestoreToSummary", color.New(color.FgGreen))
+	console.SetColor("RestoreToFailed", color.New(color.FgYellow))
+
+	args := cliCtx.Args()
+	timeRef, relative, err := parseRestoreTime(args.Get(0))
+	fatalIf(err.Trace(args.Get(0)), "Unable to parse the restore time.")
+	target := args.Get(1)
+	dryRun := cliCtx.Bool("dry-run")
+
+	alias, _, _ := mustExpandAlias(target)
+	if alias == "" {
+		fatalIf(errInvalidArgument().Trace(target), "Restoring is only supported on S3 aliases.")
+	}
+	if !checkIfBucketIsVersioned(ctx, target) {
+		fatalIf(errDummy().Trace(target), "Restoring works only with S3 versioned-enabled buckets.")
+	}
+	clnt, err := newClient(target)
+	fatalIf(err.Trace(target), "Unable to initialize target `"+target+"`.")
+
+	statePath, err := restoreToStatePath(clnt.GetURL().String())
+	fatalIf(err.Trace(target), "Unable to determine the restore state file.")
+	state, err := loadRestoreToState(statePath)
+	fatalIf(err.Trace(target), "Unable to load the progress of an interrupted restore.")
+
+	// An interrupted restore resumes with the time it started with.
+	var resuming bool
+	if !cliCtx.Bool("fresh") {
+		resuming, err = state.canResume(timeRef, relative)
+		fatalIf(err.Trace(target), "Unable to resume the interrupted restore.")
+	}
+	if resuming {
+		timeRef = state.Time
+		if !dryRun {
+			fatalIf(state.resume().Trace(target), "Unable to resume the interrupted restore.")
+		}
+	} else if !dryRun {
+		fatalIf(state.start(clnt.GetURL().String(), timeRef).Trace(target), "Unable to save the restore progress.")
+	}
+
+	summary := restoreToSummaryMessage{Target: target, Time: timeRef, DryRun: dryRun}
+	processObject := func(versions []*ClientContent) {
+		if len(versions) == 0 {
+			return
+		}
+		key := versions[0].URL.Path
+		if resuming && state.done[key] {
+			summary.Resumed++
+			return
+		}
+		sortObjectVersions(versions)
+		action, restore := planRestoreTo(versions, timeRef)
+		if action == "" {
+			summary.Untouched++
+		} else {
+			msg := restoreToMessage{
+				Key:    strings.TrimPrefix(getKey(versions[0]), "/"),
+				Action: action,
+				DryRun: dryRun,
+			}
+			if restore != nil {
+				msg.VersionID, msg.Time = restore.VersionID, restore.Time
+			}
+			if !dryRun {
+				if err := applyRestoreTo(ctx, clnt, alias, action, versions[0], restore); err != nil {
+					errorIf(err.Trace(key), "Unable to restore `"+msg.Key+"`.")
+					summary.Failed++
+					return
+				}
+			}
+			printMsg(msg)
+			if action == restoreToActionRestore {
+				summary.Restored++
+			} else {
+				summary.Deleted++
+			}
+		}
+		if !dryRun {
+			fatalIf(state.markDone(key).Trace(key), "Unable to save the restore progress.")
+		}
+	}
+
+	var versions []*ClientContent
+	for content := range clnt.List(ctx, ListOptions{
+		Recursive:         true,
+		WithOlderVersions: true,
+		WithDeleteMarkers: true,
+		ShowDir:           DirNone,
+	}) {
+		if content.Err != nil {
+			fatalIf(content.Err.Trace(target), "Unable to list `"+target+"`.")
+		}
+		if len(versions) > 0 && versions[0].URL.Path != content.URL.Path {
+			processObject(versions)
+			versions = nil
+		}
+		versions = append(versions, content)
+	}
+	processObject(versions)
+
+	printMsg(summary)
+	if !dryRun && summary.Failed == 0 {
+		state.finish()
+	}
+	if summary.Failed > 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
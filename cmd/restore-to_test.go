@@ -0,0 +1,126 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPlanRestoreTo(t *testing.T) {
+	timeRef := time.Date(2023, 10, 12, 10, 0, 0, 0, time.UTC)
+	version := func(id string, hours int, deleteMarker bool) *ClientContent {
+		return &ClientContent{VersionID: id, ETag: "etag-" + id, Size: 1, Time: timeRef.Add(time.Duration(hours) * time.Hour), IsDeleteMarker: deleteMarker}
+	}
+	restored := version("v3", 2, false)
+	restored.ETag = "etag-v1"
+	testCases := []struct {
+		name      string
+		versions  []*ClientContent
+		action    string
+		versionID string
+	}{
+		{"unchanged", []*ClientContent{version("v1", -1, false)}, "", ""},
+		{"overwritten", []*ClientContent{version("v2", 1, false), version("v1", -1, false)}, restoreToActionRestore, "v1"},
+		{"removed", []*ClientContent{version("dm", 1, true), version("v1", -1, false)}, restoreToActionRestore, "v1"},
+		{"created", []*ClientContent{version("v1", 1, false)}, restoreToActionDelete, ""},
+		{"recreated", []*ClientContent{version("v2", 2, false), version("dm", -1, true), version("v1", -2, false)}, restoreToActionDelete, ""},
+		{"created and removed", []*ClientContent{version("dm", 2, true), version("v1", 1, false)}, "", ""},
+		{"removed before", []*ClientContent{version("dm", -1, true), version("v1", -2, false)}, "", ""},
+		{"exact time", []*ClientContent{version("v2", 1, false), version("v1", 0, false)}, restoreToActionRestore, "v1"},
+		{"already restored", []*ClientContent{restored, version("v2", 1, false), version("v1", -1, false)}, "", ""},
+	}
+	for _, testCase := range testCases {
+		action, restore := planRestoreTo(testCase.versions, timeRef)
+		if action != testCase.action {
+			t.Errorf("%s: expected action %q, got %q", testCase.name, testCase.action, action)
+			continue
+		}
+		if testCase.versionID != "" && (restore == nil || restore.VersionID != testCase.versionID) {
+			t.Errorf("%s: expected version %s, got %+v", testCase.name, testCase.versionID, restore)
+		}
+	}
+}
+
+func TestParseRestoreTime(t *testing.T) {
+	timeRef, relative, err := parseRestoreTime("2023.10.12T10:15")
+	if err != nil || relative || !timeRef.Equal(time.Date(2023, 10, 12, 10, 15, 0, 0, time.Local)) {
+		t.Fatalf("unexpected time %v, relative %v, err %v", timeRef, relative, err)
+	}
+	timeRef, relative, err = parseRestoreTime("1d")
+	if err != nil || !relative || time.Since(timeRef) < 24*time.Hour || time.Since(timeRef) > 25*time.Hour {
+		t.Fatalf("unexpected time %v, relative %v, err %v", timeRef, relative, err)
+	}
+	if _, _, err = parseRestoreTime("yesterday"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestRestoreToState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), globalMCRestoreToDir, "state.json")
+	timeRef := time.Date(2023, 10, 12, 10, 15, 0, 0, time.UTC)
+
+	state, err := loadRestoreToState(path)
+	if err != nil || !state.Time.IsZero() {
+		t.Fatalf("unexpected state %+v, err %v", state, err)
+	}
+	if err = state.start("https://play.min.io/bucket", timeRef); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.markDone("/bucket/a"); err != nil {
+		t.Fatal(err)
+	}
+	state.f.Close()
+
+	state, err = loadRestoreToState(path)
+	if err != nil || !state.Time.Equal(timeRef) || !state.done["/bucket/a"] || state.done["/bucket/b"] {
+		t.Fatalf("unexpected state %+v, err %v", state, err)
+	}
+	if err = state.resume(); err != nil {
+		t.Fatal(err)
+	}
+	if err = state.markDone("/bucket/b"); err != nil {
+		t.Fatal(err)
+	}
+	state.finish()
+
+	// A duration resumes a restore only close to its time.
+	state = &restoreToState{Time: timeRef}
+	for _, testCase := range []struct {
+		timeRef  time.Time
+		relative bool
+		resume   bool
+		fail     bool
+	}{
+		{timeRef, false, true, false},
+		{timeRef.Add(time.Minute), false, false, false},
+		{timeRef.Add(10 * time.Minute), true, true, false},
+		{timeRef.Add(-6 * 24 * time.Hour), true, false, true},
+	} {
+		resume, err := state.canResume(testCase.timeRef, testCase.relative)
+		if resume != testCase.resume || (err != nil) != testCase.fail {
+			t.Errorf("%v (relative %v): unexpected resume %v, err %v", testCase.timeRef, testCase.relative, resume, err)
+		}
+	}
+
+	state, err = loadRestoreToState(path)
+	if err != nil || !state.Time.IsZero() || len(state.done) != 0 {
+		t.Fatalf("state not removed %+v, err %v", state, err)
+	}
+}
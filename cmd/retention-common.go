@@ -188,7 +188,7 @@ func applyRetention(ctx context.Context, op lockOpType, target, versionID string
 	}
 
 	// Quit early if urlStr does not point to an S3 server
-	switch clnt.(type) {
+	switch unwrapClient(clnt).(type) {
 	case *S3Client:
 	default:
 		fatal(errDummy().Trace(), "Retention is supported only for S3 servers.")
@@ -0,0 +1,267 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/console"
+)
+
+var retentionExtendFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "to",
+		Usage: "extend retention up to this date, e.g. 2025.01.31 or 2025.01.31T10:00",
+	},
+	cli.StringFlag{
+		Name:  "mode",
+		Usage: "retention mode of objects without retention, 'governance' or 'compliance', defaults to the bucket default mode",
+	},
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "extend retention of all versions of the objects",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "show the objects whose retention would be extended",
+	},
+}
+
+var retentionExtendCmd = cli.Command{
+	Name:         "extend",
+	Usage:        "extend retention of all objects under a prefix up to a date",
+	Action:       mainRetentionExtend,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(retentionExtendFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} --to DATE [FLAGS] TARGET
+
+  Retention is only ever lengthened: objects retained until DATE or later are
+  skipped, and objects keep their retention mode. DATE is in the local time
+  zone.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Retain all objects under a prefix at least until the end of January 2025
+     {{.Prompt}} {{.HelpName}} --to 2025.01.31 myminio/mybucket/prefix/
+
+  2. Show which versions would be extended, objects without retention get the compliance mode
+     {{.Prompt}} {{.HelpName}} --to 2025.01.31 --mode compliance --versions --dry-run myminio/mybucket
+`,
+}
+
+// retentionExtendMessage is an object whose retention is extended.
+type retentionExtendMessage struct {
+	Status    string              `json:"status"`
+	Key       string              `json:"key"`
+	VersionID string              `json:"versionID,omitempty"`
+	Mode      minio.RetentionMode `json:"mode"`
+	OldUntil  *time.Time          `json:"oldUntil,omitempty"`
+	Until     time.Time           `json:"until"`
+	DryRun    bool                `json:"dryRun,omitempty"`
+}
+
+// String colorized retention extend message.
+func (m retentionExtendMessage) String() string {
+	verb := "Extended"
+	if m.DryRun {
+		verb = "Would extend"
+	}
+	msg := fmt.Sprintf("%s %s retention of `%s`", verb, m.Mode, m.Key)
+	if m.VersionID != "" {
+		msg += fmt.Sprintf(" (version-id=%s)", m.VersionID)
+	}
+	msg += " to " + m.Until.Local().Format(printDate)
+	if m.OldUntil != nil {
+		msg += " (was " + m.OldUntil.Local().Format(printDate) + ")"
+	}
+	return console.Colorize("RetentionSuccess", msg+".")
+}
+
+// JSON jsonified retention extend message.
+func (m retentionExtendMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// retentionExtendSummary counts the objects extended and skipped.
+type retentionExtendSummary struct {
+	Status    string    `json:"status"`
+	Until     time.Time `json:"until"`
+	Extended  int64     `json:"extended"`
+	Compliant int64     `json:"compliant"`
+	Failed    int64     `json:"failed"`
+	DryRun    bool      `json:"dryRun,omitempty"`
+}
+
+// String colorized retention extend summary.
+func (s retentionExtendSummary) String() string {
+	verb := "extended"
+	if s.DryRun {
+		verb = "to extend"
+	}
+	msg := fmt.Sprintf("%d object(s) %s to %s, %d already retained until then", s.Extended, verb, s.Until.Local().Format(printDate), s.Compliant)
+	if s.Failed > 0 {
+		return console.Colorize("RetentionFailure", fmt.Sprintf("%s, %d failed.", msg, s.Failed))
+	}
+	return msg + "."
+}
+
+// JSON jsonified retention extend summary.
+func (s retentionExtendSummary) JSON() string {
+	s.Status = "success"
+	msgBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// parseRetainUntil parses a date in one of the rewind formats.
+func parseRetainUntil(s string) (time.Time, *probe.Error) {
+	for _, format := range rewindSupportedFormat {
+		if t, e := time.ParseInLocation(format, s, time.Local); e == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, probe.NewError(fmt.Errorf("`%s` is not a supported date", s))
+}
+
+// planRetentionExtend returns the retention mode to set for an object to
+// be retained until, ok is false when the object already complies.
+func planRetentionExtend(current retentionReportMessage, until time.Time, defaultMode minio.RetentionMode) (mode minio.RetentionMode, ok bool) {
+	if current.Mode == "" {
+		return defaultMode, true
+	}
+	if current.Until != nil && !current.Until.Before(until) {
+		return "", false
+	}
+	return current.Mode, true
+}
+
+// getBucketDefaultRetentionMode returns the default retention mode of the
+// bucket of an aliased URL.
+func getBucketDefaultRetentionMode(ctx context.Context, aliasedURL string) (minio.RetentionMode, *probe.Error) {
+	clnt, err := newClient(aliasedURL)
+	if err != nil {
+		return "", err
+	}
+	if c, ok := unwrapClient(clnt).(*S3Client); ok {
+		if _, object := c.url2BucketAndObject(); object != "" {
+			clnt, err = newClient(strings.TrimSuffix(aliasedURL, object))
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	_, mode, _, _, err := clnt.GetObjectLockConfig(ctx)
+	return mode, err
+}
+
+// main for retention extend command.
+func mainRetentionExtend(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 || cliCtx.String("to") == "" {
+		showCommandHelpAndExit(cliCtx, "extend", 1)
+	}
+	ctx, cancelRetentionExtend := context.WithCancel(globalContext)
+	defer cancelRetentionExtend()
+
+	console.SetColor("RetentionSuccess", color.New(color.FgGreen, color.Bold))
+	console.SetColor("RetentionFailure", color.New(color.FgYellow))
+
+	target := cliCtx.Args().Get(0)
+	until, err := parseRetainUntil(cliCtx.String("to"))
+	fatalIf(err.Trace(cliCtx.String("to")), "Unable to parse --to.")
+	if !until.After(time.Now()) {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("to")), "--to must be in the future.")
+	}
+	dryRun := cliCtx.Bool("dry-run")
+
+	fatalIfBucketLockNotEnabled(ctx, target)
+
+	mode := minio.RetentionMode(strings.ToUpper(cliCtx.String("mode")))
+	if mode == "" {
+		mode, err = getBucketDefaultRetentionMode(ctx, target)
+		fatalIf(err.Trace(target), "Unable to get the bucket default retention mode.")
+		if mode == "" {
+			fatalIf(errInvalidArgument().Trace(target), "The bucket has no default retention mode, --mode is required.")
+		}
+	}
+	if !mode.IsValid() {
+		fatalIf(errInvalidArgument().Trace(string(mode)), "invalid retention mode '%v'", mode)
+	}
+
+	alias, _, _ := mustExpandAlias(target)
+	summary := retentionExtendSummary{Until: until, DryRun: dryRun}
+	walkErr := walkObjectVersions(ctx, target, cliCtx.Bool("versions"), func(content *ClientContent) {
+		current, err := getObjectProtection(ctx, alias, content, true)
+		if err != nil {
+			errorIf(err.Trace(content.URL.String()), "Unable to get the retention of `%s`", content.URL)
+			summary.Failed++
+			return
+		}
+		objectMode, ok := planRetentionExtend(current, until, mode)
+		if !ok {
+			summary.Compliant++
+			return
+		}
+		if !dryRun {
+			clnt, err := newClientFromAlias(alias, content.URL.String())
+			if err == nil {
+				// Lengthening a retention never requires bypassing governance.
+				err = clnt.PutObjectRetention(ctx, content.VersionID, objectMode, until, false)
+			}
+			if err != nil {
+				errorIf(err.Trace(content.URL.String()), "Unable to extend the retention of `%s`", content.URL)
+				summary.Failed++
+				return
+			}
+		}
+		summary.Extended++
+		printMsg(retentionExtendMessage{
+			Key:       current.Key,
+			VersionID: current.VersionID,
+			Mode:      objectMode,
+			OldUntil:  current.Until,
+			Until:     until,
+			DryRun:    dryRun,
+		})
+	})
+	printMsg(summary)
+	if walkErr != nil {
+		return walkErr
+	}
+	if summary.Failed > 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
@@ -25,6 +25,8 @@ var retentionSubcommands = []cli.Command{
 	retentionSetCmd,
 	retentionClearCmd,
 	retentionInfoCmd,
+	retentionReportCmd,
+	retentionExtendCmd,
 }
 
 var retentionCmd = cli.Command{
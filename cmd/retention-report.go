@@ -0,0 +1,386 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/console"
+)
+
+var retentionReportFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "report all versions of the objects",
+	},
+	cli.BoolFlag{
+		Name:  "unprotected",
+		Usage: "only report objects with neither an active retention nor a legal hold",
+	},
+	cli.BoolFlag{
+		Name:  "summary",
+		Usage: "only show the counts by retention expiry month",
+	},
+	cli.BoolFlag{
+		Name:  "csv",
+		Usage: "write the report in CSV format",
+	},
+}
+
+var retentionReportCmd = cli.Command{
+	Name:         "report",
+	Usage:        "report the retention and legal hold of all objects under a prefix",
+	Action:       mainRetentionReport,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(retentionReportFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] TARGET
+
+  Each object is reported as protected when it has an active retention or a
+  legal hold, expired when its retention has expired and it has no legal hold,
+  and unprotected otherwise. The report ends with the counts by retention
+  expiry month.
+
+  With --csv, one row is written per object, or per month with --summary.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Report the retention of all objects under a prefix
+     {{.Prompt}} {{.HelpName}} myminio/mybucket/prefix/
+
+  2. Export the retention of all versions of all objects in a bucket to a CSV file
+     {{.Prompt}} {{.HelpName}} --versions --csv myminio/mybucket > retention.csv
+
+  3. List the objects under a prefix which are not protected
+     {{.Prompt}} {{.HelpName}} --unprotected myminio/mybucket/prefix/
+
+  4. Show the number of objects expiring per month
+     {{.Prompt}} {{.HelpName}} --summary myminio/mybucket
+`,
+}
+
+const (
+	protectionProtected   = "protected"
+	protectionExpired     = "expired"
+	protectionUnprotected = "unprotected"
+)
+
+// retentionReportMessage is the retention and legal hold of an object.
+type retentionReportMessage struct {
+	Status     string                `json:"status"`
+	Key        string                `json:"key"`
+	VersionID  string                `json:"versionID,omitempty"`
+	Mode       minio.RetentionMode   `json:"mode,omitempty"`
+	Until      *time.Time            `json:"until,omitempty"`
+	LegalHold  minio.LegalHoldStatus `json:"legalHold,omitempty"`
+	Protection string                `json:"protection"`
+}
+
+// String colorized retention report message.
+func (m retentionReportMessage) String() string {
+	var protection string
+	switch m.Protection {
+	case protectionProtected:
+		protection = console.Colorize("RetentionSuccess", fmt.Sprintf("%-11s", m.Protection))
+	case protectionExpired:
+		protection = console.Colorize("RetentionExpired", fmt.Sprintf("%-11s", m.Protection))
+	default:
+		protection = console.Colorize("RetentionNotFound", fmt.Sprintf("%-11s", m.Protection))
+	}
+	retention := "-"
+	if m.Mode != "" {
+		retention = m.Mode.String()
+		if m.Until != nil {
+			retention += " until " + m.Until.Local().Format(printDate)
+		}
+	}
+	legalHold := ""
+	if m.LegalHold == minio.LegalHoldEnabled {
+		legalHold = ", legal hold"
+	}
+	msg := "[ " + protection + " ] " + m.Key
+	if m.VersionID != "" {
+		msg += " " + console.Colorize("RetentionVersionID", m.VersionID)
+	}
+	return msg + " (" + retention + legalHold + ")"
+}
+
+// JSON jsonified retention report message.
+func (m retentionReportMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// csvRecord returns the message as a CSV record.
+func (m retentionReportMessage) csvRecord() []string {
+	var until string
+	if m.Until != nil {
+		until = m.Until.UTC().Format(time.RFC3339)
+	}
+	return []string{m.Key, m.VersionID, string(m.Mode), until, string(m.LegalHold), m.Protection}
+}
+
+// retentionReportMonth counts the objects whose retention expires in
+// a month.
+type retentionReportMonth struct {
+	Month      string `json:"month"`
+	Governance int64  `json:"governance"`
+	Compliance int64  `json:"compliance"`
+}
+
+// retentionReportSummary aggregates a retention report.
+type retentionReportSummary struct {
+	Status      string                 `json:"status"`
+	Target      string                 `json:"target"`
+	Objects     int64                  `json:"objects"`
+	Protected   int64                  `json:"protected"`
+	Expired     int64                  `json:"expired"`
+	Unprotected int64                  `json:"unprotected"`
+	LegalHold   int64                  `json:"legalHold"`
+	Months      []retentionReportMonth `json:"months"`
+
+	months map[string]*retentionReportMonth
+}
+
+// add counts a reported object.
+func (s *retentionReportSummary) add(m retentionReportMessage) {
+	s.Objects++
+	switch m.Protection {
+	case protectionProtected:
+		s.Protected++
+	case protectionExpired:
+		s.Expired++
+	default:
+		s.Unprotected++
+	}
+	if m.LegalHold == minio.LegalHoldEnabled {
+		s.LegalHold++
+	}
+	if m.Until == nil || m.Until.Before(time.Now()) {
+		return
+	}
+	if s.months == nil {
+		s.months = map[string]*retentionReportMonth{}
+	}
+	key := m.Until.UTC().Format("2006-01")
+	month, ok := s.months[key]
+	if !ok {
+		month = &retentionReportMonth{Month: key}
+		s.months[key] = month
+	}
+	if m.Mode == minio.Compliance {
+		month.Compliance++
+	} else {
+		month.Governance++
+	}
+}
+
+// sortMonths fills Months in chronological order.
+func (s *retentionReportSummary) sortMonths() {
+	s.Months = make([]retentionReportMonth, 0, len(s.months))
+	for _, month := range s.months {
+		s.Months = append(s.Months, *month)
+	}
+	sort.Slice(s.Months, func(i, j int) bool { return s.Months[i].Month < s.Months[j].Month })
+}
+
+// String colorized retention report summary.
+func (s retentionReportSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d object(s), %s protected, %s expired, %s unprotected, %d under legal hold\n",
+		s.Target, s.Objects,
+		console.Colorize("RetentionSuccess", strconv.FormatInt(s.Protected, 10)),
+		console.Colorize("RetentionExpired", strconv.FormatInt(s.Expired, 10)),
+		console.Colorize("RetentionNotFound", strconv.FormatInt(s.Unprotected, 10)),
+		s.LegalHold)
+	if len(s.Months) > 0 {
+		b.WriteString("\n" + console.Colorize("RetentionHeader", fmt.Sprintf("%-8s %12s %12s", "Expiry", "Governance", "Compliance")) + "\n")
+		for _, month := range s.Months {
+			fmt.Fprintf(&b, "%-8s %12d %12d\n", month.Month, month.Governance, month.Compliance)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// JSON jsonified retention report summary.
+func (s retentionReportSummary) JSON() string {
+	s.Status = "success"
+	msgBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// getObjectProtection returns the retention and legal hold of an object
+// version, lockEnabled is false when the bucket has no object lock.
+func getObjectProtection(ctx context.Context, alias string, content *ClientContent, lockEnabled bool) (msg retentionReportMessage, err *probe.Error) {
+	msg = retentionReportMessage{
+		Key:        strings.TrimPrefix(strings.TrimPrefix(content.URL.Path, "/"), content.BucketName+"/"),
+		VersionID:  content.VersionID,
+		Protection: protectionUnprotected,
+	}
+	if !lockEnabled {
+		return msg, nil
+	}
+	clnt, err := newClientFromAlias(alias, content.URL.String())
+	if err != nil {
+		return msg, err.Trace(content.URL.String())
+	}
+	mode, until, err := clnt.GetObjectRetention(ctx, content.VersionID)
+	if err != nil && minio.ToErrorResponse(err.ToGoError()).Code != "NoSuchObjectLockConfiguration" {
+		return msg, err.Trace(content.URL.String())
+	}
+	msg.Mode = mode
+	if !until.IsZero() {
+		msg.Until = &until
+	}
+	msg.LegalHold, err = clnt.GetObjectLegalHold(ctx, content.VersionID)
+	if err != nil {
+		return msg, err.Trace(content.URL.String())
+	}
+	switch {
+	case msg.LegalHold == minio.LegalHoldEnabled || (msg.Mode != "" && msg.Until != nil && msg.Until.After(time.Now())):
+		msg.Protection = protectionProtected
+	case msg.Mode != "":
+		msg.Protection = protectionExpired
+	}
+	return msg, nil
+}
+
+// walkObjectVersions calls fn with all objects under target, or all
+// their versions when withVersions is set, delete markers are skipped.
+func walkObjectVersions(ctx context.Context, target string, withVersions bool, fn func(content *ClientContent)) error {
+	clnt, err := newClient(target)
+	fatalIf(err.Trace(target), "Unable to parse the provided url.")
+	if _, ok := unwrapClient(clnt).(*S3Client); !ok {
+		fatal(errDummy().Trace(), "Retention is supported only for S3 servers.")
+	}
+
+	opts := ListOptions{Recursive: true, ShowDir: DirNone}
+	if withVersions {
+		opts.WithOlderVersions = true
+		opts.TimeRef = time.Now().UTC()
+	}
+	var cErr error
+	for content := range clnt.List(ctx, opts) {
+		if content.Err != nil {
+			errorIf(content.Err.Trace(clnt.GetURL().String()), "Unable to list folder.")
+			cErr = exitStatus(globalErrorExitStatus)
+			continue
+		}
+		if content.IsDeleteMarker {
+			continue
+		}
+		fn(content)
+	}
+	return cErr
+}
+
+// main for retention report command.
+func mainRetentionReport(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		showCommandHelpAndExit(cliCtx, "report", 1)
+	}
+	ctx, cancelRetentionReport := context.WithCancel(globalContext)
+	defer cancelRetentionReport()
+
+	console.SetColor("RetentionSuccess", color.New(color.FgGreen, color.Bold))
+	console.SetColor("RetentionNotFound", color.New(color.FgYellow))
+	console.SetColor("RetentionVersionID", color.New(color.FgGreen))
+	console.SetColor("RetentionExpired", color.New(color.FgRed, color.Bold))
+	console.SetColor("RetentionHeader", color.New(color.Bold))
+
+	target := cliCtx.Args().Get(0)
+	alias, _, _ := mustExpandAlias(target)
+	summaryOnly, unprotectedOnly, csvFormat := cliCtx.Bool("summary"), cliCtx.Bool("unprotected"), cliCtx.Bool("csv")
+	if csvFormat && globalJSON {
+		fatalIf(errInvalidArgument().Trace(), "--csv cannot be specified with --json.")
+	}
+
+	// Objects in a bucket without object lock are all unprotected.
+	status, err := getBucketLockStatus(ctx, target)
+	fatalIf(err.Trace(target), "Unable to get bucket lock configuration from `%s`", target)
+	lockEnabled := status == "Enabled"
+
+	var csvWriter *csv.Writer
+	if csvFormat {
+		csvWriter = csv.NewWriter(os.Stdout)
+		defer csvWriter.Flush()
+		if summaryOnly {
+			csvWriter.Write([]string{"month", "governance", "compliance"})
+		} else {
+			csvWriter.Write([]string{"key", "version_id", "mode", "retain_until", "legal_hold", "protection"})
+		}
+	}
+
+	summary := retentionReportSummary{Target: target}
+	var cErr error
+	walkErr := walkObjectVersions(ctx, target, cliCtx.Bool("versions"), func(content *ClientContent) {
+		msg, err := getObjectProtection(ctx, alias, content, lockEnabled)
+		if err != nil {
+			errorIf(err.Trace(content.URL.String()), "Unable to get the retention of `%s`", content.URL)
+			cErr = exitStatus(globalErrorExitStatus)
+			return
+		}
+		summary.add(msg)
+		if summaryOnly || (unprotectedOnly && msg.Protection == protectionProtected) {
+			return
+		}
+		if csvWriter != nil {
+			csvWriter.Write(msg.csvRecord())
+			return
+		}
+		printMsg(msg)
+	})
+
+	summary.sortMonths()
+	switch {
+	case csvWriter != nil && summaryOnly:
+		for _, month := range summary.Months {
+			csvWriter.Write([]string{month.Month, strconv.FormatInt(month.Governance, 10), strconv.FormatInt(month.Compliance, 10)})
+		}
+	case csvWriter == nil:
+		if !summaryOnly && !globalJSON && summary.Objects > 0 {
+			console.Println()
+		}
+		printMsg(summary)
+	}
+	if walkErr != nil {
+		return walkErr
+	}
+	return cErr
+}
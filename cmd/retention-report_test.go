@@ -0,0 +1,79 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestPlanRetentionExtend(t *testing.T) {
+	until := time.Now().Add(365 * 24 * time.Hour)
+	before, after := until.Add(-time.Hour), until.Add(time.Hour)
+	testCases := []struct {
+		current retentionReportMessage
+		mode    minio.RetentionMode
+		extend  bool
+	}{
+		{retentionReportMessage{}, minio.Governance, true},
+		{retentionReportMessage{Mode: minio.Compliance, Until: &before}, minio.Compliance, true},
+		{retentionReportMessage{Mode: minio.Compliance, Until: &until}, "", false},
+		{retentionReportMessage{Mode: minio.Governance, Until: &after}, "", false},
+	}
+	for i, testCase := range testCases {
+		mode, extend := planRetentionExtend(testCase.current, until, minio.Governance)
+		if mode != testCase.mode || extend != testCase.extend {
+			t.Errorf("test %d: expected %v %v, got %v %v", i+1, testCase.mode, testCase.extend, mode, extend)
+		}
+	}
+}
+
+func TestRetentionReportSummary(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	jan := time.Date(2099, 1, 15, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2099, 3, 1, 0, 0, 0, 0, time.UTC)
+	summary := retentionReportSummary{Target: "myminio/bucket"}
+	for _, msg := range []retentionReportMessage{
+		{Key: "a", Mode: minio.Governance, Until: &jan, Protection: protectionProtected},
+		{Key: "b", Mode: minio.Compliance, Until: &jan, Protection: protectionProtected},
+		{Key: "c", Mode: minio.Compliance, Until: &mar, LegalHold: minio.LegalHoldEnabled, Protection: protectionProtected},
+		{Key: "d", Mode: minio.Governance, Until: &past, Protection: protectionExpired},
+		{Key: "e", Protection: protectionUnprotected},
+	} {
+		summary.add(msg)
+	}
+	summary.sortMonths()
+	if summary.Objects != 5 || summary.Protected != 3 || summary.Expired != 1 || summary.Unprotected != 1 || summary.LegalHold != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	expected := []retentionReportMonth{
+		{Month: "2099-01", Governance: 1, Compliance: 1},
+		{Month: "2099-03", Compliance: 1},
+	}
+	if !reflect.DeepEqual(summary.Months, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, summary.Months)
+	}
+
+	record := retentionReportMessage{Key: "c", VersionID: "v1", Mode: minio.Compliance, Until: &mar, LegalHold: minio.LegalHoldEnabled, Protection: protectionProtected}.csvRecord()
+	if !reflect.DeepEqual(record, []string{"c", "v1", "COMPLIANCE", "2099-03-01T00:00:00Z", "ON", "protected"}) {
+		t.Fatalf("unexpected CSV record %q", record)
+	}
+}
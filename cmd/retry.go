@@ -0,0 +1,272 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/console"
+)
+
+const (
+	// Default number of retries for a failed operation, the operation
+	// is attempted at most defaultMaxRetries+1 times.
+	defaultMaxRetries = 3
+
+	// Base unit and upper bound of the exponential backoff.
+	defaultRetryUnit = 500 * time.Millisecond
+	defaultRetryCap  = 30 * time.Second
+)
+
+// retryClass tells whether and how a failed operation can be retried.
+type retryClass int
+
+const (
+	// retryNever - the error is permanent, retrying will not help.
+	retryNever retryClass = iota
+	// retryBeforeSend - the request was rejected or never reached the
+	// server, it is safe to retry even non-idempotent operations.
+	retryBeforeSend
+	// retryAfterSend - the request may or may not have been applied on
+	// the server, only idempotent operations can be retried.
+	retryAfterSend
+)
+
+// S3 error codes returned when the server refuses to process a request
+// because it is overloaded or not ready yet, nothing was applied.
+var retryBeforeSendS3Codes = map[string]struct{}{
+	"SlowDown":                   {},
+	"SlowDownRead":               {},
+	"SlowDownWrite":              {},
+	"Throttling":                 {},
+	"ThrottlingException":        {},
+	"RequestLimitExceeded":       {},
+	"RequestThrottled":           {},
+	"ServiceUnavailable":         {},
+	"XMinioServerNotInitialized": {},
+}
+
+// S3 error codes for failures which may happen after the server
+// started processing the request.
+var retryAfterSendS3Codes = map[string]struct{}{
+	"InternalError":    {},
+	"RequestTimeout":   {},
+	"RequestError":     {},
+	"IncompleteBody":   {},
+	"OperationAborted": {},
+}
+
+// classifyRetry - classifies an error returned by a Client operation.
+func classifyRetry(err *probe.Error) retryClass {
+	if err == nil {
+		return retryNever
+	}
+	e := err.ToGoError()
+	if errors.Is(e, context.Canceled) || errors.Is(e, context.DeadlineExceeded) {
+		return retryNever
+	}
+
+	if errResp := minio.ToErrorResponse(e); errResp.Code != "" || errResp.StatusCode != 0 {
+		if _, ok := retryBeforeSendS3Codes[errResp.Code]; ok {
+			return retryBeforeSend
+		}
+		if _, ok := retryAfterSendS3Codes[errResp.Code]; ok {
+			return retryAfterSend
+		}
+		switch errResp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return retryBeforeSend
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return retryAfterSend
+		}
+		return retryNever
+	}
+
+	// Connection could not be established, nothing was sent.
+	var opErr *net.OpError
+	if errors.As(e, &opErr) && opErr.Op == "dial" {
+		return retryBeforeSend
+	}
+	var dnsErr *net.DNSError
+	if errors.As(e, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout) {
+		return retryBeforeSend
+	}
+	if errors.Is(e, syscall.ECONNREFUSED) {
+		return retryBeforeSend
+	}
+
+	// Connection was lost while the request was in flight.
+	if errors.Is(e, syscall.ECONNRESET) || errors.Is(e, syscall.EPIPE) ||
+		errors.Is(e, io.ErrUnexpectedEOF) || errors.Is(e, io.EOF) {
+		return retryAfterSend
+	}
+	var netErr net.Error
+	if errors.As(e, &netErr) && netErr.Timeout() {
+		return retryAfterSend
+	}
+	return retryNever
+}
+
+// isRetryable returns true if the error can be retried for an operation
+// with the given idempotency.
+func isRetryable(err *probe.Error, idempotent bool) bool {
+	switch classifyRetry(err) {
+	case retryBeforeSend:
+		return true
+	case retryAfterSend:
+		return idempotent
+	}
+	return false
+}
+
+// globalRetries counts all retries performed during this run, it is
+// reported in the final summary of bulk commands.
+var globalRetries int64
+
+// getRetryCount returns the number of retries done so far.
+func getRetryCount() int64 {
+	return atomic.LoadInt64(&globalRetries)
+}
+
+// retryPolicy - decides how many times and how long to wait between
+// attempts of a failed operation.
+type retryPolicy struct {
+	maxRetries int
+	unit       time.Duration
+	cap        time.Duration
+}
+
+// newRetryPolicy returns the policy configured through --max-retries.
+func newRetryPolicy() retryPolicy {
+	return retryPolicy{
+		maxRetries: globalMaxRetries,
+		unit:       defaultRetryUnit,
+		cap:        defaultRetryCap,
+	}
+}
+
+// backoff computes the wait duration before the given retry (starting
+// at zero) with full jitter, i.e. random_between(0, min(cap, unit * 2 ** retry))
+// as described in https://www.awsarchitectureblog.com/2015/03/backoff.html
+func (p retryPolicy) backoff(retry int) time.Duration {
+	if retry > 30 {
+		retry = 30
+	}
+	sleep := p.unit * time.Duration(1<<uint(retry))
+	if sleep > p.cap || sleep <= 0 {
+		sleep = p.cap
+	}
+	if sleep <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(sleep) + 1))
+}
+
+// do runs fn until it succeeds, fails with an error which cannot be
+// retried, the context is canceled or retries are exhausted. Errors
+// which may have happened after the server applied the operation are
+// only retried for idempotent operations.
+func (p retryPolicy) do(ctx context.Context, idempotent bool, fn func() *probe.Error) *probe.Error {
+	for retry := 0; ; retry++ {
+		err := fn()
+		if err == nil || retry >= p.maxRetries || !isRetryable(err, idempotent) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.backoff(retry)):
+		}
+		atomic.AddInt64(&globalRetries, 1)
+	}
+}
+
+// retryProgressReader keeps track of the bytes reported to a progress
+// reader during one attempt so they can be taken back if the attempt
+// fails and gets retried.
+type retryProgressReader struct {
+	progress io.Reader
+	n        int64
+}
+
+func newRetryProgressReader(progress io.Reader) *retryProgressReader {
+	return &retryProgressReader{progress: progress}
+}
+
+// Read implements the io.Reader interface
+func (r *retryProgressReader) Read(p []byte) (n int, err error) {
+	n, err = r.progress.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return n, err
+}
+
+// rewind removes the bytes of the failed attempt from the progress.
+func (r *retryProgressReader) rewind() {
+	n := atomic.SwapInt64(&r.n, 0)
+	if n == 0 {
+		return
+	}
+	switch p := r.progress.(type) {
+	case *progressBar:
+		p.Add64(-n)
+	case *accounter:
+		p.Add(-n)
+	case Status:
+		p.Add(-n)
+	}
+}
+
+// uploadWithRetry uploads the source to target URL and retries the whole
+// transfer for retryable errors, the source stream is re-opened on each
+// attempt. Uploads overwrite the same target so they are idempotent.
+func uploadWithRetry(ctx context.Context, urls URLs, progress io.Reader, encKeyDB map[string][]prefixSSEPair, preserve, isZip bool) URLs {
+	var ret URLs
+	var tracker *retryProgressReader
+	newRetryPolicy().do(ctx, true, func() *probe.Error {
+		if tracker != nil {
+			tracker.rewind()
+		}
+		var pg io.Reader
+		if progress != nil {
+			tracker = newRetryProgressReader(progress)
+			pg = tracker
+		}
+		ret = uploadSourceToTargetURL(ctx, urls, pg, encKeyDB, preserve, isZip)
+		return ret.Error
+	})
+	return ret
+}
+
+// printRetrySummary prints the number of retries once a progress bar
+// is finished, accounting summaries include it in their own message.
+func printRetrySummary() {
+	if n := getRetryCount(); n > 0 && !globalQuiet && !globalJSON {
+		console.Infoln(fmt.Sprintf("Retried %d failed operation(s).", n))
+	}
+}
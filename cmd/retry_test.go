@@ -0,0 +1,183 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestClassifyRetry(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected retryClass
+	}{
+		{minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}, retryBeforeSend},
+		{minio.ErrorResponse{Code: "XMinioServerNotInitialized", StatusCode: http.StatusServiceUnavailable}, retryBeforeSend},
+		{minio.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError}, retryAfterSend},
+		{minio.ErrorResponse{StatusCode: http.StatusBadGateway}, retryAfterSend},
+		{minio.ErrorResponse{StatusCode: http.StatusTooManyRequests}, retryBeforeSend},
+		{minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, retryNever},
+		{minio.ErrorResponse{Code: "NoSuchKey", StatusCode: http.StatusNotFound}, retryNever},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, retryBeforeSend},
+		{&net.OpError{Op: "read", Err: syscall.ECONNRESET}, retryAfterSend},
+		{io.ErrUnexpectedEOF, retryAfterSend},
+		{context.Canceled, retryNever},
+		{errors.New("some error"), retryNever},
+		{PathNotFound{Path: "/tmp/foo"}, retryNever},
+	}
+
+	for i, testCase := range testCases {
+		if got := classifyRetry(probe.NewError(testCase.err)); got != testCase.expected {
+			t.Errorf("Test %d: expected %v, got %v for %v", i+1, testCase.expected, got, testCase.err)
+		}
+	}
+	if got := classifyRetry(nil); got != retryNever {
+		t.Errorf("expected no retry for nil error, got %v", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := retryPolicy{maxRetries: 5, unit: 100 * time.Millisecond, cap: time.Second}
+	for retry := 0; retry < 40; retry++ {
+		limit := p.cap
+		if retry < 4 {
+			limit = p.unit * time.Duration(1<<uint(retry))
+		}
+		if d := p.backoff(retry); d < 0 || d > limit {
+			t.Fatalf("retry %d: backoff %v out of range [0, %v]", retry, d, limit)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, unit: time.Microsecond, cap: time.Microsecond}
+	testCases := []struct {
+		err              error
+		idempotent       bool
+		expectedAttempts int
+	}{
+		{minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}, false, 4},
+		{minio.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError}, true, 4},
+		// Non idempotent operations are not retried when the request may have been applied.
+		{minio.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError}, false, 1},
+		{minio.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden}, true, 1},
+	}
+
+	for i, testCase := range testCases {
+		attempts := 0
+		err := p.do(context.Background(), testCase.idempotent, func() *probe.Error {
+			attempts++
+			return probe.NewError(testCase.err)
+		})
+		if err == nil {
+			t.Fatalf("Test %d: expected an error", i+1)
+		}
+		if attempts != testCase.expectedAttempts {
+			t.Errorf("Test %d: expected %d attempts, got %d", i+1, testCase.expectedAttempts, attempts)
+		}
+	}
+
+	attempts := 0
+	err := p.do(context.Background(), false, func() *probe.Error {
+		attempts++
+		if attempts < 3 {
+			return probe.NewError(minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable})
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("expected success after 3 attempts, got %d attempts and err %v", attempts, err)
+	}
+}
+
+func TestRemoveRetryQueue(t *testing.T) {
+	var deletes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("location"):
+			w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+		case r.URL.Query().Has("versioning"):
+			w.Write([]byte(`<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></VersioningConfiguration>`))
+		case r.URL.Query().Has("delete"):
+			if atomic.AddInt32(&deletes, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(`<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>`))
+				return
+			}
+			w.Write([]byte(`<DeleteResult><Deleted><Key>dir/object</Key><VersionId>v1</VersionId></Deleted></DeleteResult>`))
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	savedConfig := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["retry"] = aliasConfigV10{URL: server.URL, AccessKey: "minio", SecretKey: "minio123", API: "S3v4", Path: "on"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = savedConfig }()
+	savedMaxRetry := minio.MaxRetry
+	minio.MaxRetry = 1
+	defer func() { minio.MaxRetry = savedMaxRetry }()
+
+	clnt, err := newClient("retry/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := RemoveResult{
+		RemoveObjectResult: minio.RemoveObjectResult{ObjectName: "dir/object", ObjectVersionID: "v1"},
+		BucketName:         "bucket",
+		Err:                probe.NewError(minio.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable}),
+	}
+	var queue removeRetryQueue
+	if !queue.add(clnt, failed, removeOpts{}) {
+		t.Fatal("expected a throttled removal to be queued")
+	}
+	results := queue.retry(context.Background(), clnt, removeOpts{})
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[0].retries != 2 || atomic.LoadInt32(&deletes) != 2 {
+		t.Fatalf("expected 2 retries and 2 deletes, got %d and %d", results[0].retries, deletes)
+	}
+	if results[0].ObjectName != "dir/object" || results[0].ObjectVersionID != "v1" {
+		t.Fatalf("unexpected result %+v", results[0])
+	}
+
+	// A removal which may create a delete marker is not retried after
+	// the request reached the server.
+	failed.ObjectVersionID = ""
+	failed.Err = probe.NewError(minio.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError})
+	if queue.add(clnt, failed, removeOpts{}) {
+		t.Fatal("expected an unversioned removal not to be queued")
+	}
+}
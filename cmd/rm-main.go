@@ -26,6 +26,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
@@ -92,6 +93,10 @@ var (
 			Name:  "bypass",
 			Usage: "bypass governance",
 		},
+		cli.StringFlag{
+			Name:  "tags",
+			Usage: "remove only objects with tags matching an expression, e.g. 'project=x && tier!=cold'",
+		},
 		cli.BoolFlag{
 			Name:  "non-current",
 			Usage: "remove object(s) versions that are non-current",
@@ -167,6 +172,9 @@ EXAMPLES:
   14. Perform a fake removal of object(s) versions that are non-current and older than 10 days. If top-level version is a delete 
   marker, this will also be deleted when --non-current flag is specified.
       {{.Prompt}} {{.HelpName}} s3/docs/ --recursive --force --versions --non-current --older-than 10d --dry-run
+
+  15. Remove all objects tagged as temporary, unless they are tagged with a legal reference.
+      {{.Prompt}} {{.HelpName}} s3/docs/ --recursive --force --tags "temporary=true && !legal-ref"
 `,
 }
 
@@ -176,6 +184,7 @@ type rmMessage struct {
 	Key          string `json:"key"`
 	DeleteMarker bool   `json:"deleteMarker"`
 	VersionID    string `json:"versionID"`
+	Retries      int    `json:"retries,omitempty"`
 }
 
 // Colorized message for console printing.
@@ -319,6 +328,20 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 		return nil
 	}
 
+	if opts.tags != nil {
+		if ignoreStatError {
+			errorIf(pErr.Trace(url), "Unable to stat `"+url+"`.")
+			return exitStatus(globalErrorExitStatus)
+		}
+		targetAlias, _, _ := mustExpandAlias(url)
+		if skip, failed := opts.skipTags(ctx, targetAlias, content); skip {
+			if failed {
+				return exitStatus(globalErrorExitStatus)
+			}
+			return nil
+		}
+	}
+
 	if !opts.isFake {
 		targetAlias, targetURL, _ := mustExpandAlias(url)
 		clnt, pErr := newClientFromAlias(targetAlias, targetURL)
@@ -331,13 +354,29 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 			targetURL = targetURL + string(clnt.GetURL().Separator)
 		}
 
-		contentCh := make(chan *ClientContent, 1)
-		contentURL := *newClientURL(targetURL)
-		contentCh <- &ClientContent{URL: contentURL, VersionID: versionID}
-		close(contentCh)
-		isRemoveBucket := false
-		resultCh := clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, opts.isForce && opts.isForceDel, contentCh)
-		for result := range resultCh {
+		// Removing a specific version is idempotent, otherwise a
+		// retry may create an additional delete marker.
+		var results []RemoveResult
+		attempts := 0
+		newRetryPolicy().do(ctx, versionID != "", func() *probe.Error {
+			attempts++
+			contentCh := make(chan *ClientContent, 1)
+			contentURL := *newClientURL(targetURL)
+			contentCh <- &ClientContent{URL: contentURL, VersionID: versionID}
+			close(contentCh)
+			isRemoveBucket := false
+			results = results[:0]
+			for result := range clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, opts.isForce && opts.isForceDel, contentCh) {
+				results = append(results, result)
+			}
+			for _, result := range results {
+				if result.Err != nil {
+					return result.Err
+				}
+			}
+			return nil
+		})
+		for _, result := range results {
 			if result.Err != nil {
 				errorIf(result.Err.Trace(url), "Failed to remove `"+url+"`.")
 				switch result.Err.ToGoError().(type) {
@@ -350,6 +389,7 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 			msg := rmMessage{
 				Key:       path.Join(targetAlias, result.BucketName, result.ObjectName),
 				VersionID: result.ObjectVersionID,
+				Retries:   attempts - 1,
 			}
 			if result.DeleteMarker {
 				msg.DeleteMarker = true
@@ -375,9 +415,124 @@ type removeOpts struct {
 	isForceDel        bool
 	olderThan         string
 	newerThan         string
+	tags              *tagFilter
 	encKeyDB          map[string][]prefixSSEPair
 }
 
+// skipTags returns true when --tags is specified and the tags of an
+// object do not match it, or cannot be fetched. failed is true in the
+// latter case, so that the command exits with an error.
+func (opts removeOpts) skipTags(ctx context.Context, alias string, content *ClientContent) (skip, failed bool) {
+	if opts.tags == nil {
+		return false, false
+	}
+	match, err := opts.tags.match(ctx, alias, content)
+	if err != nil {
+		errorIf(err.Trace(content.URL.String()), "Unable to get object tags.")
+		return true, true
+	}
+	return !match, false
+}
+
+// removeRetryQueue collects the removals of a bulk removal which failed
+// with a transient error. They are removed again once the other removals
+// are done, so that waiting for the backoff does not hold them up.
+type removeRetryQueue struct {
+	failed []RemoveResult
+}
+
+// add queues a failed removal which can be retried, it returns false
+// if the result is final. Removing a specific version is idempotent,
+// otherwise a retry may create an additional delete marker.
+func (q *removeRetryQueue) add(clnt Client, result RemoveResult, opts removeOpts) bool {
+	if result.Err == nil || result.ObjectName == "" || opts.isIncomplete || clnt.GetURL().Type != objectStorage {
+		return false
+	}
+	if globalMaxRetries == 0 || !isRetryable(result.Err, result.ObjectVersionID != "") {
+		return false
+	}
+	q.failed = append(q.failed, result)
+	return true
+}
+
+// removeRetryResult is the final result of a queued removal.
+type removeRetryResult struct {
+	RemoveResult
+	retries int
+}
+
+// retry removes the queued objects again, all together after each
+// backoff, until they are removed, fail with an error which cannot be
+// retried or retries are exhausted.
+func (q *removeRetryQueue) retry(ctx context.Context, clnt Client, opts removeOpts) []removeRetryResult {
+	policy := newRetryPolicy()
+	contentURL := clnt.GetURL()
+	separator := string(contentURL.Separator)
+	removeKey := func(r RemoveResult) string {
+		return r.BucketName + separator + r.ObjectName + "?versionId=" + r.ObjectVersionID
+	}
+
+	var results []removeRetryResult
+	failed := q.failed
+	q.failed = nil
+	for retry := 0; len(failed) > 0; retry++ {
+		pending := make(map[string]RemoveResult, len(failed))
+		for _, result := range failed {
+			if retry >= policy.maxRetries || !isRetryable(result.Err, result.ObjectVersionID != "") {
+				results = append(results, removeRetryResult{RemoveResult: result, retries: retry})
+				continue
+			}
+			pending[removeKey(result)] = result
+		}
+		failed = nil
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, result := range pending {
+				results = append(results, removeRetryResult{RemoveResult: result, retries: retry})
+			}
+			return results
+		case <-time.After(policy.backoff(retry)):
+		}
+		atomic.AddInt64(&globalRetries, int64(len(pending)))
+
+		contentCh := make(chan *ClientContent, len(pending))
+		for _, result := range pending {
+			u := contentURL
+			u.Path = separator + result.BucketName + separator + result.ObjectName
+			contentCh <- &ClientContent{URL: u, VersionID: result.ObjectVersionID}
+		}
+		close(contentCh)
+
+		var removeErr *probe.Error
+		for r := range clnt.Remove(ctx, false, false, opts.isBypass, false, contentCh) {
+			if r.ObjectName == "" {
+				// The removal failed as a whole.
+				if r.Err != nil && removeErr == nil {
+					removeErr = r.Err
+				}
+				continue
+			}
+			delete(pending, removeKey(r))
+			if r.Err != nil {
+				failed = append(failed, r)
+				continue
+			}
+			results = append(results, removeRetryResult{RemoveResult: r, retries: retry + 1})
+		}
+		for _, result := range pending {
+			if removeErr != nil {
+				result.Err = removeErr
+			}
+			failed = append(failed, result)
+		}
+	}
+	return results
+}
+
 func printDryRunMsg(content *ClientContent) {
 	if globalJSON {
 		return
@@ -414,6 +569,10 @@ func listAndRemove(url string, opts removeOpts) error {
 		listOpts.TimeRef = opts.timeRef
 	}
 	atLeastOneObjectFound := false
+	// Objects whose tags cannot be fetched are skipped but fail the command.
+	tagsFailed := false
+	// Removals failed with a transient error, retried at the end.
+	var retryQueue removeRetryQueue
 
 	resultCh := clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, false, contentCh)
 
@@ -468,6 +627,11 @@ func listAndRemove(url string, opts removeOpts) error {
 						continue
 					}
 
+					if skip, failed := opts.skipTags(ctx, targetAlias, content); skip {
+						tagsFailed = tagsFailed || failed
+						continue
+					}
+
 					if opts.isFake {
 						printDryRunMsg(content)
 						continue
@@ -479,6 +643,9 @@ func listAndRemove(url string, opts removeOpts) error {
 						case contentCh <- content:
 							sent = true
 						case result := <-resultCh:
+							if retryQueue.add(clnt, result, opts) {
+								continue
+							}
 							path := path.Join(targetAlias, result.BucketName, result.ObjectName)
 							if result.Err != nil {
 								errorIf(result.Err.Trace(path),
@@ -530,6 +697,11 @@ func listAndRemove(url string, opts removeOpts) error {
 			continue
 		}
 
+		if skip, failed := opts.skipTags(ctx, targetAlias, content); skip {
+			tagsFailed = tagsFailed || failed
+			continue
+		}
+
 		if !opts.isFake {
 			sent := false
 			for !sent {
@@ -537,6 +709,9 @@ func listAndRemove(url string, opts removeOpts) error {
 				case contentCh <- content:
 					sent = true
 				case result := <-resultCh:
+					if retryQueue.add(clnt, result, opts) {
+						continue
+					}
 					path := path.Join(targetAlias, result.BucketName, result.ObjectName)
 					if result.Err != nil {
 						errorIf(result.Err.Trace(path),
@@ -589,6 +764,11 @@ func listAndRemove(url string, opts removeOpts) error {
 				continue
 			}
 
+			if skip, failed := opts.skipTags(ctx, targetAlias, content); skip {
+				tagsFailed = tagsFailed || failed
+				continue
+			}
+
 			if opts.isFake {
 				printDryRunMsg(content)
 				continue
@@ -600,6 +780,9 @@ func listAndRemove(url string, opts removeOpts) error {
 				case contentCh <- content:
 					sent = true
 				case result := <-resultCh:
+					if retryQueue.add(clnt, result, opts) {
+						continue
+					}
 					path := path.Join(targetAlias, result.BucketName, result.ObjectName)
 					if result.Err != nil {
 						errorIf(result.Err.Trace(path),
@@ -628,28 +811,48 @@ func listAndRemove(url string, opts removeOpts) error {
 
 	close(contentCh)
 	if opts.isFake {
+		if tagsFailed {
+			return exitStatus(globalErrorExitStatus)
+		}
 		return nil
 	}
-	for result := range resultCh {
+	// printResult reports a final removal result, it returns false if
+	// the removal must stop.
+	printResult := func(result RemoveResult, retries int) bool {
 		path := path.Join(targetAlias, result.BucketName, result.ObjectName)
 		if result.Err != nil {
 			errorIf(result.Err.Trace(path), "Failed to remove `"+path+"` recursively.")
 			switch result.Err.ToGoError().(type) {
 			case PathInsufficientPermission:
 				// Ignore Permission error.
-				continue
+				return true
 			}
-			return exitStatus(globalErrorExitStatus)
+			return false
 		}
 		msg := rmMessage{
 			Key:       path,
 			VersionID: result.ObjectVersionID,
+			Retries:   retries,
 		}
 		if result.DeleteMarker {
 			msg.DeleteMarker = true
 			msg.VersionID = result.DeleteMarkerVersionID
 		}
 		printMsg(msg)
+		return true
+	}
+	for result := range resultCh {
+		if retryQueue.add(clnt, result, opts) {
+			continue
+		}
+		if !printResult(result, 0) {
+			return exitStatus(globalErrorExitStatus)
+		}
+	}
+	for _, result := range retryQueue.retry(ctx, clnt, opts) {
+		if !printResult(result.RemoveResult, result.retries) {
+			return exitStatus(globalErrorExitStatus)
+		}
 	}
 
 	if !atLeastOneObjectFound {
@@ -662,6 +865,9 @@ func listAndRemove(url string, opts removeOpts) error {
 		return exitStatus(globalErrorExitStatus)
 	}
 
+	if tagsFailed {
+		return exitStatus(globalErrorExitStatus)
+	}
 	return nil
 }
 
@@ -691,6 +897,8 @@ func mainRm(cliCtx *cli.Context) error {
 	withVersions := cliCtx.Bool("versions")
 	versionID := cliCtx.String("version-id")
 	rewind := parseRewindFlag(cliCtx.String("rewind"))
+	tags, err := newTagFilter(cliCtx.String("tags"))
+	fatalIf(err.Trace(cliCtx.Args()...), "Unable to parse --tags.")
 
 	if withVersions && rewind.IsZero() {
 		rewind = time.Now().UTC()
@@ -715,6 +923,7 @@ func mainRm(cliCtx *cli.Context) error {
 				isBypass:          isBypass,
 				olderThan:         olderThan,
 				newerThan:         newerThan,
+				tags:              tags,
 				encKeyDB:          encKeyDB,
 			})
 		} else {
@@ -726,6 +935,7 @@ func mainRm(cliCtx *cli.Context) error {
 				isBypass:     isBypass,
 				olderThan:    olderThan,
 				newerThan:    newerThan,
+				tags:         tags,
 				encKeyDB:     encKeyDB,
 			})
 		}
@@ -753,6 +963,7 @@ func mainRm(cliCtx *cli.Context) error {
 				isBypass:          isBypass,
 				olderThan:         olderThan,
 				newerThan:         newerThan,
+				tags:              tags,
 				encKeyDB:          encKeyDB,
 			})
 		} else {
@@ -764,6 +975,7 @@ func mainRm(cliCtx *cli.Context) error {
 				isBypass:     isBypass,
 				olderThan:    olderThan,
 				newerThan:    newerThan,
+				tags:         tags,
 				encKeyDB:     encKeyDB,
 			})
 		}
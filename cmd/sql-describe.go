@@ -0,0 +1,229 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+	"github.com/minio/pkg/mimedb"
+)
+
+// sqlSchemaColumn is an inferred column.
+type sqlSchemaColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// sqlSchema infers the columns of a sequence of records.
+type sqlSchema struct {
+	columns []*sqlSchemaColumn
+	index   map[string]int
+	records int64
+}
+
+func newSQLSchema() *sqlSchema {
+	return &sqlSchema{index: make(map[string]int)}
+}
+
+// add merges the columns of a record into the schema, columns missing
+// from some records are nullable.
+func (s *sqlSchema) add(names []string, values []interface{}) {
+	seen := make(map[int]bool, len(names))
+	for i, name := range names {
+		idx, ok := s.index[name]
+		if !ok {
+			idx = len(s.columns)
+			s.index[name] = idx
+			s.columns = append(s.columns, &sqlSchemaColumn{Name: name, Nullable: s.records > 0})
+		}
+		c := s.columns[idx]
+		t := sqlInferType(values[i])
+		if t == "" {
+			c.Nullable = true
+		}
+		c.Type = sqlMergeType(c.Type, t)
+		seen[idx] = true
+	}
+	for idx, c := range s.columns {
+		if !seen[idx] {
+			c.Nullable = true
+		}
+	}
+	s.records++
+}
+
+// result returns the inferred columns, columns without any value are
+// reported as null.
+func (s *sqlSchema) result() []sqlSchemaColumn {
+	columns := make([]sqlSchemaColumn, len(s.columns))
+	for i, c := range s.columns {
+		columns[i] = *c
+		if columns[i].Type == "" {
+			columns[i].Type = "null"
+		}
+	}
+	return columns
+}
+
+// parquetFields returns the schema as Parquet columns.
+func (s *sqlSchema) parquetFields() []parquetField {
+	fields := make([]parquetField, len(s.columns))
+	for i, c := range s.columns {
+		fields[i] = parquetField{name: c.Name, typ: c.Type}
+		if c.Type == "" {
+			fields[i].typ = "string"
+		}
+	}
+	return fields
+}
+
+// sqlDescribeMessage container for 'mc sql --describe' output.
+type sqlDescribeMessage struct {
+	Status  string            `json:"status"`
+	Objects int               `json:"objects"`
+	Records int64             `json:"records"`
+	Columns []sqlSchemaColumn `json:"columns"`
+}
+
+// String colorized describe message.
+func (m sqlDescribeMessage) String() string {
+	var s strings.Builder
+	s.WriteString(console.Colorize("SQLDescribeSummary",
+		fmt.Sprintf("Sampled %d record(s) from %d object(s).", m.Records, m.Objects)))
+	s.WriteString("\n")
+	if len(m.Columns) == 0 {
+		return s.String()
+	}
+	w := tabwriter.NewWriter(&s, 1, 8, 2, ' ', 0)
+	fmt.Fprintln(w, console.Colorize("SQLDescribeHeader", "NAME\tTYPE\tNULLABLE"))
+	for _, c := range m.Columns {
+		nullable := "no"
+		if c.Nullable {
+			nullable = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", console.Colorize("SQLDescribeName", c.Name), c.Type, nullable)
+	}
+	w.Flush()
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// JSON jsonified describe message.
+func (m sqlDescribeMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+// sampleSQLObject adds up to sample records of an object to the schema.
+func sampleSQLObject(ctx context.Context, objectURL string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, sample int64, schema *sqlSchema) *probe.Error {
+	reader, _, err := getSourceStreamMetadataFromURL(ctx, objectURL, "", time.Time{}, encKeyDB, false)
+	if err != nil {
+		return err.Trace(objectURL)
+	}
+	defer reader.Close()
+
+	rd, e := newSQLInputReader(reader, selectObjectInputOpts(selOpts, objectURL))
+	if e != nil {
+		return probe.NewError(e).Trace(objectURL)
+	}
+	for n := int64(0); sample <= 0 || n < sample; n++ {
+		rec, e := rd.Read()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return probe.NewError(e).Trace(objectURL)
+		}
+		schema.add(rec.columns())
+	}
+	return nil
+}
+
+// mainSQLDescribe infers the columns of the objects instead of running a query.
+func mainSQLDescribe(cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) error {
+	ctx, cancelDescribe := context.WithCancel(globalContext)
+	defer cancelDescribe()
+
+	console.SetColor("SQLDescribeSummary", color.New(color.FgGreen, color.Bold))
+	console.SetColor("SQLDescribeHeader", color.New(color.Bold))
+	console.SetColor("SQLDescribeName", color.New(color.FgCyan))
+
+	sample := cliCtx.Int64("sample")
+	schema := newSQLSchema()
+	var objects int
+	describe := func(objectURL string) {
+		selOpts := getSQLOpts(cliCtx, nil)
+		validateOpts(selOpts, objectURL)
+		if err := sampleSQLObject(ctx, objectURL, encKeyDB, selOpts, sample, schema); err != nil {
+			errorIf(err, "Unable to sample `"+objectURL+"`.")
+			return
+		}
+		objects++
+	}
+
+	for _, url := range cliCtx.Args() {
+		_, targetContent, err := url2Stat(ctx, url, "", false, encKeyDB, time.Time{}, false)
+		if err != nil {
+			errorIf(err.Trace(url), "Unable to describe `"+url+"`.")
+			continue
+		}
+		if !targetContent.Type.IsDir() {
+			describe(url)
+			continue
+		}
+		targetAlias, targetURL, _ := mustExpandAlias(url)
+		clnt, err := newClientFromAlias(targetAlias, targetURL)
+		if err != nil {
+			errorIf(err.Trace(url), "Unable to initialize target `"+url+"`.")
+			continue
+		}
+		for content := range clnt.List(ctx, ListOptions{Recursive: cliCtx.Bool("recursive"), ShowDir: DirNone}) {
+			if content.Err != nil {
+				errorIf(content.Err.Trace(url), "Unable to list on target `"+url+"`.")
+				continue
+			}
+			contentType := mimedb.TypeByExtension(filepath.Ext(content.URL.Path))
+			for _, cTypeSuffix := range supportedContentTypes {
+				if strings.Contains(contentType, cTypeSuffix) {
+					describe(targetAlias + content.URL.Path)
+					break
+				}
+			}
+		}
+	}
+
+	printMsg(sqlDescribeMessage{
+		Objects: objects,
+		Records: schema.records,
+		Columns: schema.result(),
+	})
+	return nil
+}
@@ -0,0 +1,875 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Values produced while evaluating an expression are nil (NULL or
+// MISSING), bool, int64, float64, string, *sqlObject or []interface{}.
+
+// sqlObject is a JSON object which remembers the order of its keys, so
+// that 'SELECT *' reproduces documents the way they were written.
+type sqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *sqlObject) set(k string, v interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, ok := o.values[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.values[k] = v
+}
+
+// get looks up a key, unquoted names are matched case insensitively.
+func (o *sqlObject) get(k string, exact bool) (interface{}, bool) {
+	if v, ok := o.values[k]; ok {
+		return v, true
+	}
+	if exact {
+		return nil, false
+	}
+	for _, key := range o.keys {
+		if strings.EqualFold(key, k) {
+			return o.values[key], true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON encodes the object keeping the order of its keys.
+func (o *sqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := sqlWriteJSON(&buf, k); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := sqlWriteJSON(&buf, o.values[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// sqlWriteJSON writes v as JSON without escaping HTML characters and
+// without a trailing newline.
+func sqlWriteJSON(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// sqlDecodeJSON reads the next JSON value, objects are decoded as
+// *sqlObject and numbers as int64 or float64.
+func sqlDecodeJSON(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := &sqlObject{values: make(map[string]interface{})}
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				k, _ := kt.(string)
+				v, err := sqlDecodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				o.set(k, v)
+			}
+			_, err = dec.Token()
+			return o, err
+		case '[':
+			a := []interface{}{}
+			for dec.More() {
+				v, err := sqlDecodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, v)
+			}
+			_, err = dec.Token()
+			return a, err
+		}
+		return nil, fmt.Errorf("unexpected JSON delimiter %q", t)
+	case json.Number:
+		if i, e := t.Int64(); e == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return t, nil
+}
+
+// sqlRecord is a single input record.
+type sqlRecord interface {
+	// lookup resolves a column reference, returning nil when missing.
+	lookup(path []sqlPathElem) interface{}
+	// columns returns the names and values of all top level columns.
+	columns() ([]string, []interface{})
+}
+
+// sqlCSVRecord is a CSV row, optionally with the names from the header.
+type sqlCSVRecord struct {
+	names  []string
+	fields []string
+}
+
+func sqlPositional(name string) int {
+	if len(name) < 2 || name[0] != '_' {
+		return -1
+	}
+	n, e := strconv.Atoi(name[1:])
+	if e != nil || n < 1 {
+		return -1
+	}
+	return n - 1
+}
+
+func (r *sqlCSVRecord) lookup(path []sqlPathElem) interface{} {
+	if len(path) != 1 || path[0].index >= 0 {
+		return nil
+	}
+	name := path[0].name
+	if !path[0].quoted {
+		if n := sqlPositional(name); n >= 0 {
+			if n < len(r.fields) {
+				return r.fields[n]
+			}
+			return nil
+		}
+	}
+	for i, h := range r.names {
+		if h == name || !path[0].quoted && strings.EqualFold(h, name) {
+			if i < len(r.fields) {
+				return r.fields[i]
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *sqlCSVRecord) columns() ([]string, []interface{}) {
+	names := make([]string, len(r.fields))
+	values := make([]interface{}, len(r.fields))
+	for i, f := range r.fields {
+		if i < len(r.names) {
+			names[i] = r.names[i]
+		} else {
+			names[i] = "_" + strconv.Itoa(i+1)
+		}
+		values[i] = f
+	}
+	return names, values
+}
+
+// sqlJSONRecord is a single JSON document.
+type sqlJSONRecord struct {
+	v interface{}
+}
+
+func (r *sqlJSONRecord) lookup(path []sqlPathElem) interface{} {
+	v := r.v
+	for _, p := range path {
+		if p.index >= 0 {
+			a, ok := v.([]interface{})
+			if !ok || p.index >= len(a) {
+				return nil
+			}
+			v = a[p.index]
+			continue
+		}
+		o, ok := v.(*sqlObject)
+		if !ok {
+			return nil
+		}
+		if v, ok = o.get(p.name, p.quoted); !ok {
+			return nil
+		}
+	}
+	return v
+}
+
+func (r *sqlJSONRecord) columns() ([]string, []interface{}) {
+	o, ok := r.v.(*sqlObject)
+	if !ok {
+		return []string{"_1"}, []interface{}{r.v}
+	}
+	values := make([]interface{}, len(o.keys))
+	for i, k := range o.keys {
+		values[i] = o.values[k]
+	}
+	return o.keys, values
+}
+
+// sqlExpr is a node of a parsed SQL expression.
+type sqlExpr interface {
+	eval(rec sqlRecord) (interface{}, error)
+}
+
+type sqlLiteral struct{ v interface{} }
+
+type sqlPathElem struct {
+	name   string
+	quoted bool
+	index  int
+}
+
+type sqlColumn struct{ path []sqlPathElem }
+
+type sqlUnary struct {
+	op string
+	x  sqlExpr
+}
+
+type sqlBinary struct {
+	op   string
+	l, r sqlExpr
+}
+
+type sqlIsNull struct {
+	x   sqlExpr
+	not bool
+}
+
+type sqlLike struct {
+	x, pattern, escape sqlExpr
+	not                bool
+
+	re *regexp.Regexp
+}
+
+type sqlBetween struct {
+	x, lo, hi sqlExpr
+	not       bool
+}
+
+type sqlIn struct {
+	x    sqlExpr
+	list []sqlExpr
+	not  bool
+}
+
+type sqlCast struct {
+	x   sqlExpr
+	typ string
+}
+
+type sqlFunc struct {
+	name string
+	args []sqlExpr
+}
+
+// sqlAggregate accumulates its argument over all matching records.
+type sqlAggregate struct {
+	name string
+	arg  sqlExpr
+
+	count int64
+	sum   interface{}
+	value interface{}
+}
+
+// sqlWalk calls fn for e and all of its sub expressions.
+func sqlWalk(e sqlExpr, fn func(sqlExpr)) {
+	if e == nil {
+		return
+	}
+	fn(e)
+	switch e := e.(type) {
+	case *sqlUnary:
+		sqlWalk(e.x, fn)
+	case *sqlBinary:
+		sqlWalk(e.l, fn)
+		sqlWalk(e.r, fn)
+	case *sqlIsNull:
+		sqlWalk(e.x, fn)
+	case *sqlLike:
+		sqlWalk(e.x, fn)
+		sqlWalk(e.pattern, fn)
+		sqlWalk(e.escape, fn)
+	case *sqlBetween:
+		sqlWalk(e.x, fn)
+		sqlWalk(e.lo, fn)
+		sqlWalk(e.hi, fn)
+	case *sqlIn:
+		sqlWalk(e.x, fn)
+		for _, x := range e.list {
+			sqlWalk(x, fn)
+		}
+	case *sqlCast:
+		sqlWalk(e.x, fn)
+	case *sqlFunc:
+		for _, x := range e.args {
+			sqlWalk(x, fn)
+		}
+	case *sqlAggregate:
+		sqlWalk(e.arg, fn)
+	}
+}
+
+func sqlHasAggregate(e sqlExpr) (found bool) {
+	sqlWalk(e, func(e sqlExpr) {
+		if _, ok := e.(*sqlAggregate); ok {
+			found = true
+		}
+	})
+	return found
+}
+
+func (e *sqlLiteral) eval(rec sqlRecord) (interface{}, error) { return e.v, nil }
+
+func (e *sqlColumn) eval(rec sqlRecord) (interface{}, error) { return rec.lookup(e.path), nil }
+
+// name returns the output name of a column reference.
+func (e *sqlColumn) name() string {
+	for i := len(e.path) - 1; i >= 0; i-- {
+		if e.path[i].index < 0 {
+			return e.path[i].name
+		}
+	}
+	return ""
+}
+
+func (e *sqlUnary) eval(rec sqlRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if e.op == "NOT" {
+		b, ok := sqlToBool(v)
+		if !ok {
+			return nil, fmt.Errorf("NOT expects a boolean, got '%s'", sqlFormat(v))
+		}
+		return !b, nil
+	}
+	n, ok := sqlToNumber(v)
+	if !ok {
+		return nil, fmt.Errorf("cannot negate '%s'", sqlFormat(v))
+	}
+	if i, ok := n.(int64); ok {
+		return -i, nil
+	}
+	return -n.(float64), nil
+}
+
+func (e *sqlBinary) eval(rec sqlRecord) (interface{}, error) {
+	l, err := e.l.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	// AND and OR use three valued logic and short circuit.
+	if e.op == "AND" || e.op == "OR" {
+		lb, lok := sqlToBool(l)
+		if lok && (e.op == "AND" && !lb || e.op == "OR" && lb) {
+			return lb, nil
+		}
+		r, err := e.r.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		rb, rok := sqlToBool(r)
+		if rok && (e.op == "AND" && !rb || e.op == "OR" && rb) {
+			return rb, nil
+		}
+		if !lok || !rok {
+			return nil, nil
+		}
+		return rb, nil
+	}
+
+	r, err := e.r.eval(rec)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+	switch e.op {
+	case "||":
+		return sqlFormat(l) + sqlFormat(r), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		c := sqlCompare(l, r)
+		switch e.op {
+		case "=":
+			return c == 0, nil
+		case "!=":
+			return c != 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	}
+	return sqlArith(e.op, l, r)
+}
+
+func sqlArith(op string, l, r interface{}) (interface{}, error) {
+	ln, lok := sqlToNumber(l)
+	rn, rok := sqlToNumber(r)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply '%s' to '%s' and '%s'", op, sqlFormat(l), sqlFormat(r))
+	}
+	li, lint := ln.(int64)
+	ri, rint := rn.(int64)
+	if lint && rint {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+	lf, rf := sqlToFloat(ln), sqlToFloat(rn)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return lf / rf, nil
+	}
+	if rf == 0 {
+		return nil, errors.New("division by zero")
+	}
+	return math.Mod(lf, rf), nil
+}
+
+func (e *sqlIsNull) eval(rec sqlRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != e.not, nil
+}
+
+// sqlLikeRegexp converts a LIKE pattern into an anchored regular expression.
+func sqlLikeRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	if utf8.RuneCountInString(escape) > 1 {
+		return nil, fmt.Errorf("ESCAPE must be a single character, got '%s'", escape)
+	}
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case escape != "" && string(c) == escape:
+			escaped = true
+		case c == '%':
+			sb.WriteString(".*")
+		case c == '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("LIKE pattern '%s' ends with the escape character", pattern)
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+func (e *sqlLike) eval(rec sqlRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	re := e.re
+	if re == nil {
+		p, err := e.pattern.eval(rec)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		var esc interface{}
+		if e.escape != nil {
+			if esc, err = e.escape.eval(rec); err != nil {
+				return nil, err
+			}
+		}
+		if re, err = sqlLikeRegexp(sqlFormat(p), sqlFormat(esc)); err != nil {
+			return nil, err
+		}
+		// Constant patterns are compiled only once.
+		_, plit := e.pattern.(*sqlLiteral)
+		_, elit := e.escape.(*sqlLiteral)
+		if plit && (e.escape == nil || elit) {
+			e.re = re
+		}
+	}
+	return re.MatchString(sqlFormat(v)) != e.not, nil
+}
+
+func (e *sqlBetween) eval(rec sqlRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	lo, err := e.lo.eval(rec)
+	if err != nil || lo == nil {
+		return nil, err
+	}
+	hi, err := e.hi.eval(rec)
+	if err != nil || hi == nil {
+		return nil, err
+	}
+	in := sqlCompare(v, lo) >= 0 && sqlCompare(v, hi) <= 0
+	return in != e.not, nil
+}
+
+func (e *sqlIn) eval(rec sqlRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	for _, x := range e.list {
+		c, err := x.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil && sqlCompare(v, c) == 0 {
+			return !e.not, nil
+		}
+	}
+	return e.not, nil
+}
+
+func (e *sqlCast) eval(rec sqlRecord) (interface{}, error) {
+	v, err := e.x.eval(rec)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch e.typ {
+	case "STRING":
+		return sqlFormat(v), nil
+	case "BOOL", "BOOLEAN":
+		if b, ok := sqlToBool(v); ok {
+			return b, nil
+		}
+	case "INT", "INTEGER":
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		if n, ok := sqlToNumber(v); ok {
+			if f, ok := n.(float64); ok {
+				return int64(f), nil
+			}
+			return n, nil
+		}
+	default:
+		if n, ok := sqlToNumber(v); ok {
+			return sqlToFloat(n), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot cast '%s' to %s", sqlFormat(v), e.typ)
+}
+
+var sqlFuncArity = map[string][2]int{
+	"LOWER":            {1, 1},
+	"UPPER":            {1, 1},
+	"TRIM":             {1, 1},
+	"CHAR_LENGTH":      {1, 1},
+	"CHARACTER_LENGTH": {1, 1},
+	"COALESCE":         {1, -1},
+	"NULLIF":           {2, 2},
+}
+
+func (e *sqlFunc) check() error {
+	arity, ok := sqlFuncArity[e.name]
+	if !ok {
+		return fmt.Errorf("unsupported function '%s'", e.name)
+	}
+	if len(e.args) < arity[0] || arity[1] >= 0 && len(e.args) > arity[1] {
+		return fmt.Errorf("wrong number of arguments for %s", e.name)
+	}
+	return nil
+}
+
+func (e *sqlFunc) eval(rec sqlRecord) (interface{}, error) {
+	args := make([]interface{}, len(e.args))
+	for i, a := range e.args {
+		v, err := a.eval(rec)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch e.name {
+	case "COALESCE":
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	case "NULLIF":
+		if args[0] != nil && args[1] != nil && sqlCompare(args[0], args[1]) == 0 {
+			return nil, nil
+		}
+		return args[0], nil
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	s := sqlFormat(args[0])
+	switch e.name {
+	case "LOWER":
+		return strings.ToLower(s), nil
+	case "UPPER":
+		return strings.ToUpper(s), nil
+	case "TRIM":
+		return strings.TrimSpace(s), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return int64(utf8.RuneCountInString(s)), nil
+	case "SUBSTRING":
+		runes := []rune(s)
+		start, ok := sqlToNumber(args[1])
+		if !ok {
+			return nil, fmt.Errorf("invalid SUBSTRING start '%s'", sqlFormat(args[1]))
+		}
+		// Positions are 1 based, and may start before the string.
+		from := int64(sqlToFloat(start)) - 1
+		to := int64(len(runes))
+		if len(args) > 2 {
+			n, ok := sqlToNumber(args[2])
+			if !ok || sqlToFloat(n) < 0 {
+				return nil, fmt.Errorf("invalid SUBSTRING length '%s'", sqlFormat(args[2]))
+			}
+			if end := from + int64(sqlToFloat(n)); end < to {
+				to = end
+			}
+		}
+		if from < 0 {
+			from = 0
+		}
+		if from >= to {
+			return "", nil
+		}
+		return string(runes[from:to]), nil
+	}
+	return nil, fmt.Errorf("unsupported function '%s'", e.name)
+}
+
+// eval returns the aggregated value over the records seen so far.
+func (e *sqlAggregate) eval(rec sqlRecord) (interface{}, error) {
+	switch e.name {
+	case "COUNT":
+		return e.count, nil
+	case "SUM":
+		return e.sum, nil
+	case "AVG":
+		if e.count == 0 {
+			return nil, nil
+		}
+		return sqlToFloat(e.sum) / float64(e.count), nil
+	}
+	return e.value, nil
+}
+
+// update accumulates a matching record.
+func (e *sqlAggregate) update(rec sqlRecord) error {
+	if e.arg == nil {
+		e.count++
+		return nil
+	}
+	v, err := e.arg.eval(rec)
+	if err != nil || v == nil {
+		return err
+	}
+	switch e.name {
+	case "COUNT":
+	case "SUM", "AVG":
+		n, ok := sqlToNumber(v)
+		if !ok {
+			return fmt.Errorf("%s expects numbers, got '%s'", e.name, sqlFormat(v))
+		}
+		if e.sum == nil {
+			e.sum = n
+		} else {
+			sum, err := sqlArith("+", e.sum, n)
+			if err != nil {
+				return err
+			}
+			e.sum = sum
+		}
+	case "MIN":
+		if e.value == nil || sqlCompare(v, e.value) < 0 {
+			e.value = v
+		}
+	case "MAX":
+		if e.value == nil || sqlCompare(v, e.value) > 0 {
+			e.value = v
+		}
+	}
+	e.count++
+	return nil
+}
+
+// sqlToNumber converts v to int64 or float64, strings are parsed.
+func sqlToNumber(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case int64, float64:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if i, e := strconv.ParseInt(s, 10, 64); e == nil {
+			return i, true
+		}
+		if f, e := strconv.ParseFloat(s, 64); e == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func sqlToFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func sqlToBool(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		if b, e := strconv.ParseBool(strings.TrimSpace(v)); e == nil {
+			return b, true
+		}
+	}
+	return false, false
+}
+
+// sqlCompare orders two non NULL values, comparing numerically when one
+// side is a number and the other converts to one.
+func sqlCompare(a, b interface{}) int {
+	_, anum := a.(int64)
+	if _, ok := a.(float64); ok {
+		anum = true
+	}
+	_, bnum := b.(int64)
+	if _, ok := b.(float64); ok {
+		bnum = true
+	}
+	if anum || bnum {
+		an, aok := sqlToNumber(a)
+		bn, bok := sqlToNumber(b)
+		if aok && bok {
+			ai, aint := an.(int64)
+			bi, bint := bn.(int64)
+			if aint && bint {
+				return sqlCmp(ai < bi, ai > bi)
+			}
+			af, bf := sqlToFloat(an), sqlToFloat(bn)
+			return sqlCmp(af < bf, af > bf)
+		}
+	}
+	if ab, ok := a.(bool); ok {
+		if bb, ok := sqlToBool(b); ok {
+			return sqlCmp(!ab && bb, ab && !bb)
+		}
+	}
+	return strings.Compare(sqlFormat(a), sqlFormat(b))
+}
+
+func sqlCmp(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// sqlFormat renders a value as text, as it appears in CSV output.
+func sqlFormat(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	var buf bytes.Buffer
+	if err := sqlWriteJSON(&buf, v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return buf.String()
+}
@@ -0,0 +1,411 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+// sqlRecordReader reads input records one at a time.
+type sqlRecordReader interface {
+	Read() (sqlRecord, error)
+}
+
+// sqlCSVReader reads CSV records, honoring the S3 Select CSV input options.
+type sqlCSVReader struct {
+	r       *csv.Reader
+	scanner *bufio.Scanner
+	comma   rune
+	comment rune
+	names   []string
+}
+
+func newSQLCSVReader(r io.Reader, opts minio.CSVInputOptions) (*sqlCSVReader, error) {
+	cr := &sqlCSVReader{comma: ','}
+	if opts.FieldDelimiter != "" {
+		if utf8.RuneCountInString(opts.FieldDelimiter) != 1 {
+			return nil, fmt.Errorf("field delimiter '%s' must be a single character", opts.FieldDelimiter)
+		}
+		cr.comma, _ = utf8.DecodeRuneInString(opts.FieldDelimiter)
+	}
+	if opts.Comments != "" {
+		if utf8.RuneCountInString(opts.Comments) != 1 {
+			return nil, fmt.Errorf("comment character '%s' must be a single character", opts.Comments)
+		}
+		cr.comment, _ = utf8.DecodeRuneInString(opts.Comments)
+	}
+	if opts.QuoteCharacter != "" && opts.QuoteCharacter != `"` {
+		return nil, fmt.Errorf("quote character '%s' is not supported, only '\"' is", opts.QuoteCharacter)
+	}
+
+	switch opts.RecordDelimiter {
+	case "", "\n", "\r\n":
+		cr.r = cr.newReader(r)
+	default:
+		// Custom record delimiters are split first, each record is then
+		// parsed on its own.
+		delim := []byte(opts.RecordDelimiter)
+		cr.scanner = bufio.NewScanner(r)
+		cr.scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		cr.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+			if i := bytes.Index(data, delim); i >= 0 {
+				return i + len(delim), data[:i], nil
+			}
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		})
+	}
+
+	switch strings.ToUpper(string(opts.FileHeaderInfo)) {
+	case "", string(minio.CSVFileHeaderInfoNone):
+	case minio.CSVFileHeaderInfoUse, minio.CSVFileHeaderInfoIgnore:
+		header, err := cr.readFields()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if strings.EqualFold(string(opts.FileHeaderInfo), minio.CSVFileHeaderInfoUse) {
+			cr.names = header
+		}
+	default:
+		return nil, fmt.Errorf("unknown file header info '%s'", opts.FileHeaderInfo)
+	}
+	return cr, nil
+}
+
+func (cr *sqlCSVReader) newReader(r io.Reader) *csv.Reader {
+	c := csv.NewReader(r)
+	c.Comma = cr.comma
+	c.Comment = cr.comment
+	c.FieldsPerRecord = -1
+	c.LazyQuotes = true
+	return c
+}
+
+func (cr *sqlCSVReader) readFields() ([]string, error) {
+	if cr.scanner == nil {
+		return cr.r.Read()
+	}
+	for cr.scanner.Scan() {
+		fields, err := cr.newReader(bytes.NewReader(cr.scanner.Bytes())).Read()
+		if err == io.EOF {
+			// Empty or commented out record.
+			continue
+		}
+		return fields, err
+	}
+	if err := cr.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (cr *sqlCSVReader) Read() (sqlRecord, error) {
+	fields, err := cr.readFields()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlCSVRecord{names: cr.names, fields: fields}, nil
+}
+
+// sqlJSONReader reads JSON documents, top level arrays of a DOCUMENT
+// input are read as one record per element.
+type sqlJSONReader struct {
+	dec      *json.Decoder
+	document bool
+	pending  []interface{}
+}
+
+func (jr *sqlJSONReader) Read() (sqlRecord, error) {
+	for len(jr.pending) == 0 {
+		v, err := sqlDecodeJSON(jr.dec)
+		if err != nil {
+			return nil, err
+		}
+		a, ok := v.([]interface{})
+		if !ok || !jr.document {
+			return &sqlJSONRecord{v: v}, nil
+		}
+		jr.pending = a
+	}
+	v := jr.pending[0]
+	jr.pending = jr.pending[1:]
+	return &sqlJSONRecord{v: v}, nil
+}
+
+// sqlRecordWriter writes output records.
+type sqlRecordWriter interface {
+	Write(names []string, values []interface{}) error
+}
+
+// sqlCSVWriter writes records honoring the S3 Select CSV output options.
+type sqlCSVWriter struct {
+	w           io.Writer
+	fieldDelim  string
+	recordDelim string
+	quote       string
+	quoteEscape string
+	always      bool
+}
+
+func newSQLCSVWriter(w io.Writer, opts minio.CSVOutputOptions) *sqlCSVWriter {
+	cw := &sqlCSVWriter{
+		w:           w,
+		fieldDelim:  opts.FieldDelimiter,
+		recordDelim: opts.RecordDelimiter,
+		quote:       opts.QuoteCharacter,
+		quoteEscape: opts.QuoteEscapeCharacter,
+		always:      strings.EqualFold(string(opts.QuoteFields), string(minio.CSVQuoteFieldsAlways)),
+	}
+	if cw.fieldDelim == "" {
+		cw.fieldDelim = defaultFieldDelimiter
+	}
+	if cw.recordDelim == "" {
+		cw.recordDelim = defaultRecordDelimiter
+	}
+	if cw.quote == "" {
+		cw.quote = `"`
+	}
+	if cw.quoteEscape == "" {
+		cw.quoteEscape = cw.quote
+	}
+	return cw
+}
+
+func (cw *sqlCSVWriter) Write(names []string, values []interface{}) error {
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString(cw.fieldDelim)
+		}
+		s := sqlFormat(v)
+		if cw.always || strings.Contains(s, cw.fieldDelim) || strings.Contains(s, cw.quote) ||
+			strings.Contains(s, cw.recordDelim) || strings.ContainsAny(s, "\r\n") {
+			s = cw.quote + strings.ReplaceAll(s, cw.quote, cw.quoteEscape+cw.quote) + cw.quote
+		}
+		sb.WriteString(s)
+	}
+	sb.WriteString(cw.recordDelim)
+	_, err := io.WriteString(cw.w, sb.String())
+	return err
+}
+
+// sqlJSONWriter writes one JSON object per record.
+type sqlJSONWriter struct {
+	w           io.Writer
+	recordDelim string
+}
+
+func (jw *sqlJSONWriter) Write(names []string, values []interface{}) error {
+	o := &sqlObject{}
+	for i, name := range names {
+		o.set(name, values[i])
+	}
+	var buf bytes.Buffer
+	if err := sqlWriteJSON(&buf, o); err != nil {
+		return err
+	}
+	buf.WriteString(jw.recordDelim)
+	_, err := jw.w.Write(buf.Bytes())
+	return err
+}
+
+// sqlLocalReader is the output of a local query, closing it stops the
+// query and closes the input.
+type sqlLocalReader struct {
+	*io.PipeReader
+	src io.Closer
+}
+
+func (r *sqlLocalReader) Close() error {
+	r.PipeReader.Close()
+	return r.src.Close()
+}
+
+// localSelect evaluates an S3 Select SQL expression on the client, reading
+// records from src with the same serialization options as the Select API.
+func localSelect(src io.ReadCloser, expression string, in minio.SelectObjectInputSerialization, out minio.SelectObjectOutputSerialization) (io.ReadCloser, *probe.Error) {
+	query, e := parseSQL(expression)
+	if e != nil {
+		src.Close()
+		return nil, probe.NewError(fmt.Errorf("unable to parse query: %w", e))
+	}
+
+	rd, e := newSQLInputReader(src, in)
+	if e != nil {
+		src.Close()
+		return nil, probe.NewError(e)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer src.Close()
+		if c, ok := rd.(io.Closer); ok {
+			defer c.Close()
+		}
+		bw := bufio.NewWriter(pw)
+		var wr sqlRecordWriter
+		if out.JSON != nil {
+			recordDelim := out.JSON.RecordDelimiter
+			if recordDelim == "" {
+				recordDelim = defaultRecordDelimiter
+			}
+			wr = &sqlJSONWriter{w: bw, recordDelim: recordDelim}
+		} else {
+			csvOpts := minio.CSVOutputOptions{}
+			if out.CSV != nil {
+				csvOpts = *out.CSV
+			}
+			wr = newSQLCSVWriter(bw, csvOpts)
+		}
+		e := query.run(rd, wr)
+		if e == nil {
+			e = bw.Flush()
+		}
+		pw.CloseWithError(e)
+	}()
+	return &sqlLocalReader{PipeReader: pr, src: src}, nil
+}
+
+func newSQLInputReader(r io.Reader, in minio.SelectObjectInputSerialization) (sqlRecordReader, error) {
+	switch strings.ToUpper(string(in.CompressionType)) {
+	case "", string(minio.SelectCompressionNONE):
+	case string(minio.SelectCompressionGZIP):
+		zr, e := gzip.NewReader(r)
+		if e != nil {
+			return nil, e
+		}
+		r = zr
+	case string(minio.SelectCompressionBZIP):
+		r = bzip2.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unknown compression type '%s'", in.CompressionType)
+	}
+
+	switch {
+	case in.Parquet != nil:
+		return newSQLParquetReader(r)
+	case in.JSON != nil:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		return &sqlJSONReader{
+			dec:      dec,
+			document: strings.EqualFold(string(in.JSON.Type), string(minio.JSONDocumentType)),
+		}, nil
+	case in.CSV != nil:
+		return newSQLCSVReader(r, *in.CSV)
+	}
+	return nil, errors.New("unable to determine the input format, please use --csv-input or --json-input")
+}
+
+// names returns the output column names of the SELECT list.
+func (q *sqlQuery) names() []string {
+	names := make([]string, len(q.projections))
+	for i, p := range q.projections {
+		switch {
+		case p.alias != "":
+			names[i] = p.alias
+		default:
+			if c, ok := p.expr.(*sqlColumn); ok && c.name() != "" {
+				names[i] = c.name()
+			} else {
+				names[i] = "_" + strconv.Itoa(i+1)
+			}
+		}
+	}
+	return names
+}
+
+func (q *sqlQuery) project(rec sqlRecord, names []string) ([]string, []interface{}, error) {
+	if q.star {
+		n, v := rec.columns()
+		return n, v, nil
+	}
+	values := make([]interface{}, len(q.projections))
+	for i, p := range q.projections {
+		v, err := p.expr.eval(rec)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i] = v
+	}
+	return names, values, nil
+}
+
+// run reads all records, writing the ones matching the query.
+func (q *sqlQuery) run(rd sqlRecordReader, wr sqlRecordWriter) error {
+	names := q.names()
+	var written int64
+	for q.limit < 0 || written < q.limit || len(q.aggregates) > 0 {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if q.where != nil {
+			v, err := q.where.eval(rec)
+			if err != nil {
+				return err
+			}
+			if b, ok := sqlToBool(v); !ok || !b {
+				continue
+			}
+		}
+		if len(q.aggregates) > 0 {
+			for _, agg := range q.aggregates {
+				if err = agg.update(rec); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		n, v, err := q.project(rec, names)
+		if err != nil {
+			return err
+		}
+		if err = wr.Write(n, v); err != nil {
+			return err
+		}
+		written++
+	}
+	if len(q.aggregates) > 0 && q.limit != 0 {
+		n, v, err := q.project(&sqlCSVRecord{}, names)
+		if err != nil {
+			return err
+		}
+		return wr.Write(n, v)
+	}
+	return nil
+}
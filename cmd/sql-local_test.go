@@ -0,0 +1,252 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+const sqlTestCSV = `name,power,city
+alpha,10,"New York, NY"
+beta,25,Paris
+gamma,30.5,Berlin
+`
+
+const sqlTestJSON = `{"id":1,"user":{"name":"alice","tags":["a","b"]},"ok":true}
+{"id":2,"user":{"name":"bob","tags":[]},"ok":false}
+{"id":3,"user":{"name":"carol"}}
+`
+
+func runLocalSelect(t *testing.T, data, query string, in minio.SelectObjectInputSerialization, out minio.SelectObjectOutputSerialization) (string, error) {
+	t.Helper()
+	r, err := localSelect(io.NopCloser(strings.NewReader(data)), query, in, out)
+	if err != nil {
+		return "", err.ToGoError()
+	}
+	defer r.Close()
+	b, e := io.ReadAll(r)
+	return string(b), e
+}
+
+func TestLocalSelectCSV(t *testing.T) {
+	in := minio.SelectObjectInputSerialization{
+		CSV: &minio.CSVInputOptions{FileHeaderInfo: minio.CSVFileHeaderInfoUse},
+	}
+	csvOut := minio.SelectObjectOutputSerialization{CSV: &minio.CSVOutputOptions{}}
+	jsonOut := minio.SelectObjectOutputSerialization{JSON: &minio.JSONOutputOptions{}}
+
+	testCases := []struct {
+		query  string
+		out    minio.SelectObjectOutputSerialization
+		expect string
+	}{
+		{"select * from S3Object", csvOut, "alpha,10,\"New York, NY\"\nbeta,25,Paris\ngamma,30.5,Berlin\n"},
+		{"SELECT s.name FROM S3Object s WHERE s.power > 20", csvOut, "beta\ngamma\n"},
+		{"select name, power * 2 from s3object where city like '%York%'", csvOut, "alpha,20\n"},
+		{"select _1 from S3Object limit 1", csvOut, "alpha\n"},
+		{"select s.\"name\" from S3Object s where s.power between 10 and 25 and not s.name = 'beta'", csvOut, "alpha\n"},
+		{"select name from S3Object where upper(city) in ('PARIS', 'BERLIN')", csvOut, "beta\ngamma\n"},
+		{"select count(*), sum(s.power), min(s.name), max(power) from S3Object s", csvOut, "3,65.5,alpha,30.5\n"},
+		{"select count(*) from S3Object where power > 100", csvOut, "0\n"},
+		{"select name as n, city from S3Object limit 1", jsonOut, "{\"n\":\"alpha\",\"city\":\"New York, NY\"}\n"},
+		{"select cast(power as int) + 1 from S3Object where name = 'gamma'", jsonOut, "{\"_1\":31}\n"},
+		{"select substring(name from 2 for 3) || '!' from S3Object where name = 'alpha'", csvOut, "lph!\n"},
+	}
+	for i, tc := range testCases {
+		got, err := runLocalSelect(t, sqlTestCSV, tc.query, in, tc.out)
+		if err != nil {
+			t.Fatalf("case %d: %s: unexpected error %v", i+1, tc.query, err)
+		}
+		if got != tc.expect {
+			t.Errorf("case %d: %s: expected %q, got %q", i+1, tc.query, tc.expect, got)
+		}
+	}
+}
+
+func TestLocalSelectCSVOptions(t *testing.T) {
+	data := "# comment\na;1\nb;\"x;y\"\n"
+	in := minio.SelectObjectInputSerialization{
+		CSV: &minio.CSVInputOptions{FieldDelimiter: ";", Comments: "#"},
+	}
+	out := minio.SelectObjectOutputSerialization{
+		CSV: &minio.CSVOutputOptions{FieldDelimiter: "|", RecordDelimiter: "\r\n", QuoteFields: minio.CSVQuoteFieldsAlways},
+	}
+	got, err := runLocalSelect(t, data, "select _2, _1 from S3Object", in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "\"1\"|\"a\"\r\n\"x;y\"|\"b\"\r\n"; got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+
+	// Custom record delimiter with a header line to ignore.
+	in = minio.SelectObjectInputSerialization{
+		CSV: &minio.CSVInputOptions{RecordDelimiter: "|", FileHeaderInfo: minio.CSVFileHeaderInfoIgnore},
+	}
+	got, err = runLocalSelect(t, "h1,h2|1,2|3,4", "select _2 from S3Object", in, minio.SelectObjectOutputSerialization{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "2\n4\n"; got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+}
+
+func TestLocalSelectJSON(t *testing.T) {
+	in := minio.SelectObjectInputSerialization{JSON: &minio.JSONInputOptions{Type: minio.JSONLinesType}}
+	out := minio.SelectObjectOutputSerialization{JSON: &minio.JSONOutputOptions{}}
+
+	testCases := []struct {
+		query  string
+		expect string
+	}{
+		{"select * from S3Object s where s.id = 2", "{\"id\":2,\"user\":{\"name\":\"bob\",\"tags\":[]},\"ok\":false}\n"},
+		{"select s.user.name from S3Object[*] s where s.ok", "{\"name\":\"alice\"}\n"},
+		{"select s.user.tags[1] as tag from S3Object s where s.user.tags[1] is not null", "{\"tag\":\"b\"}\n"},
+		{"select s.id from S3Object s where s.ok is missing", "{\"id\":3}\n"},
+		{"select avg(s.id), count(s.ok) from S3Object s", "{\"_1\":2,\"_2\":2}\n"},
+	}
+	for i, tc := range testCases {
+		got, err := runLocalSelect(t, sqlTestJSON, tc.query, in, out)
+		if err != nil {
+			t.Fatalf("case %d: %s: unexpected error %v", i+1, tc.query, err)
+		}
+		if got != tc.expect {
+			t.Errorf("case %d: %s: expected %q, got %q", i+1, tc.query, tc.expect, got)
+		}
+	}
+
+	// A DOCUMENT holding an array is read one element at a time.
+	in = minio.SelectObjectInputSerialization{JSON: &minio.JSONInputOptions{Type: minio.JSONDocumentType}}
+	got, err := runLocalSelect(t, `[{"a":1},{"a":2}]`, "select s.a from S3Object s where s.a > 1", in,
+		minio.SelectObjectOutputSerialization{CSV: &minio.CSVOutputOptions{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "2\n" {
+		t.Errorf("expected %q, got %q", "2\n", got)
+	}
+}
+
+// testdata/people.parquet is written by github.com/xitongsys/parquet-go,
+// with snappy compression, a DATE, a TIMESTAMP_MILLIS and a LIST column.
+func TestLocalSelectParquet(t *testing.T) {
+	data, e := os.ReadFile("testdata/people.parquet")
+	if e != nil {
+		t.Fatal(e)
+	}
+	in := minio.SelectObjectInputSerialization{Parquet: &minio.ParquetInputOptions{}}
+	out := minio.SelectObjectOutputSerialization{JSON: &minio.JSONOutputOptions{}}
+
+	testCases := []struct {
+		query  string
+		expect string
+	}{
+		{
+			"select * from S3Object s where s.name = 'alice'",
+			"{\"name\":\"alice\",\"age\":31,\"score\":9.5,\"active\":true,\"born\":\"1990-01-01\"," +
+				"\"created\":\"2020-09-13T12:26:40Z\",\"tags\":[\"admin\",\"dev\"]}\n",
+		},
+		{"select s.name from S3Object s where s.active is null", "{\"name\":\"carol\"}\n"},
+		{"select s.tags[0] as tag from S3Object s where s.age < 30", "{\"tag\":\"dev\"}\n"},
+		{"select s.created from S3Object s where s.born = '1997-05-19'", "{\"created\":\"2020-09-13T12:26:41.5Z\"}\n"},
+		{"select count(*), sum(s.age), max(s.score) from S3Object s", "{\"_1\":3,\"_2\":98,\"_3\":9.5}\n"},
+	}
+	for i, tc := range testCases {
+		got, err := runLocalSelect(t, string(data), tc.query, in, out)
+		if err != nil {
+			t.Fatalf("case %d: %s: unexpected error %v", i+1, tc.query, err)
+		}
+		if got != tc.expect {
+			t.Errorf("case %d: %s: expected %q, got %q", i+1, tc.query, tc.expect, got)
+		}
+	}
+}
+
+func TestLocalSelectGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, sqlTestCSV)
+	zw.Close()
+
+	in := minio.SelectObjectInputSerialization{
+		CompressionType: minio.SelectCompressionGZIP,
+		CSV:             &minio.CSVInputOptions{FileHeaderInfo: minio.CSVFileHeaderInfoUse},
+	}
+	got, err := runLocalSelect(t, buf.String(), "select count(*) from S3Object", in, minio.SelectObjectOutputSerialization{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "3\n" {
+		t.Errorf("expected %q, got %q", "3\n", got)
+	}
+}
+
+func TestLocalSelectErrors(t *testing.T) {
+	csvIn := minio.SelectObjectInputSerialization{CSV: &minio.CSVInputOptions{FileHeaderInfo: minio.CSVFileHeaderInfoUse}}
+	testCases := []struct {
+		query string
+		in    minio.SelectObjectInputSerialization
+	}{
+		{"select * from S3Object where", csvIn},
+		{"select * from mytable", csvIn},
+		{"select name, count(*) from S3Object", csvIn},
+		{"select * from S3Object where count(*) > 1", csvIn},
+		{"select foo(name) from S3Object", csvIn},
+		{"select 'abc from S3Object", csvIn},
+		{"select * from S3Object limit -1", csvIn},
+		{"select * from S3Object", minio.SelectObjectInputSerialization{Parquet: &minio.ParquetInputOptions{}}},
+		{"select * from S3Object", minio.SelectObjectInputSerialization{}},
+	}
+	for i, tc := range testCases {
+		if _, err := runLocalSelect(t, sqlTestCSV, tc.query, tc.in, minio.SelectObjectOutputSerialization{}); err == nil {
+			t.Errorf("case %d: %s: expected an error", i+1, tc.query)
+		}
+	}
+
+	// Evaluation errors are reported by the reader.
+	if _, err := runLocalSelect(t, sqlTestCSV, "select power / 0 from S3Object", csvIn, minio.SelectObjectOutputSerialization{}); err == nil {
+		t.Error("expected division by zero to fail")
+	}
+}
+
+func TestIsSelectNotSupported(t *testing.T) {
+	testCases := []struct {
+		err    error
+		expect bool
+	}{
+		{minio.ErrorResponse{Code: "NotImplemented", StatusCode: http.StatusNotImplemented}, true},
+		{minio.ErrorResponse{StatusCode: http.StatusMethodNotAllowed}, true},
+		{minio.ErrorResponse{Code: "InvalidQuery", StatusCode: http.StatusBadRequest}, false},
+		{errors.New("connection reset"), false},
+	}
+	for i, tc := range testCases {
+		if got := isSelectNotSupported(tc.err); got != tc.expect {
+			t.Errorf("case %d: expected %v, got %v", i+1, tc.expect, got)
+		}
+	}
+}
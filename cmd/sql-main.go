@@ -32,9 +32,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/console"
 	"github.com/minio/pkg/mimedb"
 )
 
@@ -72,6 +74,27 @@ var sqlFlags = []cli.Flag{
 		Name:  "json-output",
 		Usage: "json output serialization option",
 	},
+	cli.BoolFlag{
+		Name:  "local",
+		Usage: "evaluate the query locally instead of using the server Select API",
+	},
+	cli.BoolFlag{
+		Name:  "describe",
+		Usage: "sample objects and print the inferred column names, types and nullability",
+	},
+	cli.Int64Flag{
+		Name:  "sample",
+		Usage: "number of records to sample per object with --describe, 0 reads all records",
+		Value: 1000,
+	},
+	cli.StringFlag{
+		Name:  "output",
+		Usage: "write the results to TARGET instead of stdout",
+	},
+	cli.StringFlag{
+		Name:  "output-format",
+		Usage: "format of --output results, one of 'parquet' or 'ndjson' (default: guessed from TARGET extension)",
+	},
 }
 
 // Display contents of a file.
@@ -123,6 +146,20 @@ EXAMPLES:
      {{.Prompt}} {{.HelpName}} --compression GZIP --csv-input "rd=\n,fh=USE,fd=;" \
          --csv-output "rd=\n" --csv-output-header "device_id,uptime,lat,lon" \
          --query "select * from S3Object" myminio/iot-devices/data.csv
+
+  7. Run a query on a local CSV file, the query is evaluated by mc itself.
+     {{.Prompt}} {{.HelpName}} --query "select s.name from S3Object s where s.power > 20" ./power-ratio.csv
+
+  8. Run a query on a gateway that does not implement S3 Select, evaluating it locally.
+     {{.Prompt}} {{.HelpName}} --local --json-input "t=lines" \
+         --query "select count(*) from S3Object s where s.status = 'failed'" mygateway/logs/events.json
+
+  9. Print the columns, types and nullability inferred from the first 500 records of each object.
+     {{.Prompt}} {{.HelpName}} --describe --sample 500 --recursive myminio/iot-devices/
+
+  10. Write the results of a query as Parquet to another bucket.
+     {{.Prompt}} {{.HelpName}} --query "select s.device_id, s.power from S3Object s where s.power > 20" \
+         --output myminio/reports/high-power.parquet myminio/iot-devices/power-ratio.csv
 `,
 }
 
@@ -364,11 +401,16 @@ func getCSVOutputHeaders(ctx *cli.Context, url string, encKeyDB map[string][]pre
 func getSQLOpts(ctx *cli.Context, csvHdrs []string) (s SelectObjectOpts) {
 	is := getInputSerializationOpts(ctx)
 	os := getOutputSerializationOpts(ctx, csvHdrs)
+	if ctx.IsSet("output") {
+		// Results are collected as JSON records and converted on upload.
+		os = map[string]map[string]string{"json": {}}
+	}
 
 	return SelectObjectOpts{
 		InputSerOpts:    is,
 		OutputSerOpts:   os,
 		CompressionType: minio.SelectCompressionType(ctx.String("compression")),
+		Local:           ctx.Bool("local"),
 	}
 }
 
@@ -382,7 +424,7 @@ func isCSVOrJSON(inOpts map[string]map[string]string) bool {
 	return false
 }
 
-func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, csvHdrs []string, writeHdr bool) *probe.Error {
+func sqlSelect(w io.Writer, targetURL, expression string, encKeyDB map[string][]prefixSSEPair, selOpts SelectObjectOpts, csvHdrs []string, writeHdr bool) *probe.Error {
 	ctx, cancelSelect := context.WithCancel(globalContext)
 	defer cancelSelect()
 
@@ -403,11 +445,11 @@ func sqlSelect(targetURL, expression string, encKeyDB map[string][]prefixSSEPair
 	}
 	defer outputer.Close()
 
-	// write csv header to stdout
+	// write csv header to the output
 	if len(csvHdrs) > 0 && writeHdr {
-		fmt.Println(strings.Join(csvHdrs, ","))
+		fmt.Fprintln(w, strings.Join(csvHdrs, ","))
 	}
-	_, e := io.Copy(os.Stdout, outputer)
+	_, e := io.Copy(w, outputer)
 	return probe.NewError(e)
 }
 
@@ -432,6 +474,18 @@ func checkSQLSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) == 0 {
 		showCommandHelpAndExit(ctx, "sql", 1) // last argument is exit code.
 	}
+	if ctx.Bool("describe") && ctx.IsSet("output") {
+		fatalIf(errInvalidArgument(), "--describe cannot be used with --output")
+	}
+	if ctx.IsSet("output") {
+		if ctx.IsSet("csv-output") || ctx.IsSet("csv-output-header") || ctx.IsSet("json-output") {
+			fatalIf(errInvalidArgument(), "--output cannot be used with --csv-output, --csv-output-header or --json-output")
+		}
+		_, err := sqlOutputFormat(ctx.String("output"), ctx.String("output-format"))
+		fatalIf(err, "Unable to determine the output format, please use --output-format parquet or --output-format ndjson")
+	} else if ctx.IsSet("output-format") {
+		fatalIf(errInvalidArgument(), "--output-format requires --output")
+	}
 }
 
 // mainSQL is the main entry point for sql command.
@@ -450,6 +504,21 @@ func mainSQL(cliCtx *cli.Context) error {
 
 	// validate sql input arguments.
 	checkSQLSyntax(cliCtx)
+
+	if cliCtx.Bool("describe") {
+		return mainSQLDescribe(cliCtx, encKeyDB)
+	}
+
+	var w io.Writer = os.Stdout
+	var output *sqlOutput
+	if cliCtx.IsSet("output") {
+		outputURL := cliCtx.String("output")
+		format, _ := sqlOutputFormat(outputURL, cliCtx.String("output-format"))
+		output, err = newSQLOutput(outputURL, format, encKeyDB)
+		fatalIf(err, "Unable to prepare output `"+outputURL+"`.")
+		w = output
+	}
+
 	// extract URLs.
 	URLs := cliCtx.Args()
 	writeHdr := true
@@ -461,7 +530,7 @@ func mainSQL(cliCtx *cli.Context) error {
 			if writeHdr {
 				query, csvHdrs, selOpts = getAndValidateArgs(cliCtx, encKeyDB, url)
 			}
-			errorIf(sqlSelect(url, query, encKeyDB, selOpts, csvHdrs, writeHdr).Trace(url), "Unable to run sql")
+			errorIf(sqlSelect(w, url, query, encKeyDB, selOpts, csvHdrs, writeHdr).Trace(url), "Unable to run sql")
 			writeHdr = false
 			continue
 		}
@@ -483,7 +552,7 @@ func mainSQL(cliCtx *cli.Context) error {
 			contentType := mimedb.TypeByExtension(filepath.Ext(content.URL.Path))
 			for _, cTypeSuffix := range supportedContentTypes {
 				if strings.Contains(contentType, cTypeSuffix) {
-					errorIf(sqlSelect(targetAlias+content.URL.Path, query,
+					errorIf(sqlSelect(w, targetAlias+content.URL.Path, query,
 						encKeyDB, selOpts, csvHdrs, writeHdr).Trace(content.URL.String()), "Unable to run sql")
 				}
 				writeHdr = false
@@ -491,6 +560,13 @@ func mainSQL(cliCtx *cli.Context) error {
 		}
 	}
 
+	if output != nil {
+		console.SetColor("SQLOutput", color.New(color.FgGreen, color.Bold))
+		msg, err := output.Close()
+		fatalIf(err, "Unable to write results to `"+msg.Target+"`.")
+		printMsg(msg)
+	}
+
 	// Done.
 	return nil
 }
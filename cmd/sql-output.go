@@ -0,0 +1,206 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	jsoncolor "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+// Output formats of 'mc sql --output'.
+const (
+	sqlOutputParquet = "parquet"
+	sqlOutputNDJSON  = "ndjson"
+)
+
+// sqlOutputFormat returns the output format, guessed from the target
+// extension unless given explicitly.
+func sqlOutputFormat(targetURL, format string) (string, *probe.Error) {
+	switch strings.ToLower(format) {
+	case sqlOutputParquet:
+		return sqlOutputParquet, nil
+	case sqlOutputNDJSON, "jsonl":
+		return sqlOutputNDJSON, nil
+	case "":
+	default:
+		return "", errInvalidArgument().Trace(format)
+	}
+	switch strings.ToLower(filepath.Ext(targetURL)) {
+	case ".parquet":
+		return sqlOutputParquet, nil
+	case ".ndjson", ".jsonl", ".json":
+		return sqlOutputNDJSON, nil
+	}
+	return "", errInvalidArgument().Trace(targetURL)
+}
+
+// sqlOutput collects the JSON records of query results in a spool file
+// and uploads them as NDJSON or Parquet once all queries are done.
+type sqlOutput struct {
+	targetURL string
+	format    string
+	opts      PutOptions
+	spool     *os.File
+	records   int64
+}
+
+func newSQLOutput(targetURL, format string, encKeyDB map[string][]prefixSSEPair) (*sqlOutput, *probe.Error) {
+	alias, _, _, err := expandAlias(targetURL)
+	if err != nil {
+		return nil, err.Trace(targetURL)
+	}
+	spool, e := os.CreateTemp("", "mc-sql-")
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &sqlOutput{
+		targetURL: targetURL,
+		format:    format,
+		opts:      PutOptions{sse: getSSE(targetURL, encKeyDB[alias])},
+		spool:     spool,
+	}, nil
+}
+
+// Write appends JSON records, one per line.
+func (o *sqlOutput) Write(p []byte) (int, error) {
+	o.records += int64(bytes.Count(p, []byte("\n")))
+	return o.spool.Write(p)
+}
+
+// readRecords calls fn for every spooled record.
+func (o *sqlOutput) readRecords(fn func(names []string, values []interface{}) error) error {
+	if _, e := o.spool.Seek(0, io.SeekStart); e != nil {
+		return e
+	}
+	dec := json.NewDecoder(bufio.NewReader(o.spool))
+	dec.UseNumber()
+	for {
+		v, e := sqlDecodeJSON(dec)
+		if e == io.EOF {
+			return nil
+		}
+		if e != nil {
+			return e
+		}
+		if e = fn((&sqlJSONRecord{v: v}).columns()); e != nil {
+			return e
+		}
+	}
+}
+
+// writeParquet converts the spooled records into a Parquet file.
+func (o *sqlOutput) writeParquet(w io.Writer) (int64, error) {
+	schema := newSQLSchema()
+	if e := o.readRecords(func(names []string, values []interface{}) error {
+		schema.add(names, values)
+		return nil
+	}); e != nil {
+		return 0, e
+	}
+
+	fields := schema.parquetFields()
+	bw := bufio.NewWriter(w)
+	pw, e := newParquetWriter(bw, fields)
+	if e != nil {
+		return 0, e
+	}
+	row := make([]interface{}, len(fields))
+	if e = o.readRecords(func(names []string, values []interface{}) error {
+		for i := range row {
+			row[i] = nil
+		}
+		for i, name := range names {
+			row[schema.index[name]] = values[i]
+		}
+		return pw.Write(row)
+	}); e != nil {
+		return 0, e
+	}
+	if e = pw.Close(); e != nil {
+		return 0, e
+	}
+	return schema.records, bw.Flush()
+}
+
+// Close uploads the results to the target and removes the spool file.
+func (o *sqlOutput) Close() (sqlOutputMessage, *probe.Error) {
+	defer os.Remove(o.spool.Name())
+	defer o.spool.Close()
+
+	msg := sqlOutputMessage{Target: o.targetURL, Format: o.format, Records: o.records}
+	upload := o.spool
+	if o.format == sqlOutputParquet {
+		f, e := os.CreateTemp("", "mc-sql-parquet-")
+		if e != nil {
+			return msg, probe.NewError(e)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		if msg.Records, e = o.writeParquet(f); e != nil {
+			return msg, probe.NewError(e)
+		}
+		upload = f
+	}
+
+	size, e := upload.Seek(0, io.SeekEnd)
+	if e == nil {
+		_, e = upload.Seek(0, io.SeekStart)
+	}
+	if e != nil {
+		return msg, probe.NewError(e)
+	}
+	msg.Size = size
+	if _, err := putTargetStreamWithURL(o.targetURL, upload, size, o.opts); err != nil {
+		return msg, err.Trace(o.targetURL)
+	}
+	return msg, nil
+}
+
+// sqlOutputMessage container for the results written by 'mc sql --output'.
+type sqlOutputMessage struct {
+	Status  string `json:"status"`
+	Target  string `json:"target"`
+	Format  string `json:"format"`
+	Records int64  `json:"records"`
+	Size    int64  `json:"size"`
+}
+
+// String colorized output message.
+func (m sqlOutputMessage) String() string {
+	return console.Colorize("SQLOutput", fmt.Sprintf("Wrote %d record(s) as %s (%s) to `%s`.",
+		m.Records, m.Format, humanize.IBytes(uint64(m.Size)), m.Target))
+}
+
+// JSON jsonified output message.
+func (m sqlOutputMessage) JSON() string {
+	m.Status = "success"
+	jsonMessageBytes, e := jsoncolor.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"time"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// sqlParquetReader reads the rows of a Parquet file as records, values
+// are converted to the types read from the other input formats.
+type sqlParquetReader struct {
+	r       *goparquet.FileReader
+	columns []*parquetschema.ColumnDefinition
+	// Local copy of an input which cannot seek.
+	spool *os.File
+}
+
+// newSQLParquetReader opens a Parquet input, the metadata is at the end
+// of a file so streamed objects are copied to a temporary file first.
+func newSQLParquetReader(r io.Reader) (*sqlParquetReader, error) {
+	pr := &sqlParquetReader{}
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		f, e := os.CreateTemp("", "mc-sql-input-")
+		if e != nil {
+			return nil, e
+		}
+		pr.spool = f
+		if _, e = io.Copy(f, r); e == nil {
+			_, e = f.Seek(0, io.SeekStart)
+		}
+		if e != nil {
+			pr.Close()
+			return nil, e
+		}
+		rs = f
+	}
+	fr, e := goparquet.NewFileReader(rs)
+	if e != nil {
+		pr.Close()
+		return nil, fmt.Errorf("unable to read parquet input: %w", e)
+	}
+	pr.r = fr
+	pr.columns = fr.GetSchemaDefinition().RootColumn.Children
+	return pr, nil
+}
+
+func (pr *sqlParquetReader) Read() (sqlRecord, error) {
+	row, e := pr.r.NextRow()
+	if e != nil {
+		return nil, e
+	}
+	return &sqlJSONRecord{v: sqlParquetGroup(row, pr.columns)}, nil
+}
+
+// Close removes the temporary copy of the input, if any.
+func (pr *sqlParquetReader) Close() error {
+	if pr.spool == nil {
+		return nil
+	}
+	pr.spool.Close()
+	return os.Remove(pr.spool.Name())
+}
+
+// sqlParquetGroup converts a row or a group to an object with the keys
+// in schema order, missing optional columns are NULL.
+func sqlParquetGroup(m map[string]interface{}, columns []*parquetschema.ColumnDefinition) *sqlObject {
+	o := &sqlObject{values: make(map[string]interface{}, len(columns))}
+	for _, col := range columns {
+		name := col.SchemaElement.Name
+		o.set(name, sqlParquetValue(m[name], col))
+	}
+	return o
+}
+
+// sqlParquetValue converts a column value to a SQL value: strings, int64,
+// float64, bool, arrays and objects. Dates and timestamps are formatted
+// as RFC3339 strings, like in the other input formats.
+func sqlParquetValue(v interface{}, col *parquetschema.ColumnDefinition) interface{} {
+	el := col.SchemaElement
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case bool, float64, string:
+		return v
+	case float32:
+		return float64(v)
+	case int32:
+		return sqlParquetInt(int64(v), el)
+	case int64:
+		return sqlParquetInt(v, el)
+	case [12]byte:
+		return goparquet.Int96ToTime(v).UTC().Format(time.RFC3339Nano)
+	case []byte:
+		if sqlParquetIsDecimal(el) {
+			return sqlParquetDecimal(new(big.Int).SetBytes(v), v, el.GetScale())
+		}
+		return string(v)
+	case map[string]interface{}:
+		switch {
+		case el.IsSetLogicalType() && el.LogicalType.IsSetLIST(),
+			el.GetConvertedType() == parquet.ConvertedType_LIST:
+			return sqlParquetList(v, col)
+		case el.IsSetLogicalType() && el.LogicalType.IsSetMAP(),
+			el.GetConvertedType() == parquet.ConvertedType_MAP,
+			el.GetConvertedType() == parquet.ConvertedType_MAP_KEY_VALUE:
+			return sqlParquetMap(v, col)
+		}
+		return sqlParquetGroup(v, col.Children)
+	}
+
+	// Repeated fields are read as slices of their values.
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		a := make([]interface{}, rv.Len())
+		for i := range a {
+			a[i] = sqlParquetValue(rv.Index(i).Interface(), col)
+		}
+		return a
+	}
+	return fmt.Sprint(v)
+}
+
+// sqlParquetInt applies the logical type of an integer column.
+func sqlParquetInt(v int64, el *parquet.SchemaElement) interface{} {
+	if el.IsSetLogicalType() && el.LogicalType.IsSetTIMESTAMP() {
+		unit := el.LogicalType.TIMESTAMP.GetUnit()
+		switch {
+		case unit.IsSetNANOS():
+			return time.Unix(0, v).UTC().Format(time.RFC3339Nano)
+		case unit.IsSetMICROS():
+			return time.UnixMicro(v).UTC().Format(time.RFC3339Nano)
+		}
+		return time.UnixMilli(v).UTC().Format(time.RFC3339Nano)
+	}
+	switch {
+	case el.IsSetLogicalType() && el.LogicalType.IsSetDATE(),
+		el.GetConvertedType() == parquet.ConvertedType_DATE:
+		return time.Unix(v*24*60*60, 0).UTC().Format("2006-01-02")
+	case el.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MILLIS:
+		return time.UnixMilli(v).UTC().Format(time.RFC3339Nano)
+	case el.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS:
+		return time.UnixMicro(v).UTC().Format(time.RFC3339Nano)
+	case sqlParquetIsDecimal(el):
+		return sqlParquetDecimal(big.NewInt(v), nil, el.GetScale())
+	}
+	return v
+}
+
+func sqlParquetIsDecimal(el *parquet.SchemaElement) bool {
+	return el.IsSetLogicalType() && el.LogicalType.IsSetDECIMAL() ||
+		el.GetConvertedType() == parquet.ConvertedType_DECIMAL
+}
+
+// sqlParquetDecimal returns a decimal as float64, b is the big endian
+// two's complement encoding of byte array decimals.
+func sqlParquetDecimal(unscaled *big.Int, b []byte, scale int32) interface{} {
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	if scale == 0 && unscaled.IsInt64() {
+		return unscaled.Int64()
+	}
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(unscaled),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))).Float64()
+	return f
+}
+
+// sqlParquetList returns the elements of a LIST annotated group.
+func sqlParquetList(v map[string]interface{}, col *parquetschema.ColumnDefinition) interface{} {
+	if len(col.Children) != 1 {
+		return sqlParquetGroup(v, col.Children)
+	}
+	repeated := col.Children[0]
+	items, ok := v[repeated.SchemaElement.Name].([]map[string]interface{})
+	if !ok {
+		return sqlParquetValue(v[repeated.SchemaElement.Name], repeated)
+	}
+	a := make([]interface{}, len(items))
+	for i, item := range items {
+		if len(repeated.Children) == 1 {
+			// Standard three level list, the group wraps the element.
+			element := repeated.Children[0]
+			a[i] = sqlParquetValue(item[element.SchemaElement.Name], element)
+		} else {
+			a[i] = sqlParquetGroup(item, repeated.Children)
+		}
+	}
+	return a
+}
+
+// sqlParquetMap returns a MAP annotated group as an object.
+func sqlParquetMap(v map[string]interface{}, col *parquetschema.ColumnDefinition) interface{} {
+	if len(col.Children) != 1 || len(col.Children[0].Children) != 2 {
+		return sqlParquetGroup(v, col.Children)
+	}
+	keyValue := col.Children[0]
+	keyCol, valueCol := keyValue.Children[0], keyValue.Children[1]
+	o := &sqlObject{values: make(map[string]interface{})}
+	items, _ := v[keyValue.SchemaElement.Name].([]map[string]interface{})
+	for _, item := range items {
+		key := sqlFormat(sqlParquetValue(item[keyCol.SchemaElement.Name], keyCol))
+		o.set(key, sqlParquetValue(item[valueCol.SchemaElement.Name], valueCol))
+	}
+	return o
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquet"
+	"github.com/fraugster/parquet-go/parquetschema"
+)
+
+// parquetRowGroupSize is the approximate size of a row group in bytes.
+const parquetRowGroupSize = 64 * 1024 * 1024
+
+// parquetField is a column of the written file, typ is one of the
+// types inferred by sqlInferType.
+type parquetField struct {
+	name string
+	typ  string
+}
+
+// schemaElement returns the OPTIONAL column holding the field. Converted
+// types are set along with logical types for older readers.
+func (f parquetField) schemaElement() *parquet.SchemaElement {
+	el := &parquet.SchemaElement{
+		Name:           f.name,
+		RepetitionType: parquet.FieldRepetitionTypePtr(parquet.FieldRepetitionType_OPTIONAL),
+	}
+	switch f.typ {
+	case "bool":
+		el.Type = parquet.TypePtr(parquet.Type_BOOLEAN)
+	case "int":
+		el.Type = parquet.TypePtr(parquet.Type_INT64)
+	case "float":
+		el.Type = parquet.TypePtr(parquet.Type_DOUBLE)
+	case "timestamp":
+		el.Type = parquet.TypePtr(parquet.Type_INT64)
+		el.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_TIMESTAMP_MILLIS)
+		el.LogicalType = &parquet.LogicalType{TIMESTAMP: &parquet.TimestampType{
+			IsAdjustedToUTC: true,
+			Unit:            &parquet.TimeUnit{MILLIS: parquet.NewMilliSeconds()},
+		}}
+	case "object", "array":
+		el.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+		el.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_JSON)
+		el.LogicalType = &parquet.LogicalType{JSON: parquet.NewJsonType()}
+	default:
+		el.Type = parquet.TypePtr(parquet.Type_BYTE_ARRAY)
+		el.ConvertedType = parquet.ConvertedTypePtr(parquet.ConvertedType_UTF8)
+		el.LogicalType = &parquet.LogicalType{STRING: parquet.NewStringType()}
+	}
+	return el
+}
+
+// convert returns v as the Go type stored for the column, nil for NULL.
+func (f parquetField) convert(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if s, ok := v.(string); ok && s == "" && f.typ != "string" {
+		return nil, nil
+	}
+	switch f.typ {
+	case "bool":
+		if b, ok := sqlToBool(v); ok {
+			return b, nil
+		}
+	case "int":
+		if n, ok := sqlToNumber(v); ok {
+			if i, ok := n.(int64); ok {
+				return i, nil
+			}
+		}
+	case "float":
+		if n, ok := sqlToNumber(v); ok {
+			return sqlToFloat(n), nil
+		}
+	case "timestamp":
+		if t, ok := sqlParseTimestamp(sqlFormat(v)); ok {
+			return t.UnixNano() / int64(time.Millisecond), nil
+		}
+	default:
+		return []byte(sqlFormat(v)), nil
+	}
+	return nil, fmt.Errorf("value '%s' of column '%s' is not of type %s", sqlFormat(v), f.name, f.typ)
+}
+
+// parquetWriter writes rows to a snappy compressed Parquet file.
+type parquetWriter struct {
+	fw     *goparquet.FileWriter
+	fields []parquetField
+}
+
+func newParquetWriter(w io.Writer, fields []parquetField) (*parquetWriter, error) {
+	root := &parquetschema.ColumnDefinition{
+		SchemaElement: &parquet.SchemaElement{Name: "schema"},
+	}
+	for _, f := range fields {
+		root.Children = append(root.Children, &parquetschema.ColumnDefinition{SchemaElement: f.schemaElement()})
+	}
+	sd := parquetschema.SchemaDefinitionFromColumnDefinition(root)
+	if err := sd.Validate(); err != nil {
+		return nil, err
+	}
+	return &parquetWriter{
+		fw: goparquet.NewFileWriter(w,
+			goparquet.WithSchemaDefinition(sd),
+			goparquet.WithCompressionCodec(parquet.CompressionCodec_SNAPPY),
+			goparquet.WithMaxRowGroupSize(parquetRowGroupSize),
+			goparquet.WithCreator("mc version "+Version),
+		),
+		fields: fields,
+	}, nil
+}
+
+// Write adds a row, values are in the order of the fields.
+func (pw *parquetWriter) Write(values []interface{}) error {
+	row := make(map[string]interface{}, len(pw.fields))
+	for i, f := range pw.fields {
+		if i >= len(values) {
+			break
+		}
+		v, err := f.convert(values[i])
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			row[f.name] = v
+		}
+	}
+	return pw.fw.AddData(row)
+}
+
+// Close writes the remaining rows and the file footer.
+func (pw *parquetWriter) Close() error {
+	return pw.fw.Close()
+}
+
+// sqlParseTimestamp parses the timestamp layouts recognized by schema
+// inference.
+func sqlParseTimestamp(s string) (time.Time, bool) {
+	if len(s) < 10 || !strings.ContainsAny(s[4:5], "-") {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, e := time.Parse(layout, s); e == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sqlInferType returns the type of a value as reported by 'mc sql
+// --describe', strings are probed for numbers, booleans and timestamps.
+// An empty result means the value does not tell anything about the type.
+func sqlInferType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case *sqlObject:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		switch {
+		case v == "":
+			return ""
+		case sqlIsCanonicalInt(v):
+			return "int"
+		case sqlIsCanonicalFloat(v):
+			return "float"
+		case strings.EqualFold(v, "true") || strings.EqualFold(v, "false"):
+			return "bool"
+		}
+		if _, ok := sqlParseTimestamp(v); ok {
+			return "timestamp"
+		}
+	}
+	return "string"
+}
+
+// sqlIsCanonicalInt returns true for integers that survive a round trip,
+// so that values such as zip codes with leading zeros stay strings.
+func sqlIsCanonicalInt(s string) bool {
+	i, e := strconv.ParseInt(s, 10, 64)
+	return e == nil && strconv.FormatInt(i, 10) == s
+}
+
+func sqlIsCanonicalFloat(s string) bool {
+	if _, e := strconv.ParseFloat(s, 64); e != nil {
+		return false
+	}
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		// Rejects Inf, NaN and a leading '+' or '.'.
+		return false
+	}
+	return !(len(digits) > 1 && digits[0] == '0' && digits[1] != '.')
+}
+
+// sqlMergeType returns the type able to hold values of both types.
+func sqlMergeType(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "", a == b:
+		return a
+	case a == "int" && b == "float", a == "float" && b == "int":
+		return "float"
+	}
+	return "string"
+}
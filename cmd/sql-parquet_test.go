@@ -0,0 +1,185 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	goparquet "github.com/fraugster/parquet-go"
+	"github.com/fraugster/parquet-go/parquet"
+)
+
+// readParquetColumns returns the values of every column of a Parquet
+// file, and the schema elements of the columns by name.
+func readParquetColumns(t *testing.T, data []byte) (map[string]*parquet.SchemaElement, map[string][]interface{}) {
+	t.Helper()
+	fr, err := goparquet.NewFileReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elements := map[string]*parquet.SchemaElement{}
+	columns := map[string][]interface{}{}
+	for _, col := range fr.GetSchemaDefinition().RootColumn.Children {
+		elements[col.SchemaElement.Name] = col.SchemaElement
+	}
+	for {
+		row, err := fr.NextRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name := range elements {
+			v := row[name]
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			columns[name] = append(columns[name], v)
+		}
+	}
+	return elements, columns
+}
+
+func TestParquetWriter(t *testing.T) {
+	fields := []parquetField{
+		{name: "name", typ: "string"},
+		{name: "power", typ: "float"},
+		{name: "count", typ: "int"},
+		{name: "ok", typ: "bool"},
+		{name: "seen", typ: "timestamp"},
+		{name: "tags", typ: "array"},
+	}
+	rows := [][]interface{}{
+		{"alpha", "10.5", int64(1), true, "2024-01-02T10:00:00Z", []interface{}{"a"}},
+		{"beta", nil, "2", "false", "2024-02-01", nil},
+		{"", int64(3), nil, nil, nil, []interface{}{}},
+	}
+
+	var buf bytes.Buffer
+	pw, err := newParquetWriter(&buf, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err = pw.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	elements, columns := readParquetColumns(t, buf.Bytes())
+	if el := elements["seen"]; el.GetType() != parquet.Type_INT64 || !el.LogicalType.IsSetTIMESTAMP() {
+		t.Errorf("expected a timestamp column, got %v", el)
+	}
+	if el := elements["tags"]; el.GetConvertedType() != parquet.ConvertedType_JSON {
+		t.Errorf("expected a JSON column, got %v", el)
+	}
+	seen := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	expected := map[string][]interface{}{
+		"name":  {"alpha", "beta", ""},
+		"power": {10.5, nil, float64(3)},
+		"count": {int64(1), int64(2), nil},
+		"ok":    {true, false, nil},
+		"seen":  {seen, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond), nil},
+		"tags":  {`["a"]`, nil, `[]`},
+	}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected %v, got %v", expected, columns)
+	}
+
+	// Values not matching the column type are rejected.
+	pw, _ = newParquetWriter(&bytes.Buffer{}, fields[2:3])
+	if err = pw.Write([]interface{}{"abc"}); err == nil {
+		t.Error("expected an error writing a string into an int column")
+	}
+}
+
+func TestSQLSchemaInference(t *testing.T) {
+	testCases := []struct {
+		value  interface{}
+		expect string
+	}{
+		{nil, ""},
+		{"", ""},
+		{"42", "int"},
+		{"-7", "int"},
+		{"02134", "string"},
+		{"3.25", "float"},
+		{"0.5", "float"},
+		{"+1", "string"},
+		{"NaN", "string"},
+		{"TRUE", "bool"},
+		{"2024-01-02", "timestamp"},
+		{"2024-01-02T10:00:00.5Z", "timestamp"},
+		{"hello", "string"},
+		{int64(1), "int"},
+		{1.5, "float"},
+		{&sqlObject{}, "object"},
+		{[]interface{}{}, "array"},
+	}
+	for i, tc := range testCases {
+		if got := sqlInferType(tc.value); got != tc.expect {
+			t.Errorf("case %d: %v: expected %q, got %q", i+1, tc.value, tc.expect, got)
+		}
+	}
+
+	schema := newSQLSchema()
+	schema.add([]string{"a", "b"}, []interface{}{"1", "x"})
+	schema.add([]string{"a", "c"}, []interface{}{"2.5", nil})
+	schema.add([]string{"a", "b"}, []interface{}{"3", "4"})
+	expected := []sqlSchemaColumn{
+		{Name: "a", Type: "float"},
+		{Name: "b", Type: "string", Nullable: true},
+		{Name: "c", Type: "null", Nullable: true},
+	}
+	if got := schema.result(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSQLOutputFormat(t *testing.T) {
+	testCases := []struct {
+		target, format string
+		expect         string
+		fail           bool
+	}{
+		{"myminio/bucket/out.parquet", "", sqlOutputParquet, false},
+		{"myminio/bucket/out.NDJSON", "", sqlOutputNDJSON, false},
+		{"myminio/bucket/out.jsonl", "", sqlOutputNDJSON, false},
+		{"myminio/bucket/out", "parquet", sqlOutputParquet, false},
+		{"myminio/bucket/out.parquet", "ndjson", sqlOutputNDJSON, false},
+		{"myminio/bucket/out", "", "", true},
+		{"myminio/bucket/out.csv", "csv", "", true},
+	}
+	for i, tc := range testCases {
+		got, err := sqlOutputFormat(tc.target, tc.format)
+		if (err != nil) != tc.fail {
+			t.Fatalf("case %d: unexpected error state %v", i+1, err)
+		}
+		if got != tc.expect {
+			t.Errorf("case %d: expected %q, got %q", i+1, tc.expect, got)
+		}
+	}
+}
@@ -0,0 +1,696 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The local SQL engine understands the subset of the S3 Select SQL
+// dialect that is commonly used with 'mc sql':
+//
+//	SELECT <* | expr [AS alias], ...> FROM S3Object[[*]] [[AS] alias]
+//	  [WHERE expr] [LIMIT n]
+//
+// Expressions support column references (by name, by position as _N and
+// as dotted paths into JSON documents), string and number literals,
+// arithmetic, comparisons, AND/OR/NOT, LIKE, BETWEEN, IN, IS [NOT] NULL,
+// string concatenation with ||, CAST, a handful of scalar functions and
+// the COUNT, SUM, AVG, MIN and MAX aggregates.
+
+type sqlTokenKind int
+
+const (
+	sqlTokEOF sqlTokenKind = iota
+	sqlTokIdent
+	sqlTokQuotedIdent
+	sqlTokString
+	sqlTokNumber
+	sqlTokOp
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+	pos  int
+}
+
+// is returns true if the token is the given keyword or operator.
+func (t sqlToken) is(s string) bool {
+	switch t.kind {
+	case sqlTokIdent:
+		return strings.EqualFold(t.text, s)
+	case sqlTokOp:
+		return t.text == s
+	}
+	return false
+}
+
+var sqlReserved = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "ESCAPE": true,
+	"BETWEEN": true, "IN": true, "IS": true, "NULL": true, "MISSING": true,
+	"TRUE": true, "FALSE": true,
+}
+
+func sqlTokenize(s string) ([]sqlToken, error) {
+	var toks []sqlToken
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated string literal at position %d", start)
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						sb.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteByte(s[i])
+				i++
+			}
+			toks = append(toks, sqlToken{sqlTokString, sb.String(), start})
+		case c == '"':
+			start := i
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted identifier at position %d", start)
+			}
+			toks = append(toks, sqlToken{sqlTokQuotedIdent, s[i+1 : i+1+end], start})
+			i += end + 2
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9'):
+			start := i
+			for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+				i++
+			}
+			if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+				i++
+				if i < len(s) && (s[i] == '+' || s[i] == '-') {
+					i++
+				}
+				for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+					i++
+				}
+			}
+			toks = append(toks, sqlToken{sqlTokNumber, s[start:i], start})
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(s) && (s[i] == '_' || s[i] == '$' || unicode.IsLetter(rune(s[i])) || unicode.IsDigit(rune(s[i]))) {
+				i++
+			}
+			toks = append(toks, sqlToken{sqlTokIdent, s[start:i], start})
+		default:
+			start := i
+			op := string(c)
+			if i+1 < len(s) {
+				switch two := s[i : i+2]; two {
+				case "<=", ">=", "<>", "!=", "||":
+					op = two
+				}
+			}
+			if !strings.Contains("()[],.*+-/%=<>!|", op[:1]) || op == "!" || op == "|" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, start)
+			}
+			i += len(op)
+			toks = append(toks, sqlToken{sqlTokOp, op, start})
+		}
+	}
+	return append(toks, sqlToken{kind: sqlTokEOF, pos: len(s)}), nil
+}
+
+// sqlProjection is a single entry of the SELECT list.
+type sqlProjection struct {
+	expr  sqlExpr
+	alias string
+}
+
+// sqlQuery is a parsed SELECT statement.
+type sqlQuery struct {
+	star        bool
+	projections []sqlProjection
+	tableAlias  string
+	where       sqlExpr
+	limit       int64
+	aggregates  []*sqlAggregate
+}
+
+type sqlParser struct {
+	toks []sqlToken
+	pos  int
+
+	aggregates []*sqlAggregate
+	inAgg      bool
+}
+
+func (p *sqlParser) peek() sqlToken { return p.toks[p.pos] }
+
+func (p *sqlParser) next() sqlToken {
+	t := p.toks[p.pos]
+	if t.kind != sqlTokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *sqlParser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sqlParser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf("expected %s", s)
+	}
+	return nil
+}
+
+func (p *sqlParser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	if t.kind == sqlTokEOF {
+		return fmt.Errorf("%s at end of query", fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf("%s near '%s' at position %d", fmt.Sprintf(format, args...), t.text, t.pos)
+}
+
+// parseSQL parses an S3 Select SQL expression.
+func parseSQL(query string) (*sqlQuery, error) {
+	toks, err := sqlTokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &sqlParser{toks: toks}
+	q := &sqlQuery{limit: -1}
+
+	if err = p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	if p.accept("*") {
+		q.star = true
+	} else {
+		for {
+			if err = p.parseProjection(q); err != nil {
+				return nil, err
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if err = p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	from := p.next()
+	if from.kind != sqlTokIdent || !strings.EqualFold(from.text, "S3Object") {
+		return nil, errors.New("only S3Object is supported in the FROM clause")
+	}
+	if p.accept("[") {
+		if err = p.expect("*"); err != nil {
+			return nil, err
+		}
+		if err = p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	p.accept("AS")
+	if t := p.peek(); t.kind == sqlTokIdent && !sqlReserved[strings.ToUpper(t.text)] || t.kind == sqlTokQuotedIdent {
+		q.tableAlias = p.next().text
+	}
+
+	if p.accept("WHERE") {
+		n := len(p.aggregates)
+		if q.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if len(p.aggregates) > n {
+			return nil, errors.New("aggregate functions are not allowed in the WHERE clause")
+		}
+	}
+	if p.accept("LIMIT") {
+		t := p.next()
+		n, e := strconv.ParseInt(t.text, 10, 64)
+		if t.kind != sqlTokNumber || e != nil || n < 0 {
+			return nil, fmt.Errorf("invalid LIMIT value '%s'", t.text)
+		}
+		q.limit = n
+	}
+	if p.peek().kind != sqlTokEOF {
+		return nil, p.errorf("unexpected token")
+	}
+
+	q.aggregates = p.aggregates
+	if len(q.aggregates) > 0 {
+		for _, proj := range q.projections {
+			if !sqlHasAggregate(proj.expr) {
+				return nil, errors.New("cannot mix aggregate and non-aggregate expressions in the SELECT list")
+			}
+		}
+	}
+	q.resolveColumns()
+	return q, nil
+}
+
+func (p *sqlParser) parseProjection(q *sqlQuery) error {
+	// alias.* selects the whole record as well.
+	if t := p.peek(); (t.kind == sqlTokIdent || t.kind == sqlTokQuotedIdent) && p.pos+2 < len(p.toks) &&
+		p.toks[p.pos+1].is(".") && p.toks[p.pos+2].is("*") && len(q.projections) == 0 {
+		p.pos += 3
+		q.star = true
+		return nil
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return err
+	}
+	proj := sqlProjection{expr: e}
+	if p.accept("AS") {
+		t := p.next()
+		if t.kind != sqlTokIdent && t.kind != sqlTokQuotedIdent && t.kind != sqlTokString {
+			return fmt.Errorf("invalid alias '%s'", t.text)
+		}
+		proj.alias = t.text
+	} else if t := p.peek(); t.kind == sqlTokQuotedIdent || t.kind == sqlTokIdent && !sqlReserved[strings.ToUpper(t.text)] {
+		proj.alias = p.next().text
+	}
+	q.projections = append(q.projections, proj)
+	return nil
+}
+
+func (p *sqlParser) parseExpr() (sqlExpr, error) { return p.parseOr() }
+
+func (p *sqlParser) parseOr() (sqlExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: "OR", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseAnd() (sqlExpr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: "AND", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseNot() (sqlExpr, error) {
+	if p.accept("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "NOT", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *sqlParser) parseComparison() (sqlExpr, error) {
+	l, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "!=", "<>", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			r, err := p.parseConcat()
+			if err != nil {
+				return nil, err
+			}
+			if op == "<>" {
+				op = "!="
+			}
+			return &sqlBinary{op: op, l: l, r: r}, nil
+		}
+	}
+
+	if p.accept("IS") {
+		not := p.accept("NOT")
+		if !p.accept("NULL") && !p.accept("MISSING") {
+			return nil, p.errorf("expected NULL or MISSING")
+		}
+		return &sqlIsNull{x: l, not: not}, nil
+	}
+
+	not := p.accept("NOT")
+	switch {
+	case p.accept("LIKE"):
+		pattern, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		like := &sqlLike{x: l, pattern: pattern, not: not}
+		if p.accept("ESCAPE") {
+			if like.escape, err = p.parseConcat(); err != nil {
+				return nil, err
+			}
+		}
+		return like, nil
+	case p.accept("BETWEEN"):
+		lo, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlBetween{x: l, lo: lo, hi: hi, not: not}, nil
+	case p.accept("IN"):
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		in := &sqlIn{x: l, not: not}
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, e)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return in, nil
+	case not:
+		return nil, p.errorf("expected LIKE, BETWEEN or IN after NOT")
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseConcat() (sqlExpr, error) {
+	l, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		r, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: "||", l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *sqlParser) parseAdditive() (sqlExpr, error) {
+	l, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if !op.is("+") && !op.is("-") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: op.text, l: l, r: r}
+	}
+}
+
+func (p *sqlParser) parseMultiplicative() (sqlExpr, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if !op.is("*") && !op.is("/") && !op.is("%") {
+			return l, nil
+		}
+		p.next()
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &sqlBinary{op: op.text, l: l, r: r}
+	}
+}
+
+func (p *sqlParser) parseUnary() (sqlExpr, error) {
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &sqlUnary{op: "-", x: x}, nil
+	}
+	p.accept("+")
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (sqlExpr, error) {
+	t := p.peek()
+	switch t.kind {
+	case sqlTokNumber:
+		p.next()
+		if i, e := strconv.ParseInt(t.text, 10, 64); e == nil {
+			return &sqlLiteral{v: i}, nil
+		}
+		f, e := strconv.ParseFloat(t.text, 64)
+		if e != nil {
+			return nil, fmt.Errorf("invalid number '%s'", t.text)
+		}
+		return &sqlLiteral{v: f}, nil
+	case sqlTokString:
+		p.next()
+		return &sqlLiteral{v: t.text}, nil
+	case sqlTokOp:
+		if p.accept("(") {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+		return nil, p.errorf("unexpected token")
+	case sqlTokQuotedIdent:
+		return p.parseColumn()
+	case sqlTokIdent:
+		switch strings.ToUpper(t.text) {
+		case "NULL", "MISSING":
+			p.next()
+			return &sqlLiteral{}, nil
+		case "TRUE":
+			p.next()
+			return &sqlLiteral{v: true}, nil
+		case "FALSE":
+			p.next()
+			return &sqlLiteral{v: false}, nil
+		}
+		if sqlReserved[strings.ToUpper(t.text)] {
+			return nil, p.errorf("unexpected keyword")
+		}
+		if p.toks[p.pos+1].is("(") {
+			return p.parseCall()
+		}
+		return p.parseColumn()
+	}
+	return nil, p.errorf("expected an expression")
+}
+
+func (p *sqlParser) parseColumn() (sqlExpr, error) {
+	col := &sqlColumn{}
+	for {
+		t := p.next()
+		if t.kind != sqlTokIdent && t.kind != sqlTokQuotedIdent {
+			return nil, fmt.Errorf("expected a column name at position %d", t.pos)
+		}
+		col.path = append(col.path, sqlPathElem{name: t.text, quoted: t.kind == sqlTokQuotedIdent, index: -1})
+		for p.accept("[") {
+			n := p.next()
+			idx, e := strconv.Atoi(n.text)
+			if n.kind != sqlTokNumber || e != nil {
+				return nil, fmt.Errorf("invalid array index '%s'", n.text)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			col.path = append(col.path, sqlPathElem{index: idx})
+		}
+		if !p.accept(".") {
+			return col, nil
+		}
+	}
+}
+
+func (p *sqlParser) parseCall() (sqlExpr, error) {
+	name := strings.ToUpper(p.next().text)
+	p.next() // '('
+
+	switch name {
+	case "COUNT", "SUM", "AVG", "MIN", "MAX":
+		if p.inAgg {
+			return nil, errors.New("aggregate functions cannot be nested")
+		}
+		agg := &sqlAggregate{name: name}
+		if name == "COUNT" && p.accept("*") {
+			// COUNT(*) counts every record.
+		} else {
+			p.inAgg = true
+			arg, err := p.parseExpr()
+			p.inAgg = false
+			if err != nil {
+				return nil, err
+			}
+			agg.arg = arg
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		p.aggregates = append(p.aggregates, agg)
+		return agg, nil
+	case "CAST":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("AS"); err != nil {
+			return nil, err
+		}
+		typ := strings.ToUpper(p.next().text)
+		switch typ {
+		case "INT", "INTEGER", "FLOAT", "DECIMAL", "NUMERIC", "STRING", "BOOL", "BOOLEAN":
+		default:
+			return nil, fmt.Errorf("unsupported CAST type '%s'", typ)
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &sqlCast{x: x, typ: typ}, nil
+	case "SUBSTRING":
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		fn := &sqlFunc{name: name, args: []sqlExpr{x}}
+		if p.accept("FROM") || p.accept(",") {
+			start, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			fn.args = append(fn.args, start)
+			if p.accept("FOR") || p.accept(",") {
+				length, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				fn.args = append(fn.args, length)
+			}
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		if len(fn.args) < 2 {
+			return nil, errors.New("SUBSTRING requires a start position")
+		}
+		return fn, nil
+	}
+
+	fn := &sqlFunc{name: name}
+	if !p.accept(")") {
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			fn.args = append(fn.args, e)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	if err := fn.check(); err != nil {
+		return nil, err
+	}
+	return fn, nil
+}
+
+// resolveColumns strips the table name or alias from column references.
+func (q *sqlQuery) resolveColumns() {
+	strip := func(c *sqlColumn) {
+		if len(c.path) < 2 || c.path[0].index >= 0 {
+			return
+		}
+		first := c.path[0].name
+		if strings.EqualFold(first, "S3Object") || q.tableAlias != "" && strings.EqualFold(first, q.tableAlias) {
+			c.path = c.path[1:]
+		}
+	}
+	visit := func(e sqlExpr) {
+		sqlWalk(e, func(e sqlExpr) {
+			if c, ok := e.(*sqlColumn); ok {
+				strip(c)
+			}
+		})
+	}
+	for _, proj := range q.projections {
+		visit(proj.expr)
+	}
+	if q.where != nil {
+		visit(q.where)
+	}
+}
@@ -225,6 +225,7 @@ func (ps *ProgressStatus) Start() {
 // Finish displays the accounting summary
 func (ps *ProgressStatus) Finish() {
 	ps.progressBar.Finish()
+	printRetrySummary()
 }
 
 // Update is ignored for quietstatus
@@ -0,0 +1,223 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/wildcard"
+)
+
+// tagExpr is a boolean expression on the tags of an object, such as
+// 'project=x && (tier!=cold || !archived)'. A term is either a key,
+// true when the tag exists, or a key compared with '=' or '!=' to a
+// value which may contain '*' and '?' wildcards. Terms are combined
+// with '!', '&&', '||' and parentheses.
+type tagExpr interface {
+	eval(tags map[string]string) bool
+}
+
+type tagExprNot struct{ expr tagExpr }
+
+func (e tagExprNot) eval(tags map[string]string) bool { return !e.expr.eval(tags) }
+
+type tagExprAnd struct{ left, right tagExpr }
+
+func (e tagExprAnd) eval(tags map[string]string) bool {
+	return e.left.eval(tags) && e.right.eval(tags)
+}
+
+type tagExprOr struct{ left, right tagExpr }
+
+func (e tagExprOr) eval(tags map[string]string) bool {
+	return e.left.eval(tags) || e.right.eval(tags)
+}
+
+// tagExprTerm compares a tag, a term without operator only checks that
+// the tag exists. A missing tag is different from any value.
+type tagExprTerm struct {
+	key, op, value string
+}
+
+func (e tagExprTerm) eval(tags map[string]string) bool {
+	value, ok := tags[e.key]
+	switch e.op {
+	case "=":
+		return ok && wildcard.MatchSimple(e.value, value)
+	case "!=":
+		return !ok || !wildcard.MatchSimple(e.value, value)
+	}
+	return ok
+}
+
+// tagExprParser is a recursive descent parser of tag expressions.
+type tagExprParser struct {
+	input string
+	pos   int
+}
+
+// parseTagExpr parses a tag expression.
+func parseTagExpr(s string) (tagExpr, *probe.Error) {
+	p := &tagExprParser{input: s}
+	expr, e := p.parseOr()
+	if e == nil && p.skipSpaces() < len(p.input) {
+		e = p.errorf("unexpected `%s`", p.input[p.pos:])
+	}
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return expr, nil
+}
+
+func (p *tagExprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid tag expression `%s` at position %d: %s", p.input, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *tagExprParser) skipSpaces() int {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+	return p.pos
+}
+
+// consume skips the token if it is next in the input.
+func (p *tagExprParser) consume(token string) bool {
+	p.skipSpaces()
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *tagExprParser) parseOr() (tagExpr, error) {
+	left, e := p.parseAnd()
+	for e == nil && p.consume("||") {
+		var right tagExpr
+		if right, e = p.parseAnd(); e == nil {
+			left = tagExprOr{left, right}
+		}
+	}
+	return left, e
+}
+
+func (p *tagExprParser) parseAnd() (tagExpr, error) {
+	left, e := p.parseUnary()
+	for e == nil && p.consume("&&") {
+		var right tagExpr
+		if right, e = p.parseUnary(); e == nil {
+			left = tagExprAnd{left, right}
+		}
+	}
+	return left, e
+}
+
+func (p *tagExprParser) parseUnary() (tagExpr, error) {
+	switch {
+	case p.consume("!"):
+		expr, e := p.parseUnary()
+		return tagExprNot{expr}, e
+	case p.consume("("):
+		expr, e := p.parseOr()
+		if e == nil && !p.consume(")") {
+			e = p.errorf("missing `)`")
+		}
+		return expr, e
+	}
+	key, e := p.parseWord()
+	if e != nil {
+		return nil, e
+	}
+	term := tagExprTerm{key: key}
+	switch {
+	case p.consume("!="):
+		term.op = "!="
+	case p.consume("="):
+		term.op = "="
+	default:
+		return term, nil
+	}
+	if term.value, e = p.parseWord(); e != nil {
+		return nil, e
+	}
+	return term, nil
+}
+
+// parseWord parses a key or a value, either quoted or ending at the
+// next operator.
+func (p *tagExprParser) parseWord() (string, error) {
+	p.skipSpaces()
+	if p.pos < len(p.input) && (p.input[p.pos] == '"' || p.input[p.pos] == '\'') {
+		quote := p.input[p.pos]
+		end := strings.IndexByte(p.input[p.pos+1:], quote)
+		if end < 0 {
+			return "", p.errorf("missing closing quote")
+		}
+		word := p.input[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return word, nil
+	}
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("=!&|() \t", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos == len(p.input) {
+			return "", p.errorf("unexpected end")
+		}
+		return "", p.errorf("expected a tag key or value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// tagFilter selects objects by evaluating a tag expression on their tags.
+type tagFilter struct {
+	expr tagExpr
+}
+
+// newTagFilter returns nil when the expression is empty.
+func newTagFilter(s string) (*tagFilter, *probe.Error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	expr, err := parseTagExpr(s)
+	if err != nil {
+		return nil, err.Trace(s)
+	}
+	return &tagFilter{expr: expr}, nil
+}
+
+// match fetches the tags of an object version of an alias and evaluates
+// the expression. Delete markers have no tags and never match.
+func (f *tagFilter) match(ctx context.Context, alias string, content *ClientContent) (bool, *probe.Error) {
+	if content.IsDeleteMarker {
+		return false, nil
+	}
+	clnt, err := newClientFromAlias(alias, content.URL.String())
+	if err != nil {
+		return false, err.Trace(content.URL.String())
+	}
+	tags, err := clnt.GetTags(ctx, content.VersionID)
+	if err != nil {
+		return false, err.Trace(content.URL.String())
+	}
+	return f.expr.eval(tags), nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestTagExpr(t *testing.T) {
+	tags := map[string]string{"project": "x", "tier": "hot", "owner": "data team"}
+	testCases := []struct {
+		expr  string
+		match bool
+	}{
+		{"project=x", true},
+		{"project=y", false},
+		{"project!=y", true},
+		{"missing!=y", true},
+		{"missing=y", false},
+		{"project", true},
+		{"!project", false},
+		{"!missing", true},
+		{"project=x&&tier!=cold", true},
+		{"project=x && tier=cold", false},
+		{"project=y || tier=hot", true},
+		{"project=y || tier=cold && owner", false},
+		{"(project=y || tier=hot) && owner", true},
+		{"!(project=x && tier=hot)", false},
+		{"tier=h*", true},
+		{"tier=c?ld", false},
+		{`owner="data team"`, true},
+		{"owner='data*'", true},
+	}
+	for _, testCase := range testCases {
+		expr, err := parseTagExpr(testCase.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.expr, err)
+			continue
+		}
+		if match := expr.eval(tags); match != testCase.match {
+			t.Errorf("%s: expected %v, got %v", testCase.expr, testCase.match, match)
+		}
+	}
+
+	for _, invalid := range []string{"", "project=", "=x", "(project=x", "project=x)", "project=x &&", "owner='data", "a b"} {
+		if _, err := parseTagExpr(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestTagMutations(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mutation tagMutation
+		expected map[string]string
+		changed  bool
+		fail     bool
+	}{
+		{"merge", mergeTags(map[string]string{"tier": "cold", "owner": "ops"}), map[string]string{"env": "dev", "tier": "cold", "owner": "ops"}, true, false},
+		{"merge unchanged", mergeTags(map[string]string{"tier": "hot"}), map[string]string{"env": "dev", "tier": "hot"}, false, false},
+		{"remove", removeTagKeys([]string{"tier", "missing"}), map[string]string{"env": "dev"}, true, false},
+		{"remove unchanged", removeTagKeys([]string{"missing"}), map[string]string{"env": "dev", "tier": "hot"}, false, false},
+		{"rename", renameTagKey("env", "environment"), map[string]string{"environment": "dev", "tier": "hot"}, true, false},
+		{"rename missing", renameTagKey("missing", "other"), map[string]string{"env": "dev", "tier": "hot"}, false, false},
+		{"rename existing", renameTagKey("env", "tier"), map[string]string{"env": "dev", "tier": "hot"}, false, true},
+	}
+	for _, testCase := range testCases {
+		tags := map[string]string{"env": "dev", "tier": "hot"}
+		changed, e := testCase.mutation(tags)
+		if (e != nil) != testCase.fail {
+			t.Errorf("%s: unexpected error %v", testCase.name, e)
+			continue
+		}
+		if changed != testCase.changed || !reflect.DeepEqual(tags, testCase.expected) {
+			t.Errorf("%s: expected %v %v, got %v %v", testCase.name, testCase.changed, testCase.expected, changed, tags)
+		}
+	}
+}
+
+func TestRemoveSkipTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("location"):
+			w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+		case r.URL.Query().Has("tagging") && r.URL.Path == "/bucket/denied":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied.</Message></Error>`))
+		case r.URL.Query().Has("tagging"):
+			w.Write([]byte(`<Tagging><TagSet><Tag><Key>project</Key><Value>x</Value></Tag></TagSet></Tagging>`))
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	savedConfig := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["tags"] = aliasConfigV10{URL: server.URL, AccessKey: "minio", SecretKey: "minio123", API: "S3v4", Path: "on"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = savedConfig }()
+
+	filter, err := newTagFilter("project=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := removeOpts{tags: filter}
+	content := func(object string) *ClientContent {
+		return &ClientContent{URL: *newClientURL(server.URL + "/bucket/" + object)}
+	}
+	if skip, failed := opts.skipTags(context.Background(), "tags", content("matching")); skip || failed {
+		t.Fatalf("expected a matching object to be removed, got skip %v, failed %v", skip, failed)
+	}
+	// An object whose tags cannot be fetched is skipped and fails the command.
+	if skip, failed := opts.skipTags(context.Background(), "tags", content("denied")); !skip || !failed {
+		t.Fatalf("expected a failure to be reported, got skip %v, failed %v", skip, failed)
+	}
+}
@@ -25,6 +25,7 @@ var tagSubcommands = []cli.Command{
 	tagListCmd,
 	tagRemoveCmd,
 	tagSetCmd,
+	tagRenameCmd,
 }
 
 var tagCmd = cli.Command{
@@ -0,0 +1,158 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// tagMutation modifies a tag set in place and reports whether it changed.
+type tagMutation func(tags map[string]string) (changed bool, err error)
+
+// mergeTags adds tags, replacing the values of existing keys.
+func mergeTags(add map[string]string) tagMutation {
+	return func(tags map[string]string) (changed bool, err error) {
+		for key, value := range add {
+			if current, ok := tags[key]; !ok || current != value {
+				tags[key] = value
+				changed = true
+			}
+		}
+		return changed, nil
+	}
+}
+
+// removeTagKeys removes tags by key, missing keys are ignored.
+func removeTagKeys(keys []string) tagMutation {
+	return func(tags map[string]string) (changed bool, err error) {
+		for _, key := range keys {
+			if _, ok := tags[key]; ok {
+				delete(tags, key)
+				changed = true
+			}
+		}
+		return changed, nil
+	}
+}
+
+// renameTagKey renames a tag key keeping its value, tags without the
+// key are unchanged and an existing new key is never overwritten.
+func renameTagKey(oldKey, newKey string) tagMutation {
+	return func(tags map[string]string) (changed bool, err error) {
+		value, ok := tags[oldKey]
+		if !ok || oldKey == newKey {
+			return false, nil
+		}
+		if _, ok = tags[newKey]; ok {
+			return false, fmt.Errorf("tag `%s` already exists", newKey)
+		}
+		delete(tags, oldKey)
+		tags[newKey] = value
+		return true, nil
+	}
+}
+
+// parseTagString parses tags formatted like 'key1=value1&key2=value2'.
+func parseTagString(s string) (map[string]string, *probe.Error) {
+	t, e := tags.Parse(s, false)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(s)
+	}
+	return t.ToMap(), nil
+}
+
+// mutateTags reads the tags of a bucket or an object version, applies
+// the mutation and writes them back when they changed. This is not
+// atomic, a concurrent change of the tags between the read and the
+// write is lost.
+func mutateTags(ctx context.Context, clnt Client, versionID string, mutate tagMutation) (changed bool, err *probe.Error) {
+	current, err := clnt.GetTags(ctx, versionID)
+	if err != nil {
+		if minio.ToErrorResponse(err.ToGoError()).Code != "NoSuchTagSet" {
+			return false, err.Trace(clnt.GetURL().String())
+		}
+		current = map[string]string{}
+	}
+	if current == nil {
+		current = map[string]string{}
+	}
+	changed, e := mutate(current)
+	if e != nil {
+		return false, probe.NewError(e).Trace(clnt.GetURL().String())
+	}
+	if !changed {
+		return false, nil
+	}
+	if len(current) == 0 {
+		err = clnt.DeleteTags(ctx, versionID)
+	} else {
+		var t *tags.Tags
+		if t, e = tags.NewTags(current, false); e != nil {
+			return false, probe.NewError(e).Trace(clnt.GetURL().String())
+		}
+		err = clnt.SetTags(ctx, versionID, t.String())
+	}
+	if err != nil {
+		return false, err.Trace(clnt.GetURL().String())
+	}
+	return true, nil
+}
+
+// forEachObjectTarget calls fn with the target, the object versions listed
+// for --versions and --rewind, or all objects under the target when
+// recursive. Delete markers are skipped.
+func forEachObjectTarget(ctx context.Context, clnt Client, targetURL, versionID string, timeRef time.Time, withVersions, recursive bool, fn func(clnt Client, versionID string)) {
+	if timeRef.IsZero() && !withVersions && !recursive {
+		fn(clnt, versionID)
+		return
+	}
+	opts := ListOptions{TimeRef: timeRef, WithOlderVersions: withVersions}
+	if !recursive {
+		for content := range clnt.List(ctx, opts) {
+			if content.Err != nil {
+				fatalIf(content.Err.Trace(), "Unable to list target "+targetURL)
+			}
+			if content.IsDeleteMarker {
+				continue
+			}
+			fn(clnt, content.VersionID)
+		}
+		return
+	}
+
+	alias, _, _ := mustExpandAlias(targetURL)
+	opts.Recursive = true
+	opts.ShowDir = DirNone
+	for content := range clnt.List(ctx, opts) {
+		if content.Err != nil {
+			fatalIf(content.Err.Trace(), "Unable to list target "+targetURL)
+		}
+		if content.IsDeleteMarker {
+			continue
+		}
+		objectClnt, err := newClientFromAlias(alias, content.URL.String())
+		fatalIf(err.Trace(content.URL.String()), "Unable to initialize target "+content.URL.String())
+		fn(objectClnt, content.VersionID)
+	}
+}
@@ -19,6 +19,7 @@ package cmd
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -41,6 +42,14 @@ var tagRemoveFlags = []cli.Flag{
 		Name:  "versions",
 		Usage: "remote tags on multiple versions of an object",
 	},
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "remove tags on all objects under a prefix",
+	},
+	cli.StringSliceFlag{
+		Name:  "key",
+		Usage: "remove only the tag with this key, can be specified multiple times",
+	},
 }
 
 var tagRemoveCmd = cli.Command{
@@ -74,20 +83,28 @@ EXAMPLES:
 
   4. Remove the tags assigned to a bucket.
      {{.Prompt}} {{.HelpName}} play/testbucket
+
+  5. Remove the tags 'tmp' and 'owner' from all objects under a prefix, keeping their other tags.
+     {{.Prompt}} {{.HelpName}} --recursive --key tmp --key owner myminio/testbucket/prefix/
 `,
 }
 
 // tagSetTagMessage structure will show message depending on the type of console.
 type tagRemoveMessage struct {
-	Status    string `json:"status"`
-	Name      string `json:"name"`
-	VersionID string `json:"versionID"`
+	Status    string   `json:"status"`
+	Name      string   `json:"name"`
+	VersionID string   `json:"versionID"`
+	Keys      []string `json:"keys,omitempty"`
 }
 
 // tagRemoveMessage console colorized output.
 func (t tagRemoveMessage) String() string {
 	var msg string
-	msg += "Tags removed for " + t.Name
+	if len(t.Keys) > 0 {
+		msg += "Tags `" + strings.Join(t.Keys, "`, `") + "` removed for " + t.Name
+	} else {
+		msg += "Tags removed for " + t.Name
+	}
 	if t.VersionID != "" {
 		msg += " (" + t.VersionID + ")"
 	}
@@ -102,7 +119,7 @@ func (t tagRemoveMessage) JSON() string {
 	return string(msgBytes)
 }
 
-func parseRemoveTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef time.Time, withVersions bool) {
+func parseRemoveTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef time.Time, withVersions, recursive bool) {
 	if len(ctx.Args()) != 1 {
 		showCommandHelpAndExit(ctx, "remove", globalErrorExitStatus)
 	}
@@ -110,24 +127,31 @@ func parseRemoveTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRe
 	targetURL = ctx.Args().Get(0)
 	versionID = ctx.String("version-id")
 	withVersions = ctx.Bool("versions")
+	recursive = ctx.Bool("recursive")
 	rewind := ctx.String("rewind")
 
-	if versionID != "" && (rewind != "" || withVersions) {
-		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --rewind or --versions flags at the same time")
+	if versionID != "" && (rewind != "" || withVersions || recursive) {
+		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --rewind, --versions or --recursive flags at the same time")
 	}
 
 	timeRef = parseRewindFlag(rewind)
 	return
 }
 
-// Delete tags of a bucket or a specified object/version
-func deleteTags(ctx context.Context, clnt Client, versionID string, verbose bool) {
+// Delete tags of a bucket or a specified object/version, only the tags
+// with the given keys are removed when keys is not empty.
+func deleteTags(ctx context.Context, clnt Client, versionID string, keys []string) {
 	targetName := clnt.GetURL().String()
 	if versionID != "" {
 		targetName += " (" + versionID + ")"
 	}
 
-	err := clnt.DeleteTags(ctx, versionID)
+	var err *probe.Error
+	if len(keys) > 0 {
+		_, err = mutateTags(ctx, clnt, versionID, removeTagKeys(keys))
+	} else {
+		err = clnt.DeleteTags(ctx, versionID)
+	}
 	if err != nil {
 		fatalIf(err, "Unable to remove tags for "+targetName)
 		return
@@ -137,6 +161,7 @@ func deleteTags(ctx context.Context, clnt Client, versionID string, verbose bool
 		Status:    "success",
 		Name:      clnt.GetURL().String(),
 		VersionID: versionID,
+		Keys:      keys,
 	})
 }
 
@@ -146,7 +171,7 @@ func mainRemoveTag(cliCtx *cli.Context) error {
 
 	console.SetColor("Remove", color.New(color.FgGreen))
 
-	targetURL, versionID, timeRef, withVersions := parseRemoveTagSyntax(cliCtx)
+	targetURL, versionID, timeRef, withVersions, recursive := parseRemoveTagSyntax(cliCtx)
 	if timeRef.IsZero() && withVersions {
 		timeRef = time.Now().UTC()
 	}
@@ -154,15 +179,9 @@ func mainRemoveTag(cliCtx *cli.Context) error {
 	clnt, pErr := newClient(targetURL)
 	fatalIf(pErr, "Unable to initialize target "+targetURL)
 
-	if timeRef.IsZero() && !withVersions {
-		deleteTags(ctx, clnt, versionID, true)
-	} else {
-		for content := range clnt.List(ctx, ListOptions{TimeRef: timeRef, WithOlderVersions: withVersions}) {
-			if content.Err != nil {
-				fatalIf(content.Err.Trace(), "Unable to list target "+targetURL)
-			}
-			deleteTags(ctx, clnt, content.VersionID, false)
-		}
-	}
+	keys := cliCtx.StringSlice("key")
+	forEachObjectTarget(ctx, clnt, targetURL, versionID, timeRef, withVersions, recursive, func(clnt Client, versionID string) {
+		deleteTags(ctx, clnt, versionID, keys)
+	})
 	return nil
 }
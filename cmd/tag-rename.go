@@ -0,0 +1,166 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/console"
+)
+
+var tagRenameFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "version-id, vid",
+		Usage: "rename a tag of a specific object version",
+	},
+	cli.StringFlag{
+		Name:  "rewind",
+		Usage: "rename a tag of an object version at specified time",
+	},
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "rename a tag of multiple versions of an object",
+	},
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "rename a tag of all objects under a prefix",
+	},
+}
+
+var tagRenameCmd = cli.Command{
+	Name:         "rename",
+	Usage:        "rename a tag key of a bucket or object(s)",
+	Action:       mainRenameTag,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(tagRenameFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [COMMAND FLAGS] TARGET OLDKEY NEWKEY
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Rename a tag key keeping its value and the other tags. Buckets and objects
+  without the tag are left unchanged, renaming fails when NEWKEY already exists.
+
+EXAMPLES:
+  1. Rename the tag 'env' of an object to 'environment'.
+     {{.Prompt}} {{.HelpName}} myminio/testbucket/testobject env environment
+
+  2. Rename the tag 'env' of all objects under a prefix to 'environment'.
+     {{.Prompt}} {{.HelpName}} --recursive myminio/testbucket/prefix/ env environment
+`,
+}
+
+// tagRenameMessage structure will show message depending on the type of console.
+type tagRenameMessage struct {
+	Status    string `json:"status"`
+	Name      string `json:"name"`
+	VersionID string `json:"versionID"`
+	OldKey    string `json:"oldKey"`
+	NewKey    string `json:"newKey"`
+}
+
+// tagRenameMessage console colorized output.
+func (t tagRenameMessage) String() string {
+	msg := "Tag `" + t.OldKey + "` renamed to `" + t.NewKey + "` for " + t.Name
+	if t.VersionID != "" {
+		msg += " (" + t.VersionID + ")"
+	}
+	msg += "."
+	return console.Colorize("List", msg)
+}
+
+// JSON tagRenameMessage.
+func (t tagRenameMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(t, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+func parseRenameTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef time.Time, withVersions, recursive bool, oldKey, newKey string) {
+	if len(ctx.Args()) != 3 || ctx.Args().Get(1) == "" || ctx.Args().Get(2) == "" {
+		showCommandHelpAndExit(ctx, "rename", globalErrorExitStatus)
+	}
+
+	targetURL = ctx.Args().Get(0)
+	oldKey = ctx.Args().Get(1)
+	newKey = ctx.Args().Get(2)
+	versionID = ctx.String("version-id")
+	withVersions = ctx.Bool("versions")
+	recursive = ctx.Bool("recursive")
+	rewind := ctx.String("rewind")
+
+	if versionID != "" && (rewind != "" || withVersions || recursive) {
+		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --rewind, --versions or --recursive flags at the same time")
+	}
+
+	timeRef = parseRewindFlag(rewind)
+	return
+}
+
+// Rename a tag key of a bucket or a specified object/version.
+func renameTag(ctx context.Context, clnt Client, versionID, oldKey, newKey string) {
+	targetName := clnt.GetURL().String()
+	if versionID != "" {
+		targetName += " (" + versionID + ")"
+	}
+
+	renamed, err := mutateTags(ctx, clnt, versionID, renameTagKey(oldKey, newKey))
+	fatalIf(err, "Unable to rename tag for "+targetName)
+	if !renamed {
+		return
+	}
+
+	printMsg(tagRenameMessage{
+		Status:    "success",
+		Name:      clnt.GetURL().String(),
+		VersionID: versionID,
+		OldKey:    oldKey,
+		NewKey:    newKey,
+	})
+}
+
+func mainRenameTag(cliCtx *cli.Context) error {
+	ctx, cancelRenameTag := context.WithCancel(globalContext)
+	defer cancelRenameTag()
+
+	console.SetColor("List", color.New(color.FgGreen))
+
+	targetURL, versionID, timeRef, withVersions, recursive, oldKey, newKey := parseRenameTagSyntax(cliCtx)
+	if timeRef.IsZero() && withVersions {
+		timeRef = time.Now().UTC()
+	}
+
+	clnt, err := newClient(targetURL)
+	fatalIf(err.Trace(cliCtx.Args()...), "Unable to initialize target "+targetURL)
+
+	forEachObjectTarget(ctx, clnt, targetURL, versionID, timeRef, withVersions, recursive, func(clnt Client, versionID string) {
+		renameTag(ctx, clnt, versionID, oldKey, newKey)
+	})
+	return nil
+}
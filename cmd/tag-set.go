@@ -41,6 +41,14 @@ var tagSetFlags = []cli.Flag{
 		Name:  "versions",
 		Usage: "set tags on multiple versions for an object",
 	},
+	cli.BoolFlag{
+		Name:  "recursive, r",
+		Usage: "set tags on all objects under a prefix",
+	},
+	cli.BoolFlag{
+		Name:  "merge",
+		Usage: "add the tags to the existing tags instead of replacing them",
+	},
 }
 
 var tagSetCmd = cli.Command{
@@ -73,6 +81,9 @@ EXAMPLES:
 
   4. Assign tags to a bucket.
      {{.Prompt}} {{.HelpName}} myminio/testbucket "key1=value1&key2=value2&key3=value3"
+
+  5. Add a tag to all objects under a prefix, keeping their other tags.
+     {{.Prompt}} {{.HelpName}} --recursive --merge myminio/testbucket/prefix/ "tier=cold"
 `,
 }
 
@@ -101,7 +112,7 @@ func (t tagSetMessage) JSON() string {
 	return string(msgBytes)
 }
 
-func parseSetTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef time.Time, withVersions bool, tags string) {
+func parseSetTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef time.Time, withVersions, recursive bool, tags string) {
 	if len(ctx.Args()) != 2 || ctx.Args().Get(1) == "" {
 		showCommandHelpAndExit(ctx, "set", globalErrorExitStatus)
 	}
@@ -110,24 +121,34 @@ func parseSetTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef t
 	tags = ctx.Args().Get(1)
 	versionID = ctx.String("version-id")
 	withVersions = ctx.Bool("versions")
+	recursive = ctx.Bool("recursive")
 	rewind := ctx.String("rewind")
 
-	if versionID != "" && (rewind != "" || withVersions) {
-		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --rewind or --versions flags at the same time")
+	if versionID != "" && (rewind != "" || withVersions || recursive) {
+		fatalIf(errDummy().Trace(), "You cannot specify both --version-id and --rewind, --versions or --recursive flags at the same time")
 	}
 
 	timeRef = parseRewindFlag(rewind)
 	return
 }
 
-// Set tags to a bucket or to a specified object/version
-func setTags(ctx context.Context, clnt Client, versionID, tags string, verbose bool) {
+// Set tags to a bucket or to a specified object/version, the tags are
+// added to the existing tags when merge is set.
+func setTags(ctx context.Context, clnt Client, versionID, tags string, merge bool) {
 	targetName := clnt.GetURL().String()
 	if versionID != "" {
 		targetName += " (" + versionID + ")"
 	}
 
-	err := clnt.SetTags(ctx, versionID, tags)
+	var err *probe.Error
+	if merge {
+		var add map[string]string
+		if add, err = parseTagString(tags); err == nil {
+			_, err = mutateTags(ctx, clnt, versionID, mergeTags(add))
+		}
+	} else {
+		err = clnt.SetTags(ctx, versionID, tags)
+	}
 	if err != nil {
 		fatalIf(err.Trace(tags), "Failed to set tags for "+targetName)
 		return
@@ -145,7 +166,7 @@ func mainSetTag(cliCtx *cli.Context) error {
 
 	console.SetColor("List", color.New(color.FgGreen))
 
-	targetURL, versionID, timeRef, withVersions, tags := parseSetTagSyntax(cliCtx)
+	targetURL, versionID, timeRef, withVersions, recursive, tags := parseSetTagSyntax(cliCtx)
 	if timeRef.IsZero() && withVersions {
 		timeRef = time.Now().UTC()
 	}
@@ -153,16 +174,9 @@ func mainSetTag(cliCtx *cli.Context) error {
 	clnt, err := newClient(targetURL)
 	fatalIf(err.Trace(cliCtx.Args()...), "Unable to initialize target "+targetURL)
 
-	if timeRef.IsZero() && !withVersions {
-		setTags(ctx, clnt, versionID, tags, true)
-	} else {
-		for content := range clnt.List(ctx, ListOptions{TimeRef: timeRef, WithOlderVersions: withVersions}) {
-			if content.Err != nil {
-				fatalIf(content.Err.Trace(), "Unable to list target "+targetURL)
-			}
-			setTags(ctx, clnt, content.VersionID, tags, false)
-		}
-	}
-
+	merge := cliCtx.Bool("merge")
+	forEachObjectTarget(ctx, clnt, targetURL, versionID, timeRef, withVersions, recursive, func(clnt Client, versionID string) {
+		setTags(ctx, clnt, versionID, tags, merge)
+	})
 	return nil
 }
@@ -75,8 +75,9 @@ func marshalPublicKey(pub interface{}) (publicKeyBytes []byte, e error) {
 // If not, it computes a fingerprint of the peer certificate
 // public key, asks the user to confirm the fingerprint and
 // adds the peer certificate to the local trust store in the
-// CAs directory.
-func promptTrustSelfSignedCert(ctx context.Context, endpoint, alias string) (*x509.Certificate, *probe.Error) {
+// CAs directory. The optional client certificate is presented
+// to servers requiring mTLS.
+func promptTrustSelfSignedCert(ctx context.Context, endpoint, alias string, clientCert *tls.Certificate) (*x509.Certificate, *probe.Error) {
 	req, e := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if e != nil {
 		return nil, probe.NewError(e)
@@ -87,12 +88,16 @@ func promptTrustSelfSignedCert(ctx context.Context, endpoint, alias string) (*x5
 		return nil, nil
 	}
 
+	tlsConfig := &tls.Config{
+		RootCAs: globalRootCAs, // make sure to use loaded certs before probing
+	}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
 	client := http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			TLSClientConfig: &tls.Config{
-				RootCAs: globalRootCAs, // make sure to use loaded certs before probing
-			},
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
 		},
 	}
 
@@ -111,10 +116,11 @@ func promptTrustSelfSignedCert(ctx context.Context, endpoint, alias string) (*x5
 	// public key and let the user confirm the fingerprint.
 	// If the user confirms, we store the peer certificate in the CAs
 	// directory and retry.
-	peerCert, e := fetchPeerCertificate(ctx, endpoint)
-	if e != nil {
-		return nil, probe.NewError(e)
+	peerCerts, err := fetchPeerCertificateChain(ctx, endpoint, clientCert)
+	if err != nil {
+		return nil, err.Trace(endpoint)
 	}
+	peerCert := peerCerts[0]
 
 	if peerCert.IsCA && len(peerCert.AuthorityKeyId) == 0 {
 		// If peerCert is its own CA then AuthorityKeyId will be empty
@@ -154,27 +160,3 @@ func promptTrustSelfSignedCert(ctx context.Context, endpoint, alias string) (*x5
 	}
 	return peerCert, nil
 }
-
-// fetchPeerCertificate uses the given transport to fetch the peer
-// certificate from the given endpoint.
-func fetchPeerCertificate(ctx context.Context, endpoint string) (*x509.Certificate, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return nil, err
-	}
-	client := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
-		return nil, fmt.Errorf("Unable to read remote TLS certificate")
-	}
-	return resp.TLS.PeerCertificates[0], nil
-}
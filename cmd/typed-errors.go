@@ -126,3 +126,10 @@ var errConflictSSE = func(sseServer, sseKeys string) *probe.Error {
 	err := fmt.Errorf("SSE alias '%s' overlaps with SSE-C aliases '%s'", sseServer, sseKeys)
 	return probe.NewError(conflictSSEErr(err)).Untrace()
 }
+
+type configSealedErr error
+
+var errConfigSealed = func() *probe.Error {
+	msg := "Credentials in the configuration file are sealed. Unlock them with `eval $(mc alias unlock)` or set " + mcEnvConfigPassphrase + "."
+	return probe.NewError(configSealedErr(errors.New(msg))).Untrace()
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/env"
+)
+
+const (
+	globalMCUndoDir = "undo"
+
+	// Set to "on" to record operations in the undo journal.
+	envUndoJournal = "MC_UNDO_JOURNAL"
+
+	// Operations older than this duration are removed from the undo
+	// journal when a new one is recorded, "0" keeps all operations.
+	envUndoJournalExpiry     = "MC_UNDO_JOURNAL_EXPIRY"
+	defaultUndoJournalExpiry = "30d"
+
+	undoActionPut    = "put"
+	undoActionDelete = "delete"
+
+	// Operation IDs start with the time of the operation.
+	undoOperationTimeLayout = "20060102T150405Z"
+)
+
+// undoJournalEntry is one line of a journal file. The first line of a
+// file describes the operation, then one line is written per version
+// created by the operation and a last line once it is reverted.
+type undoJournalEntry struct {
+	// Operation header.
+	ID      string     `json:"id,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+	Command string     `json:"command,omitempty"`
+
+	// Version created by the operation, an uploaded version or a
+	// delete marker, and the version which was the latest before.
+	URL            string `json:"url,omitempty"`
+	Action         string `json:"action,omitempty"`
+	VersionID      string `json:"versionId,omitempty"`
+	PriorVersionID string `json:"priorVersionId,omitempty"`
+
+	RevertedAt *time.Time `json:"revertedAt,omitempty"`
+}
+
+// undoOperation is a mutating run of mc loaded from the journal.
+type undoOperation struct {
+	ID         string
+	Time       time.Time
+	Command    string
+	Entries    []undoJournalEntry
+	RevertedAt *time.Time
+}
+
+// undoJournal records the versions created by this process, the
+// journal file is only created once a first version is recorded.
+type undoJournal struct {
+	mu     sync.Mutex
+	dir    string
+	id     string
+	cmd    string
+	expiry time.Duration
+	f      *os.File
+}
+
+var (
+	globalUndoJournal     *undoJournal
+	globalUndoJournalOnce sync.Once
+)
+
+// getUndoJournal returns the undo journal of this process, nil is
+// returned unless the journal is turned on.
+func getUndoJournal() *undoJournal {
+	globalUndoJournalOnce.Do(func() {
+		if !strings.EqualFold(env.Get(envUndoJournal, "off"), "on") {
+			return
+		}
+		dir, err := getUndoJournalDir()
+		if err != nil {
+			return
+		}
+		expiry := env.Get(envUndoJournalExpiry, defaultUndoJournalExpiry)
+		d, e := ParseDuration(expiry)
+		if e != nil {
+			errorIf(probe.NewError(e), "Unable to parse %s=`%s`, using %s.", envUndoJournalExpiry, expiry, defaultUndoJournalExpiry)
+			d, _ = ParseDuration(defaultUndoJournalExpiry)
+		}
+		globalUndoJournal = &undoJournal{dir: dir, cmd: redactCommandLine(os.Args), expiry: time.Duration(d)}
+	})
+	return globalUndoJournal
+}
+
+// getUndoJournalDir - return the full path of the undo journal dir.
+func getUndoJournalDir() (string, *probe.Error) {
+	p, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	return filepath.Join(p, globalMCUndoDir), nil
+}
+
+// newUndoOperationID returns a sortable and unique operation ID.
+func newUndoOperationID(now time.Time) string {
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return now.UTC().Format(undoOperationTimeLayout) + "-" + hex.EncodeToString(suffix)
+}
+
+// record appends a version created by this process to the journal,
+// failures are reported but do not fail the operation itself.
+func (j *undoJournal) record(entry undoJournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.f == nil {
+		if e := os.MkdirAll(j.dir, 0o700); e != nil {
+			errorIf(probe.NewError(e).Trace(j.dir), "Unable to create the undo journal folder.")
+			return
+		}
+		now := UTCNow()
+		j.id = newUndoOperationID(now)
+		f, e := os.OpenFile(filepath.Join(j.dir, j.id+".json"), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o600)
+		if e != nil {
+			errorIf(probe.NewError(e).Trace(j.dir), "Unable to create the undo journal.")
+			return
+		}
+		j.f = f
+		if e = j.write(undoJournalEntry{ID: j.id, Time: &now, Command: j.cmd}); e != nil {
+			errorIf(probe.NewError(e), "Unable to write the undo journal.")
+			return
+		}
+		if j.expiry > 0 {
+			errorIf(expireUndoOperations(j.dir, now.Add(-j.expiry)).Trace(j.dir), "Unable to remove expired operations from the undo journal.")
+		}
+	}
+	if e := j.write(entry); e != nil {
+		errorIf(probe.NewError(e), "Unable to write the undo journal.")
+	}
+}
+
+func (j *undoJournal) write(entry undoJournalEntry) error {
+	line, e := json.Marshal(entry)
+	if e != nil {
+		return e
+	}
+	_, e = j.f.Write(append(line, '\n'))
+	return e
+}
+
+// loadUndoOperation reads an operation from the journal.
+func loadUndoOperation(dir, id string) (op undoOperation, err *probe.Error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return op, probe.NewError(errors.New("invalid operation ID `" + id + "`"))
+	}
+	f, e := os.Open(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(e) {
+		return op, probe.NewError(errors.New("no operation `" + id + "` in the undo journal"))
+	}
+	if e != nil {
+		return op, probe.NewError(e)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry undoJournalEntry
+		if e = json.Unmarshal(scanner.Bytes(), &entry); e != nil {
+			// A line may be truncated when mc was killed.
+			continue
+		}
+		switch {
+		case entry.ID != "" && entry.Time != nil:
+			op.ID, op.Time, op.Command = entry.ID, *entry.Time, entry.Command
+		case entry.RevertedAt != nil:
+			op.RevertedAt = entry.RevertedAt
+		case entry.URL != "" && entry.VersionID != "":
+			op.Entries = append(op.Entries, entry)
+		}
+	}
+	if e = scanner.Err(); e != nil {
+		return op, probe.NewError(e).Trace(id)
+	}
+	if op.ID == "" {
+		return op, probe.NewError(errors.New("undo journal of `" + id + "` is corrupted"))
+	}
+	return op, nil
+}
+
+// listUndoOperations returns all operations of the journal, oldest first.
+func listUndoOperations(dir string) ([]undoOperation, *probe.Error) {
+	dirEntries, e := os.ReadDir(dir)
+	if os.IsNotExist(e) {
+		return nil, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(dir)
+	}
+	var ops []undoOperation
+	for _, dirEntry := range dirEntries {
+		id := strings.TrimSuffix(dirEntry.Name(), ".json")
+		if dirEntry.IsDir() || id == dirEntry.Name() {
+			continue
+		}
+		op, err := loadUndoOperation(dir, id)
+		if err != nil {
+			continue
+		}
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	return ops, nil
+}
+
+// markUndoOperationReverted records that an operation was reverted.
+func markUndoOperationReverted(dir, id string) *probe.Error {
+	f, e := os.OpenFile(filepath.Join(dir, id+".json"), os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return probe.NewError(e).Trace(id)
+	}
+	defer f.Close()
+	now := UTCNow()
+	line, e := json.Marshal(undoJournalEntry{RevertedAt: &now})
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if _, e = f.Write(append(line, '\n')); e != nil {
+		return probe.NewError(e).Trace(id)
+	}
+	return nil
+}
+
+// pruneUndoOperations removes the operations recorded before a time.
+func pruneUndoOperations(dir string, before time.Time) ([]undoOperation, *probe.Error) {
+	ops, err := listUndoOperations(dir)
+	if err != nil {
+		return nil, err.Trace(dir)
+	}
+	var pruned []undoOperation
+	for _, op := range ops {
+		if !op.Time.Before(before) {
+			continue
+		}
+		if e := os.Remove(filepath.Join(dir, op.ID+".json")); e != nil {
+			return pruned, probe.NewError(e).Trace(op.ID)
+		}
+		pruned = append(pruned, op)
+	}
+	return pruned, nil
+}
+
+// expireUndoOperations removes the operations recorded before a time,
+// the time is read from the operation IDs so journals are not loaded.
+func expireUndoOperations(dir string, before time.Time) *probe.Error {
+	dirEntries, e := os.ReadDir(dir)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".json") || len(name) < len(undoOperationTimeLayout) {
+			continue
+		}
+		t, e := time.Parse(undoOperationTimeLayout, name[:len(undoOperationTimeLayout)])
+		if e != nil || !t.Before(before) {
+			continue
+		}
+		if e = os.Remove(filepath.Join(dir, name)); e != nil && !os.IsNotExist(e) {
+			return probe.NewError(e).Trace(name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// versionedHandler is a minimal S3 server with versioning enabled on
+// every bucket, supporting uploads, HEAD and bulk deletes.
+type versionedHandler struct {
+	mu       sync.Mutex
+	next     int
+	versions map[string][]string // key to version IDs, latest last, "dm-" prefixed delete markers
+}
+
+func (h *versionedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	query := r.URL.Query()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && query.Has("location"):
+		w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+	case r.Method == http.MethodGet && query.Has("versioning"):
+		w.Write([]byte(`<VersioningConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>Enabled</Status></VersioningConfiguration>`))
+	case r.Method == http.MethodPut:
+		io.Copy(io.Discard, r.Body)
+		h.next++
+		versionID := fmt.Sprintf("v%d", h.next)
+		h.versions[path] = append(h.versions[path], versionID)
+		w.Header().Set("x-amz-version-id", versionID)
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	case r.Method == http.MethodHead:
+		versions := h.versions[path]
+		versionID := query.Get("versionId")
+		if versionID == "" && len(versions) > 0 {
+			versionID = versions[len(versions)-1]
+		}
+		found := false
+		for _, v := range versions {
+			found = found || v == versionID
+		}
+		switch {
+		case !found:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case strings.HasPrefix(versionID, "dm-"):
+			w.Header().Set("x-amz-delete-marker", "true")
+			w.Header().Set("x-amz-version-id", versionID)
+			if query.Has("versionId") {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+		w.Header().Set("x-amz-version-id", versionID)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+	case r.Method == http.MethodPost && query.Has("delete"):
+		var req struct {
+			Objects []struct {
+				Key       string
+				VersionID string `xml:"VersionId"`
+			} `xml:"Object"`
+		}
+		if e := xml.NewDecoder(r.Body).Decode(&req); e != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var resp bytes.Buffer
+		resp.WriteString(`<DeleteResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+		for _, object := range req.Objects {
+			key := strings.SplitN(path, "/", 2)[0] + "/" + object.Key
+			if object.VersionID == "" {
+				h.next++
+				marker := fmt.Sprintf("dm-%d", h.next)
+				h.versions[key] = append(h.versions[key], marker)
+				fmt.Fprintf(&resp, "<Deleted><Key>%s</Key><DeleteMarker>true</DeleteMarker><DeleteMarkerVersionId>%s</DeleteMarkerVersionId></Deleted>", object.Key, marker)
+				continue
+			}
+			var kept []string
+			for _, versionID := range h.versions[key] {
+				if versionID != object.VersionID {
+					kept = append(kept, versionID)
+				}
+			}
+			h.versions[key] = kept
+			fmt.Fprintf(&resp, "<Deleted><Key>%s</Key><VersionId>%s</VersionId></Deleted>", object.Key, object.VersionID)
+		}
+		resp.WriteString(`</DeleteResult>`)
+		w.Write(resp.Bytes())
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestUndoJournal(t *testing.T) {
+	defer setMcConfigDir(mcCustomConfigDir)
+	setMcConfigDir(t.TempDir())
+
+	handler := &versionedHandler{versions: map[string][]string{}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	savedConfig := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["undo"] = aliasConfigV10{URL: server.URL, AccessKey: "minio", SecretKey: "minio123", API: "S3v4", Path: "on"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = savedConfig }()
+
+	dir, err := getUndoJournalDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	getUndoJournal()
+	savedJournal := globalUndoJournal
+	globalUndoJournal = &undoJournal{dir: dir, cmd: "mc cp", expiry: 24 * time.Hour}
+
+	// An expired operation is removed once a new one is recorded.
+	if e := os.MkdirAll(dir, 0o700); e != nil {
+		t.Fatal(e)
+	}
+	expiredID := newUndoOperationID(UTCNow().Add(-48 * time.Hour))
+	if e := os.WriteFile(filepath.Join(dir, expiredID+".json"), []byte("{}\n"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	defer func() { globalUndoJournal = savedJournal }()
+
+	ctx := context.Background()
+	put := func(key string) {
+		clnt, err := newClient("undo/bucket/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := unwrapClient(clnt).(*S3Client); !ok {
+			t.Fatalf("unexpected client %T", unwrapClient(clnt))
+		}
+		if _, err = clnt.Put(ctx, strings.NewReader("data"), 4, nil, PutOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("a")
+	put("a")
+	put("b")
+	put("c")
+	if _, e := os.Stat(filepath.Join(dir, expiredID+".json")); !os.IsNotExist(e) {
+		t.Fatalf("expected the expired operation to be removed: %v", e)
+	}
+
+	// The removal is recorded as a delete marker, removing a
+	// specific version is not recorded.
+	clnt, err := newClient("undo/bucket/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contentCh := make(chan *ClientContent, 1)
+	contentCh <- &ClientContent{URL: clnt.GetURL()}
+	close(contentCh)
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	ops, err := listUndoOperations(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 || ops[0].Command != "mc cp" || len(ops[0].Entries) != 5 {
+		t.Fatalf("unexpected journal %+v", ops)
+	}
+	entries := ops[0].Entries
+	if entries[0].PriorVersionID != "" {
+		t.Fatalf("unexpected entry %+v", entries[0])
+	}
+	if entries[1].URL != "undo/bucket/a" || entries[1].VersionID != "v2" || entries[1].PriorVersionID != "v1" {
+		t.Fatalf("unexpected entry %+v", entries[1])
+	}
+	if entries[4].Action != undoActionDelete || entries[4].VersionID != "dm-5" || entries[4].PriorVersionID != "v3" {
+		t.Fatalf("unexpected entry %+v", entries[4])
+	}
+
+	// c is overwritten after the operation, outside of the journal.
+	handler.mu.Lock()
+	handler.versions["bucket/c"] = append(handler.versions["bucket/c"], "v9")
+	handler.mu.Unlock()
+
+	// A dry run changes nothing.
+	if e := undoOperationByID(ctx, ops[0].ID, true); e != nil {
+		t.Fatal(e)
+	}
+	if len(handler.versions["bucket/a"]) != 2 {
+		t.Fatal("dry run removed versions")
+	}
+
+	// The versions of c are left alone and the operation is not
+	// reverted until the conflict is resolved.
+	if e := undoOperationByID(ctx, ops[0].ID, false); e == nil {
+		t.Fatal("expected the changed object to fail the undo")
+	}
+	if len(handler.versions["bucket/a"]) != 0 || len(handler.versions["bucket/b"]) != 0 || len(handler.versions["bucket/c"]) != 2 {
+		t.Fatalf("unexpected versions left %v", handler.versions)
+	}
+	op, err := loadUndoOperation(dir, ops[0].ID)
+	if err != nil || op.RevertedAt != nil {
+		t.Fatalf("operation marked as reverted: %v", err)
+	}
+
+	// Objects reverted by the first run are not reported again.
+	handler.mu.Lock()
+	handler.versions["bucket/c"] = handler.versions["bucket/c"][:1]
+	handler.mu.Unlock()
+	if e := undoOperationByID(ctx, ops[0].ID, false); e != nil {
+		t.Fatal(e)
+	}
+	if len(handler.versions["bucket/c"]) != 0 {
+		t.Fatalf("unexpected versions left %v", handler.versions)
+	}
+	op, err = loadUndoOperation(dir, ops[0].ID)
+	if err != nil || op.RevertedAt == nil {
+		t.Fatalf("operation not marked as reverted: %v", err)
+	}
+
+	pruned, err := pruneUndoOperations(dir, op.Time)
+	if err != nil || len(pruned) != 0 {
+		t.Fatalf("unexpected prune %v: %v", pruned, err)
+	}
+	pruned, err = pruneUndoOperations(dir, op.Time.Add(time.Second))
+	if err != nil || len(pruned) != 1 {
+		t.Fatalf("unexpected prune %v: %v", pruned, err)
+	}
+	if _, err = loadUndoOperation(dir, "../config"); err == nil {
+		t.Fatal("expected an error for an invalid ID")
+	}
+}
@@ -49,6 +49,22 @@ var undoFlags = []cli.Flag{
 		Name:  "dry-run",
 		Usage: "fake an undo operation",
 	},
+	cli.StringFlag{
+		Name:  "op",
+		Usage: "revert all changes of an operation recorded in the undo journal",
+	},
+	cli.BoolFlag{
+		Name:  "list",
+		Usage: "list the operations recorded in the undo journal",
+	},
+	cli.BoolFlag{
+		Name:  "prune",
+		Usage: "remove operations from the undo journal",
+	},
+	cli.StringFlag{
+		Name:  "older-than",
+		Usage: "prune operations older than value in duration string (e.g. 7d10h31s)",
+	},
 }
 
 var undoCmd = cli.Command{
@@ -63,6 +79,17 @@ var undoCmd = cli.Command{
 
 USAGE:
   {{.HelpName}} [FLAGS] TARGET
+  {{.HelpName}} --op ID [--dry-run]
+  {{.HelpName}} --list | --prune [--older-than DURATION]
+
+UNDO JOURNAL:
+  With MC_UNDO_JOURNAL=on, every run of mc records the versions its uploads,
+  copies and removals create in versioned buckets, and the versions they
+  replace, in an undo journal. Reverting an operation removes exactly these
+  versions and delete markers, so the versions they replaced become the latest
+  again. Objects changed after the operation are skipped and reported.
+  Operations older than MC_UNDO_JOURNAL_EXPIRY (default: 30d) are removed from
+  the journal when a new one is recorded.
 
 FLAGS:
   {{range .VisibleFlags}}{{.}}
@@ -73,6 +100,16 @@ EXAMPLES:
 
   2. Undo the last upload/removal change of all objects under a prefix
      {{.Prompt}} {{.HelpName}} s3/backups/prefix/ --recursive --force
+
+  3. List the operations recorded in the undo journal
+     {{.Prompt}} {{.HelpName}} --list
+
+  4. Preview, then revert a mistaken 'mc rm -r'
+     {{.Prompt}} {{.HelpName}} --op 20231012T101500Z-3fa21c --dry-run
+     {{.Prompt}} {{.HelpName}} --op 20231012T101500Z-3fa21c
+
+  5. Remove operations older than 30 days from the undo journal
+     {{.Prompt}} {{.HelpName}} --prune --older-than 30d
 `,
 }
 
@@ -257,6 +294,24 @@ func checkIfBucketIsVersioned(ctx context.Context, aliasedURL string) (versioned
 }
 
 func checkUndoSyntax(cliCtx *cli.Context) {
+	journalFlags := 0
+	for _, flag := range []string{"op", "list", "prune"} {
+		if cliCtx.IsSet(flag) {
+			journalFlags++
+		}
+	}
+	if journalFlags > 1 {
+		fatalIf(errInvalidArgument().Trace(), "--op, --list and --prune are mutually exclusive.")
+	}
+	if journalFlags == 1 {
+		if cliCtx.Args().Present() {
+			fatalIf(errInvalidArgument().Trace(cliCtx.Args()...), "No TARGET is accepted with --op, --list and --prune.")
+		}
+		if cliCtx.IsSet("older-than") && !cliCtx.Bool("prune") {
+			fatalIf(errInvalidArgument().Trace(), "--older-than is only accepted with --prune.")
+		}
+		return
+	}
 	if !cliCtx.Args().Present() {
 		showCommandHelpAndExit(cliCtx, "undo", 1)
 	}
@@ -271,6 +326,15 @@ func mainUndo(cliCtx *cli.Context) error {
 
 	console.SetColor("Success", color.New(color.FgGreen, color.Bold))
 
+	switch {
+	case cliCtx.Bool("list"):
+		return undoListOperations()
+	case cliCtx.Bool("prune"):
+		return undoPruneOperations(cliCtx.String("older-than"))
+	case cliCtx.IsSet("op"):
+		return undoOperationByID(ctx, cliCtx.String("op"), cliCtx.Bool("dry-run"))
+	}
+
 	// check 'undo' cli arguments.
 	targetAliasedURL, last, recursive, dryRun := parseUndoSyntax(cliCtx)
 
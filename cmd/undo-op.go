@@ -0,0 +1,332 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/console"
+)
+
+// undoOperationMessage describes an operation of the undo journal.
+type undoOperationMessage struct {
+	Status     string     `json:"status"`
+	ID         string     `json:"id"`
+	Time       time.Time  `json:"time"`
+	Command    string     `json:"command"`
+	Objects    int        `json:"objects"`
+	RevertedAt *time.Time `json:"revertedAt,omitempty"`
+	Pruned     bool       `json:"pruned,omitempty"`
+}
+
+// String colorized operation message.
+func (u undoOperationMessage) String() string {
+	msg := console.Colorize("Time", "["+u.Time.Local().Format(printDate)+"] ") +
+		console.Colorize("ID", u.ID) + fmt.Sprintf(" %6d object(s) ", u.Objects) + u.Command
+	if u.Pruned {
+		msg = "Pruned " + msg
+	}
+	if u.RevertedAt != nil {
+		msg += console.Colorize("Reverted", " (reverted)")
+	}
+	return msg
+}
+
+// JSON jsonified operation message.
+func (u undoOperationMessage) JSON() string {
+	u.Status = "success"
+	jsonMessageBytes, e := json.MarshalIndent(u, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func newUndoOperationMessage(op undoOperation) undoOperationMessage {
+	return undoOperationMessage{
+		ID:         op.ID,
+		Time:       op.Time,
+		Command:    op.Command,
+		Objects:    len(op.Entries),
+		RevertedAt: op.RevertedAt,
+	}
+}
+
+// undoRevertMessage describes a version removed to revert an operation,
+// or skipped because the object changed after the operation.
+type undoRevertMessage struct {
+	Status          string `json:"status"`
+	Operation       string `json:"operation"`
+	URL             string `json:"url"`
+	Action          string `json:"action"`
+	VersionID       string `json:"versionId"`
+	PriorVersionID  string `json:"priorVersionId,omitempty"`
+	Skipped         bool   `json:"skipped,omitempty"`
+	LatestVersionID string `json:"latestVersionId,omitempty"`
+	DryRun          bool   `json:"dryRun,omitempty"`
+}
+
+// String colorized revert message.
+func (u undoRevertMessage) String() string {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	var msg string
+	if u.Action == undoActionDelete {
+		msg = color.RedString("Delete") + " of `" + yellow(u.URL) + "` (delete marker vid=" + u.VersionID + ")"
+	} else {
+		msg = color.BlueString("Upload") + " of `" + yellow(u.URL) + "` (vid=" + u.VersionID + ")"
+	}
+	switch {
+	case u.Skipped:
+		latest := "the object has no version left"
+		if u.LatestVersionID != "" {
+			latest = "the latest version is vid=" + u.LatestVersionID
+		}
+		prefix := color.YellowString("Skipped ")
+		if u.DryRun {
+			prefix = "Would skip "
+		}
+		return prefix + strings.ToLower(msg[:1]) + msg[1:] + ", the object changed since, " + latest + "."
+	case u.DryRun:
+		msg = "Would revert " + strings.ToLower(msg[:1]) + msg[1:]
+	default:
+		msg = color.GreenString("✓ ") + msg + " is reverted"
+	}
+	if u.PriorVersionID != "" {
+		msg += ", restoring vid=" + u.PriorVersionID
+	}
+	return msg + "."
+}
+
+// JSON jsonified revert message.
+func (u undoRevertMessage) JSON() string {
+	u.Status = "success"
+	if u.Skipped {
+		u.Status = "skipped"
+	}
+	jsonMessageBytes, e := json.MarshalIndent(u, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(jsonMessageBytes)
+}
+
+func setUndoJournalColors() {
+	console.SetColor("Time", color.New(color.FgGreen))
+	console.SetColor("ID", color.New(color.Bold))
+	console.SetColor("Reverted", color.New(color.FgYellow))
+}
+
+// undoListOperations lists the operations of the undo journal.
+func undoListOperations() error {
+	setUndoJournalColors()
+	dir, err := getUndoJournalDir()
+	fatalIf(err.Trace(), "Unable to determine the undo journal folder.")
+	ops, err := listUndoOperations(dir)
+	fatalIf(err.Trace(dir), "Unable to list the undo journal.")
+	for _, op := range ops {
+		printMsg(newUndoOperationMessage(op))
+	}
+	return nil
+}
+
+// undoPruneOperations removes operations older than a duration from the
+// undo journal, all operations when olderThan is empty.
+func undoPruneOperations(olderThan string) error {
+	setUndoJournalColors()
+	before := UTCNow()
+	if olderThan != "" {
+		d, e := ParseDuration(olderThan)
+		fatalIf(probe.NewError(e), "Unable to parse older-than=`"+olderThan+"`.")
+		before = before.Add(-time.Duration(d))
+	}
+	dir, err := getUndoJournalDir()
+	fatalIf(err.Trace(), "Unable to determine the undo journal folder.")
+	pruned, err := pruneUndoOperations(dir, before)
+	for _, op := range pruned {
+		msg := newUndoOperationMessage(op)
+		msg.Pruned = true
+		printMsg(msg)
+	}
+	fatalIf(err.Trace(dir), "Unable to prune the undo journal.")
+	return nil
+}
+
+// undoOperationByID reverts an operation of the undo journal by removing
+// the versions and delete markers it created, most recent first.
+func undoOperationByID(ctx context.Context, id string, dryRun bool) (exitErr error) {
+	dir, err := getUndoJournalDir()
+	fatalIf(err.Trace(), "Unable to determine the undo journal folder.")
+	op, err := loadUndoOperation(dir, id)
+	fatalIf(err.Trace(id), "Unable to load operation `"+id+"`.")
+	if op.RevertedAt != nil {
+		fatalIf(errInvalidArgument().Trace(id), "Operation `"+id+"` was already reverted on "+op.RevertedAt.Local().Format(printDate)+".")
+	}
+	if len(op.Entries) == 0 {
+		fatalIf(errDummy().Trace(id), "Operation `"+id+"` did not create any version which can be reverted.")
+	}
+
+	// Group the versions per bucket so that they are removed in bulk.
+	type bucketEntries struct {
+		url     string
+		entries []undoJournalEntry
+	}
+	var buckets []*bucketEntries
+	byBucket := map[string]*bucketEntries{}
+	for i := len(op.Entries) - 1; i >= 0; i-- {
+		entry := op.Entries[i]
+		tokens := splitStr(entry.URL, "/", 3)
+		bucketURL := tokens[0] + "/" + tokens[1]
+		b, ok := byBucket[bucketURL]
+		if !ok {
+			b = &bucketEntries{url: bucketURL}
+			byBucket[bucketURL] = b
+			buckets = append(buckets, b)
+		}
+		b.entries = append(b.entries, entry)
+	}
+
+	for _, b := range buckets {
+		entries, skipped := undoCheckLatest(ctx, op.ID, b.url, b.entries, dryRun)
+		if skipped && !dryRun {
+			exitErr = exitStatus(globalErrorExitStatus)
+		}
+		if dryRun {
+			for _, entry := range entries {
+				printMsg(newUndoRevertMessage(op.ID, entry, true))
+			}
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if err := undoBucketEntries(ctx, op.ID, b.url, entries); err != nil {
+			exitErr = err
+		}
+	}
+
+	if !dryRun && exitErr == nil {
+		err = markUndoOperationReverted(dir, op.ID)
+		fatalIf(err.Trace(op.ID), "Unable to mark operation `"+op.ID+"` as reverted.")
+	}
+	return exitErr
+}
+
+func newUndoRevertMessage(id string, entry undoJournalEntry, dryRun bool) undoRevertMessage {
+	return undoRevertMessage{
+		Operation:      id,
+		URL:            entry.URL,
+		Action:         entry.Action,
+		VersionID:      entry.VersionID,
+		PriorVersionID: entry.PriorVersionID,
+		DryRun:         dryRun,
+	}
+}
+
+// undoCheckLatest returns the entries of a bucket which can be reverted,
+// latest first. The versions created by the operation must still be the
+// latest ones, objects changed afterwards are skipped with a message so
+// that later versions are never removed from the middle of the history.
+func undoCheckLatest(ctx context.Context, id, bucketURL string, entries []undoJournalEntry, dryRun bool) (revert []undoJournalEntry, skipped bool) {
+	clnt, err := newClient(bucketURL)
+	fatalIf(err.Trace(bucketURL), "Unable to initialize target `"+bucketURL+"`.")
+	s3Clnt, ok := unwrapClient(clnt).(*S3Client)
+	if !ok {
+		fatalIf(errDummy().Trace(bucketURL), "Unable to revert changes of `"+bucketURL+"`, it is not an S3 server.")
+	}
+	bucket, _ := s3Clnt.url2BucketAndObject()
+
+	// Entries are latest first, the first one of each object must be
+	// its latest version. When it does not exist anymore, the object was
+	// reverted by a previous, partially failed, run.
+	type objectState struct {
+		changed  bool
+		reverted bool
+		latest   string
+	}
+	states := map[string]objectState{}
+	for _, entry := range entries {
+		key := splitStr(entry.URL, "/", 3)[2]
+		if _, ok := states[key]; ok {
+			continue
+		}
+		versionID, _, e := latestObjectVersion(ctx, s3Clnt.api, bucket, key)
+		fatalIf(probe.NewError(e).Trace(entry.URL), "Unable to get the latest version of `"+entry.URL+"`.")
+		state := objectState{changed: versionID != entry.VersionID, latest: versionID}
+		if state.changed {
+			_, e = s3Clnt.api.StatObject(ctx, bucket, key, minio.StatObjectOptions{VersionID: entry.VersionID})
+			switch minio.ToErrorResponse(e).Code {
+			case "NoSuchKey", "NoSuchVersion":
+				state.reverted = true
+			}
+		}
+		states[key] = state
+	}
+	for _, entry := range entries {
+		state := states[splitStr(entry.URL, "/", 3)[2]]
+		switch {
+		case state.reverted:
+		case !state.changed:
+			revert = append(revert, entry)
+		default:
+			msg := newUndoRevertMessage(id, entry, dryRun)
+			msg.Skipped, msg.LatestVersionID = true, state.latest
+			printMsg(msg)
+			skipped = true
+		}
+	}
+	return revert, skipped
+}
+
+// undoBucketEntries removes the versions created by an operation in a bucket.
+func undoBucketEntries(ctx context.Context, id, bucketURL string, entries []undoJournalEntry) (exitErr error) {
+	clnt, err := newClient(bucketURL)
+	fatalIf(err.Trace(bucketURL), "Unable to initialize target `"+bucketURL+"`.")
+	s3Clnt, ok := unwrapClient(clnt).(*S3Client)
+	if !ok {
+		fatalIf(errDummy().Trace(bucketURL), "Unable to revert changes of `"+bucketURL+"`, it is not an S3 server.")
+	}
+	bucket, _ := s3Clnt.url2BucketAndObject()
+
+	pending := make(map[string]undoJournalEntry, len(entries))
+	for _, entry := range entries {
+		pending[splitStr(entry.URL, "/", 3)[2]+"\x00"+entry.VersionID] = entry
+	}
+	contentCh := make(chan *ClientContent)
+	go func() {
+		defer close(contentCh)
+		for _, entry := range entries {
+			key := splitStr(entry.URL, "/", 3)[2]
+			contentCh <- s3Clnt.objectInfo2ClientContent(bucket, minio.ObjectInfo{Key: key, VersionID: entry.VersionID})
+		}
+	}()
+
+	for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+		if result.Err != nil {
+			errorIf(result.Err.Trace(bucketURL), "Unable to revert a change of operation `"+id+"`.")
+			exitErr = exitStatus(globalErrorExitStatus)
+			continue
+		}
+		if entry, ok := pending[result.ObjectName+"\x00"+result.ObjectVersionID]; ok {
+			printMsg(newUndoRevertMessage(id, entry, false))
+		}
+	}
+	return exitErr
+}
@@ -56,6 +56,14 @@ var updateCmd = cli.Command{
 			Name:  "json",
 			Usage: "enable JSON lines formatted output",
 		},
+		cli.StringFlag{
+			Name:  "from",
+			Usage: "install a release from a local file or directory instead of downloading it",
+		},
+		cli.BoolFlag{
+			Name:  "rollback",
+			Usage: "restore the binary replaced by the last update",
+		},
 	},
 	CustomHelpTemplate: `Name:
    {{.HelpName}} - {{.Usage}}
@@ -66,6 +74,17 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}{{end}}
+ENVIRONMENT VARIABLES:
+  MC_UPDATE_MINISIGN_PUBKEY:  minisign public key verifying the release signature, required with --from
+
+OFFLINE RELEASES:
+  A directory given to --from follows the layout of the download server: the
+  release info file 'mc.sha256sum' and the release binary 'mc.RELEASE.<TAG>'
+  signed in 'mc.RELEASE.<TAG>.minisig'. A file given to --from is the release
+  binary, with FILE.sha256sum and FILE.minisig next to it.
+
+  The replaced binary is kept next to mc for --rollback.
+
 EXIT STATUS:
   0 - you are already running the most recent version
   1 - new update was applied successfully
@@ -74,6 +93,13 @@ EXIT STATUS:
 EXAMPLES:
   1. Check and update mc:
      {{.Prompt}} {{.HelpName}}
+
+  2. Update mc from a release copied from the download server to a USB drive:
+     {{.Prompt}} export MC_UPDATE_MINISIGN_PUBKEY=$(tail -n 1 /media/usb/minisign.pub)
+     {{.Prompt}} {{.HelpName}} --from /media/usb/mc/release/linux-amd64/
+
+  3. Restore the binary replaced by the last update:
+     {{.Prompt}} {{.HelpName}} --rollback
 `,
 }
 
@@ -449,9 +475,15 @@ func doUpdate(customReleaseURL string, sha256Hex string, latestReleaseTime time.
 	}
 	defer rc.Close()
 
+	// Keep the replaced binary for 'mc update --rollback'.
+	rollbackPath, err := getRollbackPath("")
+	if err != nil {
+		return updateStatusMsg, err.Trace()
+	}
 	opts := selfupdate.Options{
-		Hash:     crypto.SHA256,
-		Checksum: sha256Sum,
+		Hash:        crypto.SHA256,
+		Checksum:    sha256Sum,
+		OldSavePath: rollbackPath,
 	}
 
 	minisignPubkey := env.Get(envMinisignPubKey, "")
@@ -511,6 +543,33 @@ func (s updateMessage) JSON() string {
 	return string(updateJSONBytes)
 }
 
+// mainUpdateOffline installs a release carried in with --from.
+func mainUpdateOffline(from string) {
+	updateMsg, release, err := getOfflineUpdateInfo(from)
+	if err != nil {
+		errorIf(err, "Unable to update ‘mc’.")
+		os.Exit(-1)
+	}
+	if updateMsg == "" {
+		printMsg(updateMessage{
+			Status:  "success",
+			Message: colorGreenBold("You are already running the most recent version of ‘mc’."),
+		})
+		os.Exit(0)
+	}
+	printMsg(updateMessage{Status: "success", Message: updateMsg})
+
+	if err = applyOfflineUpdate(release, ""); err != nil {
+		errorIf(err, "Unable to update ‘mc’.")
+		os.Exit(-1)
+	}
+	printMsg(updateMessage{
+		Status:  "success",
+		Message: colorGreenBold("mc updated to version %s successfully.", release.releaseTag),
+	})
+	os.Exit(1)
+}
+
 func mainUpdate(ctx *cli.Context) {
 	if len(ctx.Args()) > 1 {
 		showCommandHelpAndExit(ctx, ctx.Command.Name, -1)
@@ -519,6 +578,27 @@ func mainUpdate(ctx *cli.Context) {
 	globalQuiet = ctx.Bool("quiet") || ctx.GlobalBool("quiet")
 	globalJSON = ctx.Bool("json") || ctx.GlobalBool("json")
 
+	switch {
+	case ctx.Bool("rollback"):
+		if ctx.IsSet("from") || ctx.NArg() > 0 {
+			showCommandHelpAndExit(ctx, ctx.Command.Name, -1)
+		}
+		if err := rollbackUpdate(""); err != nil {
+			errorIf(err, "Unable to roll back ‘mc’.")
+			os.Exit(-1)
+		}
+		printMsg(updateMessage{
+			Status:  "success",
+			Message: colorGreenBold("mc rolled back to the binary replaced by the last update."),
+		})
+		os.Exit(1)
+	case ctx.IsSet("from"):
+		if ctx.NArg() > 0 {
+			showCommandHelpAndExit(ctx, ctx.Command.Name, -1)
+		}
+		mainUpdateOffline(ctx.String("from"))
+	}
+
 	customReleaseURL := ctx.Args().Get(0)
 
 	updateMsg, sha256Hex, _, latestReleaseTime, releaseTag, err := getUpdateInfo(customReleaseURL, 10*time.Second)
@@ -0,0 +1,187 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/env"
+	"github.com/minio/selfupdate"
+)
+
+// offlineRelease is a release carried in for an air-gapped update.
+type offlineRelease struct {
+	binary      string
+	signature   string
+	sha256Hex   string
+	releaseTime time.Time
+	releaseTag  string
+}
+
+// findOfflineRelease locates the binary, checksum and signature of a
+// release on disk. A directory follows the layout of the download
+// server, the release info file names the binary next to it:
+//
+//	DIR/mc.sha256sum
+//	DIR/mc.RELEASE.2023-01-28T20-29-38Z
+//	DIR/mc.RELEASE.2023-01-28T20-29-38Z.minisig
+//
+// A file is the binary itself, with FILE.sha256sum and FILE.minisig.
+func findOfflineRelease(from string) (release offlineRelease, err *probe.Error) {
+	fi, e := os.Stat(from)
+	if e != nil {
+		return release, probe.NewError(e).Trace(from)
+	}
+
+	infoPath := from + ".sha256sum"
+	if fi.IsDir() {
+		infoFile := path.Base(mcReleaseInfoURL)
+		if runtime.GOOS == "windows" {
+			infoFile = path.Base(mcReleaseWindowsInfoURL)
+		}
+		infoPath = filepath.Join(from, infoFile)
+	}
+	data, e := os.ReadFile(infoPath)
+	if e != nil {
+		return release, probe.NewError(e).Trace(infoPath)
+	}
+	release.sha256Hex, release.releaseTime, release.releaseTag, err = parseReleaseData(string(data))
+	if err != nil {
+		return release, err.Trace(infoPath)
+	}
+
+	release.binary = from
+	if fi.IsDir() {
+		release.binary = filepath.Join(from, "mc."+release.releaseTag)
+	}
+	release.signature = release.binary + ".minisig"
+	return release, nil
+}
+
+// getOfflineUpdateInfo compares a release on disk with the running binary.
+func getOfflineUpdateInfo(from string) (updateMsg string, release offlineRelease, err *probe.Error) {
+	currentReleaseTime, err := GetCurrentReleaseTime()
+	if err != nil {
+		return updateMsg, release, err.Trace()
+	}
+	release, err = findOfflineRelease(from)
+	if err != nil {
+		return updateMsg, release, err.Trace(from)
+	}
+	if release.releaseTime.After(currentReleaseTime) {
+		updateMsg = prepareUpdateMessage(release.binary, release.releaseTime.Sub(currentReleaseTime))
+	}
+	return updateMsg, release, nil
+}
+
+// getRollbackPath returns where the binary replaced by the last
+// update is kept, next to the binary so that it can be renamed back.
+func getRollbackPath(targetPath string) (string, *probe.Error) {
+	if targetPath == "" {
+		var e error
+		if targetPath, e = os.Executable(); e != nil {
+			return "", probe.NewError(e)
+		}
+		if targetPath, e = filepath.EvalSymlinks(targetPath); e != nil {
+			return "", probe.NewError(e)
+		}
+	}
+	return filepath.Join(filepath.Dir(targetPath), "."+filepath.Base(targetPath)+".rollback"), nil
+}
+
+// applyOfflineUpdate verifies the checksum and the minisign signature of
+// a release on disk before replacing the binary at targetPath, or the
+// running binary when empty. Unlike downloads, offline releases are
+// never installed without a signature.
+func applyOfflineUpdate(release offlineRelease, targetPath string) *probe.Error {
+	minisignPubkey := env.Get(envMinisignPubKey, "")
+	if minisignPubkey == "" {
+		return probe.NewError(fmt.Errorf("%s must be set to verify the signature of offline releases", envMinisignPubKey))
+	}
+
+	sha256Sum, e := hex.DecodeString(release.sha256Hex)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	v := selfupdate.NewVerifier()
+	if e = v.LoadFromFile(release.signature, minisignPubkey); e != nil {
+		return probe.NewError(e).Trace(release.signature)
+	}
+	binary, e := os.ReadFile(release.binary)
+	if e != nil {
+		return probe.NewError(e).Trace(release.binary)
+	}
+
+	rollbackPath, err := getRollbackPath(targetPath)
+	if err != nil {
+		return err.Trace(targetPath)
+	}
+	return applyUpdateBinary(bytes.NewReader(binary), selfupdate.Options{
+		TargetPath:  targetPath,
+		Hash:        crypto.SHA256,
+		Checksum:    sha256Sum,
+		Verifier:    v,
+		OldSavePath: rollbackPath,
+	})
+}
+
+// rollbackUpdate swaps the binary at targetPath, or the running binary
+// when empty, with the copy kept by the last update. Rolling back twice
+// restores the updated binary.
+func rollbackUpdate(targetPath string) *probe.Error {
+	rollbackPath, err := getRollbackPath(targetPath)
+	if err != nil {
+		return err.Trace(targetPath)
+	}
+	previous, e := os.ReadFile(rollbackPath)
+	if os.IsNotExist(e) {
+		return probe.NewError(errors.New("no previous binary was kept by an update"))
+	}
+	if e != nil {
+		return probe.NewError(e).Trace(rollbackPath)
+	}
+	return applyUpdateBinary(bytes.NewReader(previous), selfupdate.Options{
+		TargetPath:  targetPath,
+		OldSavePath: rollbackPath,
+	})
+}
+
+// applyUpdateBinary replaces the binary, reporting a failed restore of
+// the original binary separately.
+func applyUpdateBinary(binary *bytes.Reader, opts selfupdate.Options) *probe.Error {
+	if e := opts.CheckPermissions(); e != nil {
+		return probe.NewError(e)
+	}
+	if e := selfupdate.Apply(binary, opts); e != nil {
+		if re := selfupdate.RollbackError(e); re != nil {
+			return probe.NewError(fmt.Errorf("%w, failed to restore the original binary: %v", e, re))
+		}
+		return probe.NewError(e)
+	}
+	return nil
+}
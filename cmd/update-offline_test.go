@@ -0,0 +1,133 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeMinisignRelease writes a release in the layout of the download
+// server, signed with a new minisign key which is returned.
+func writeMinisignRelease(t *testing.T, dir, releaseTag string, binary []byte) string {
+	pub, priv, e := ed25519.GenerateKey(rand.Reader)
+	if e != nil {
+		t.Fatal(e)
+	}
+	keyID := []byte("mctestid")
+	trustedComment := "timestamp:0\tfile:mc." + releaseTag
+
+	signature := ed25519.Sign(priv, binary)
+	globalSignature := ed25519.Sign(priv, append(append([]byte{}, signature...), trustedComment...))
+	minisig := "untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), signature...)) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(globalSignature) + "\n"
+
+	sum := sha256.Sum256(binary)
+	for name, data := range map[string][]byte{
+		"mc.sha256sum":                  []byte(hex.EncodeToString(sum[:]) + " mc." + releaseTag + "\n"),
+		"mc." + releaseTag:              binary,
+		"mc." + releaseTag + ".minisig": []byte(minisig),
+	} {
+		if e = os.WriteFile(filepath.Join(dir, name), data, 0o600); e != nil {
+			t.Fatal(e)
+		}
+	}
+	return base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+}
+
+func TestOfflineUpdate(t *testing.T) {
+	const releaseTag = "RELEASE.2099-01-02T03-04-05Z"
+	dir := t.TempDir()
+	newBinary := []byte("#!/bin/sh\necho new\n")
+	pubkey := writeMinisignRelease(t, dir, releaseTag, newBinary)
+
+	release, err := findOfflineRelease(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release.releaseTag != releaseTag || release.binary != filepath.Join(dir, "mc."+releaseTag) {
+		t.Fatalf("unexpected release %+v", release)
+	}
+	// A release binary given as a file needs its own checksum file.
+	if _, err = findOfflineRelease(release.binary); err == nil {
+		t.Fatal("expected an error without FILE.sha256sum")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "mc.sha256sum"))
+	os.WriteFile(release.binary+".sha256sum", data, 0o600)
+	if fileRelease, err := findOfflineRelease(release.binary); err != nil || fileRelease != release {
+		t.Fatalf("unexpected release %+v: %v", fileRelease, err)
+	}
+
+	target := filepath.Join(t.TempDir(), "mc")
+	oldBinary := []byte("#!/bin/sh\necho old\n")
+	if e := os.WriteFile(target, oldBinary, 0o755); e != nil {
+		t.Fatal(e)
+	}
+	expectTarget := func(expected []byte) {
+		t.Helper()
+		if got, _ := os.ReadFile(target); string(got) != string(expected) {
+			t.Fatalf("expected %q, got %q", expected, got)
+		}
+	}
+
+	// Nothing is installed without a public key or with another key.
+	t.Setenv(envMinisignPubKey, "")
+	if err = applyOfflineUpdate(release, target); err == nil {
+		t.Fatal("expected an error without a public key")
+	}
+	t.Setenv(envMinisignPubKey, writeMinisignRelease(t, t.TempDir(), releaseTag, newBinary))
+	if err = applyOfflineUpdate(release, target); err == nil {
+		t.Fatal("expected an error with another public key")
+	}
+	// Nor when the binary does not match the checksum.
+	t.Setenv(envMinisignPubKey, pubkey)
+	tampered := release
+	tampered.sha256Hex = hex.EncodeToString(make([]byte, sha256.Size))
+	if err = applyOfflineUpdate(tampered, target); err == nil {
+		t.Fatal("expected a checksum error")
+	}
+	expectTarget(oldBinary)
+
+	if err = applyOfflineUpdate(release, target); err != nil {
+		t.Fatal(err)
+	}
+	expectTarget(newBinary)
+
+	// Rolling back swaps the binaries.
+	if err = rollbackUpdate(target); err != nil {
+		t.Fatal(err)
+	}
+	expectTarget(oldBinary)
+	if err = rollbackUpdate(target); err != nil {
+		t.Fatal(err)
+	}
+	expectTarget(newBinary)
+
+	os.Remove(filepath.Join(filepath.Dir(target), ".mc.rollback"))
+	if err = rollbackUpdate(target); err == nil {
+		t.Fatal("expected an error without a previous binary")
+	}
+}
@@ -161,6 +161,9 @@ func NewS3Config(urlStr string, aliasCfg *aliasConfigV10) *Config {
 		s3Config.SessionToken = aliasCfg.SessionToken
 		s3Config.Signature = aliasCfg.API
 		s3Config.Lookup = getLookupType(aliasCfg.Path)
+		s3Config.ClientCert = aliasCfg.ClientCert
+		s3Config.ClientKey = aliasCfg.ClientKey
+		s3Config.PinnedKeys = aliasCfg.PinnedKeys
 	}
 	return s3Config
 }
@@ -0,0 +1,527 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/shlex"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+	"github.com/minio/pkg/console"
+)
+
+// watchEvent is the event record handed to watch actions.
+type watchEvent struct {
+	Time      string                 `json:"time"`
+	Type      notification.EventType `json:"type"`
+	Path      string                 `json:"path"`
+	Size      int64                  `json:"size"`
+	Host      string                 `json:"host,omitempty"`
+	Port      string                 `json:"port,omitempty"`
+	UserAgent string                 `json:"userAgent,omitempty"`
+	ETag      string                 `json:"etag,omitempty"`
+	// Replay is set for events synthesized from a listing after a restart.
+	Replay bool `json:"replay,omitempty"`
+}
+
+func newWatchEvent(ev EventInfo) watchEvent {
+	return watchEvent{
+		Time:      ev.Time,
+		Type:      ev.Type,
+		Path:      ev.Path,
+		Size:      ev.Size,
+		Host:      ev.Host,
+		Port:      ev.Port,
+		UserAgent: ev.UserAgent,
+		ETag:      strings.Trim(ev.ETag, "\""),
+	}
+}
+
+func (ev watchEvent) eventTime() time.Time {
+	t, e := time.Parse(time.RFC3339Nano, ev.Time)
+	if e != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// watchAction is an action taken on every batch of watched events.
+type watchAction interface {
+	dispatch(ctx context.Context, events []watchEvent) *probe.Error
+}
+
+// watchExecAction runs a templated command for every event, the
+// placeholders are the same as 'find --exec' plus {event}.
+type watchExecAction struct {
+	args []string
+}
+
+func newWatchExecAction(cmdline string) (*watchExecAction, *probe.Error) {
+	args, e := shlex.Split(cmdline)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(cmdline)
+	}
+	if len(args) == 0 {
+		return nil, errInvalidArgument().Trace(cmdline)
+	}
+	return &watchExecAction{args: args}, nil
+}
+
+// expandWatchTemplate substitutes the event placeholders in arg, in a
+// single pass so that placeholders within the values are kept as is.
+func expandWatchTemplate(arg string, ev watchEvent) string {
+	replacements := []struct{ token, value string }{
+		{"{}", ev.Path},
+		{"{base}", filepath.Base(ev.Path)},
+		{"{dir}", filepath.Dir(ev.Path)},
+		{"{size}", strconv.FormatInt(ev.Size, 10)},
+		{"{time}", ev.Time},
+		{"{event}", string(ev.Type)},
+	}
+	oldnew := make([]string, 0, 4*len(replacements))
+	for _, r := range replacements {
+		// {""}, {"base"}... are replaced by the quoted value.
+		quoted := "{" + strconv.Quote(r.token[1:len(r.token)-1]) + "}"
+		oldnew = append(oldnew, quoted, strconv.Quote(r.value))
+	}
+	for _, r := range replacements {
+		oldnew = append(oldnew, r.token, r.value)
+	}
+	return strings.NewReplacer(oldnew...).Replace(arg)
+}
+
+func (a *watchExecAction) dispatch(ctx context.Context, events []watchEvent) *probe.Error {
+	for _, ev := range events {
+		args := make([]string, len(a.args))
+		for i, arg := range a.args {
+			args[i] = expandWatchTemplate(arg, ev)
+		}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		var out, stderr bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &stderr
+		if e := cmd.Run(); e != nil {
+			if stderr.Len() > 0 {
+				e = fmt.Errorf("%v: %s", e, strings.TrimSpace(stderr.String()))
+			}
+			return probe.NewError(e).Trace(args...)
+		}
+		if out.Len() > 0 {
+			console.PrintC(out.String())
+		}
+	}
+	return nil
+}
+
+// watchWebhookAction POSTs every batch of events as JSON, failed
+// deliveries are retried with backoff, receivers get events at least once.
+type watchWebhookAction struct {
+	endpoint string
+	token    string
+	client   *http.Client
+	policy   retryPolicy
+}
+
+func newWatchWebhookAction(endpoint, token string) *watchWebhookAction {
+	return &watchWebhookAction{
+		endpoint: endpoint,
+		token:    token,
+		client:   httpClient(30 * time.Second),
+		policy:   newRetryPolicy(),
+	}
+}
+
+func (a *watchWebhookAction) post(ctx context.Context, body []byte) *probe.Error {
+	req, e := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(body))
+	if e != nil {
+		return probe.NewError(e)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "mc-watch")
+	if a.token != "" {
+		req.Header.Set("Authorization", a.token)
+	}
+	resp, e := a.client.Do(req)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode/100 != 2 {
+		// Reported as an error response so that 429 and 5xx are retried.
+		return probe.NewError(minio.ErrorResponse{
+			StatusCode: resp.StatusCode,
+			Message:    "webhook " + a.endpoint + " returned " + resp.Status,
+		})
+	}
+	return nil
+}
+
+func (a *watchWebhookAction) dispatch(ctx context.Context, events []watchEvent) *probe.Error {
+	body, e := json.Marshal(struct {
+		Events []watchEvent `json:"events"`
+	}{events})
+	if e != nil {
+		return probe.NewError(e)
+	}
+	return a.send(ctx, body)
+}
+
+// send posts body to the webhook, retrying failed deliveries.
+func (a *watchWebhookAction) send(ctx context.Context, body []byte) *probe.Error {
+	return a.policy.do(ctx, true, func() *probe.Error {
+		return a.post(ctx, body)
+	})
+}
+
+// watchQueueAction appends every event as a JSON line to a local file.
+type watchQueueAction struct {
+	file *os.File
+}
+
+func newWatchQueueAction(name string) (*watchQueueAction, *probe.Error) {
+	f, e := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	return &watchQueueAction{file: f}, nil
+}
+
+func (a *watchQueueAction) dispatch(ctx context.Context, events []watchEvent) *probe.Error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range events {
+		if e := enc.Encode(ev); e != nil {
+			return probe.NewError(e)
+		}
+	}
+	if _, e := a.file.Write(buf.Bytes()); e != nil {
+		return probe.NewError(e).Trace(a.file.Name())
+	}
+	if e := a.file.Sync(); e != nil {
+		return probe.NewError(e).Trace(a.file.Name())
+	}
+	return nil
+}
+
+// watchCursor is the durable checkpoint of a watcher, the time of the
+// most recent event successfully handed to all actions. Listings only
+// have a second precision, the ETags of the objects handed over within
+// the second of the cursor are kept to not replay them again.
+type watchCursor struct {
+	file   string
+	Target string            `json:"target"`
+	Time   time.Time         `json:"time"`
+	ETags  map[string]string `json:"etags,omitempty"`
+}
+
+// loadWatchCursor reads the cursor file, a missing file or a cursor
+// saved for another target yields an empty cursor.
+func loadWatchCursor(file, target string) (*watchCursor, *probe.Error) {
+	c := &watchCursor{file: file, Target: target}
+	data, e := os.ReadFile(file)
+	if os.IsNotExist(e) {
+		return c, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(file)
+	}
+	var saved watchCursor
+	if e = json.Unmarshal(data, &saved); e != nil {
+		return nil, probe.NewError(e).Trace(file)
+	}
+	if saved.Target == target {
+		c.Time = saved.Time
+		c.ETags = saved.ETags
+	}
+	return c, nil
+}
+
+// advance records the events as handled and persists the cursor.
+func (c *watchCursor) advance(events []watchEvent) *probe.Error {
+	latest := c.Time
+	for _, ev := range events {
+		if t := ev.eventTime(); t.After(latest) {
+			latest = t
+		}
+	}
+	changed := latest.After(c.Time)
+	second := latest.Truncate(time.Second)
+	if second.After(c.Time.Truncate(time.Second)) {
+		c.ETags = nil
+	}
+	for _, ev := range events {
+		if ev.ETag == "" || !ev.eventTime().Truncate(time.Second).Equal(second) {
+			continue
+		}
+		if c.ETags[ev.Path] != ev.ETag {
+			if c.ETags == nil {
+				c.ETags = make(map[string]string)
+			}
+			c.ETags[ev.Path] = ev.ETag
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	c.Time = latest
+	data, e := json.Marshal(c)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	tmp := c.file + ".tmp"
+	if e = os.WriteFile(tmp, data, 0o600); e != nil {
+		return probe.NewError(e).Trace(tmp)
+	}
+	if e = os.Rename(tmp, c.file); e != nil {
+		return probe.NewError(e).Trace(c.file)
+	}
+	return nil
+}
+
+// Maximum number of events waiting to be dispatched, events received
+// while actions keep failing are dropped beyond it.
+const watchMaxPendingEvents = 10000
+
+// watchDispatcher batches events over the debounce window and hands
+// them to every action, the cursor only advances once all succeeded.
+// Failed batches are dispatched again, to every action, after a backoff
+// or along with the next batch.
+type watchDispatcher struct {
+	actions  []watchAction
+	debounce time.Duration
+	cursor   *watchCursor
+
+	// Backoff between dispatches of failed batches and bound of the
+	// pending events, the defaults apply when unset.
+	retry      retryPolicy
+	maxPending int
+
+	mu       sync.Mutex
+	pending  []watchEvent
+	timer    *time.Timer
+	failures int
+	stopped  bool
+
+	// Serializes dispatching, batches are handed over in order.
+	flushMu sync.Mutex
+}
+
+// coalesceWatchEvents keeps only the last event of each path and type
+// within a batch, preserving the order of their last occurrence.
+func coalesceWatchEvents(events []watchEvent) []watchEvent {
+	last := make(map[string]int, len(events))
+	for i, ev := range events {
+		last[string(ev.Type)+"\x00"+ev.Path] = i
+	}
+	out := make([]watchEvent, 0, len(last))
+	for i, ev := range events {
+		if last[string(ev.Type)+"\x00"+ev.Path] == i {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// add queues events for dispatching, without a debounce window they
+// are dispatched right away.
+func (d *watchDispatcher) add(ctx context.Context, events []watchEvent) {
+	d.mu.Lock()
+	d.pending = append(d.pending, events...)
+	dropped := d.trimPending()
+	if d.debounce <= 0 {
+		d.mu.Unlock()
+		d.reportDropped(dropped)
+		d.flush(ctx)
+		return
+	}
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.debounce, func() { d.flush(ctx) })
+	}
+	d.mu.Unlock()
+	d.reportDropped(dropped)
+}
+
+// trimPending drops the newest pending events beyond the limit and
+// returns how many were dropped, d.mu must be held.
+func (d *watchDispatcher) trimPending() int {
+	maxPending := d.maxPending
+	if maxPending <= 0 {
+		maxPending = watchMaxPendingEvents
+	}
+	if len(d.pending) <= maxPending {
+		return 0
+	}
+	dropped := len(d.pending) - maxPending
+	d.pending = d.pending[:maxPending]
+	return dropped
+}
+
+func (d *watchDispatcher) reportDropped(dropped int) {
+	if dropped > 0 {
+		errorIf(errDummy().Trace(), "Too many events waiting to be dispatched, %d event(s) dropped.", dropped)
+	}
+}
+
+// retryDelay returns the backoff before dispatching a failed batch again.
+func (d *watchDispatcher) retryDelay() time.Duration {
+	p := d.retry
+	if p.unit <= 0 {
+		p = retryPolicy{unit: defaultRetryUnit, cap: defaultRetryCap}
+	}
+	// At least one unit, the jitter could make it zero.
+	return p.unit + p.backoff(d.failures)
+}
+
+// flush dispatches all pending events.
+func (d *watchDispatcher) flush(ctx context.Context) {
+	d.flushMu.Lock()
+	defer d.flushMu.Unlock()
+
+	d.mu.Lock()
+	events := coalesceWatchEvents(d.pending)
+	d.pending = nil
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.mu.Unlock()
+	if len(events) == 0 {
+		return
+	}
+	for _, action := range d.actions {
+		if err := action.dispatch(ctx, events); err != nil {
+			// Keep the events ahead of newer ones, the cursor must not
+			// move past them until every action got them.
+			d.mu.Lock()
+			d.pending = append(events, d.pending...)
+			dropped := d.trimPending()
+			delay := d.retryDelay()
+			d.failures++
+			if d.timer == nil && !d.stopped && ctx.Err() == nil {
+				d.timer = time.AfterFunc(delay, func() { d.flush(ctx) })
+			}
+			d.mu.Unlock()
+			errorIf(err, "Unable to dispatch %d event(s), they are retried in %s.", len(events), delay.Round(time.Millisecond))
+			d.reportDropped(dropped)
+			return
+		}
+	}
+	d.mu.Lock()
+	d.failures = 0
+	d.mu.Unlock()
+	if d.cursor != nil {
+		errorIf(d.cursor.advance(events), "Unable to save watch cursor.")
+	}
+}
+
+// stop flushes the events still waiting for the debounce window or for
+// a retry, failed events are not retried anymore afterwards.
+func (d *watchDispatcher) stop(ctx context.Context) {
+	d.mu.Lock()
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.mu.Unlock()
+	d.flush(ctx)
+}
+
+// matchWatchFilter applies the --prefix and --suffix filters of a watch
+// to an object key.
+func matchWatchFilter(key string, opts WatchOptions) bool {
+	return strings.HasPrefix(key, opts.Prefix) && strings.HasSuffix(key, opts.Suffix)
+}
+
+// replayWatchEvents relists the watched location and returns synthetic
+// 'put' events for objects modified since the cursor, oldest first.
+// Modification times are compared to the second as listings have no
+// better precision, objects of that second already handed over are
+// recognized by their ETag. Removals cannot be recovered from a listing
+// and are not replayed.
+func replayWatchEvents(ctx context.Context, clnt Client, opts WatchOptions, cursor *watchCursor) ([]watchEvent, *probe.Error) {
+	var events []watchEvent
+	root := clnt.GetURL()
+	since := cursor.Time.Truncate(time.Second)
+	for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			return nil, content.Err
+		}
+		if content.Type.IsDir() {
+			continue
+		}
+		modTime := content.Time.Truncate(time.Second)
+		if modTime.Before(since) {
+			continue
+		}
+		etag := strings.Trim(content.ETag, "\"")
+		if modTime.Equal(since) && etag != "" && cursor.ETags[content.URL.String()] == etag {
+			continue
+		}
+		var key string
+		if root.Type == objectStorage {
+			// Keys are matched relative to the bucket like the server does.
+			if parts := strings.SplitN(strings.TrimPrefix(content.URL.Path, "/"), "/", 2); len(parts) == 2 {
+				key = parts[1]
+			}
+		} else {
+			key = strings.TrimPrefix(strings.TrimPrefix(content.URL.Path, root.Path), string(root.Separator))
+		}
+		if !matchWatchFilter(key, opts) {
+			continue
+		}
+		events = append(events, watchEvent{
+			Time:   content.Time.UTC().Format(time.RFC3339Nano),
+			Type:   notification.ObjectCreatedPut,
+			Path:   content.URL.String(),
+			Size:   content.Size,
+			ETag:   etag,
+			Replay: true,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].eventTime().Before(events[j].eventTime())
+	})
+	return events, nil
+}
+
+// watchReplayWanted returns true if the watched events include new objects.
+func watchReplayWanted(events []string) bool {
+	for _, ev := range events {
+		if strings.TrimSpace(ev) == "put" {
+			return true
+		}
+	}
+	return false
+}
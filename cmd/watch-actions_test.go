@@ -0,0 +1,335 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+func TestExpandWatchTemplate(t *testing.T) {
+	ev := watchEvent{
+		Time: "2022-10-01T10:00:00.000Z",
+		Type: notification.ObjectCreatedPut,
+		Path: "/data/photos/cat.jpg",
+		Size: 1024,
+	}
+	testCases := []struct {
+		arg, want string
+	}{
+		{"{}", "/data/photos/cat.jpg"},
+		{`{""}`, `"/data/photos/cat.jpg"`},
+		{"{dir}/thumbs/{base}", "/data/photos/thumbs/cat.jpg"},
+		{`--size={size} --event={"event"}`, `--size=1024 --event="s3:ObjectCreated:Put"`},
+		{"{time}", "2022-10-01T10:00:00.000Z"},
+	}
+	for i, testCase := range testCases {
+		if got := expandWatchTemplate(testCase.arg, ev); got != testCase.want {
+			t.Fatalf("Test %d: expected %q, got %q", i+1, testCase.want, got)
+		}
+	}
+
+	// Placeholders within the substituted values are kept as is.
+	ev.Path = "/data/{size}/{base}.jpg"
+	if got, want := expandWatchTemplate("{} {size}", ev), "/data/{size}/{base}.jpg 1024"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCoalesceWatchEvents(t *testing.T) {
+	events := []watchEvent{
+		{Path: "a", Type: notification.ObjectCreatedPut, Size: 1},
+		{Path: "b", Type: notification.ObjectCreatedPut},
+		{Path: "a", Type: notification.ObjectCreatedPut, Size: 2},
+		{Path: "a", Type: notification.ObjectRemovedDelete},
+	}
+	want := []watchEvent{
+		{Path: "b", Type: notification.ObjectCreatedPut},
+		{Path: "a", Type: notification.ObjectCreatedPut, Size: 2},
+		{Path: "a", Type: notification.ObjectRemovedDelete},
+	}
+	if got := coalesceWatchEvents(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWatchDispatcherQueueAndCursor(t *testing.T) {
+	dir := t.TempDir()
+	queue, err := newWatchQueueAction(filepath.Join(dir, "events.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursorFile := filepath.Join(dir, "cursor")
+	cursor, err := loadWatchCursor(cursorFile, "play/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &watchDispatcher{actions: []watchAction{queue}, cursor: cursor, debounce: time.Hour}
+	d.add(context.Background(), []watchEvent{
+		{Path: "x", Type: notification.ObjectCreatedPut, Time: "2022-10-01T10:00:00.000Z"},
+		{Path: "x", Type: notification.ObjectCreatedPut, Time: "2022-10-01T10:00:05.000Z", ETag: "e1"},
+	})
+	d.add(context.Background(), []watchEvent{
+		{Path: "y", Type: notification.ObjectRemovedDelete, Time: "2022-10-01T10:00:03.000Z"},
+	})
+	// Nothing is dispatched before the debounce window ends.
+	if st, e := os.Stat(filepath.Join(dir, "events.ndjson")); e != nil || st.Size() != 0 {
+		t.Fatalf("expected an empty queue, got %v %v", st, e)
+	}
+	d.stop(context.Background())
+
+	f, e := os.Open(filepath.Join(dir, "events.ndjson"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer f.Close()
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev watchEvent
+		if e = json.Unmarshal(scanner.Bytes(), &ev); e != nil {
+			t.Fatal(e)
+		}
+		paths = append(paths, ev.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"x", "y"}) {
+		t.Fatalf("unexpected queued events %v", paths)
+	}
+
+	saved, err := loadWatchCursor(cursorFile, "play/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2022, 10, 1, 10, 0, 5, 0, time.UTC); !saved.Time.Equal(want) {
+		t.Fatalf("expected cursor at %v, got %v", want, saved.Time)
+	}
+	if want := map[string]string{"x": "e1"}; !reflect.DeepEqual(saved.ETags, want) {
+		t.Fatalf("expected cursor ETags %v, got %v", want, saved.ETags)
+	}
+	// A cursor of another target is ignored.
+	other, err := loadWatchCursor(cursorFile, "play/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !other.Time.IsZero() {
+		t.Fatalf("expected an empty cursor, got %v", other.Time)
+	}
+}
+
+func TestWatchWebhookRetry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			Events []watchEvent `json:"events"`
+		}
+		if e := json.NewDecoder(r.Body).Decode(&body); e != nil || len(body.Events) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}))
+	defer srv.Close()
+
+	action := newWatchWebhookAction(srv.URL, "Bearer token")
+	action.policy = retryPolicy{maxRetries: 2, unit: time.Millisecond, cap: time.Millisecond}
+	if err := action.dispatch(context.Background(), []watchEvent{{Path: "x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+
+	action = newWatchWebhookAction(srv.URL, "")
+	action.policy = retryPolicy{maxRetries: 2, unit: time.Millisecond, cap: time.Millisecond}
+	if err := action.dispatch(context.Background(), []watchEvent{{Path: "x"}}); err == nil {
+		t.Fatal("expected a permanent failure")
+	}
+}
+
+// failingWatchAction fails the first dispatches and records the others.
+type failingWatchAction struct {
+	failures   int
+	dispatched [][]string
+}
+
+func (a *failingWatchAction) dispatch(_ context.Context, events []watchEvent) *probe.Error {
+	if a.failures > 0 {
+		a.failures--
+		return probe.NewError(errors.New("action failed"))
+	}
+	var paths []string
+	for _, ev := range events {
+		paths = append(paths, ev.Path)
+	}
+	a.dispatched = append(a.dispatched, paths)
+	return nil
+}
+
+func TestWatchDispatcherFailedBatch(t *testing.T) {
+	cursorFile := filepath.Join(t.TempDir(), "cursor")
+	cursor, err := loadWatchCursor(cursorFile, "play/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	action := &failingWatchAction{failures: 1}
+	d := &watchDispatcher{actions: []watchAction{action}, cursor: cursor}
+
+	d.add(context.Background(), []watchEvent{
+		{Path: "x", Type: notification.ObjectCreatedPut, Time: "2022-10-01T10:00:00.000Z"},
+	})
+	if len(action.dispatched) != 0 || !cursor.Time.IsZero() {
+		t.Fatalf("cursor advanced past a failed batch: %v", cursor.Time)
+	}
+	if _, e := os.Stat(cursorFile); !os.IsNotExist(e) {
+		t.Fatalf("cursor saved after a failed batch: %v", e)
+	}
+
+	d.add(context.Background(), []watchEvent{
+		{Path: "y", Type: notification.ObjectCreatedPut, Time: "2022-10-01T10:00:05.000Z"},
+	})
+	if !reflect.DeepEqual(action.dispatched, [][]string{{"x", "y"}}) {
+		t.Fatalf("unexpected dispatched events %v", action.dispatched)
+	}
+	saved, err := loadWatchCursor(cursorFile, "play/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2022, 10, 1, 10, 0, 5, 0, time.UTC); !saved.Time.Equal(want) {
+		t.Fatalf("expected cursor at %v, got %v", want, saved.Time)
+	}
+}
+
+func TestWatchDispatcherRetryTimer(t *testing.T) {
+	action := &failingWatchAction{failures: 2}
+	d := &watchDispatcher{
+		actions: []watchAction{action},
+		retry:   retryPolicy{unit: time.Millisecond, cap: time.Millisecond},
+	}
+	d.add(context.Background(), []watchEvent{
+		{Path: "x", Type: notification.ObjectCreatedPut, Time: "2022-10-01T10:00:00.000Z"},
+	})
+
+	// The failed batch is dispatched again without any new event.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		d.flushMu.Lock()
+		dispatched := action.dispatched
+		d.flushMu.Unlock()
+		if len(dispatched) > 0 {
+			if !reflect.DeepEqual(dispatched, [][]string{{"x"}}) {
+				t.Fatalf("unexpected dispatched events %v", dispatched)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the failed batch was not retried")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchDispatcherMaxPending(t *testing.T) {
+	action := &failingWatchAction{failures: 1}
+	d := &watchDispatcher{actions: []watchAction{action}, maxPending: 2, retry: retryPolicy{unit: time.Hour, cap: time.Hour}}
+	defer d.stop(context.Background())
+
+	d.add(context.Background(), []watchEvent{
+		{Path: "x", Type: notification.ObjectCreatedPut},
+		{Path: "y", Type: notification.ObjectCreatedPut},
+		{Path: "z", Type: notification.ObjectCreatedPut},
+	})
+	d.mu.Lock()
+	pending := len(d.pending)
+	d.mu.Unlock()
+	if pending != 2 {
+		t.Fatalf("expected 2 pending events, got %d", pending)
+	}
+	d.flush(context.Background())
+	if !reflect.DeepEqual(action.dispatched, [][]string{{"x", "y"}}) {
+		t.Fatalf("unexpected dispatched events %v", action.dispatched)
+	}
+}
+
+func TestReplayWatchEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("location") {
+			w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`))
+			return
+		}
+		w.Write([]byte(`<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket</Name><IsTruncated>false</IsTruncated>` +
+			`<Contents><Key>old</Key><LastModified>2022-10-01T09:59:59.000Z</LastModified><ETag>"e0"</ETag><Size>1</Size></Contents>` +
+			`<Contents><Key>seen</Key><LastModified>2022-10-01T10:00:00.000Z</LastModified><ETag>"e1"</ETag><Size>1</Size></Contents>` +
+			`<Contents><Key>same-second</Key><LastModified>2022-10-01T10:00:00.000Z</LastModified><ETag>"e2"</ETag><Size>1</Size></Contents>` +
+			`<Contents><Key>new</Key><LastModified>2022-10-01T10:00:01.000Z</LastModified><ETag>"e3"</ETag><Size>1</Size></Contents>` +
+			`</ListBucketResult>`))
+	}))
+	defer server.Close()
+
+	saved := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["replay"] = aliasConfigV10{URL: server.URL, AccessKey: "minio", SecretKey: "minio123", API: "S3v4", Path: "on"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = saved }()
+
+	clnt, err := newClient("replay/bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := server.URL + "/bucket/seen"
+	cursor := &watchCursor{
+		Time:  time.Date(2022, 10, 1, 10, 0, 0, 500*int(time.Millisecond), time.UTC),
+		ETags: map[string]string{seen: "e1"},
+	}
+	events, err := replayWatchEvents(context.Background(), clnt, WatchOptions{}, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, ev := range events {
+		paths = append(paths, strings.TrimPrefix(ev.Path, server.URL+"/bucket/"))
+	}
+	// An object listed in the second of the cursor is replayed unless
+	// it was already handed over.
+	if !reflect.DeepEqual(paths, []string{"same-second", "new"}) {
+		t.Fatalf("unexpected replayed events %v", paths)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/fatih/color"
@@ -50,6 +51,44 @@ var watchFlags = []cli.Flag{
 		Name:  "recursive",
 		Usage: "recursively watch for events",
 	},
+	cli.BoolFlag{
+		Name:  "poll",
+		Usage: "detect changes by listing a bucket periodically, used automatically for servers without event notifications",
+	},
+	cli.DurationFlag{
+		Name:  "poll-interval",
+		Value: defaultWatchPollInterval,
+		Usage: "interval between two listings when polling",
+	},
+	cli.IntFlag{
+		Name:  "poll-max-objects",
+		Value: defaultWatchPollMaxObjects,
+		Usage: "maximum number of objects remembered when polling, removals beyond are not detected",
+	},
+	cli.StringFlag{
+		Name:  "exec",
+		Usage: "run a command for each event, {} {base} {dir} {size} {time} {event} are substituted",
+	},
+	cli.StringFlag{
+		Name:  "webhook",
+		Usage: "POST events as JSON to this endpoint, failed deliveries are retried",
+	},
+	cli.StringFlag{
+		Name:  "webhook-token",
+		Usage: "value of the Authorization header sent to the webhook",
+	},
+	cli.StringFlag{
+		Name:  "queue",
+		Usage: "append events as JSON lines to this local file",
+	},
+	cli.DurationFlag{
+		Name:  "debounce",
+		Usage: "collect events over this window and dispatch them as one batch, coalescing repeated events",
+	},
+	cli.StringFlag{
+		Name:  "cursor",
+		Usage: "checkpoint file, on restart objects changed since the last dispatched event are replayed",
+	},
 }
 
 var watchCmd = cli.Command{
@@ -86,6 +125,19 @@ EXAMPLES:
 
   6. Watch for events on local directory.
      {{.Prompt}} {{.HelpName}} /usr/share
+
+  7. Generate a thumbnail for every new ".jpg" object.
+     {{.Prompt}} {{.HelpName}} --events put --suffix ".jpg" --exec "thumbnail {}" play/testbucket
+
+  8. POST batches of events collected over 5 seconds to a webhook, replaying
+     events missed while not running on restart.
+     {{.Prompt}} {{.HelpName}} --webhook https://hooks.example.com/mc --debounce 5s --cursor ~/.mc-watch.cursor play/testbucket
+
+  9. Append all events to a local queue file.
+     {{.Prompt}} {{.HelpName}} --queue /var/spool/mc/events.ndjson play/testbucket
+
+  10. Watch an AWS S3 bucket, changes are detected by listing the bucket every minute.
+     {{.Prompt}} {{.HelpName}} --poll-interval 1m s3/mybucket
 `,
 }
 
@@ -131,6 +183,52 @@ func (u watchMessage) String() string {
 	return msg
 }
 
+// newWatchDispatcher builds the dispatcher of the configured watch
+// actions, nil when events are only printed.
+func newWatchDispatcher(cliCtx *cli.Context, target string) (*watchDispatcher, *probe.Error) {
+	d := &watchDispatcher{debounce: cliCtx.Duration("debounce")}
+	if cmdline := cliCtx.String("exec"); cmdline != "" {
+		action, err := newWatchExecAction(cmdline)
+		if err != nil {
+			return nil, err
+		}
+		d.actions = append(d.actions, action)
+	}
+	if endpoint := cliCtx.String("webhook"); endpoint != "" {
+		d.actions = append(d.actions, newWatchWebhookAction(endpoint, cliCtx.String("webhook-token")))
+	}
+	if file := cliCtx.String("queue"); file != "" {
+		action, err := newWatchQueueAction(file)
+		if err != nil {
+			return nil, err
+		}
+		d.actions = append(d.actions, action)
+	}
+	if file := cliCtx.String("cursor"); file != "" {
+		cursor, err := loadWatchCursor(file, target)
+		if err != nil {
+			return nil, err
+		}
+		d.cursor = cursor
+	}
+	if len(d.actions) == 0 && d.cursor == nil {
+		return nil, nil
+	}
+	return d, nil
+}
+
+func printWatchEvent(event watchEvent) {
+	msg := watchMessage{}
+	msg.Event.Path = event.Path
+	msg.Event.Size = event.Size
+	msg.Event.Time = event.Time
+	msg.Event.Type = event.Type
+	msg.Source.Host = event.Host
+	msg.Source.Port = event.Port
+	msg.Source.UserAgent = event.UserAgent
+	printMsg(msg)
+}
+
 func mainWatch(cliCtx *cli.Context) error {
 	console.SetColor("Time", color.New(color.FgGreen))
 	console.SetColor("Size", color.New(color.FgYellow))
@@ -153,12 +251,18 @@ func mainWatch(cliCtx *cli.Context) error {
 	}
 
 	options := WatchOptions{
-		Recursive: recursive,
-		Events:    events,
-		Prefix:    prefix,
-		Suffix:    suffix,
+		Recursive:      recursive,
+		Events:         events,
+		Prefix:         prefix,
+		Suffix:         suffix,
+		Poll:           cliCtx.Bool("poll"),
+		PollInterval:   cliCtx.Duration("poll-interval"),
+		PollMaxObjects: cliCtx.Int("poll-max-objects"),
 	}
 
+	dispatcher, pErr := newWatchDispatcher(cliCtx, path)
+	fatalIf(pErr, "Unable to initialize watch actions.")
+
 	ctx, cancelWatch := context.WithCancel(globalContext)
 	defer cancelWatch()
 
@@ -176,6 +280,26 @@ func mainWatch(cliCtx *cli.Context) error {
 	go func() {
 		defer wg.Done()
 
+		if dispatcher != nil {
+			defer func() {
+				// Hand over events still waiting for the debounce window.
+				stopCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				dispatcher.stop(stopCtx)
+			}()
+		}
+
+		// Objects changed while no watcher was running, the live watch
+		// is already established so nothing falls in between.
+		if dispatcher != nil && dispatcher.cursor != nil && !dispatcher.cursor.Time.IsZero() && watchReplayWanted(events) {
+			replayed, err := replayWatchEvents(ctx, s3Client, options, dispatcher.cursor)
+			errorIf(err, "Unable to replay events since %s.", dispatcher.cursor.Time)
+			for _, event := range replayed {
+				printWatchEvent(event)
+			}
+			dispatcher.add(ctx, replayed)
+		}
+
 		// Wait for all events.
 		for {
 			select {
@@ -187,16 +311,13 @@ func mainWatch(cliCtx *cli.Context) error {
 				if !ok {
 					return
 				}
+				batch := make([]watchEvent, 0, len(events))
 				for _, event := range events {
-					msg := watchMessage{}
-					msg.Event.Path = event.Path
-					msg.Event.Size = event.Size
-					msg.Event.Time = event.Time
-					msg.Event.Type = event.Type
-					msg.Source.Host = event.Host
-					msg.Source.Port = event.Port
-					msg.Source.UserAgent = event.UserAgent
-					printMsg(msg)
+					batch = append(batch, newWatchEvent(event))
+					printWatchEvent(batch[len(batch)-1])
+				}
+				if dispatcher != nil {
+					dispatcher.add(ctx, batch)
 				}
 			case err, ok := <-wo.Errors():
 				if !ok {
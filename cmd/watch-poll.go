@@ -0,0 +1,224 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+const (
+	// Default interval between two listings of a polling watcher.
+	defaultWatchPollInterval = 30 * time.Second
+
+	// Default number of objects a polling watcher remembers, objects
+	// beyond this bound are only reported when created or modified.
+	defaultWatchPollMaxObjects = 1000000
+)
+
+// isWatchNotSupported returns true when the server does not implement
+// the MinIO listen notification API, as with AWS S3 or GCS.
+func isWatchNotSupported(e error) bool {
+	errResp := minio.ToErrorResponse(e)
+	if errResp.Code == "NotImplemented" {
+		return true
+	}
+	switch errResp.StatusCode {
+	case http.StatusNotImplemented, http.StatusMethodNotAllowed:
+		return true
+	}
+	return false
+}
+
+// watchSnapshot is the state of the watched prefix as of the last
+// listing, a fingerprint of ETag, size and modification time per object.
+// At most maxObjects are remembered, objects beyond the bound are only
+// reported when modified after the previous listing and their removal
+// goes unnoticed.
+type watchSnapshot struct {
+	maxObjects int
+	objects    map[string]uint64
+	// Set when the listing had more objects than maxObjects, 'last' is
+	// the last remembered key in listing order.
+	truncated bool
+	last      string
+}
+
+func newWatchSnapshot(maxObjects int) *watchSnapshot {
+	if maxObjects <= 0 {
+		maxObjects = defaultWatchPollMaxObjects
+	}
+	return &watchSnapshot{maxObjects: maxObjects, objects: make(map[string]uint64)}
+}
+
+func watchFingerprint(content *ClientContent) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(content.ETag))
+	h.Write([]byte(strconv.FormatInt(content.Size, 10)))
+	h.Write([]byte(strconv.FormatInt(content.Time.UnixNano(), 10)))
+	return h.Sum64()
+}
+
+// observe records a listed object in the snapshot being built and
+// returns true when it was created or modified since 'prev'.
+func (s *watchSnapshot) observe(prev *watchSnapshot, content *ClientContent, since time.Time) bool {
+	key := content.URL.String()
+	fp := watchFingerprint(content)
+	if len(s.objects) < s.maxObjects {
+		s.objects[key] = fp
+		s.last = key
+	} else {
+		s.truncated = true
+	}
+	if old, ok := prev.objects[key]; ok {
+		return old != fp
+	}
+	if prev.truncated && key > prev.last {
+		// Not remembered by the previous snapshot, rely on the time.
+		return content.Time.After(since)
+	}
+	return true
+}
+
+// removed returns the objects of 'prev' missing from this snapshot.
+func (s *watchSnapshot) removed(prev *watchSnapshot) []string {
+	var keys []string
+	for key := range prev.objects {
+		if _, ok := s.objects[key]; ok {
+			continue
+		}
+		if s.truncated && key > s.last {
+			// Beyond what this listing remembered, may still exist.
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pollWatch emulates a watch by periodically listing the client URL and
+// diffing consecutive listings, it returns when done or ctx is canceled.
+func pollWatch(ctx context.Context, clnt Client, options WatchOptions, wo *WatchObject) {
+	interval := options.PollInterval
+	if interval <= 0 {
+		interval = defaultWatchPollInterval
+	}
+	wantPut, wantDelete := false, false
+	for _, event := range options.Events {
+		switch event {
+		case "put":
+			wantPut = true
+		case "delete":
+			wantDelete = true
+		}
+	}
+
+	isS3 := clnt.GetURL().Type == objectStorage
+	snapshot := newWatchSnapshot(options.PollMaxObjects)
+	var since time.Time
+
+	// poll lists the watched location once and returns the changes
+	// against the previous listing.
+	poll := func() ([]EventInfo, *probe.Error) {
+		now := time.Now()
+		next := newWatchSnapshot(snapshot.maxObjects)
+		var events []EventInfo
+		for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+			if content.Err != nil {
+				return nil, content.Err
+			}
+			if content.Type.IsDir() {
+				continue
+			}
+			key := content.URL.Path
+			if isS3 {
+				// Filters apply to the object name within its bucket.
+				if parts := strings.SplitN(strings.TrimPrefix(key, "/"), "/", 2); len(parts) == 2 {
+					key = parts[1]
+				}
+			}
+			if !matchWatchFilter(key, options) {
+				continue
+			}
+			if next.observe(snapshot, content, since) && wantPut {
+				events = append(events, EventInfo{
+					Time:         content.Time.UTC().Format(time.RFC3339Nano),
+					Size:         content.Size,
+					UserMetadata: content.UserMetadata,
+					Path:         content.URL.String(),
+					ETag:         content.ETag,
+					Type:         notification.ObjectCreatedPut,
+				})
+			}
+		}
+		if wantDelete {
+			for _, key := range next.removed(snapshot) {
+				events = append(events, EventInfo{
+					Time: now.UTC().Format(time.RFC3339Nano),
+					Path: key,
+					Type: notification.ObjectRemovedDelete,
+				})
+			}
+		}
+		snapshot, since = next, now
+		return events, nil
+	}
+
+	baseline := true
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-wo.DoneChan:
+			return
+		case <-timer.C:
+		}
+
+		events, err := poll()
+		switch {
+		case err != nil:
+			select {
+			case wo.Errors() <- err:
+			case <-wo.DoneChan:
+				return
+			}
+		case baseline:
+			// The first listing only establishes the initial state.
+			baseline = false
+		case len(events) > 0:
+			select {
+			case wo.Events() <- events:
+			case <-wo.DoneChan:
+				return
+			}
+		}
+		timer.Reset(interval)
+	}
+}
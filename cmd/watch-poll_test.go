@@ -0,0 +1,169 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+func TestIsWatchNotSupported(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{minio.ErrorResponse{Code: "NotImplemented", StatusCode: 501}, true},
+		{minio.ErrorResponse{Code: "APINotSupported"}, false},
+		{minio.ErrorResponse{StatusCode: 405}, true},
+		{minio.ErrorResponse{Code: "AccessDenied", StatusCode: 403}, false},
+		{os.ErrNotExist, false},
+	}
+	for i, testCase := range testCases {
+		if got := isWatchNotSupported(testCase.err); got != testCase.want {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, testCase.want, got)
+		}
+	}
+}
+
+func TestPollWatchRequiresBucket(t *testing.T) {
+	saved := loadMcConfig
+	loadMcConfig = func() (*configV10, *probe.Error) {
+		config := newMcConfig()
+		config.Aliases["poll"] = aliasConfigV10{URL: "http://localhost:9000", AccessKey: "minio", SecretKey: "minio123", API: "S3v4", Path: "on"}
+		return config, nil
+	}
+	defer func() { loadMcConfig = saved }()
+
+	clnt, err := newClient("poll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clnt.Watch(context.Background(), WatchOptions{Poll: true, Events: []string{"put"}}); err == nil {
+		t.Fatal("expected a server-wide watch not to be polled")
+	}
+}
+
+func TestWatchSnapshot(t *testing.T) {
+	t0 := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	content := func(name string, size int64, modTime time.Time) *ClientContent {
+		return &ClientContent{URL: *newClientURL("/bucket/" + name), Size: size, Time: modTime}
+	}
+	observeAll := func(prev *watchSnapshot, since time.Time, contents ...*ClientContent) (*watchSnapshot, []string) {
+		next := newWatchSnapshot(prev.maxObjects)
+		var changed []string
+		for _, c := range contents {
+			if next.observe(prev, c, since) {
+				changed = append(changed, c.URL.Path)
+			}
+		}
+		return next, changed
+	}
+
+	s0 := newWatchSnapshot(10)
+	s1, changed := observeAll(s0, time.Time{}, content("a", 1, t0), content("b", 1, t0))
+	if len(changed) != 2 {
+		t.Fatalf("expected all objects to be new, got %v", changed)
+	}
+	s2, changed := observeAll(s1, t0, content("a", 2, t0.Add(time.Minute)), content("c", 1, t0))
+	if !reflect.DeepEqual(changed, []string{"/bucket/a", "/bucket/c"}) {
+		t.Fatalf("unexpected changes %v", changed)
+	}
+	if removed := s2.removed(s1); !reflect.DeepEqual(removed, []string{"/bucket/b"}) {
+		t.Fatalf("unexpected removals %v", removed)
+	}
+
+	// Only two objects are remembered, 'c' is reported through its time.
+	small := newWatchSnapshot(2)
+	s3, _ := observeAll(small, time.Time{}, content("a", 1, t0), content("b", 1, t0), content("c", 1, t0))
+	if !s3.truncated || len(s3.objects) != 2 {
+		t.Fatalf("expected a truncated snapshot, got %d objects", len(s3.objects))
+	}
+	s4, changed := observeAll(s3, t0, content("a", 1, t0), content("b", 1, t0), content("c", 1, t0), content("d", 1, t0.Add(time.Second)))
+	if !reflect.DeepEqual(changed, []string{"/bucket/d"}) {
+		t.Fatalf("unexpected changes %v", changed)
+	}
+	if removed := s4.removed(s3); len(removed) != 0 {
+		t.Fatalf("unexpected removals %v", removed)
+	}
+}
+
+func TestPollWatchFS(t *testing.T) {
+	dir := t.TempDir()
+	if e := os.WriteFile(filepath.Join(dir, "old.txt"), []byte("old"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	clnt, err := fsNew(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wo, err := clnt.Watch(ctx, WatchOptions{
+		Poll:         true,
+		PollInterval: 50 * time.Millisecond,
+		Events:       []string{"put", "delete"},
+		Suffix:       ".txt",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(wo.DoneChan)
+
+	// Let the baseline listing happen before changing anything.
+	time.Sleep(200 * time.Millisecond)
+	if e := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	if e := os.WriteFile(filepath.Join(dir, "new.log"), []byte("new"), 0o600); e != nil {
+		t.Fatal(e)
+	}
+	if e := os.Remove(filepath.Join(dir, "old.txt")); e != nil {
+		t.Fatal(e)
+	}
+
+	got := map[string]notification.EventType{}
+	timeout := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case events := <-wo.Events():
+			for _, ev := range events {
+				got[filepath.Base(ev.Path)] = ev.Type
+			}
+		case err := <-wo.Errors():
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatalf("timed out, got %v", got)
+		}
+	}
+	want := map[string]notification.EventType{
+		"new.txt": notification.ObjectCreatedPut,
+		"old.txt": notification.ObjectRemovedDelete,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
@@ -36,6 +36,7 @@ type EventInfo struct {
 	Host         string
 	Port         string
 	UserAgent    string
+	ETag         string
 	Type         notification.EventType
 }
 
@@ -45,6 +46,12 @@ type WatchOptions struct {
 	Suffix    string
 	Events    []string
 	Recursive bool
+
+	// Poll forces listing based watching, which is otherwise only
+	// used when the server does not support listening for events.
+	Poll           bool
+	PollInterval   time.Duration
+	PollMaxObjects int
 }
 
 // WatchObject captures watch channels to read and listen on.
@@ -46,6 +46,7 @@ require (
 require (
 	github.com/charmbracelet/bubbles v0.14.0
 	github.com/charmbracelet/lipgloss v0.6.0
+	github.com/fraugster/parquet-go v0.12.0
 	github.com/gdamore/tcell/v2 v2.5.3
 	github.com/golang-jwt/jwt/v4 v4.4.2
 	github.com/muesli/reflow v0.3.0
@@ -58,6 +59,7 @@ require (
 )
 
 require (
+	github.com/apache/thrift v0.16.0 // indirect
 	github.com/aymanbagabas/go-osc52 v1.2.1 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.1.2 // indirect
@@ -69,6 +71,7 @@ require (
 	github.com/gdamore/encoding v1.0.0 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/golang/snappy v0.0.4 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/klauspost/cpuid/v2 v2.1.2 // indirect